@@ -14,6 +14,9 @@
 
 package abi
 
+//go:generate go run ./internal/marshalgen abi_linux.go abi_unix.go
+//go:generate go run ./internal/uapigen -headers internal/uapigen/testdata/include -o zuapi_linux.go -test zuapi_linux_test.go
+
 import (
 	"encoding/binary"
 	"syscall"
@@ -164,6 +167,8 @@ const SizeOfTimeval = 16
 type ClockT int64
 
 // Tms represents struct tms, used by times(2).
+//
+// +marshal
 type Tms struct {
 	UTime  ClockT
 	STime  ClockT
@@ -335,7 +340,9 @@ var PtraceRequestSet = FlagSet{
 
 // clone
 
-// CloneFlagSet is the set of clone(2) flags.
+// CloneFlagSet is the set of clone(2) flags, also used by unshare(2) and
+// setns(2). CLONE_NEWTIME shares its bit with the exit signal of clone(2)
+// and is only valid in unshare(2), setns(2) and clone3(2).
 var CloneFlagSet = FlagSet{
 	&BitFlag{
 		Value: syscall.CLONE_VM,
@@ -425,6 +432,44 @@ var CloneFlagSet = FlagSet{
 		Value: syscall.CLONE_IO,
 		Name:  "CLONE_IO",
 	},
+	&BitFlag{
+		Value: syscall.CLONE_NEWCGROUP,
+		Name:  "CLONE_NEWCGROUP",
+	},
+	&BitFlag{
+		Value: syscall.CLONE_NEWTIME,
+		Name:  "CLONE_NEWTIME",
+	},
+	&BitFlag{
+		Value: syscall.CLONE_PIDFD,
+		Name:  "CLONE_PIDFD",
+	},
+	&BitFlag{
+		Value: syscall.CLONE_CLEAR_SIGHAND,
+		Name:  "CLONE_CLEAR_SIGHAND",
+	},
+	&BitFlag{
+		Value: syscall.CLONE_INTO_CGROUP,
+		Name:  "CLONE_INTO_CGROUP",
+	},
+}
+
+// CloneArgs is struct clone_args of clone3(2). Older kernels and programs
+// use a prefix of it, its size is passed to clone3.
+//
+// +marshal
+type CloneArgs struct {
+	Flags      uint64
+	Pidfd      uint64
+	ChildTID   uint64
+	ParentTID  uint64
+	ExitSignal uint64
+	Stack      uint64
+	StackSize  uint64
+	TLS        uint64
+	SetTID     uint64
+	SetTIDSize uint64
+	Cgroup     uint64
 }
 
 // Socket defines. Some of these might move to abi_unix.go
@@ -557,6 +602,8 @@ const (
 const SockAddrMax = 128
 
 // SockAddrInt is struct sockaddr_in, from uapi/linux/in.h.
+//
+// +marshal
 type SockAddrInet struct {
 	Family uint16
 	Port   uint16
@@ -565,6 +612,8 @@ type SockAddrInet struct {
 }
 
 // SockAddrInt6 is struct sockaddr_in6, from uapi/linux/in6.h.
+//
+// +marshal
 type SockAddrInet6 struct {
 	Family   uint16
 	Port     uint16
@@ -579,6 +628,8 @@ type SockAddrInet6 struct {
 const UnixPathMax = 108
 
 // SockAddrUnix is struct sockaddr_un, from uapi/linux/un.h.
+//
+// +marshal
 type SockAddrUnix struct {
 	Family uint16
 	Path   [UnixPathMax]int8
@@ -587,6 +638,8 @@ type SockAddrUnix struct {
 // TCPInfo is a collection of TCP statistics.
 //
 // From uapi/linux/tcp.h.
+//
+// +marshal
 type TCPInfo struct {
 	State       uint8
 	CaState     uint8
@@ -671,6 +724,8 @@ const (
 // A ControlMessageHeader is the header for a socket control message.
 //
 // ControlMessageHeader represents struct cmsghdr from linux/socket.h.
+//
+// +marshal
 type ControlMessageHeader struct {
 	Length uint64
 	Level  int32
@@ -684,6 +739,8 @@ var SizeOfControlMessageHeader = int(binary.Size(ControlMessageHeader{}))
 // A ControlMessageCredentials is an SCM_CREDENTIALS socket control message.
 //
 // ControlMessageCredentials represents struct ucred from linux/socket.h.
+//
+// +marshal
 type ControlMessageCredentials struct {
 	PID int32
 	UID uint32
@@ -756,6 +813,17 @@ func Futex(op uint64) Flags {
 	return f
 }
 
+// futexOpFlagSet are the flags Futex adds to a command.
+var futexOpFlagSet = FlagSet{
+	&BitFlag{Value: FUTEX_CLOCK_REALTIME, Name: "FUTEX_CLOCK_REALTIME"},
+	&BitFlag{Value: FUTEX_PRIVATE_FLAG, Name: "FUTEX_PRIVATE_FLAG"},
+}
+
+// UnparseFutex is the inverse of Futex.
+func UnparseFutex(s string) (uint64, error) {
+	return unparse(s, FutexCmd, futexOpFlagSet)
+}
+
 // madvise
 
 // MadviseFlagSet are madvise(2) posible flags.
@@ -774,3 +842,820 @@ var MadviseFlagSet = FlagSet{
 	&BitFlag{Value: syscall.MADV_UNMERGEABLE, Name: "MADV_UNMERGEABLE"},
 	&BitFlag{Value: syscall.MADV_WILLNEED, Name: "MADV_WILLNEED"},
 }
+
+// poll
+
+// poll(2) events, from uapi/asm-generic/poll.h.
+const (
+	POLLIN     = 0x1
+	POLLPRI    = 0x2
+	POLLOUT    = 0x4
+	POLLERR    = 0x8
+	POLLHUP    = 0x10
+	POLLNVAL   = 0x20
+	POLLRDNORM = 0x40
+	POLLRDBAND = 0x80
+	POLLWRNORM = 0x100
+	POLLWRBAND = 0x200
+	POLLMSG    = 0x400
+	POLLREMOVE = 0x1000
+	POLLRDHUP  = 0x2000
+)
+
+// PollEventSet are the possible events of struct pollfd.
+var PollEventSet = FlagSet{
+	&BitFlag{Value: POLLIN, Name: "POLLIN"},
+	&BitFlag{Value: POLLPRI, Name: "POLLPRI"},
+	&BitFlag{Value: POLLOUT, Name: "POLLOUT"},
+	&BitFlag{Value: POLLERR, Name: "POLLERR"},
+	&BitFlag{Value: POLLHUP, Name: "POLLHUP"},
+	&BitFlag{Value: POLLNVAL, Name: "POLLNVAL"},
+	&BitFlag{Value: POLLRDNORM, Name: "POLLRDNORM"},
+	&BitFlag{Value: POLLRDBAND, Name: "POLLRDBAND"},
+	&BitFlag{Value: POLLWRNORM, Name: "POLLWRNORM"},
+	&BitFlag{Value: POLLWRBAND, Name: "POLLWRBAND"},
+	&BitFlag{Value: POLLMSG, Name: "POLLMSG"},
+	&BitFlag{Value: POLLREMOVE, Name: "POLLREMOVE"},
+	&BitFlag{Value: POLLRDHUP, Name: "POLLRDHUP"},
+}
+
+// FD_SETSIZE is the number of descriptors an fd_set can hold, from
+// uapi/linux/posix_types.h.
+const FD_SETSIZE = 1024
+
+// getdents
+
+// Dirent64 is struct linux_dirent64, an entry returned by getdents64(2).
+// The name is padded with zeros up to Reclen.
+type Dirent64 struct {
+	Ino    uint64
+	Off    int64
+	Reclen uint16
+	Type   uint8
+	Name   []byte `binary:"total=Reclen,term=0"`
+}
+
+// DirentTypes are file types of struct linux_dirent64.
+var DirentTypes = FlagSet{
+	&Value{Value: unix.DT_UNKNOWN, Name: "DT_UNKNOWN"},
+	&Value{Value: unix.DT_FIFO, Name: "DT_FIFO"},
+	&Value{Value: unix.DT_CHR, Name: "DT_CHR"},
+	&Value{Value: unix.DT_DIR, Name: "DT_DIR"},
+	&Value{Value: unix.DT_BLK, Name: "DT_BLK"},
+	&Value{Value: unix.DT_REG, Name: "DT_REG"},
+	&Value{Value: unix.DT_LNK, Name: "DT_LNK"},
+	&Value{Value: unix.DT_SOCK, Name: "DT_SOCK"},
+	&Value{Value: unix.DT_WHT, Name: "DT_WHT"},
+}
+
+// epoll
+
+// EpollEvent is struct epoll_event from uapi/linux/eventpoll.h. It is packed
+// on amd64, so it's 12 bytes long.
+//
+// +marshal
+type EpollEvent struct {
+	Events uint32
+	Data   uint64
+}
+
+// SizeOfEpollEvent is the binary size of an EpollEvent struct.
+var SizeOfEpollEvent = binary.Size(EpollEvent{})
+
+// EpollEventSet are the possible events of struct epoll_event.
+var EpollEventSet = FlagSet{
+	&BitFlag{Value: unix.EPOLLIN, Name: "EPOLLIN"},
+	&BitFlag{Value: unix.EPOLLPRI, Name: "EPOLLPRI"},
+	&BitFlag{Value: unix.EPOLLOUT, Name: "EPOLLOUT"},
+	&BitFlag{Value: unix.EPOLLERR, Name: "EPOLLERR"},
+	&BitFlag{Value: unix.EPOLLHUP, Name: "EPOLLHUP"},
+	&BitFlag{Value: unix.EPOLLRDNORM, Name: "EPOLLRDNORM"},
+	&BitFlag{Value: unix.EPOLLRDBAND, Name: "EPOLLRDBAND"},
+	&BitFlag{Value: unix.EPOLLWRNORM, Name: "EPOLLWRNORM"},
+	&BitFlag{Value: unix.EPOLLWRBAND, Name: "EPOLLWRBAND"},
+	&BitFlag{Value: unix.EPOLLMSG, Name: "EPOLLMSG"},
+	&BitFlag{Value: unix.EPOLLRDHUP, Name: "EPOLLRDHUP"},
+	&BitFlag{Value: unix.EPOLLEXCLUSIVE, Name: "EPOLLEXCLUSIVE"},
+	&BitFlag{Value: unix.EPOLLWAKEUP, Name: "EPOLLWAKEUP"},
+	&BitFlag{Value: unix.EPOLLONESHOT, Name: "EPOLLONESHOT"},
+	&BitFlag{Value: unix.EPOLLET, Name: "EPOLLET"},
+}
+
+// EpollCtlOps are the possible epoll_ctl(2) operations.
+var EpollCtlOps = FlagSet{
+	&Value{Value: unix.EPOLL_CTL_ADD, Name: "EPOLL_CTL_ADD"},
+	&Value{Value: unix.EPOLL_CTL_DEL, Name: "EPOLL_CTL_DEL"},
+	&Value{Value: unix.EPOLL_CTL_MOD, Name: "EPOLL_CTL_MOD"},
+}
+
+// EpollCreateFlagSet are epoll_create1(2) flags.
+var EpollCreateFlagSet = FlagSet{
+	&BitFlag{Value: unix.EPOLL_CLOEXEC, Name: "EPOLL_CLOEXEC"},
+}
+
+// eventfd
+
+// EventFDFlagSet are eventfd2(2) flags.
+var EventFDFlagSet = FlagSet{
+	&BitFlag{Value: unix.EFD_CLOEXEC, Name: "EFD_CLOEXEC"},
+	&BitFlag{Value: unix.EFD_NONBLOCK, Name: "EFD_NONBLOCK"},
+	&BitFlag{Value: unix.EFD_SEMAPHORE, Name: "EFD_SEMAPHORE"},
+}
+
+// timers
+
+// TimerFDFlagSet are timerfd_create(2) flags.
+var TimerFDFlagSet = FlagSet{
+	&BitFlag{Value: TFD_CLOEXEC, Name: "TFD_CLOEXEC"},
+	&BitFlag{Value: TFD_NONBLOCK, Name: "TFD_NONBLOCK"},
+}
+
+// TimerFDSettimeFlagSet are timerfd_settime(2) flags.
+var TimerFDSettimeFlagSet = FlagSet{
+	&BitFlag{Value: TFD_TIMER_ABSTIME, Name: "TFD_TIMER_ABSTIME"},
+	&BitFlag{Value: unix.TFD_TIMER_CANCEL_ON_SET, Name: "TFD_TIMER_CANCEL_ON_SET"},
+}
+
+// TimerFlagSet are timer_settime(2) and clock_nanosleep(2) flags.
+var TimerFlagSet = FlagSet{
+	&BitFlag{Value: TIMER_ABSTIME, Name: "TIMER_ABSTIME"},
+}
+
+// ClockIDs are the possible clock ids, from uapi/linux/time.h.
+var ClockIDs = FlagSet{
+	&Value{Value: unix.CLOCK_REALTIME, Name: "CLOCK_REALTIME"},
+	&Value{Value: unix.CLOCK_MONOTONIC, Name: "CLOCK_MONOTONIC"},
+	&Value{Value: unix.CLOCK_PROCESS_CPUTIME_ID, Name: "CLOCK_PROCESS_CPUTIME_ID"},
+	&Value{Value: unix.CLOCK_THREAD_CPUTIME_ID, Name: "CLOCK_THREAD_CPUTIME_ID"},
+	&Value{Value: unix.CLOCK_MONOTONIC_RAW, Name: "CLOCK_MONOTONIC_RAW"},
+	&Value{Value: unix.CLOCK_REALTIME_COARSE, Name: "CLOCK_REALTIME_COARSE"},
+	&Value{Value: unix.CLOCK_MONOTONIC_COARSE, Name: "CLOCK_MONOTONIC_COARSE"},
+	&Value{Value: unix.CLOCK_BOOTTIME, Name: "CLOCK_BOOTTIME"},
+	&Value{Value: unix.CLOCK_REALTIME_ALARM, Name: "CLOCK_REALTIME_ALARM"},
+	&Value{Value: unix.CLOCK_BOOTTIME_ALARM, Name: "CLOCK_BOOTTIME_ALARM"},
+	&Value{Value: unix.CLOCK_TAI, Name: "CLOCK_TAI"},
+}
+
+// netlink
+
+// NetlinkProtocols are protocols of AF_NETLINK sockets.
+var NetlinkProtocols = FlagSet{
+	&Value{Value: unix.NETLINK_ROUTE, Name: "NETLINK_ROUTE"},
+	&Value{Value: unix.NETLINK_USERSOCK, Name: "NETLINK_USERSOCK"},
+	&Value{Value: unix.NETLINK_FIREWALL, Name: "NETLINK_FIREWALL"},
+	&Value{Value: unix.NETLINK_SOCK_DIAG, Name: "NETLINK_SOCK_DIAG"},
+	&Value{Value: unix.NETLINK_NFLOG, Name: "NETLINK_NFLOG"},
+	&Value{Value: unix.NETLINK_XFRM, Name: "NETLINK_XFRM"},
+	&Value{Value: unix.NETLINK_SELINUX, Name: "NETLINK_SELINUX"},
+	&Value{Value: unix.NETLINK_ISCSI, Name: "NETLINK_ISCSI"},
+	&Value{Value: unix.NETLINK_AUDIT, Name: "NETLINK_AUDIT"},
+	&Value{Value: unix.NETLINK_FIB_LOOKUP, Name: "NETLINK_FIB_LOOKUP"},
+	&Value{Value: unix.NETLINK_CONNECTOR, Name: "NETLINK_CONNECTOR"},
+	&Value{Value: unix.NETLINK_NETFILTER, Name: "NETLINK_NETFILTER"},
+	&Value{Value: unix.NETLINK_IP6_FW, Name: "NETLINK_IP6_FW"},
+	&Value{Value: unix.NETLINK_DNRTMSG, Name: "NETLINK_DNRTMSG"},
+	&Value{Value: unix.NETLINK_KOBJECT_UEVENT, Name: "NETLINK_KOBJECT_UEVENT"},
+	&Value{Value: unix.NETLINK_GENERIC, Name: "NETLINK_GENERIC"},
+	&Value{Value: unix.NETLINK_SCSITRANSPORT, Name: "NETLINK_SCSITRANSPORT"},
+	&Value{Value: unix.NETLINK_ECRYPTFS, Name: "NETLINK_ECRYPTFS"},
+	&Value{Value: unix.NETLINK_RDMA, Name: "NETLINK_RDMA"},
+	&Value{Value: unix.NETLINK_CRYPTO, Name: "NETLINK_CRYPTO"},
+	&Value{Value: unix.NETLINK_SMC, Name: "NETLINK_SMC"},
+}
+
+// NetlinkMessageTypes are control message types common to all netlink protocols.
+var NetlinkMessageTypes = FlagSet{
+	&Value{Value: unix.NLMSG_NOOP, Name: "NLMSG_NOOP"},
+	&Value{Value: unix.NLMSG_ERROR, Name: "NLMSG_ERROR"},
+	&Value{Value: unix.NLMSG_DONE, Name: "NLMSG_DONE"},
+	&Value{Value: unix.NLMSG_OVERRUN, Name: "NLMSG_OVERRUN"},
+}
+
+// RouteMessageTypes are rtnetlink message types.
+var RouteMessageTypes = FlagSet{
+	&Value{Value: unix.RTM_NEWLINK, Name: "RTM_NEWLINK"},
+	&Value{Value: unix.RTM_DELLINK, Name: "RTM_DELLINK"},
+	&Value{Value: unix.RTM_GETLINK, Name: "RTM_GETLINK"},
+	&Value{Value: unix.RTM_SETLINK, Name: "RTM_SETLINK"},
+	&Value{Value: unix.RTM_NEWADDR, Name: "RTM_NEWADDR"},
+	&Value{Value: unix.RTM_DELADDR, Name: "RTM_DELADDR"},
+	&Value{Value: unix.RTM_GETADDR, Name: "RTM_GETADDR"},
+	&Value{Value: unix.RTM_NEWROUTE, Name: "RTM_NEWROUTE"},
+	&Value{Value: unix.RTM_DELROUTE, Name: "RTM_DELROUTE"},
+	&Value{Value: unix.RTM_GETROUTE, Name: "RTM_GETROUTE"},
+	&Value{Value: unix.RTM_NEWNEIGH, Name: "RTM_NEWNEIGH"},
+	&Value{Value: unix.RTM_DELNEIGH, Name: "RTM_DELNEIGH"},
+	&Value{Value: unix.RTM_GETNEIGH, Name: "RTM_GETNEIGH"},
+	&Value{Value: unix.RTM_NEWRULE, Name: "RTM_NEWRULE"},
+	&Value{Value: unix.RTM_DELRULE, Name: "RTM_DELRULE"},
+	&Value{Value: unix.RTM_GETRULE, Name: "RTM_GETRULE"},
+	&Value{Value: unix.RTM_NEWQDISC, Name: "RTM_NEWQDISC"},
+	&Value{Value: unix.RTM_DELQDISC, Name: "RTM_DELQDISC"},
+	&Value{Value: unix.RTM_GETQDISC, Name: "RTM_GETQDISC"},
+	&Value{Value: unix.RTM_NEWTCLASS, Name: "RTM_NEWTCLASS"},
+	&Value{Value: unix.RTM_DELTCLASS, Name: "RTM_DELTCLASS"},
+	&Value{Value: unix.RTM_GETTCLASS, Name: "RTM_GETTCLASS"},
+	&Value{Value: unix.RTM_NEWTFILTER, Name: "RTM_NEWTFILTER"},
+	&Value{Value: unix.RTM_DELTFILTER, Name: "RTM_DELTFILTER"},
+	&Value{Value: unix.RTM_GETTFILTER, Name: "RTM_GETTFILTER"},
+	&Value{Value: unix.RTM_NEWACTION, Name: "RTM_NEWACTION"},
+	&Value{Value: unix.RTM_DELACTION, Name: "RTM_DELACTION"},
+	&Value{Value: unix.RTM_GETACTION, Name: "RTM_GETACTION"},
+	&Value{Value: unix.RTM_NEWPREFIX, Name: "RTM_NEWPREFIX"},
+	&Value{Value: unix.RTM_GETMULTICAST, Name: "RTM_GETMULTICAST"},
+	&Value{Value: unix.RTM_GETANYCAST, Name: "RTM_GETANYCAST"},
+	&Value{Value: unix.RTM_NEWNEIGHTBL, Name: "RTM_NEWNEIGHTBL"},
+	&Value{Value: unix.RTM_GETNEIGHTBL, Name: "RTM_GETNEIGHTBL"},
+	&Value{Value: unix.RTM_SETNEIGHTBL, Name: "RTM_SETNEIGHTBL"},
+	&Value{Value: unix.RTM_NEWNDUSEROPT, Name: "RTM_NEWNDUSEROPT"},
+	&Value{Value: unix.RTM_NEWADDRLABEL, Name: "RTM_NEWADDRLABEL"},
+	&Value{Value: unix.RTM_DELADDRLABEL, Name: "RTM_DELADDRLABEL"},
+	&Value{Value: unix.RTM_GETADDRLABEL, Name: "RTM_GETADDRLABEL"},
+	&Value{Value: unix.RTM_GETDCB, Name: "RTM_GETDCB"},
+	&Value{Value: unix.RTM_SETDCB, Name: "RTM_SETDCB"},
+	&Value{Value: unix.RTM_NEWNETCONF, Name: "RTM_NEWNETCONF"},
+	&Value{Value: unix.RTM_DELNETCONF, Name: "RTM_DELNETCONF"},
+	&Value{Value: unix.RTM_GETNETCONF, Name: "RTM_GETNETCONF"},
+	&Value{Value: unix.RTM_NEWMDB, Name: "RTM_NEWMDB"},
+	&Value{Value: unix.RTM_DELMDB, Name: "RTM_DELMDB"},
+	&Value{Value: unix.RTM_GETMDB, Name: "RTM_GETMDB"},
+	&Value{Value: unix.RTM_NEWNSID, Name: "RTM_NEWNSID"},
+	&Value{Value: unix.RTM_DELNSID, Name: "RTM_DELNSID"},
+	&Value{Value: unix.RTM_GETNSID, Name: "RTM_GETNSID"},
+	&Value{Value: unix.RTM_NEWSTATS, Name: "RTM_NEWSTATS"},
+	&Value{Value: unix.RTM_GETSTATS, Name: "RTM_GETSTATS"},
+	&Value{Value: unix.RTM_SETSTATS, Name: "RTM_SETSTATS"},
+	&Value{Value: unix.RTM_NEWCACHEREPORT, Name: "RTM_NEWCACHEREPORT"},
+	&Value{Value: unix.RTM_NEWCHAIN, Name: "RTM_NEWCHAIN"},
+	&Value{Value: unix.RTM_DELCHAIN, Name: "RTM_DELCHAIN"},
+	&Value{Value: unix.RTM_GETCHAIN, Name: "RTM_GETCHAIN"},
+	&Value{Value: unix.RTM_NEWNEXTHOP, Name: "RTM_NEWNEXTHOP"},
+	&Value{Value: unix.RTM_DELNEXTHOP, Name: "RTM_DELNEXTHOP"},
+	&Value{Value: unix.RTM_GETNEXTHOP, Name: "RTM_GETNEXTHOP"},
+	&Value{Value: unix.RTM_NEWLINKPROP, Name: "RTM_NEWLINKPROP"},
+	&Value{Value: unix.RTM_DELLINKPROP, Name: "RTM_DELLINKPROP"},
+	&Value{Value: unix.RTM_GETLINKPROP, Name: "RTM_GETLINKPROP"},
+	&Value{Value: unix.RTM_NEWNVLAN, Name: "RTM_NEWNVLAN"},
+	&Value{Value: unix.RTM_DELVLAN, Name: "RTM_DELVLAN"},
+	&Value{Value: unix.RTM_GETVLAN, Name: "RTM_GETVLAN"},
+	&Value{Value: unix.RTM_NEWNEXTHOPBUCKET, Name: "RTM_NEWNEXTHOPBUCKET"},
+	&Value{Value: unix.RTM_DELNEXTHOPBUCKET, Name: "RTM_DELNEXTHOPBUCKET"},
+	&Value{Value: unix.RTM_GETNEXTHOPBUCKET, Name: "RTM_GETNEXTHOPBUCKET"},
+	&Value{Value: unix.RTM_NEWTUNNEL, Name: "RTM_NEWTUNNEL"},
+	&Value{Value: unix.RTM_DELTUNNEL, Name: "RTM_DELTUNNEL"},
+	&Value{Value: unix.RTM_GETTUNNEL, Name: "RTM_GETTUNNEL"},
+}
+
+// NetlinkFlagSet are nlmsg_flags common to all requests.
+var NetlinkFlagSet = FlagSet{
+	&BitFlag{Value: unix.NLM_F_REQUEST, Name: "NLM_F_REQUEST"},
+	&BitFlag{Value: unix.NLM_F_MULTI, Name: "NLM_F_MULTI"},
+	&BitFlag{Value: unix.NLM_F_ACK, Name: "NLM_F_ACK"},
+	&BitFlag{Value: unix.NLM_F_ECHO, Name: "NLM_F_ECHO"},
+	&BitFlag{Value: unix.NLM_F_DUMP_INTR, Name: "NLM_F_DUMP_INTR"},
+	&BitFlag{Value: unix.NLM_F_DUMP_FILTERED, Name: "NLM_F_DUMP_FILTERED"},
+}
+
+// NetlinkGetFlagSet are nlmsg_flags of GET requests.
+var NetlinkGetFlagSet = append(FlagSet{
+	&BitFlag{Value: unix.NLM_F_DUMP, Name: "NLM_F_DUMP"},
+	&BitFlag{Value: unix.NLM_F_ROOT, Name: "NLM_F_ROOT"},
+	&BitFlag{Value: unix.NLM_F_MATCH, Name: "NLM_F_MATCH"},
+	&BitFlag{Value: unix.NLM_F_ATOMIC, Name: "NLM_F_ATOMIC"},
+}, NetlinkFlagSet...)
+
+// NetlinkNewFlagSet are nlmsg_flags of NEW requests.
+var NetlinkNewFlagSet = append(FlagSet{
+	&BitFlag{Value: unix.NLM_F_REPLACE, Name: "NLM_F_REPLACE"},
+	&BitFlag{Value: unix.NLM_F_EXCL, Name: "NLM_F_EXCL"},
+	&BitFlag{Value: unix.NLM_F_CREATE, Name: "NLM_F_CREATE"},
+	&BitFlag{Value: unix.NLM_F_APPEND, Name: "NLM_F_APPEND"},
+}, NetlinkFlagSet...)
+
+// InterfaceFlagSet are network device flags from uapi/linux/if.h.
+var InterfaceFlagSet = FlagSet{
+	&BitFlag{Value: 0x1, Name: "IFF_UP"},
+	&BitFlag{Value: 0x2, Name: "IFF_BROADCAST"},
+	&BitFlag{Value: 0x4, Name: "IFF_DEBUG"},
+	&BitFlag{Value: 0x8, Name: "IFF_LOOPBACK"},
+	&BitFlag{Value: 0x10, Name: "IFF_POINTOPOINT"},
+	&BitFlag{Value: 0x20, Name: "IFF_NOTRAILERS"},
+	&BitFlag{Value: 0x40, Name: "IFF_RUNNING"},
+	&BitFlag{Value: 0x80, Name: "IFF_NOARP"},
+	&BitFlag{Value: 0x100, Name: "IFF_PROMISC"},
+	&BitFlag{Value: 0x200, Name: "IFF_ALLMULTI"},
+	&BitFlag{Value: 0x400, Name: "IFF_MASTER"},
+	&BitFlag{Value: 0x800, Name: "IFF_SLAVE"},
+	&BitFlag{Value: 0x1000, Name: "IFF_MULTICAST"},
+	&BitFlag{Value: 0x2000, Name: "IFF_PORTSEL"},
+	&BitFlag{Value: 0x4000, Name: "IFF_AUTOMEDIA"},
+	&BitFlag{Value: 0x8000, Name: "IFF_DYNAMIC"},
+	&BitFlag{Value: 0x10000, Name: "IFF_LOWER_UP"},
+	&BitFlag{Value: 0x20000, Name: "IFF_DORMANT"},
+	&BitFlag{Value: 0x40000, Name: "IFF_ECHO"},
+}
+
+// OperStates are RFC 2863 operational states of IFLA_OPERSTATE.
+var OperStates = FlagSet{
+	&Value{Value: 0, Name: "IF_OPER_UNKNOWN"},
+	&Value{Value: 1, Name: "IF_OPER_NOTPRESENT"},
+	&Value{Value: 2, Name: "IF_OPER_DOWN"},
+	&Value{Value: 3, Name: "IF_OPER_LOWERLAYERDOWN"},
+	&Value{Value: 4, Name: "IF_OPER_TESTING"},
+	&Value{Value: 5, Name: "IF_OPER_DORMANT"},
+	&Value{Value: 6, Name: "IF_OPER_UP"},
+}
+
+// InterfaceAddrFlagSet are flags of struct ifaddrmsg and IFA_FLAGS.
+var InterfaceAddrFlagSet = FlagSet{
+	&BitFlag{Value: unix.IFA_F_SECONDARY, Name: "IFA_F_SECONDARY"},
+	&BitFlag{Value: unix.IFA_F_NODAD, Name: "IFA_F_NODAD"},
+	&BitFlag{Value: unix.IFA_F_OPTIMISTIC, Name: "IFA_F_OPTIMISTIC"},
+	&BitFlag{Value: unix.IFA_F_DADFAILED, Name: "IFA_F_DADFAILED"},
+	&BitFlag{Value: unix.IFA_F_HOMEADDRESS, Name: "IFA_F_HOMEADDRESS"},
+	&BitFlag{Value: unix.IFA_F_DEPRECATED, Name: "IFA_F_DEPRECATED"},
+	&BitFlag{Value: unix.IFA_F_TENTATIVE, Name: "IFA_F_TENTATIVE"},
+	&BitFlag{Value: unix.IFA_F_PERMANENT, Name: "IFA_F_PERMANENT"},
+	&BitFlag{Value: unix.IFA_F_MANAGETEMPADDR, Name: "IFA_F_MANAGETEMPADDR"},
+	&BitFlag{Value: unix.IFA_F_NOPREFIXROUTE, Name: "IFA_F_NOPREFIXROUTE"},
+	&BitFlag{Value: unix.IFA_F_MCAUTOJOIN, Name: "IFA_F_MCAUTOJOIN"},
+	&BitFlag{Value: unix.IFA_F_STABLE_PRIVACY, Name: "IFA_F_STABLE_PRIVACY"},
+}
+
+// LinkAttrTypes are attribute types of link (RTM_*LINK) messages.
+var LinkAttrTypes = FlagSet{
+	&Value{Value: unix.IFLA_UNSPEC, Name: "IFLA_UNSPEC"},
+	&Value{Value: unix.IFLA_ADDRESS, Name: "IFLA_ADDRESS"},
+	&Value{Value: unix.IFLA_BROADCAST, Name: "IFLA_BROADCAST"},
+	&Value{Value: unix.IFLA_IFNAME, Name: "IFLA_IFNAME"},
+	&Value{Value: unix.IFLA_MTU, Name: "IFLA_MTU"},
+	&Value{Value: unix.IFLA_LINK, Name: "IFLA_LINK"},
+	&Value{Value: unix.IFLA_QDISC, Name: "IFLA_QDISC"},
+	&Value{Value: unix.IFLA_STATS, Name: "IFLA_STATS"},
+	&Value{Value: unix.IFLA_COST, Name: "IFLA_COST"},
+	&Value{Value: unix.IFLA_PRIORITY, Name: "IFLA_PRIORITY"},
+	&Value{Value: unix.IFLA_MASTER, Name: "IFLA_MASTER"},
+	&Value{Value: unix.IFLA_WIRELESS, Name: "IFLA_WIRELESS"},
+	&Value{Value: unix.IFLA_PROTINFO, Name: "IFLA_PROTINFO"},
+	&Value{Value: unix.IFLA_TXQLEN, Name: "IFLA_TXQLEN"},
+	&Value{Value: unix.IFLA_MAP, Name: "IFLA_MAP"},
+	&Value{Value: unix.IFLA_WEIGHT, Name: "IFLA_WEIGHT"},
+	&Value{Value: unix.IFLA_OPERSTATE, Name: "IFLA_OPERSTATE"},
+	&Value{Value: unix.IFLA_LINKMODE, Name: "IFLA_LINKMODE"},
+	&Value{Value: unix.IFLA_LINKINFO, Name: "IFLA_LINKINFO"},
+	&Value{Value: unix.IFLA_NET_NS_PID, Name: "IFLA_NET_NS_PID"},
+	&Value{Value: unix.IFLA_IFALIAS, Name: "IFLA_IFALIAS"},
+	&Value{Value: unix.IFLA_NUM_VF, Name: "IFLA_NUM_VF"},
+	&Value{Value: unix.IFLA_VFINFO_LIST, Name: "IFLA_VFINFO_LIST"},
+	&Value{Value: unix.IFLA_STATS64, Name: "IFLA_STATS64"},
+	&Value{Value: unix.IFLA_VF_PORTS, Name: "IFLA_VF_PORTS"},
+	&Value{Value: unix.IFLA_PORT_SELF, Name: "IFLA_PORT_SELF"},
+	&Value{Value: unix.IFLA_AF_SPEC, Name: "IFLA_AF_SPEC"},
+	&Value{Value: unix.IFLA_GROUP, Name: "IFLA_GROUP"},
+	&Value{Value: unix.IFLA_NET_NS_FD, Name: "IFLA_NET_NS_FD"},
+	&Value{Value: unix.IFLA_EXT_MASK, Name: "IFLA_EXT_MASK"},
+	&Value{Value: unix.IFLA_PROMISCUITY, Name: "IFLA_PROMISCUITY"},
+	&Value{Value: unix.IFLA_NUM_TX_QUEUES, Name: "IFLA_NUM_TX_QUEUES"},
+	&Value{Value: unix.IFLA_NUM_RX_QUEUES, Name: "IFLA_NUM_RX_QUEUES"},
+	&Value{Value: unix.IFLA_CARRIER, Name: "IFLA_CARRIER"},
+	&Value{Value: unix.IFLA_PHYS_PORT_ID, Name: "IFLA_PHYS_PORT_ID"},
+	&Value{Value: unix.IFLA_CARRIER_CHANGES, Name: "IFLA_CARRIER_CHANGES"},
+	&Value{Value: unix.IFLA_PHYS_SWITCH_ID, Name: "IFLA_PHYS_SWITCH_ID"},
+	&Value{Value: unix.IFLA_LINK_NETNSID, Name: "IFLA_LINK_NETNSID"},
+	&Value{Value: unix.IFLA_PHYS_PORT_NAME, Name: "IFLA_PHYS_PORT_NAME"},
+	&Value{Value: unix.IFLA_PROTO_DOWN, Name: "IFLA_PROTO_DOWN"},
+	&Value{Value: unix.IFLA_GSO_MAX_SEGS, Name: "IFLA_GSO_MAX_SEGS"},
+	&Value{Value: unix.IFLA_GSO_MAX_SIZE, Name: "IFLA_GSO_MAX_SIZE"},
+	&Value{Value: unix.IFLA_PAD, Name: "IFLA_PAD"},
+	&Value{Value: unix.IFLA_XDP, Name: "IFLA_XDP"},
+	&Value{Value: unix.IFLA_EVENT, Name: "IFLA_EVENT"},
+	&Value{Value: unix.IFLA_NEW_NETNSID, Name: "IFLA_NEW_NETNSID"},
+	&Value{Value: unix.IFLA_IF_NETNSID, Name: "IFLA_IF_NETNSID"},
+	&Value{Value: unix.IFLA_CARRIER_UP_COUNT, Name: "IFLA_CARRIER_UP_COUNT"},
+	&Value{Value: unix.IFLA_CARRIER_DOWN_COUNT, Name: "IFLA_CARRIER_DOWN_COUNT"},
+	&Value{Value: unix.IFLA_NEW_IFINDEX, Name: "IFLA_NEW_IFINDEX"},
+	&Value{Value: unix.IFLA_MIN_MTU, Name: "IFLA_MIN_MTU"},
+	&Value{Value: unix.IFLA_MAX_MTU, Name: "IFLA_MAX_MTU"},
+	&Value{Value: unix.IFLA_PROP_LIST, Name: "IFLA_PROP_LIST"},
+	&Value{Value: unix.IFLA_ALT_IFNAME, Name: "IFLA_ALT_IFNAME"},
+	&Value{Value: unix.IFLA_PERM_ADDRESS, Name: "IFLA_PERM_ADDRESS"},
+	&Value{Value: unix.IFLA_PROTO_DOWN_REASON, Name: "IFLA_PROTO_DOWN_REASON"},
+	&Value{Value: unix.IFLA_PARENT_DEV_NAME, Name: "IFLA_PARENT_DEV_NAME"},
+	&Value{Value: unix.IFLA_PARENT_DEV_BUS_NAME, Name: "IFLA_PARENT_DEV_BUS_NAME"},
+	&Value{Value: unix.IFLA_GRO_MAX_SIZE, Name: "IFLA_GRO_MAX_SIZE"},
+	&Value{Value: unix.IFLA_TSO_MAX_SIZE, Name: "IFLA_TSO_MAX_SIZE"},
+	&Value{Value: unix.IFLA_TSO_MAX_SEGS, Name: "IFLA_TSO_MAX_SEGS"},
+}
+
+// LinkInfoAttrTypes are attribute types nested in IFLA_LINKINFO.
+var LinkInfoAttrTypes = FlagSet{
+	&Value{Value: unix.IFLA_INFO_UNSPEC, Name: "IFLA_INFO_UNSPEC"},
+	&Value{Value: unix.IFLA_INFO_KIND, Name: "IFLA_INFO_KIND"},
+	&Value{Value: unix.IFLA_INFO_DATA, Name: "IFLA_INFO_DATA"},
+	&Value{Value: unix.IFLA_INFO_XSTATS, Name: "IFLA_INFO_XSTATS"},
+	&Value{Value: unix.IFLA_INFO_SLAVE_KIND, Name: "IFLA_INFO_SLAVE_KIND"},
+	&Value{Value: unix.IFLA_INFO_SLAVE_DATA, Name: "IFLA_INFO_SLAVE_DATA"},
+}
+
+// AddrAttrTypes are attribute types of address (RTM_*ADDR) messages.
+var AddrAttrTypes = FlagSet{
+	&Value{Value: unix.IFA_UNSPEC, Name: "IFA_UNSPEC"},
+	&Value{Value: unix.IFA_ADDRESS, Name: "IFA_ADDRESS"},
+	&Value{Value: unix.IFA_LOCAL, Name: "IFA_LOCAL"},
+	&Value{Value: unix.IFA_LABEL, Name: "IFA_LABEL"},
+	&Value{Value: unix.IFA_BROADCAST, Name: "IFA_BROADCAST"},
+	&Value{Value: unix.IFA_ANYCAST, Name: "IFA_ANYCAST"},
+	&Value{Value: unix.IFA_CACHEINFO, Name: "IFA_CACHEINFO"},
+	&Value{Value: unix.IFA_MULTICAST, Name: "IFA_MULTICAST"},
+	&Value{Value: unix.IFA_FLAGS, Name: "IFA_FLAGS"},
+	&Value{Value: unix.IFA_RT_PRIORITY, Name: "IFA_RT_PRIORITY"},
+	&Value{Value: unix.IFA_TARGET_NETNSID, Name: "IFA_TARGET_NETNSID"},
+}
+
+// RouteAttrTypes are attribute types of route (RTM_*ROUTE) messages.
+var RouteAttrTypes = FlagSet{
+	&Value{Value: unix.RTA_UNSPEC, Name: "RTA_UNSPEC"},
+	&Value{Value: unix.RTA_DST, Name: "RTA_DST"},
+	&Value{Value: unix.RTA_SRC, Name: "RTA_SRC"},
+	&Value{Value: unix.RTA_IIF, Name: "RTA_IIF"},
+	&Value{Value: unix.RTA_OIF, Name: "RTA_OIF"},
+	&Value{Value: unix.RTA_GATEWAY, Name: "RTA_GATEWAY"},
+	&Value{Value: unix.RTA_PRIORITY, Name: "RTA_PRIORITY"},
+	&Value{Value: unix.RTA_PREFSRC, Name: "RTA_PREFSRC"},
+	&Value{Value: unix.RTA_METRICS, Name: "RTA_METRICS"},
+	&Value{Value: unix.RTA_MULTIPATH, Name: "RTA_MULTIPATH"},
+	&Value{Value: unix.RTA_FLOW, Name: "RTA_FLOW"},
+	&Value{Value: unix.RTA_CACHEINFO, Name: "RTA_CACHEINFO"},
+	&Value{Value: unix.RTA_TABLE, Name: "RTA_TABLE"},
+	&Value{Value: unix.RTA_MARK, Name: "RTA_MARK"},
+	&Value{Value: unix.RTA_MFC_STATS, Name: "RTA_MFC_STATS"},
+	&Value{Value: unix.RTA_VIA, Name: "RTA_VIA"},
+	&Value{Value: unix.RTA_NEWDST, Name: "RTA_NEWDST"},
+	&Value{Value: unix.RTA_PREF, Name: "RTA_PREF"},
+	&Value{Value: unix.RTA_ENCAP_TYPE, Name: "RTA_ENCAP_TYPE"},
+	&Value{Value: unix.RTA_ENCAP, Name: "RTA_ENCAP"},
+	&Value{Value: unix.RTA_EXPIRES, Name: "RTA_EXPIRES"},
+	&Value{Value: unix.RTA_PAD, Name: "RTA_PAD"},
+	&Value{Value: unix.RTA_UID, Name: "RTA_UID"},
+	&Value{Value: unix.RTA_TTL_PROPAGATE, Name: "RTA_TTL_PROPAGATE"},
+	&Value{Value: unix.RTA_IP_PROTO, Name: "RTA_IP_PROTO"},
+	&Value{Value: unix.RTA_SPORT, Name: "RTA_SPORT"},
+	&Value{Value: unix.RTA_DPORT, Name: "RTA_DPORT"},
+}
+
+// RouteTables are well-known routing table ids.
+var RouteTables = FlagSet{
+	&Value{Value: unix.RT_TABLE_UNSPEC, Name: "RT_TABLE_UNSPEC"},
+	&Value{Value: unix.RT_TABLE_COMPAT, Name: "RT_TABLE_COMPAT"},
+	&Value{Value: unix.RT_TABLE_DEFAULT, Name: "RT_TABLE_DEFAULT"},
+	&Value{Value: unix.RT_TABLE_MAIN, Name: "RT_TABLE_MAIN"},
+	&Value{Value: unix.RT_TABLE_LOCAL, Name: "RT_TABLE_LOCAL"},
+}
+
+// RouteProtocols are origins of routes.
+var RouteProtocols = FlagSet{
+	&Value{Value: unix.RTPROT_UNSPEC, Name: "RTPROT_UNSPEC"},
+	&Value{Value: unix.RTPROT_REDIRECT, Name: "RTPROT_REDIRECT"},
+	&Value{Value: unix.RTPROT_KERNEL, Name: "RTPROT_KERNEL"},
+	&Value{Value: unix.RTPROT_BOOT, Name: "RTPROT_BOOT"},
+	&Value{Value: unix.RTPROT_STATIC, Name: "RTPROT_STATIC"},
+	&Value{Value: unix.RTPROT_RA, Name: "RTPROT_RA"},
+	&Value{Value: unix.RTPROT_DHCP, Name: "RTPROT_DHCP"},
+}
+
+// RouteScopes are scopes of routes and addresses.
+var RouteScopes = FlagSet{
+	&Value{Value: unix.RT_SCOPE_UNIVERSE, Name: "RT_SCOPE_UNIVERSE"},
+	&Value{Value: unix.RT_SCOPE_SITE, Name: "RT_SCOPE_SITE"},
+	&Value{Value: unix.RT_SCOPE_LINK, Name: "RT_SCOPE_LINK"},
+	&Value{Value: unix.RT_SCOPE_HOST, Name: "RT_SCOPE_HOST"},
+	&Value{Value: unix.RT_SCOPE_NOWHERE, Name: "RT_SCOPE_NOWHERE"},
+}
+
+// RouteTypes are types of routes.
+var RouteTypes = FlagSet{
+	&Value{Value: unix.RTN_UNSPEC, Name: "RTN_UNSPEC"},
+	&Value{Value: unix.RTN_UNICAST, Name: "RTN_UNICAST"},
+	&Value{Value: unix.RTN_LOCAL, Name: "RTN_LOCAL"},
+	&Value{Value: unix.RTN_BROADCAST, Name: "RTN_BROADCAST"},
+	&Value{Value: unix.RTN_ANYCAST, Name: "RTN_ANYCAST"},
+	&Value{Value: unix.RTN_MULTICAST, Name: "RTN_MULTICAST"},
+	&Value{Value: unix.RTN_BLACKHOLE, Name: "RTN_BLACKHOLE"},
+	&Value{Value: unix.RTN_UNREACHABLE, Name: "RTN_UNREACHABLE"},
+	&Value{Value: unix.RTN_PROHIBIT, Name: "RTN_PROHIBIT"},
+	&Value{Value: unix.RTN_THROW, Name: "RTN_THROW"},
+	&Value{Value: unix.RTN_NAT, Name: "RTN_NAT"},
+	&Value{Value: unix.RTN_XRESOLVE, Name: "RTN_XRESOLVE"},
+}
+
+// bpf
+
+// BPFCommands are commands of bpf(2).
+var BPFCommands = FlagSet{
+	&Value{Value: unix.BPF_MAP_CREATE, Name: "BPF_MAP_CREATE"},
+	&Value{Value: unix.BPF_MAP_LOOKUP_ELEM, Name: "BPF_MAP_LOOKUP_ELEM"},
+	&Value{Value: unix.BPF_MAP_UPDATE_ELEM, Name: "BPF_MAP_UPDATE_ELEM"},
+	&Value{Value: unix.BPF_MAP_DELETE_ELEM, Name: "BPF_MAP_DELETE_ELEM"},
+	&Value{Value: unix.BPF_MAP_GET_NEXT_KEY, Name: "BPF_MAP_GET_NEXT_KEY"},
+	&Value{Value: unix.BPF_PROG_LOAD, Name: "BPF_PROG_LOAD"},
+	&Value{Value: unix.BPF_OBJ_PIN, Name: "BPF_OBJ_PIN"},
+	&Value{Value: unix.BPF_OBJ_GET, Name: "BPF_OBJ_GET"},
+	&Value{Value: unix.BPF_PROG_ATTACH, Name: "BPF_PROG_ATTACH"},
+	&Value{Value: unix.BPF_PROG_DETACH, Name: "BPF_PROG_DETACH"},
+	&Value{Value: unix.BPF_PROG_TEST_RUN, Name: "BPF_PROG_TEST_RUN"},
+	&Value{Value: unix.BPF_PROG_GET_NEXT_ID, Name: "BPF_PROG_GET_NEXT_ID"},
+	&Value{Value: unix.BPF_MAP_GET_NEXT_ID, Name: "BPF_MAP_GET_NEXT_ID"},
+	&Value{Value: unix.BPF_PROG_GET_FD_BY_ID, Name: "BPF_PROG_GET_FD_BY_ID"},
+	&Value{Value: unix.BPF_MAP_GET_FD_BY_ID, Name: "BPF_MAP_GET_FD_BY_ID"},
+	&Value{Value: unix.BPF_OBJ_GET_INFO_BY_FD, Name: "BPF_OBJ_GET_INFO_BY_FD"},
+	&Value{Value: unix.BPF_PROG_QUERY, Name: "BPF_PROG_QUERY"},
+	&Value{Value: unix.BPF_RAW_TRACEPOINT_OPEN, Name: "BPF_RAW_TRACEPOINT_OPEN"},
+	&Value{Value: unix.BPF_BTF_LOAD, Name: "BPF_BTF_LOAD"},
+	&Value{Value: unix.BPF_BTF_GET_FD_BY_ID, Name: "BPF_BTF_GET_FD_BY_ID"},
+	&Value{Value: unix.BPF_TASK_FD_QUERY, Name: "BPF_TASK_FD_QUERY"},
+	&Value{Value: unix.BPF_MAP_LOOKUP_AND_DELETE_ELEM, Name: "BPF_MAP_LOOKUP_AND_DELETE_ELEM"},
+	&Value{Value: unix.BPF_MAP_FREEZE, Name: "BPF_MAP_FREEZE"},
+	&Value{Value: unix.BPF_BTF_GET_NEXT_ID, Name: "BPF_BTF_GET_NEXT_ID"},
+	&Value{Value: unix.BPF_MAP_LOOKUP_BATCH, Name: "BPF_MAP_LOOKUP_BATCH"},
+	&Value{Value: unix.BPF_MAP_LOOKUP_AND_DELETE_BATCH, Name: "BPF_MAP_LOOKUP_AND_DELETE_BATCH"},
+	&Value{Value: unix.BPF_MAP_UPDATE_BATCH, Name: "BPF_MAP_UPDATE_BATCH"},
+	&Value{Value: unix.BPF_MAP_DELETE_BATCH, Name: "BPF_MAP_DELETE_BATCH"},
+	&Value{Value: unix.BPF_LINK_CREATE, Name: "BPF_LINK_CREATE"},
+	&Value{Value: unix.BPF_LINK_UPDATE, Name: "BPF_LINK_UPDATE"},
+	&Value{Value: unix.BPF_LINK_GET_FD_BY_ID, Name: "BPF_LINK_GET_FD_BY_ID"},
+	&Value{Value: unix.BPF_LINK_GET_NEXT_ID, Name: "BPF_LINK_GET_NEXT_ID"},
+	&Value{Value: unix.BPF_ENABLE_STATS, Name: "BPF_ENABLE_STATS"},
+	&Value{Value: unix.BPF_ITER_CREATE, Name: "BPF_ITER_CREATE"},
+	&Value{Value: unix.BPF_LINK_DETACH, Name: "BPF_LINK_DETACH"},
+	&Value{Value: unix.BPF_PROG_BIND_MAP, Name: "BPF_PROG_BIND_MAP"},
+}
+
+// BPFMapTypes are types of BPF maps.
+var BPFMapTypes = FlagSet{
+	&Value{Value: unix.BPF_MAP_TYPE_UNSPEC, Name: "BPF_MAP_TYPE_UNSPEC"},
+	&Value{Value: unix.BPF_MAP_TYPE_HASH, Name: "BPF_MAP_TYPE_HASH"},
+	&Value{Value: unix.BPF_MAP_TYPE_ARRAY, Name: "BPF_MAP_TYPE_ARRAY"},
+	&Value{Value: unix.BPF_MAP_TYPE_PROG_ARRAY, Name: "BPF_MAP_TYPE_PROG_ARRAY"},
+	&Value{Value: unix.BPF_MAP_TYPE_PERF_EVENT_ARRAY, Name: "BPF_MAP_TYPE_PERF_EVENT_ARRAY"},
+	&Value{Value: unix.BPF_MAP_TYPE_PERCPU_HASH, Name: "BPF_MAP_TYPE_PERCPU_HASH"},
+	&Value{Value: unix.BPF_MAP_TYPE_PERCPU_ARRAY, Name: "BPF_MAP_TYPE_PERCPU_ARRAY"},
+	&Value{Value: unix.BPF_MAP_TYPE_STACK_TRACE, Name: "BPF_MAP_TYPE_STACK_TRACE"},
+	&Value{Value: unix.BPF_MAP_TYPE_CGROUP_ARRAY, Name: "BPF_MAP_TYPE_CGROUP_ARRAY"},
+	&Value{Value: unix.BPF_MAP_TYPE_LRU_HASH, Name: "BPF_MAP_TYPE_LRU_HASH"},
+	&Value{Value: unix.BPF_MAP_TYPE_LRU_PERCPU_HASH, Name: "BPF_MAP_TYPE_LRU_PERCPU_HASH"},
+	&Value{Value: unix.BPF_MAP_TYPE_LPM_TRIE, Name: "BPF_MAP_TYPE_LPM_TRIE"},
+	&Value{Value: unix.BPF_MAP_TYPE_ARRAY_OF_MAPS, Name: "BPF_MAP_TYPE_ARRAY_OF_MAPS"},
+	&Value{Value: unix.BPF_MAP_TYPE_HASH_OF_MAPS, Name: "BPF_MAP_TYPE_HASH_OF_MAPS"},
+	&Value{Value: unix.BPF_MAP_TYPE_DEVMAP, Name: "BPF_MAP_TYPE_DEVMAP"},
+	&Value{Value: unix.BPF_MAP_TYPE_SOCKMAP, Name: "BPF_MAP_TYPE_SOCKMAP"},
+	&Value{Value: unix.BPF_MAP_TYPE_CPUMAP, Name: "BPF_MAP_TYPE_CPUMAP"},
+	&Value{Value: unix.BPF_MAP_TYPE_XSKMAP, Name: "BPF_MAP_TYPE_XSKMAP"},
+	&Value{Value: unix.BPF_MAP_TYPE_SOCKHASH, Name: "BPF_MAP_TYPE_SOCKHASH"},
+	&Value{Value: unix.BPF_MAP_TYPE_CGROUP_STORAGE, Name: "BPF_MAP_TYPE_CGROUP_STORAGE"},
+	&Value{Value: unix.BPF_MAP_TYPE_REUSEPORT_SOCKARRAY, Name: "BPF_MAP_TYPE_REUSEPORT_SOCKARRAY"},
+	&Value{Value: unix.BPF_MAP_TYPE_PERCPU_CGROUP_STORAGE, Name: "BPF_MAP_TYPE_PERCPU_CGROUP_STORAGE"},
+	&Value{Value: unix.BPF_MAP_TYPE_QUEUE, Name: "BPF_MAP_TYPE_QUEUE"},
+	&Value{Value: unix.BPF_MAP_TYPE_STACK, Name: "BPF_MAP_TYPE_STACK"},
+	&Value{Value: unix.BPF_MAP_TYPE_SK_STORAGE, Name: "BPF_MAP_TYPE_SK_STORAGE"},
+	&Value{Value: unix.BPF_MAP_TYPE_DEVMAP_HASH, Name: "BPF_MAP_TYPE_DEVMAP_HASH"},
+	&Value{Value: unix.BPF_MAP_TYPE_STRUCT_OPS, Name: "BPF_MAP_TYPE_STRUCT_OPS"},
+	&Value{Value: unix.BPF_MAP_TYPE_RINGBUF, Name: "BPF_MAP_TYPE_RINGBUF"},
+	&Value{Value: unix.BPF_MAP_TYPE_INODE_STORAGE, Name: "BPF_MAP_TYPE_INODE_STORAGE"},
+}
+
+// BPFProgTypes are types of BPF programs.
+var BPFProgTypes = FlagSet{
+	&Value{Value: unix.BPF_PROG_TYPE_UNSPEC, Name: "BPF_PROG_TYPE_UNSPEC"},
+	&Value{Value: unix.BPF_PROG_TYPE_SOCKET_FILTER, Name: "BPF_PROG_TYPE_SOCKET_FILTER"},
+	&Value{Value: unix.BPF_PROG_TYPE_KPROBE, Name: "BPF_PROG_TYPE_KPROBE"},
+	&Value{Value: unix.BPF_PROG_TYPE_SCHED_CLS, Name: "BPF_PROG_TYPE_SCHED_CLS"},
+	&Value{Value: unix.BPF_PROG_TYPE_SCHED_ACT, Name: "BPF_PROG_TYPE_SCHED_ACT"},
+	&Value{Value: unix.BPF_PROG_TYPE_TRACEPOINT, Name: "BPF_PROG_TYPE_TRACEPOINT"},
+	&Value{Value: unix.BPF_PROG_TYPE_XDP, Name: "BPF_PROG_TYPE_XDP"},
+	&Value{Value: unix.BPF_PROG_TYPE_PERF_EVENT, Name: "BPF_PROG_TYPE_PERF_EVENT"},
+	&Value{Value: unix.BPF_PROG_TYPE_CGROUP_SKB, Name: "BPF_PROG_TYPE_CGROUP_SKB"},
+	&Value{Value: unix.BPF_PROG_TYPE_CGROUP_SOCK, Name: "BPF_PROG_TYPE_CGROUP_SOCK"},
+	&Value{Value: unix.BPF_PROG_TYPE_LWT_IN, Name: "BPF_PROG_TYPE_LWT_IN"},
+	&Value{Value: unix.BPF_PROG_TYPE_LWT_OUT, Name: "BPF_PROG_TYPE_LWT_OUT"},
+	&Value{Value: unix.BPF_PROG_TYPE_LWT_XMIT, Name: "BPF_PROG_TYPE_LWT_XMIT"},
+	&Value{Value: unix.BPF_PROG_TYPE_SOCK_OPS, Name: "BPF_PROG_TYPE_SOCK_OPS"},
+	&Value{Value: unix.BPF_PROG_TYPE_SK_SKB, Name: "BPF_PROG_TYPE_SK_SKB"},
+	&Value{Value: unix.BPF_PROG_TYPE_CGROUP_DEVICE, Name: "BPF_PROG_TYPE_CGROUP_DEVICE"},
+	&Value{Value: unix.BPF_PROG_TYPE_SK_MSG, Name: "BPF_PROG_TYPE_SK_MSG"},
+	&Value{Value: unix.BPF_PROG_TYPE_RAW_TRACEPOINT, Name: "BPF_PROG_TYPE_RAW_TRACEPOINT"},
+	&Value{Value: unix.BPF_PROG_TYPE_CGROUP_SOCK_ADDR, Name: "BPF_PROG_TYPE_CGROUP_SOCK_ADDR"},
+	&Value{Value: unix.BPF_PROG_TYPE_LWT_SEG6LOCAL, Name: "BPF_PROG_TYPE_LWT_SEG6LOCAL"},
+	&Value{Value: unix.BPF_PROG_TYPE_LIRC_MODE2, Name: "BPF_PROG_TYPE_LIRC_MODE2"},
+	&Value{Value: unix.BPF_PROG_TYPE_SK_REUSEPORT, Name: "BPF_PROG_TYPE_SK_REUSEPORT"},
+	&Value{Value: unix.BPF_PROG_TYPE_FLOW_DISSECTOR, Name: "BPF_PROG_TYPE_FLOW_DISSECTOR"},
+	&Value{Value: unix.BPF_PROG_TYPE_CGROUP_SYSCTL, Name: "BPF_PROG_TYPE_CGROUP_SYSCTL"},
+	&Value{Value: unix.BPF_PROG_TYPE_RAW_TRACEPOINT_WRITABLE, Name: "BPF_PROG_TYPE_RAW_TRACEPOINT_WRITABLE"},
+	&Value{Value: unix.BPF_PROG_TYPE_CGROUP_SOCKOPT, Name: "BPF_PROG_TYPE_CGROUP_SOCKOPT"},
+	&Value{Value: unix.BPF_PROG_TYPE_TRACING, Name: "BPF_PROG_TYPE_TRACING"},
+	&Value{Value: unix.BPF_PROG_TYPE_STRUCT_OPS, Name: "BPF_PROG_TYPE_STRUCT_OPS"},
+	&Value{Value: unix.BPF_PROG_TYPE_EXT, Name: "BPF_PROG_TYPE_EXT"},
+	&Value{Value: unix.BPF_PROG_TYPE_LSM, Name: "BPF_PROG_TYPE_LSM"},
+	&Value{Value: unix.BPF_PROG_TYPE_SK_LOOKUP, Name: "BPF_PROG_TYPE_SK_LOOKUP"},
+}
+
+// BPFAttachTypes are attach types of BPF programs.
+var BPFAttachTypes = FlagSet{
+	&Value{Value: unix.BPF_CGROUP_INET_INGRESS, Name: "BPF_CGROUP_INET_INGRESS"},
+	&Value{Value: unix.BPF_CGROUP_INET_EGRESS, Name: "BPF_CGROUP_INET_EGRESS"},
+	&Value{Value: unix.BPF_CGROUP_INET_SOCK_CREATE, Name: "BPF_CGROUP_INET_SOCK_CREATE"},
+	&Value{Value: unix.BPF_CGROUP_SOCK_OPS, Name: "BPF_CGROUP_SOCK_OPS"},
+	&Value{Value: unix.BPF_SK_SKB_STREAM_PARSER, Name: "BPF_SK_SKB_STREAM_PARSER"},
+	&Value{Value: unix.BPF_SK_SKB_STREAM_VERDICT, Name: "BPF_SK_SKB_STREAM_VERDICT"},
+	&Value{Value: unix.BPF_CGROUP_DEVICE, Name: "BPF_CGROUP_DEVICE"},
+	&Value{Value: unix.BPF_SK_MSG_VERDICT, Name: "BPF_SK_MSG_VERDICT"},
+	&Value{Value: unix.BPF_CGROUP_INET4_BIND, Name: "BPF_CGROUP_INET4_BIND"},
+	&Value{Value: unix.BPF_CGROUP_INET6_BIND, Name: "BPF_CGROUP_INET6_BIND"},
+	&Value{Value: unix.BPF_CGROUP_INET4_CONNECT, Name: "BPF_CGROUP_INET4_CONNECT"},
+	&Value{Value: unix.BPF_CGROUP_INET6_CONNECT, Name: "BPF_CGROUP_INET6_CONNECT"},
+	&Value{Value: unix.BPF_CGROUP_INET4_POST_BIND, Name: "BPF_CGROUP_INET4_POST_BIND"},
+	&Value{Value: unix.BPF_CGROUP_INET6_POST_BIND, Name: "BPF_CGROUP_INET6_POST_BIND"},
+	&Value{Value: unix.BPF_CGROUP_UDP4_SENDMSG, Name: "BPF_CGROUP_UDP4_SENDMSG"},
+	&Value{Value: unix.BPF_CGROUP_UDP6_SENDMSG, Name: "BPF_CGROUP_UDP6_SENDMSG"},
+	&Value{Value: unix.BPF_LIRC_MODE2, Name: "BPF_LIRC_MODE2"},
+	&Value{Value: unix.BPF_FLOW_DISSECTOR, Name: "BPF_FLOW_DISSECTOR"},
+	&Value{Value: unix.BPF_CGROUP_SYSCTL, Name: "BPF_CGROUP_SYSCTL"},
+	&Value{Value: unix.BPF_CGROUP_UDP4_RECVMSG, Name: "BPF_CGROUP_UDP4_RECVMSG"},
+	&Value{Value: unix.BPF_CGROUP_UDP6_RECVMSG, Name: "BPF_CGROUP_UDP6_RECVMSG"},
+	&Value{Value: unix.BPF_CGROUP_GETSOCKOPT, Name: "BPF_CGROUP_GETSOCKOPT"},
+	&Value{Value: unix.BPF_CGROUP_SETSOCKOPT, Name: "BPF_CGROUP_SETSOCKOPT"},
+	&Value{Value: unix.BPF_TRACE_RAW_TP, Name: "BPF_TRACE_RAW_TP"},
+	&Value{Value: unix.BPF_TRACE_FENTRY, Name: "BPF_TRACE_FENTRY"},
+	&Value{Value: unix.BPF_TRACE_FEXIT, Name: "BPF_TRACE_FEXIT"},
+	&Value{Value: unix.BPF_MODIFY_RETURN, Name: "BPF_MODIFY_RETURN"},
+	&Value{Value: unix.BPF_LSM_MAC, Name: "BPF_LSM_MAC"},
+	&Value{Value: unix.BPF_TRACE_ITER, Name: "BPF_TRACE_ITER"},
+	&Value{Value: unix.BPF_CGROUP_INET4_GETPEERNAME, Name: "BPF_CGROUP_INET4_GETPEERNAME"},
+	&Value{Value: unix.BPF_CGROUP_INET6_GETPEERNAME, Name: "BPF_CGROUP_INET6_GETPEERNAME"},
+	&Value{Value: unix.BPF_CGROUP_INET4_GETSOCKNAME, Name: "BPF_CGROUP_INET4_GETSOCKNAME"},
+	&Value{Value: unix.BPF_CGROUP_INET6_GETSOCKNAME, Name: "BPF_CGROUP_INET6_GETSOCKNAME"},
+	&Value{Value: unix.BPF_XDP_DEVMAP, Name: "BPF_XDP_DEVMAP"},
+	&Value{Value: unix.BPF_CGROUP_INET_SOCK_RELEASE, Name: "BPF_CGROUP_INET_SOCK_RELEASE"},
+	&Value{Value: unix.BPF_XDP_CPUMAP, Name: "BPF_XDP_CPUMAP"},
+	&Value{Value: unix.BPF_SK_LOOKUP, Name: "BPF_SK_LOOKUP"},
+	&Value{Value: unix.BPF_XDP, Name: "BPF_XDP"},
+}
+
+// BPFMapUpdateFlagSet are flags of BPF_MAP_UPDATE_ELEM besides the mode.
+var BPFMapUpdateFlagSet = FlagSet{
+	&BitFlag{Value: unix.BPF_F_LOCK, Name: "BPF_F_LOCK"},
+}
+
+// BPFMapUpdateModes are the low bits of BPF_MAP_UPDATE_ELEM flags.
+var BPFMapUpdateModes = FlagSet{
+	&Value{Value: unix.BPF_ANY, Name: "BPF_ANY"},
+	&Value{Value: unix.BPF_NOEXIST, Name: "BPF_NOEXIST"},
+	&Value{Value: unix.BPF_EXIST, Name: "BPF_EXIST"},
+}
+
+// BPFMapFlagSet are map_flags of BPF_MAP_CREATE.
+var BPFMapFlagSet = FlagSet{
+	&BitFlag{Value: unix.BPF_F_NO_PREALLOC, Name: "BPF_F_NO_PREALLOC"},
+	&BitFlag{Value: unix.BPF_F_NO_COMMON_LRU, Name: "BPF_F_NO_COMMON_LRU"},
+	&BitFlag{Value: unix.BPF_F_NUMA_NODE, Name: "BPF_F_NUMA_NODE"},
+	&BitFlag{Value: unix.BPF_F_RDONLY, Name: "BPF_F_RDONLY"},
+	&BitFlag{Value: unix.BPF_F_WRONLY, Name: "BPF_F_WRONLY"},
+	&BitFlag{Value: unix.BPF_F_STACK_BUILD_ID, Name: "BPF_F_STACK_BUILD_ID"},
+	&BitFlag{Value: unix.BPF_F_ZERO_SEED, Name: "BPF_F_ZERO_SEED"},
+	&BitFlag{Value: unix.BPF_F_RDONLY_PROG, Name: "BPF_F_RDONLY_PROG"},
+	&BitFlag{Value: unix.BPF_F_WRONLY_PROG, Name: "BPF_F_WRONLY_PROG"},
+	&BitFlag{Value: unix.BPF_F_CLONE, Name: "BPF_F_CLONE"},
+	&BitFlag{Value: unix.BPF_F_MMAPABLE, Name: "BPF_F_MMAPABLE"},
+}
+
+// perf
+
+// PerfTypes are types of perf events.
+var PerfTypes = FlagSet{
+	&Value{Value: unix.PERF_TYPE_HARDWARE, Name: "PERF_TYPE_HARDWARE"},
+	&Value{Value: unix.PERF_TYPE_SOFTWARE, Name: "PERF_TYPE_SOFTWARE"},
+	&Value{Value: unix.PERF_TYPE_TRACEPOINT, Name: "PERF_TYPE_TRACEPOINT"},
+	&Value{Value: unix.PERF_TYPE_HW_CACHE, Name: "PERF_TYPE_HW_CACHE"},
+	&Value{Value: unix.PERF_TYPE_RAW, Name: "PERF_TYPE_RAW"},
+	&Value{Value: unix.PERF_TYPE_BREAKPOINT, Name: "PERF_TYPE_BREAKPOINT"},
+}
+
+// PerfHardwareConfigs are generalized hardware events of PERF_TYPE_HARDWARE.
+var PerfHardwareConfigs = FlagSet{
+	&Value{Value: unix.PERF_COUNT_HW_CPU_CYCLES, Name: "PERF_COUNT_HW_CPU_CYCLES"},
+	&Value{Value: unix.PERF_COUNT_HW_INSTRUCTIONS, Name: "PERF_COUNT_HW_INSTRUCTIONS"},
+	&Value{Value: unix.PERF_COUNT_HW_BRANCH_INSTRUCTIONS, Name: "PERF_COUNT_HW_BRANCH_INSTRUCTIONS"},
+	&Value{Value: unix.PERF_COUNT_HW_BRANCH_MISSES, Name: "PERF_COUNT_HW_BRANCH_MISSES"},
+	&Value{Value: unix.PERF_COUNT_HW_BUS_CYCLES, Name: "PERF_COUNT_HW_BUS_CYCLES"},
+	&Value{Value: unix.PERF_COUNT_HW_STALLED_CYCLES_FRONTEND, Name: "PERF_COUNT_HW_STALLED_CYCLES_FRONTEND"},
+	&Value{Value: unix.PERF_COUNT_HW_STALLED_CYCLES_BACKEND, Name: "PERF_COUNT_HW_STALLED_CYCLES_BACKEND"},
+	&Value{Value: unix.PERF_COUNT_HW_REF_CPU_CYCLES, Name: "PERF_COUNT_HW_REF_CPU_CYCLES"},
+}
+
+// PerfSoftwareConfigs are software events of PERF_TYPE_SOFTWARE.
+var PerfSoftwareConfigs = FlagSet{
+	&Value{Value: unix.PERF_COUNT_SW_CPU_CLOCK, Name: "PERF_COUNT_SW_CPU_CLOCK"},
+	&Value{Value: unix.PERF_COUNT_SW_TASK_CLOCK, Name: "PERF_COUNT_SW_TASK_CLOCK"},
+	&Value{Value: unix.PERF_COUNT_SW_PAGE_FAULTS, Name: "PERF_COUNT_SW_PAGE_FAULTS"},
+	&Value{Value: unix.PERF_COUNT_SW_CONTEXT_SWITCHES, Name: "PERF_COUNT_SW_CONTEXT_SWITCHES"},
+	&Value{Value: unix.PERF_COUNT_SW_CPU_MIGRATIONS, Name: "PERF_COUNT_SW_CPU_MIGRATIONS"},
+	&Value{Value: unix.PERF_COUNT_SW_PAGE_FAULTS_MIN, Name: "PERF_COUNT_SW_PAGE_FAULTS_MIN"},
+	&Value{Value: unix.PERF_COUNT_SW_PAGE_FAULTS_MAJ, Name: "PERF_COUNT_SW_PAGE_FAULTS_MAJ"},
+	&Value{Value: unix.PERF_COUNT_SW_ALIGNMENT_FAULTS, Name: "PERF_COUNT_SW_ALIGNMENT_FAULTS"},
+	&Value{Value: unix.PERF_COUNT_SW_EMULATION_FAULTS, Name: "PERF_COUNT_SW_EMULATION_FAULTS"},
+	&Value{Value: unix.PERF_COUNT_SW_DUMMY, Name: "PERF_COUNT_SW_DUMMY"},
+	&Value{Value: unix.PERF_COUNT_SW_BPF_OUTPUT, Name: "PERF_COUNT_SW_BPF_OUTPUT"},
+}
+
+// PerfHWCacheIDs are caches of PERF_TYPE_HW_CACHE events, bits 0-7 of config.
+var PerfHWCacheIDs = FlagSet{
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_L1D, Name: "PERF_COUNT_HW_CACHE_L1D"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_L1I, Name: "PERF_COUNT_HW_CACHE_L1I"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_LL, Name: "PERF_COUNT_HW_CACHE_LL"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_DTLB, Name: "PERF_COUNT_HW_CACHE_DTLB"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_ITLB, Name: "PERF_COUNT_HW_CACHE_ITLB"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_BPU, Name: "PERF_COUNT_HW_CACHE_BPU"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_NODE, Name: "PERF_COUNT_HW_CACHE_NODE"},
+}
+
+// PerfHWCacheOps are operations of PERF_TYPE_HW_CACHE events, bits 8-15 of
+// config.
+var PerfHWCacheOps = FlagSet{
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_OP_READ, Name: "PERF_COUNT_HW_CACHE_OP_READ"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_OP_WRITE, Name: "PERF_COUNT_HW_CACHE_OP_WRITE"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_OP_PREFETCH, Name: "PERF_COUNT_HW_CACHE_OP_PREFETCH"},
+}
+
+// PerfHWCacheResults are results of PERF_TYPE_HW_CACHE events, bits 16-23 of
+// config.
+var PerfHWCacheResults = FlagSet{
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_RESULT_ACCESS, Name: "PERF_COUNT_HW_CACHE_RESULT_ACCESS"},
+	&Value{Value: unix.PERF_COUNT_HW_CACHE_RESULT_MISS, Name: "PERF_COUNT_HW_CACHE_RESULT_MISS"},
+}
+
+// PerfAttrBitSet are the bit fields of struct perf_event_attr.
+var PerfAttrBitSet = FlagSet{
+	&BitFlag{Value: unix.PerfBitDisabled, Name: "disabled"},
+	&BitFlag{Value: unix.PerfBitInherit, Name: "inherit"},
+	&BitFlag{Value: unix.PerfBitPinned, Name: "pinned"},
+	&BitFlag{Value: unix.PerfBitExclusive, Name: "exclusive"},
+	&BitFlag{Value: unix.PerfBitExcludeUser, Name: "exclude_user"},
+	&BitFlag{Value: unix.PerfBitExcludeKernel, Name: "exclude_kernel"},
+	&BitFlag{Value: unix.PerfBitExcludeHv, Name: "exclude_hv"},
+	&BitFlag{Value: unix.PerfBitExcludeIdle, Name: "exclude_idle"},
+	&BitFlag{Value: unix.PerfBitMmap, Name: "mmap"},
+	&BitFlag{Value: unix.PerfBitComm, Name: "comm"},
+	&BitFlag{Value: unix.PerfBitFreq, Name: "freq"},
+	&BitFlag{Value: unix.PerfBitInheritStat, Name: "inherit_stat"},
+	&BitFlag{Value: unix.PerfBitEnableOnExec, Name: "enable_on_exec"},
+	&BitFlag{Value: unix.PerfBitTask, Name: "task"},
+	&BitFlag{Value: unix.PerfBitWatermark, Name: "watermark"},
+	&Field{Name: "precise_ip", BitMask: unix.PerfBitPreciseIPBit1 | unix.PerfBitPreciseIPBit2, Shift: 15},
+	&BitFlag{Value: unix.PerfBitMmapData, Name: "mmap_data"},
+	&BitFlag{Value: unix.PerfBitSampleIDAll, Name: "sample_id_all"},
+	&BitFlag{Value: unix.PerfBitExcludeHost, Name: "exclude_host"},
+	&BitFlag{Value: unix.PerfBitExcludeGuest, Name: "exclude_guest"},
+	&BitFlag{Value: unix.PerfBitExcludeCallchainKernel, Name: "exclude_callchain_kernel"},
+	&BitFlag{Value: unix.PerfBitExcludeCallchainUser, Name: "exclude_callchain_user"},
+	&BitFlag{Value: unix.PerfBitMmap2, Name: "mmap2"},
+	&BitFlag{Value: unix.PerfBitCommExec, Name: "comm_exec"},
+	&BitFlag{Value: unix.PerfBitUseClockID, Name: "use_clockid"},
+	&BitFlag{Value: unix.PerfBitContextSwitch, Name: "context_switch"},
+	&BitFlag{Value: unix.PerfBitWriteBackward, Name: "write_backward"},
+}
+
+// PerfSampleTypeSet are values recorded in samples.
+var PerfSampleTypeSet = FlagSet{
+	&BitFlag{Value: unix.PERF_SAMPLE_IP, Name: "PERF_SAMPLE_IP"},
+	&BitFlag{Value: unix.PERF_SAMPLE_TID, Name: "PERF_SAMPLE_TID"},
+	&BitFlag{Value: unix.PERF_SAMPLE_TIME, Name: "PERF_SAMPLE_TIME"},
+	&BitFlag{Value: unix.PERF_SAMPLE_ADDR, Name: "PERF_SAMPLE_ADDR"},
+	&BitFlag{Value: unix.PERF_SAMPLE_READ, Name: "PERF_SAMPLE_READ"},
+	&BitFlag{Value: unix.PERF_SAMPLE_CALLCHAIN, Name: "PERF_SAMPLE_CALLCHAIN"},
+	&BitFlag{Value: unix.PERF_SAMPLE_ID, Name: "PERF_SAMPLE_ID"},
+	&BitFlag{Value: unix.PERF_SAMPLE_CPU, Name: "PERF_SAMPLE_CPU"},
+	&BitFlag{Value: unix.PERF_SAMPLE_PERIOD, Name: "PERF_SAMPLE_PERIOD"},
+	&BitFlag{Value: unix.PERF_SAMPLE_STREAM_ID, Name: "PERF_SAMPLE_STREAM_ID"},
+	&BitFlag{Value: unix.PERF_SAMPLE_RAW, Name: "PERF_SAMPLE_RAW"},
+	&BitFlag{Value: unix.PERF_SAMPLE_BRANCH_STACK, Name: "PERF_SAMPLE_BRANCH_STACK"},
+	&BitFlag{Value: unix.PERF_SAMPLE_REGS_USER, Name: "PERF_SAMPLE_REGS_USER"},
+	&BitFlag{Value: unix.PERF_SAMPLE_STACK_USER, Name: "PERF_SAMPLE_STACK_USER"},
+	&BitFlag{Value: unix.PERF_SAMPLE_WEIGHT, Name: "PERF_SAMPLE_WEIGHT"},
+	&BitFlag{Value: unix.PERF_SAMPLE_DATA_SRC, Name: "PERF_SAMPLE_DATA_SRC"},
+	&BitFlag{Value: unix.PERF_SAMPLE_IDENTIFIER, Name: "PERF_SAMPLE_IDENTIFIER"},
+	&BitFlag{Value: unix.PERF_SAMPLE_TRANSACTION, Name: "PERF_SAMPLE_TRANSACTION"},
+	&BitFlag{Value: unix.PERF_SAMPLE_REGS_INTR, Name: "PERF_SAMPLE_REGS_INTR"},
+	&BitFlag{Value: unix.PERF_SAMPLE_PHYS_ADDR, Name: "PERF_SAMPLE_PHYS_ADDR"},
+	&BitFlag{Value: unix.PERF_SAMPLE_AUX, Name: "PERF_SAMPLE_AUX"},
+	&BitFlag{Value: unix.PERF_SAMPLE_CGROUP, Name: "PERF_SAMPLE_CGROUP"},
+	&BitFlag{Value: unix.PERF_SAMPLE_DATA_PAGE_SIZE, Name: "PERF_SAMPLE_DATA_PAGE_SIZE"},
+	&BitFlag{Value: unix.PERF_SAMPLE_CODE_PAGE_SIZE, Name: "PERF_SAMPLE_CODE_PAGE_SIZE"},
+	&BitFlag{Value: unix.PERF_SAMPLE_WEIGHT_STRUCT, Name: "PERF_SAMPLE_WEIGHT_STRUCT"},
+}
+
+// PerfEventOpenFlagSet are flags of perf_event_open(2).
+var PerfEventOpenFlagSet = FlagSet{
+	&BitFlag{Value: unix.PERF_FLAG_FD_NO_GROUP, Name: "PERF_FLAG_FD_NO_GROUP"},
+	&BitFlag{Value: unix.PERF_FLAG_FD_OUTPUT, Name: "PERF_FLAG_FD_OUTPUT"},
+	&BitFlag{Value: unix.PERF_FLAG_PID_CGROUP, Name: "PERF_FLAG_PID_CGROUP"},
+	&BitFlag{Value: unix.PERF_FLAG_FD_CLOEXEC, Name: "PERF_FLAG_FD_CLOEXEC"},
+}
@@ -104,6 +104,11 @@ func Open(val uint64) Flags {
 	return f1
 }
 
+// UnparseOpen is the inverse of Open.
+func UnparseOpen(s string) (uint64, error) {
+	return unparse(s, OpenMode, OpenFlagSet)
+}
+
 // socket
 
 // SocketFamily are the possible socket(2) families.
@@ -239,6 +244,11 @@ func SockType(stype int32) Flags {
 	return f1
 }
 
+// UnparseSockType is the inverse of SockType.
+func UnparseSockType(s string) (uint64, error) {
+	return unparse(s, SocketType, SocketFlagSet)
+}
+
 func SockProtocol(family, protocol int32) Flags {
 	protocols, ok := SocketProtocol[family]
 	if !ok {
@@ -255,6 +265,8 @@ func SockFlags(flags int32) Flags {
 
 // MessageHeader64 is the 64-bit representation of the msghdr struct used in
 // the recvmsg and sendmsg syscalls.
+//
+// +marshal
 type MessageHeader64 struct {
 	// Name is the optional pointer to a network address buffer.
 	Name uint64
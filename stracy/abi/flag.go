@@ -20,6 +20,7 @@ import (
 	"math"
 	"math/bits"
 	"strconv"
+	"strings"
 )
 
 // The Flag interface has three functions:
@@ -159,6 +160,66 @@ func (s FlagSet) Parse(val uint64) Flags {
 	return flags
 }
 
+// Unparse is the inverse of Parse: it converts flags joined with "|", like
+// "O_CREAT|O_EXCL|0x80000", back to the value. Elements are names of
+// BitFlags and Values, Fields as name=value and numbers for bits not
+// covered by the FlagSet. An empty string is 0.
+func (s FlagSet) Unparse(str string) (uint64, error) {
+	return unparse(str, s)
+}
+
+// unparse converts flags of several FlagSets to a value, see FlagSet.Unparse.
+func unparse(str string, sets ...FlagSet) (uint64, error) {
+	if str == "" {
+		return 0, nil
+	}
+	var val uint64
+	for _, name := range strings.Split(str, "|") {
+		name = strings.TrimSpace(name)
+		v, err := unparseFlag(name, sets)
+		if err != nil {
+			return 0, err
+		}
+		val |= v
+	}
+	return val, nil
+}
+
+func unparseFlag(name string, sets []FlagSet) (uint64, error) {
+	if n, err := strconv.ParseUint(name, 0, 64); err == nil {
+		return n, nil
+	}
+	field, fieldVal, isField := strings.Cut(name, "=")
+	for _, s := range sets {
+		for _, f := range s {
+			switch f := f.(type) {
+			case *BitFlag:
+				if f.Name == name {
+					return f.Value, nil
+				}
+			case *Value:
+				if f.Name == name {
+					return f.Value, nil
+				}
+			case *Field:
+				if !isField || f.Name != field {
+					continue
+				}
+				n, err := strconv.ParseUint(fieldVal, 0, 64)
+				if err != nil {
+					return 0, fmt.Errorf("bad value of field %s: %w", field, err)
+				}
+				v := n << f.Shift
+				if v&^f.BitMask != 0 || v>>f.Shift != n {
+					return 0, fmt.Errorf("value %#x doesn't fit field %s", n, field)
+				}
+				return v, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unknown flag %q", name)
+}
+
 type Flags struct {
 	flags []string
 }
@@ -171,6 +232,11 @@ func (f *Flags) AddFlags(flags Flags) {
 	f.Add(flags.flags...)
 }
 
+// String joins the flags with "|".
+func (f Flags) String() string {
+	return strings.Join(f.flags, "|")
+}
+
 func (f Flags) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
 		Type  string
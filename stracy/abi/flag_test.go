@@ -5,6 +5,12 @@
 package abi
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"math/rand"
 	"strings"
 	"testing"
 
@@ -61,3 +67,236 @@ func TestCrosGPT(t *testing.T) {
 		})
 	}
 }
+
+// flagSets are all FlagSets of the package, TestFlagSetsListed checks the
+// list is complete.
+var flagSets = map[string]FlagSet{
+	"PtraceRequestSet":      PtraceRequestSet,
+	"CloneFlagSet":          CloneFlagSet,
+	"ItimerTypes":           ItimerTypes,
+	"FutexCmd":              FutexCmd,
+	"futexOpFlagSet":        futexOpFlagSet,
+	"MadviseFlagSet":        MadviseFlagSet,
+	"PollEventSet":          PollEventSet,
+	"EpollEventSet":         EpollEventSet,
+	"EpollCtlOps":           EpollCtlOps,
+	"EpollCreateFlagSet":    EpollCreateFlagSet,
+	"EventFDFlagSet":        EventFDFlagSet,
+	"TimerFDFlagSet":        TimerFDFlagSet,
+	"TimerFDSettimeFlagSet": TimerFDSettimeFlagSet,
+	"TimerFlagSet":          TimerFlagSet,
+	"ClockIDs":              ClockIDs,
+	"NetlinkProtocols":      NetlinkProtocols,
+	"NetlinkMessageTypes":   NetlinkMessageTypes,
+	"RouteMessageTypes":     RouteMessageTypes,
+	"NetlinkFlagSet":        NetlinkFlagSet,
+	"NetlinkGetFlagSet":     NetlinkGetFlagSet,
+	"NetlinkNewFlagSet":     NetlinkNewFlagSet,
+	"InterfaceFlagSet":      InterfaceFlagSet,
+	"OperStates":            OperStates,
+	"InterfaceAddrFlagSet":  InterfaceAddrFlagSet,
+	"LinkAttrTypes":         LinkAttrTypes,
+	"LinkInfoAttrTypes":     LinkInfoAttrTypes,
+	"AddrAttrTypes":         AddrAttrTypes,
+	"RouteAttrTypes":        RouteAttrTypes,
+	"RouteTables":           RouteTables,
+	"RouteProtocols":        RouteProtocols,
+	"RouteScopes":           RouteScopes,
+	"RouteTypes":            RouteTypes,
+	"BPFCommands":           BPFCommands,
+	"BPFMapTypes":           BPFMapTypes,
+	"BPFProgTypes":          BPFProgTypes,
+	"BPFAttachTypes":        BPFAttachTypes,
+	"BPFMapUpdateFlagSet":   BPFMapUpdateFlagSet,
+	"BPFMapUpdateModes":     BPFMapUpdateModes,
+	"BPFMapFlagSet":         BPFMapFlagSet,
+	"PerfTypes":             PerfTypes,
+	"PerfHardwareConfigs":   PerfHardwareConfigs,
+	"PerfSoftwareConfigs":   PerfSoftwareConfigs,
+	"PerfHWCacheIDs":        PerfHWCacheIDs,
+	"PerfHWCacheOps":        PerfHWCacheOps,
+	"PerfHWCacheResults":    PerfHWCacheResults,
+	"PerfAttrBitSet":        PerfAttrBitSet,
+	"PerfSampleTypeSet":     PerfSampleTypeSet,
+	"PerfEventOpenFlagSet":  PerfEventOpenFlagSet,
+	"OpenMode":              OpenMode,
+	"OpenFlagSet":           OpenFlagSet,
+	"SocketFamily":          SocketFamily,
+	"SocketType":            SocketType,
+	"SocketFlagSet":         SocketFlagSet,
+	"ipProtocol":            ipProtocol,
+	"MmapProt":              MmapProt,
+	"MmapFlagSet":           MmapFlagSet,
+	"FcntlCmds":             FcntlCmds,
+	"FcntlSealFlagSet":      FcntlSealFlagSet,
+	"DnotifyFlagSet":        DnotifyFlagSet,
+	"PrctlOptions":          PrctlOptions,
+	"InotifyMaskSet":        InotifyMaskSet,
+	"InotifyInitFlagSet":    InotifyInitFlagSet,
+	"FanotifyInitFlagSet":   FanotifyInitFlagSet,
+	"FanotifyMarkFlagSet":   FanotifyMarkFlagSet,
+	"FanotifyEventSet":      FanotifyEventSet,
+	"DirentTypes":           DirentTypes,
+}
+
+func init() {
+	for family, set := range SocketProtocol {
+		flagSets[fmt.Sprintf("SocketProtocol[%d]", family)] = set
+	}
+}
+
+func TestFlagSetsListed(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, file := range []string{"abi_linux.go", "abi_unix.go", "zuapi_linux.go"} {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			spec, ok := n.(*ast.ValueSpec)
+			if !ok || len(spec.Values) != 1 {
+				return true
+			}
+			var typ ast.Expr
+			switch v := spec.Values[0].(type) {
+			case *ast.CompositeLit:
+				typ = v.Type
+			case *ast.CallExpr: // append(FlagSet{...}, ...)
+				if len(v.Args) > 0 {
+					if lit, ok := v.Args[0].(*ast.CompositeLit); ok {
+						typ = lit.Type
+					}
+				}
+			}
+			if id, ok := typ.(*ast.Ident); ok && id.Name == "FlagSet" {
+				if _, ok := flagSets[spec.Names[0].Name]; !ok {
+					t.Errorf("%s: FlagSet %s isn't tested", file, spec.Names[0].Name)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// testValues returns values covering every flag of a set alone, in pairs and
+// with random other bits.
+func testValues(s FlagSet, rnd *rand.Rand) []uint64 {
+	vals := []uint64{0, math.MaxUint64}
+	for i := 0; i < 64; i++ {
+		vals = append(vals, 1<<i)
+	}
+	var flags []uint64
+	for _, f := range s {
+		switch f := f.(type) {
+		case *BitFlag:
+			flags = append(flags, f.Value)
+		case *Value:
+			flags = append(flags, f.Value)
+		case *Field:
+			for v := uint64(0); v<<f.Shift&f.BitMask == v<<f.Shift && v < 256; v++ {
+				flags = append(flags, v<<f.Shift)
+			}
+		}
+	}
+	vals = append(vals, flags...)
+	for _, a := range flags {
+		for _, b := range flags {
+			vals = append(vals, a|b)
+		}
+		vals = append(vals, a|rnd.Uint64())
+	}
+	for i := 0; i < 1000; i++ {
+		vals = append(vals, rnd.Uint64(), rnd.Uint64()&0xffff)
+	}
+	return vals
+}
+
+func TestUnparseRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for name, s := range flagSets {
+		for _, v := range testValues(s, rnd) {
+			str := s.Parse(v).String()
+			got, err := s.Unparse(str)
+			if err != nil {
+				t.Errorf("%s.Unparse(%q) of %#x: %v", name, str, v, err)
+				continue
+			}
+			if got != v {
+				t.Errorf("%s.Unparse(%q) = %#x, want %#x", name, str, got, v)
+			}
+		}
+	}
+}
+
+func TestUnparseNames(t *testing.T) {
+	for name, s := range flagSets {
+		// Names listed twice, like aliases of old values, resolve to the
+		// first entry.
+		seen := make(map[string]bool)
+		for _, f := range s {
+			var flag string
+			var want uint64
+			switch f := f.(type) {
+			case *BitFlag:
+				flag, want = f.Name, f.Value
+			case *Value:
+				flag, want = f.Name, f.Value
+			default:
+				continue
+			}
+			if seen[flag] {
+				continue
+			}
+			seen[flag] = true
+			if got, err := s.Unparse(flag); err != nil || got != want {
+				t.Errorf("%s.Unparse(%q) = %#x, %v, want %#x", name, flag, got, err, want)
+			}
+		}
+	}
+}
+
+func TestUnparse(t *testing.T) {
+	tests := []struct {
+		s       FlagSet
+		str     string
+		want    uint64
+		wantErr bool
+	}{
+		{s: OpenFlagSet, str: "O_CREAT|O_EXCL", want: unix.O_CREAT | unix.O_EXCL},
+		{s: OpenFlagSet, str: "O_CREAT | 0x80000 | 0100", want: unix.O_CREAT | 0x80000 | 0o100},
+		{s: OpenFlagSet, str: "", want: 0},
+		{s: OpenFlagSet, str: "O_RDWR", wantErr: true},
+		{s: OpenFlagSet, str: "O_CREAT|", wantErr: true},
+		{s: PerfAttrBitSet, str: "disabled|precise_ip=0x2", want: unix.PerfBitDisabled | unix.PerfBitPreciseIPBit2},
+		{s: PerfAttrBitSet, str: "precise_ip=4", wantErr: true},
+		{s: PerfAttrBitSet, str: "precise_ip=x", wantErr: true},
+		{s: ClockIDs, str: "CLOCK_MONOTONIC", want: unix.CLOCK_MONOTONIC},
+	}
+	for _, tt := range tests {
+		got, err := tt.s.Unparse(tt.str)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Unparse(%q) = %#x, %v, want %#x (error %v)", tt.str, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUnparseComposite(t *testing.T) {
+	if got, err := UnparseOpen("O_RDWR|O_CREAT|0x80000"); err != nil || got != unix.O_RDWR|unix.O_CREAT|0x80000 {
+		t.Errorf("UnparseOpen = %#x, %v", got, err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		v := rnd.Uint64() >> rnd.Intn(64)
+		if got, err := UnparseOpen(Open(v).String()); err != nil || got != v {
+			t.Errorf("UnparseOpen(Open(%#x)) = %#x, %v", v, got, err)
+		}
+		if got, err := UnparseFutex(Futex(v).String()); err != nil || got != v {
+			t.Errorf("UnparseFutex(Futex(%#x)) = %#x, %v", v, got, err)
+		}
+		st := int32(v)
+		if got, err := UnparseSockType(SockType(st).String()); err != nil || int32(got) != st {
+			t.Errorf("UnparseSockType(SockType(%#x)) = %#x, %v", st, got, err)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+// Marshalgen generates SizeBytes, MarshalBytes and UnmarshalBytes methods,
+// see binary.Marshaler and binary.Unmarshaler, for structs annotated with a
+// "+marshal" line in their doc comment.
+//
+// The layout is the one of binary.Marshal: fields are packed without
+// alignment, in the native byte order. Fields can be fixed-size ints, types
+// defined as them, arrays of them and other annotated structs. Like
+// binary.Unmarshal, UnmarshalBytes skips blank and unexported fields.
+//
+// Methods of structs from abi_xxx.go are written to zmarshal_xxx.go, so they
+// have the same build constraints. Usage, from the abi directory:
+//
+//	go run ./internal/marshalgen abi_linux.go abi_unix.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: marshalgen file.go...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("marshalgen: ")
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	out, err := generate(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range flag.Args() {
+		if src, ok := out[name]; ok {
+			if err := os.WriteFile(outputName(name), src, 0o644); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+// outputName returns the name of the generated file for an input file.
+func outputName(name string) string {
+	dir, base := filepath.Split(name)
+	if _, suffix, ok := strings.Cut(base, "_"); ok {
+		return filepath.Join(dir, "zmarshal_"+suffix)
+	}
+	return filepath.Join(dir, "zmarshal.go")
+}
+
+// basicSizes are sizes of supported basic types.
+var basicSizes = map[string]int{
+	"int8": 1, "uint8": 1, "byte": 1,
+	"int16": 2, "uint16": 2,
+	"int32": 4, "uint32": 4,
+	"int64": 8, "uint64": 8,
+}
+
+// field is a marshaled field of a struct.
+type field struct {
+	Name   string // "_" for blank fields
+	Type   string // Go type, the element type for arrays
+	Basic  string // basic type of Type, "" for structs
+	Offset int
+	Size   int // of the element for arrays
+	Len    int // of arrays, 0 for other types
+}
+
+// layout is a struct to generate methods for.
+type layout struct {
+	Name   string
+	File   string
+	Fields []field
+	Size   int
+}
+
+// pkg is the parsed input files.
+type pkg struct {
+	name    string
+	types   map[string]*ast.TypeSpec
+	consts  map[string]ast.Expr
+	marshal map[string]bool // annotated structs
+	layouts map[string]*layout
+}
+
+// generate returns the generated source for input files that have
+// annotated structs.
+func generate(files []string) (map[string][]byte, error) {
+	p := &pkg{
+		types:   make(map[string]*ast.TypeSpec),
+		consts:  make(map[string]ast.Expr),
+		marshal: make(map[string]bool),
+		layouts: make(map[string]*layout),
+	}
+	fset := token.NewFileSet()
+	var order []*ast.TypeSpec
+	fileOf := make(map[*ast.TypeSpec]string)
+	for _, name := range files {
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if p.name != "" && p.name != f.Name.Name {
+			return nil, fmt.Errorf("%s: package %s, want %s", name, f.Name.Name, p.name)
+		}
+		p.name = f.Name.Name
+		for _, decl := range f.Decls {
+			d, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					p.types[spec.Name.Name] = spec
+					doc := spec.Doc
+					if doc == nil && len(d.Specs) == 1 {
+						doc = d.Doc
+					}
+					if annotated(doc) {
+						if _, ok := spec.Type.(*ast.StructType); !ok {
+							return nil, fmt.Errorf("%s: %s isn't a struct", fset.Position(spec.Pos()), spec.Name.Name)
+						}
+						p.marshal[spec.Name.Name] = true
+						order = append(order, spec)
+						fileOf[spec] = name
+					}
+				case *ast.ValueSpec:
+					if d.Tok != token.CONST {
+						continue
+					}
+					for i, id := range spec.Names {
+						if i < len(spec.Values) {
+							p.consts[id.Name] = spec.Values[i]
+						}
+					}
+				}
+			}
+		}
+	}
+
+	out := make(map[string][]byte)
+	byFile := make(map[string][]*layout)
+	for _, spec := range order {
+		l, err := p.layout(spec.Name.Name, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fset.Position(spec.Pos()), err)
+		}
+		l.File = fileOf[spec]
+		byFile[l.File] = append(byFile[l.File], l)
+	}
+	for _, name := range files {
+		if len(byFile[name]) == 0 {
+			continue
+		}
+		src, err := p.source(byFile[name])
+		if err != nil {
+			return nil, err
+		}
+		out[name] = src
+	}
+	return out, nil
+}
+
+// annotated reports if a doc comment has a "+marshal" line.
+func annotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if strings.TrimSpace(line) == "+marshal" {
+			return true
+		}
+	}
+	return false
+}
+
+// layout computes the layout of an annotated struct. visiting detects
+// recursive structs.
+func (p *pkg) layout(name string, visiting map[string]bool) (*layout, error) {
+	if l, ok := p.layouts[name]; ok {
+		return l, nil
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("%s is recursive", name)
+	}
+	if visiting == nil {
+		visiting = make(map[string]bool)
+	}
+	visiting[name] = true
+
+	l := &layout{Name: name}
+	st := p.types[name].Type.(*ast.StructType)
+	for _, f := range st.Fields.List {
+		if f.Tag != nil {
+			return nil, fmt.Errorf("%s: struct tags aren't supported", name)
+		}
+		typ, n, err := p.array(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ident, ok := typ.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported type %s", name, exprString(f.Type))
+		}
+		fl := field{Type: ident.Name, Len: n}
+		if p.marshal[ident.Name] {
+			sub, err := p.layout(ident.Name, visiting)
+			if err != nil {
+				return nil, err
+			}
+			fl.Size = sub.Size
+		} else {
+			if fl.Basic, err = p.basic(ident.Name); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			fl.Size = basicSizes[fl.Basic]
+		}
+		size := fl.Size
+		if n > 0 {
+			size *= n
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s: embedded fields aren't supported", name)
+		}
+		for _, id := range names {
+			fl.Name = id.Name
+			fl.Offset = l.Size
+			l.Fields = append(l.Fields, fl)
+			l.Size += size
+		}
+	}
+	if l.Size == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+	p.layouts[name] = l
+	return l, nil
+}
+
+// array returns the element type and length of an array type, and the type
+// itself with length 0 for other types.
+func (p *pkg) array(expr ast.Expr) (ast.Expr, int, error) {
+	a, ok := expr.(*ast.ArrayType)
+	if !ok {
+		return expr, 0, nil
+	}
+	if a.Len == nil {
+		return nil, 0, fmt.Errorf("slice %s has no fixed size", exprString(expr))
+	}
+	n, err := p.intConst(a.Len)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, nested := a.Elt.(*ast.ArrayType); nested {
+		return nil, 0, fmt.Errorf("unsupported type %s", exprString(expr))
+	}
+	return a.Elt, n, nil
+}
+
+// intConst evaluates an array length: an int literal or a constant defined
+// as one.
+func (p *pkg) intConst(expr ast.Expr) (int, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT {
+			n, err := strconv.ParseInt(e.Value, 0, 64)
+			return int(n), err
+		}
+	case *ast.Ident:
+		if v, ok := p.consts[e.Name]; ok {
+			return p.intConst(v)
+		}
+	case *ast.ParenExpr:
+		return p.intConst(e.X)
+	}
+	return 0, fmt.Errorf("can't evaluate array length %s", exprString(expr))
+}
+
+// basic returns the basic type of a type.
+func (p *pkg) basic(name string) (string, error) {
+	for i := 0; i < 16; i++ {
+		if _, ok := basicSizes[name]; ok {
+			return name, nil
+		}
+		spec, ok := p.types[name]
+		if !ok || spec.Assign.IsValid() {
+			break
+		}
+		ident, ok := spec.Type.(*ast.Ident)
+		if !ok {
+			break
+		}
+		name = ident.Name
+	}
+	return "", fmt.Errorf("unsupported type %s", name)
+}
+
+func exprString(expr ast.Expr) string {
+	var b bytes.Buffer
+	format.Node(&b, token.NewFileSet(), expr)
+	return b.String()
+}
+
+// source returns the generated file with methods of structs.
+func (p *pkg) source(layouts []*layout) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by marshalgen from %s. DO NOT EDIT.\n\n", filepath.Base(layouts[0].File))
+	fmt.Fprintf(&b, "package %s\n\n", p.name)
+	b.WriteString("import \"github.com/iimos/play/stracy/ubinary\"\n")
+	for _, l := range layouts {
+		r := strings.ToLower(l.Name[:1])
+		fmt.Fprintf(&b, "\n// SizeBytes implements binary.Marshaler.\nfunc (%s %s) SizeBytes() int {\nreturn %d\n}\n", r, l.Name, l.Size)
+
+		fmt.Fprintf(&b, "\n// MarshalBytes implements binary.Marshaler.\nfunc (%s %s) MarshalBytes(dst []byte) {\n", r, l.Name)
+		fmt.Fprintf(&b, "_ = dst[%d] // bounds check hint\n", l.Size-1)
+		for _, f := range l.Fields {
+			b.WriteString(marshalField(r, f))
+		}
+		b.WriteString("}\n")
+
+		fmt.Fprintf(&b, "\n// UnmarshalBytes implements binary.Unmarshaler.\nfunc (%s *%s) UnmarshalBytes(src []byte) {\n", r, l.Name)
+		fmt.Fprintf(&b, "_ = src[%d] // bounds check hint\n", l.Size-1)
+		for _, f := range l.Fields {
+			b.WriteString(unmarshalField(r, f))
+		}
+		b.WriteString("}\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+// isByte reports if a type is stored as is.
+func isByte(typ string) bool {
+	return typ == "byte" || typ == "uint8"
+}
+
+func marshalField(r string, f field) string {
+	size := f.Size
+	if f.Len > 0 {
+		size *= f.Len
+	}
+	if f.Name == "_" {
+		return fmt.Sprintf("clear(dst[%d:%d])\n", f.Offset, f.Offset+size)
+	}
+	v := r + "." + f.Name
+	if f.Len == 0 {
+		return marshalValue(v, f, fmt.Sprint(f.Offset))
+	}
+	if isByte(f.Type) {
+		return fmt.Sprintf("copy(dst[%d:%d], %s[:])\n", f.Offset, f.Offset+size, v)
+	}
+	return fmt.Sprintf("for i := range %s {\n%s}\n", v, marshalValue(v+"[i]", f, elemOffset(f)))
+}
+
+func marshalValue(v string, f field, off string) string {
+	switch {
+	case f.Basic == "":
+		return fmt.Sprintf("%s.MarshalBytes(dst[%s:])\n", v, off)
+	case f.Size == 1:
+		if isByte(f.Type) {
+			return fmt.Sprintf("dst[%s] = %s\n", off, v)
+		}
+		return fmt.Sprintf("dst[%s] = byte(%s)\n", off, v)
+	}
+	if f.Type != fmt.Sprintf("uint%d", f.Size*8) {
+		v = fmt.Sprintf("uint%d(%s)", f.Size*8, v)
+	}
+	return fmt.Sprintf("ubinary.NativeEndian.PutUint%d(dst[%s:], %s)\n", f.Size*8, off, v)
+}
+
+// elemOffset returns the offset of the i-th element of an array.
+func elemOffset(f field) string {
+	if f.Size == 1 {
+		return fmt.Sprintf("%d+i", f.Offset)
+	}
+	return fmt.Sprintf("%d+%d*i", f.Offset, f.Size)
+}
+
+func unmarshalField(r string, f field) string {
+	if !ast.IsExported(f.Name) {
+		return ""
+	}
+	v := r + "." + f.Name
+	if f.Len == 0 {
+		return unmarshalValue(v, f, fmt.Sprint(f.Offset))
+	}
+	if isByte(f.Type) {
+		return fmt.Sprintf("copy(%s[:], src[%d:%d])\n", v, f.Offset, f.Offset+f.Size*f.Len)
+	}
+	return fmt.Sprintf("for i := range %s {\n%s}\n", v, unmarshalValue(v+"[i]", f, elemOffset(f)))
+}
+
+func unmarshalValue(v string, f field, off string) string {
+	switch {
+	case f.Basic == "":
+		return fmt.Sprintf("%s.UnmarshalBytes(src[%s:])\n", v, off)
+	case f.Size == 1:
+		if isByte(f.Type) {
+			return fmt.Sprintf("%s = src[%s]\n", v, off)
+		}
+		return fmt.Sprintf("%s = %s(src[%s])\n", v, f.Type, off)
+	}
+	x := fmt.Sprintf("ubinary.NativeEndian.Uint%d(src[%s:])", f.Size*8, off)
+	if f.Type != fmt.Sprintf("uint%d", f.Size*8) {
+		x = fmt.Sprintf("%s(%s)", f.Type, x)
+	}
+	return fmt.Sprintf("%s = %s\n", v, x)
+}
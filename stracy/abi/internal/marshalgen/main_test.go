@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerated checks the generated files are up to date.
+func TestGenerated(t *testing.T) {
+	files := []string{"../../abi_linux.go", "../../abi_unix.go"}
+	out, err := generate(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range files {
+		got, err := os.ReadFile(outputName(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, out[name]) {
+			t.Errorf("%s is out of date, run go generate", outputName(name))
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	src := `package p
+
+const n = 3
+
+type T int16
+
+// A is annotated.
+//
+// +marshal
+type A struct {
+	X, Y uint8
+	_    [n]byte
+	Z    [2]T
+	B    B
+}
+
+// +marshal
+type B struct {
+	u uint64
+}
+`
+	name := filepath.Join(t.TempDir(), "p_linux.go")
+	if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := generate([]string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := outputName(name), filepath.Join(filepath.Dir(name), "zmarshal_linux.go"); got != want {
+		t.Errorf("outputName() = %s, want %s", got, want)
+	}
+	for _, want := range []string{
+		"func (a A) SizeBytes() int {\n\treturn 17\n}",
+		"dst[1] = a.Y\n",
+		"clear(dst[2:5])\n",
+		"ubinary.NativeEndian.PutUint16(dst[5+2*i:], uint16(a.Z[i]))\n",
+		"a.Z[i] = T(ubinary.NativeEndian.Uint16(src[5+2*i:]))\n",
+		"a.B.MarshalBytes(dst[9:])\n",
+		"a.B.UnmarshalBytes(src[9:])\n",
+		"ubinary.NativeEndian.PutUint64(dst[0:], b.u)\n",
+	} {
+		if !strings.Contains(string(out[name]), want) {
+			t.Errorf("no %q in\n%s", want, out[name])
+		}
+	}
+	// Unexported fields aren't unmarshaled, like by binary.Unmarshal.
+	if strings.Contains(string(out[name]), "b.u =") {
+		t.Errorf("b.u is unmarshaled:\n%s", out[name])
+	}
+}
+
+func TestLayoutErrors(t *testing.T) {
+	for _, typ := range []string{
+		"struct{ X []byte }",
+		"struct{ X *int32 }",
+		"struct{ X int }",
+		"struct{ X [m]byte }",
+		"struct{ X [2][2]byte }",
+		"struct{ S }",
+		"struct{ X S }",
+		"struct{}",
+		"struct{ X int8 `binary:\"pad\"` }",
+	} {
+		src := "package p\n\nvar m = 1\n\ntype S struct{ X int8 }\n\n// +marshal\ntype T " + typ + "\n"
+		name := filepath.Join(t.TempDir(), "p.go")
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := generate([]string{name}); err == nil {
+			t.Errorf("%s: no error", typ)
+		}
+	}
+}
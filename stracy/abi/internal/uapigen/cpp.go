@@ -0,0 +1,425 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// macro is an object-like #define.
+type macro struct {
+	Name string
+	Expr string
+	File string
+
+	// Indented is set for "# define", prctl.h indents arguments of
+	// options this way.
+	Indented bool
+}
+
+// headers is a minimal C preprocessor: it collects object-like macros of
+// headers and their includes, honoring conditionals. It doesn't expand
+// function-like macros, so values defined with them, like ioctl numbers,
+// can't be evaluated.
+type headers struct {
+	dir      string
+	macros   []*macro          // in the order of definition
+	defined  map[string]*macro // nil for function-like macros
+	included map[string]bool
+}
+
+func newHeaders(dir string, predefined map[string]string) *headers {
+	h := &headers{dir: dir, defined: make(map[string]*macro), included: make(map[string]bool)}
+	for name, expr := range predefined {
+		h.defined[name] = &macro{Name: name, Expr: expr}
+	}
+	return h
+}
+
+var (
+	reComment   = regexp.MustCompile(`(?s)/\*.*?\*/|//[^\n]*`)
+	reDirective = regexp.MustCompile(`^#(\s*)(\w+)\s*(.*)$`)
+	reDefine    = regexp.MustCompile(`^(\w+)(\()?\s*(.*)$`)
+	reInclude   = regexp.MustCompile(`^[<"]([^>"]+)[>"]`)
+)
+
+// include reads a header, the name is relative to the headers directory.
+// Headers that aren't vendored are skipped.
+func (h *headers) include(name string) error {
+	if h.included[name] {
+		return nil
+	}
+	h.included[name] = true
+	data, err := os.ReadFile(filepath.Join(h.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	src := strings.ReplaceAll(string(data), "\\\n", " ")
+	src = reComment.ReplaceAllStringFunc(src, func(c string) string {
+		// keep line numbers for errors
+		return strings.Repeat("\n", strings.Count(c, "\n")) + " "
+	})
+
+	// active conditions, done is set once a branch of #if was taken
+	type cond struct{ active, done bool }
+	var conds []cond
+	active := func() bool {
+		for _, c := range conds {
+			if !c.active {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i, line := range strings.Split(src, "\n") {
+		m := reDirective.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		indented, directive, arg := m[1] != "", m[2], strings.TrimSpace(m[3])
+		pos := fmt.Sprintf("%s:%d", name, i+1)
+
+		switch directive {
+		case "if", "ifdef", "ifndef":
+			var ok bool
+			switch directive {
+			case "ifdef":
+				_, ok = h.defined[arg]
+			case "ifndef":
+				_, ok = h.defined[arg]
+				ok = !ok
+			default:
+				v, err := h.eval(arg, true)
+				if err != nil {
+					return fmt.Errorf("%s: %w", pos, err)
+				}
+				ok = v != 0
+			}
+			conds = append(conds, cond{active: ok, done: ok})
+		case "elif":
+			if len(conds) == 0 {
+				return fmt.Errorf("%s: #elif without #if", pos)
+			}
+			c := &conds[len(conds)-1]
+			c.active = false
+			if !c.done {
+				v, err := h.eval(arg, true)
+				if err != nil {
+					return fmt.Errorf("%s: %w", pos, err)
+				}
+				c.active, c.done = v != 0, v != 0
+			}
+		case "else":
+			if len(conds) == 0 {
+				return fmt.Errorf("%s: #else without #if", pos)
+			}
+			c := &conds[len(conds)-1]
+			c.active, c.done = !c.done, true
+		case "endif":
+			if len(conds) == 0 {
+				return fmt.Errorf("%s: #endif without #if", pos)
+			}
+			conds = conds[:len(conds)-1]
+		case "include":
+			if !active() {
+				continue
+			}
+			inc := reInclude.FindStringSubmatch(arg)
+			if inc == nil {
+				return fmt.Errorf("%s: bad #include %s", pos, arg)
+			}
+			if err := h.include(inc[1]); err != nil {
+				return err
+			}
+		case "define":
+			if !active() {
+				continue
+			}
+			d := reDefine.FindStringSubmatch(arg)
+			if d == nil {
+				return fmt.Errorf("%s: bad #define %s", pos, arg)
+			}
+			if d[2] != "" { // function-like
+				h.defined[d[1]] = nil
+				continue
+			}
+			m := &macro{Name: d[1], Expr: strings.TrimSpace(d[3]), File: name, Indented: indented}
+			h.macros = append(h.macros, m)
+			h.defined[m.Name] = m
+		case "undef":
+			if active() {
+				delete(h.defined, arg)
+			}
+		}
+	}
+	if len(conds) != 0 {
+		return fmt.Errorf("%s: unterminated #if", name)
+	}
+	return nil
+}
+
+// value evaluates a macro.
+func (h *headers) value(name string) (int64, error) {
+	m, ok := h.defined[name]
+	if !ok {
+		return 0, fmt.Errorf("%s is undefined", name)
+	}
+	if m == nil {
+		return 0, fmt.Errorf("%s is a function-like macro", name)
+	}
+	return h.eval(m.Expr, false)
+}
+
+// idents returns identifiers referenced by an expression.
+func idents(expr string) []string {
+	toks, err := tokenize(expr)
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, t := range toks {
+		if isIdent(t) {
+			ids = append(ids, t)
+		}
+	}
+	return ids
+}
+
+// eval evaluates a constant expression. In conditions of #if undefined
+// identifiers are 0 and defined() is allowed.
+func (h *headers) eval(expr string, cond bool) (int64, error) {
+	toks, err := tokenize(expr)
+	if err != nil {
+		return 0, err
+	}
+	p := &exprParser{h: h, toks: toks, cond: cond}
+	v, err := p.binary(1)
+	if err != nil {
+		return 0, err
+	}
+	if p.pos != len(p.toks) {
+		return 0, fmt.Errorf("unexpected %q in %q", p.toks[p.pos], expr)
+	}
+	return v, nil
+}
+
+var reToken = regexp.MustCompile(`^(?:0[xX][0-9a-fA-F]+[uUlL]*|[0-9]+[uUlL]*|[A-Za-z_]\w*|\|\||&&|==|!=|<=|>=|<<|>>|[-+*/%&|^~!<>()])`)
+
+func tokenize(expr string) ([]string, error) {
+	var toks []string
+	for expr = strings.TrimSpace(expr); expr != ""; expr = strings.TrimSpace(expr) {
+		t := reToken.FindString(expr)
+		if t == "" {
+			return nil, fmt.Errorf("can't parse %q", expr)
+		}
+		toks = append(toks, t)
+		expr = expr[len(t):]
+	}
+	return toks, nil
+}
+
+func isIdent(tok string) bool {
+	c := tok[0]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// exprParser is a precedence climbing parser of C constant expressions.
+type exprParser struct {
+	h     *headers
+	toks  []string
+	pos   int
+	cond  bool
+	depth int
+}
+
+var precedence = map[string]int{
+	"||": 1, "&&": 2, "|": 3, "^": 4, "&": 5,
+	"==": 6, "!=": 6,
+	"<": 7, ">": 7, "<=": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *exprParser) binary(minPrec int) (int64, error) {
+	x, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		prec, ok := precedence[op]
+		if !ok || prec < minPrec {
+			return x, nil
+		}
+		p.next()
+		y, err := p.binary(prec + 1)
+		if err != nil {
+			return 0, err
+		}
+		if x, err = apply(op, x, y); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func apply(op string, x, y int64) (int64, error) {
+	b := func(v bool) int64 {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	switch op {
+	case "||":
+		return b(x != 0 || y != 0), nil
+	case "&&":
+		return b(x != 0 && y != 0), nil
+	case "|":
+		return x | y, nil
+	case "^":
+		return x ^ y, nil
+	case "&":
+		return x & y, nil
+	case "==":
+		return b(x == y), nil
+	case "!=":
+		return b(x != y), nil
+	case "<":
+		return b(x < y), nil
+	case ">":
+		return b(x > y), nil
+	case "<=":
+		return b(x <= y), nil
+	case ">=":
+		return b(x >= y), nil
+	case "<<":
+		return x << y, nil
+	case ">>":
+		return x >> y, nil
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	}
+	if y == 0 {
+		return 0, errors.New("division by zero")
+	}
+	if op == "/" {
+		return x / y, nil
+	}
+	return x % y, nil
+}
+
+func (p *exprParser) unary() (int64, error) {
+	switch t := p.next(); {
+	case t == "":
+		return 0, errors.New("unexpected end of expression")
+	case t == "-" || t == "+" || t == "~" || t == "!":
+		x, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		switch t {
+		case "-":
+			return -x, nil
+		case "~":
+			return ^x, nil
+		case "!":
+			if x == 0 {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		return x, nil
+	case t == "(":
+		x, err := p.binary(1)
+		if err != nil {
+			return 0, err
+		}
+		if p.next() != ")" {
+			return 0, errors.New("missing )")
+		}
+		return x, nil
+	case t == "defined" && p.cond:
+		name := p.next()
+		paren := name == "("
+		if paren {
+			name = p.next()
+		}
+		if name == "" || !isIdent(name) || paren && p.next() != ")" {
+			return 0, errors.New("bad defined()")
+		}
+		if _, ok := p.h.defined[name]; ok {
+			return 1, nil
+		}
+		return 0, nil
+	case isIdent(t):
+		if p.peek() == "(" {
+			return 0, fmt.Errorf("can't expand %s()", t)
+		}
+		m, ok := p.h.defined[t]
+		if !ok && p.cond {
+			return 0, nil
+		}
+		if !ok || m == nil {
+			return 0, fmt.Errorf("%s is undefined", t)
+		}
+		if p.depth > 32 {
+			return 0, fmt.Errorf("%s is recursive", t)
+		}
+		toks, err := tokenize(m.Expr)
+		if err != nil {
+			return 0, err
+		}
+		sub := &exprParser{h: p.h, toks: toks, cond: p.cond, depth: p.depth + 1}
+		v, err := sub.binary(1)
+		if err == nil && sub.pos != len(sub.toks) {
+			err = fmt.Errorf("unexpected %q in %s", sub.toks[sub.pos], t)
+		}
+		return v, err
+	default:
+		return parseInt(t)
+	}
+}
+
+// parseInt parses a C integer literal.
+func parseInt(t string) (int64, error) {
+	t = strings.TrimRight(t, "uUlL")
+	base := 10
+	switch {
+	case strings.HasPrefix(t, "0x") || strings.HasPrefix(t, "0X"):
+		base, t = 16, t[2:]
+	case len(t) > 1 && t[0] == '0':
+		base, t = 8, t[1:]
+	}
+	v, err := strconv.ParseUint(t, base, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q", t)
+	}
+	return int64(v), nil
+}
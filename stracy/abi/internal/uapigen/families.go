@@ -0,0 +1,103 @@
+package main
+
+// kind selects how constants of a family are combined.
+type kind int
+
+const (
+	values kind = iota // one of the constants: &Value{}
+	bits               // constants or-ed together: &BitFlag{}
+)
+
+// family is a group of constants emitted as one FlagSet.
+type family struct {
+	Name   string // Go variable
+	Doc    string // doc comment, without the name
+	Header string // header to read, with its includes
+	Kind   kind
+
+	// Match selects constants by name, Exclude drops some of them.
+	Match   string
+	Exclude string
+
+	// Unindented skips "# define" constants, prctl.h defines arguments of
+	// options this way.
+	Unindented bool
+}
+
+// predefined are macros set by the compiler or headers that aren't vendored,
+// for x86-64.
+var predefined = map[string]string{
+	"__BITS_PER_LONG": "64",
+}
+
+// families are the generated FlagSets. Constants defined as combinations of
+// other constants of a family, like IN_CLOSE, are skipped.
+var families = []family{
+	{
+		Name:    "FcntlCmds",
+		Doc:     "are fcntl(2) commands.",
+		Header:  "linux/fcntl.h",
+		Kind:    values,
+		Match:   `^F_`,
+		Exclude: `^F_(OWNER_|SEAL_|RDLCK|WRLCK|UNLCK|EXLCK|SHLCK|LINUX_SPECIFIC_BASE)`,
+	},
+	{
+		Name:   "FcntlSealFlagSet",
+		Doc:    "are file seals of fcntl(2) F_ADD_SEALS and F_GET_SEALS.",
+		Header: "linux/fcntl.h",
+		Kind:   bits,
+		Match:  `^F_SEAL_`,
+	},
+	{
+		Name:   "DnotifyFlagSet",
+		Doc:    "are directory events of fcntl(2) F_NOTIFY.",
+		Header: "linux/fcntl.h",
+		Kind:   bits,
+		Match:  `^DN_`,
+	},
+	{
+		Name:       "PrctlOptions",
+		Doc:        "are prctl(2) options.",
+		Header:     "linux/prctl.h",
+		Kind:       values,
+		Match:      `^PR_`,
+		Unindented: true,
+	},
+	{
+		Name:    "InotifyMaskSet",
+		Doc:     "are inotify_add_watch(2) events and flags.",
+		Header:  "linux/inotify.h",
+		Kind:    bits,
+		Match:   `^IN_`,
+		Exclude: `^IN_(CLOEXEC|NONBLOCK)$`,
+	},
+	{
+		Name:   "InotifyInitFlagSet",
+		Doc:    "are inotify_init1(2) flags.",
+		Header: "linux/inotify.h",
+		Kind:   bits,
+		Match:  `^IN_(CLOEXEC|NONBLOCK)$`,
+	},
+	{
+		Name:   "FanotifyInitFlagSet",
+		Doc:    "are fanotify_init(2) flags.",
+		Header: "linux/fanotify.h",
+		Kind:   bits,
+		Match:  `^FAN_(CLOEXEC|NONBLOCK|CLASS_\w+|UNLIMITED_\w+|ENABLE_AUDIT|REPORT_\w+)$`,
+	},
+	{
+		Name:   "FanotifyMarkFlagSet",
+		Doc:    "are fanotify_mark(2) flags.",
+		Header: "linux/fanotify.h",
+		Kind:   bits,
+		Match:  `^FAN_MARK_`,
+	},
+	{
+		Name:   "FanotifyEventSet",
+		Doc:    "are fanotify_mark(2) events.",
+		Header: "linux/fanotify.h",
+		Kind:   bits,
+		Match: `^FAN_(ACCESS|MODIFY|ATTRIB|CLOSE_WRITE|CLOSE_NOWRITE|OPEN|MOVED_FROM|MOVED_TO|CREATE|DELETE|DELETE_SELF|MOVE_SELF|` +
+			`OPEN_EXEC|Q_OVERFLOW|FS_ERROR|OPEN_PERM|ACCESS_PERM|OPEN_EXEC_PERM|EVENT_ON_CHILD|RENAME|ONDIR)$`,
+	},
+}
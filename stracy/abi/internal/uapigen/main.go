@@ -0,0 +1,197 @@
+// Uapigen generates FlagSets of the abi package from kernel UAPI headers.
+//
+// The headers are vendored in testdata/include, they are from linux-libc-dev
+// 6.1 for x86-64. Constant families to generate are listed in families.go.
+// Values are taken from golang.org/x/sys/unix when it has the constant, a
+// generated test checks they agree with the headers.
+//
+// Usage, from the abi directory:
+//
+//	go run ./internal/uapigen -headers internal/uapigen/testdata/include -o zuapi_linux.go -test zuapi_linux_test.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+func main() {
+	headersDir := flag.String("headers", "testdata/include", "`dir` with kernel UAPI headers")
+	out := flag.String("o", "zuapi_linux.go", "output `file`")
+	testOut := flag.String("test", "zuapi_linux_test.go", "output `file` of the test checking values against x/sys/unix")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("uapigen: ")
+
+	known, err := unixConstants()
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, test, err := generate(*headersDir, families, known)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*testOut, test, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// constant is a member of a generated FlagSet.
+type constant struct {
+	Name  string
+	Value uint64
+}
+
+// collect returns constants of a family in the order of definition.
+func collect(dir string, f family) ([]constant, error) {
+	h := newHeaders(dir, predefined)
+	if err := h.include(f.Header); err != nil {
+		return nil, err
+	}
+	if !h.included[f.Header] || len(h.macros) == 0 {
+		return nil, fmt.Errorf("%s: no macros in %s", f.Name, f.Header)
+	}
+
+	match := regexp.MustCompile(f.Match)
+	var exclude *regexp.Regexp
+	if f.Exclude != "" {
+		exclude = regexp.MustCompile(f.Exclude)
+	}
+	member := func(m *macro) bool {
+		return match.MatchString(m.Name) && (exclude == nil || !exclude.MatchString(m.Name)) && !(f.Unindented && m.Indented)
+	}
+	members := make(map[string]bool)
+	for _, m := range h.macros {
+		if member(m) {
+			members[m.Name] = true
+		}
+	}
+
+	var consts []constant
+	seen := make(map[string]bool)
+	for _, m := range h.macros {
+		if !members[m.Name] || seen[m.Name] || h.defined[m.Name] != m {
+			continue
+		}
+		seen[m.Name] = true
+		alias := false
+		for _, id := range idents(m.Expr) {
+			alias = alias || members[id]
+		}
+		if alias {
+			continue
+		}
+		v, err := h.value(m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", f.Name, m.File, err)
+		}
+		consts = append(consts, constant{Name: m.Name, Value: uint64(v)})
+	}
+	if len(consts) == 0 {
+		return nil, fmt.Errorf("%s: no constants match", f.Name)
+	}
+	return consts, nil
+}
+
+// generate returns the source of the FlagSets and of their test. known are
+// constants of golang.org/x/sys/unix.
+func generate(dir string, fams []family, known map[string]bool) (src, test []byte, err error) {
+	var b, t bytes.Buffer
+	const header = "// Code generated by uapigen from kernel UAPI headers. DO NOT EDIT.\n\npackage abi\n\n"
+	b.WriteString(header)
+	b.WriteString("import \"golang.org/x/sys/unix\"\n")
+	t.WriteString(header)
+	t.WriteString("import (\n\"testing\"\n\n\"golang.org/x/sys/unix\"\n)\n\n")
+	t.WriteString("// uapiConstants are values of the kernel headers with x/sys/unix counterparts.\n")
+	t.WriteString("var uapiConstants = []struct {\nname string\nheader, unix uint64\n}{\n")
+
+	tested := make(map[string]bool)
+	for _, f := range fams {
+		consts, err := collect(dir, f)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmt.Fprintf(&b, "\n// %s %s\n// From <%s>.\nvar %s = FlagSet{\n", f.Name, f.Doc, f.Header, f.Name)
+		for _, c := range consts {
+			typ := "Value"
+			if f.Kind == bits && c.Value != 0 {
+				typ = "BitFlag"
+			}
+			value := fmt.Sprintf("%#x", c.Value)
+			if known[c.Name] {
+				value = "unix." + c.Name
+				if !tested[c.Name] {
+					tested[c.Name] = true
+					fmt.Fprintf(&t, "{%q, %#x, unix.%s},\n", c.Name, c.Value, c.Name)
+				}
+			}
+			fmt.Fprintf(&b, "&%s{Value: %s, Name: %q},\n", typ, value, c.Name)
+		}
+		b.WriteString("}\n")
+	}
+
+	t.WriteString("}\n\n")
+	t.WriteString(`func TestUAPIConstants(t *testing.T) {
+	for _, c := range uapiConstants {
+		if c.header != c.unix {
+			t.Errorf("%s is %#x in the kernel headers and %#x in x/sys/unix", c.name, c.header, c.unix)
+		}
+	}
+}
+`)
+
+	if src, err = format.Source(b.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	if test, err = format.Source(t.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	return src, test, nil
+}
+
+// unixConstants returns names of constants golang.org/x/sys/unix declares
+// for linux/amd64.
+func unixConstants() (map[string]bool, error) {
+	pkg, err := build.Import("golang.org/x/sys/unix", ".", build.FindOnly)
+	if err != nil {
+		return nil, err
+	}
+	ctx := build.Default
+	ctx.GOOS, ctx.GOARCH = "linux", "amd64"
+	pkg, err = ctx.ImportDir(pkg.Dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.CONST {
+				for _, spec := range d.Specs {
+					for _, id := range spec.(*ast.ValueSpec).Names {
+						known[id.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return known, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	h := newHeaders(t.TempDir(), map[string]string{
+		"A":   "4",
+		"B":   "(A << 2)",
+		"C":   "(A | B)",
+		"REC": "REC + 1",
+	})
+	h.defined["F"] = nil
+
+	tests := []struct {
+		expr string
+		want int64
+	}{
+		{"1", 1},
+		{"0x10", 16},
+		{"010", 8},
+		{"0x80000000U", 0x80000000},
+		{"1UL << 40", 1 << 40},
+		{"-1", -1},
+		{"~0", -1},
+		{"!0", 1},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"7 / 2 % 2", 1},
+		{"1 | 2 & 3", 3},
+		{"1 << 2 | 1", 5},
+		{"2 > 1 && 1 >= 1", 1},
+		{"1 == 2 || 3 != 3", 0},
+		{"B", 16},
+		{"C", 20},
+		{"C - A", 16},
+	}
+	for _, tt := range tests {
+		got, err := h.eval(tt.expr, false)
+		if err != nil {
+			t.Errorf("eval(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("eval(%q) = %d, want %d", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"", "(1", "1 +", "1 2", "1 / 0", "D", "F", "F(1)", "REC", "defined(A)", "'a'"} {
+		if v, err := h.eval(expr, false); err == nil {
+			t.Errorf("eval(%q) = %d, want an error", expr, v)
+		}
+	}
+
+	for expr, want := range map[string]int64{
+		"defined(A)":           1,
+		"defined A":            1,
+		"defined(D)":           0,
+		"defined(F)":           1,
+		"D":                    0,
+		"!defined(D) && A":     1,
+		"__BITS_PER_LONG == 0": 1,
+	} {
+		got, err := h.eval(expr, true)
+		if err != nil {
+			t.Errorf("eval(%q) in #if: %v", expr, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("eval(%q) in #if = %d, want %d", expr, got, want)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.h", `#ifndef _A_H
+#define _A_H
+#include <sub/b.h>
+#include <missing.h>
+
+/* comment
+ * #define COMMENTED 1
+ */
+#define X_ONE	1 // one
+#define X_TWO	\
+	(X_ONE + 1)
+# define X_INDENTED 3
+#define X_FUNC(a) (a)
+#define X_UNDEF 4
+#undef X_UNDEF
+
+#if defined(B) && B > 1
+#define X_IF 1
+#elif B == 1
+#define X_ELIF 1
+#else
+#define X_ELSE 1
+#endif
+
+#ifdef NOPE
+#if 1
+#define X_NESTED 1
+#endif
+#else
+#define X_NOT_NESTED 1
+#endif
+#endif
+`)
+	write("sub/b.h", "#define B 1\n")
+
+	h := newHeaders(dir, nil)
+	if err := h.include("a.h"); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range h.macros {
+		names = append(names, m.Name)
+	}
+	want := []string{"_A_H", "B", "X_ONE", "X_TWO", "X_INDENTED", "X_UNDEF", "X_ELIF", "X_NOT_NESTED"}
+	if len(names) != len(want) {
+		t.Fatalf("macros = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("macros = %v, want %v", names, want)
+		}
+	}
+
+	if v, err := h.value("X_TWO"); err != nil || v != 2 {
+		t.Errorf("X_TWO = %d, %v; want 2", v, err)
+	}
+	if m := h.defined["X_INDENTED"]; m == nil || !m.Indented || h.defined["X_ONE"].Indented {
+		t.Errorf("X_INDENTED isn't the only indented macro")
+	}
+	for _, name := range []string{"X_FUNC", "X_UNDEF"} {
+		if _, err := h.value(name); err == nil {
+			t.Errorf("value(%s) succeeded, want an error", name)
+		}
+	}
+
+	write("bad.h", "#if 1\n#define X 1\n")
+	if err := newHeaders(dir, nil).include("bad.h"); err == nil {
+		t.Error("unterminated #if isn't an error")
+	}
+}
+
+// TestGenerated checks the generated files are up to date.
+func TestGenerated(t *testing.T) {
+	known, err := unixConstants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, test, err := generate("testdata/include", families, known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string][]byte{"zuapi_linux.go": src, "zuapi_linux_test.go": test} {
+		got, err := os.ReadFile(filepath.Join("..", "..", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date, run go generate", file)
+		}
+	}
+}
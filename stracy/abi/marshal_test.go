@@ -0,0 +1,124 @@
+package abi
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
+)
+
+type marshaler[T any] interface {
+	*T
+	binary.Marshaler
+	binary.Unmarshaler
+}
+
+// testMarshal checks generated methods of T against reflection, which is
+// used for [1]T as arrays have no methods.
+func testMarshal[T any, P marshaler[T]](t *testing.T) {
+	var ref [1]T
+	size := int(binary.Size(&ref))
+	if got := P(&ref[0]).SizeBytes(); got != size {
+		t.Fatalf("SizeBytes() = %d, want %d", got, size)
+	}
+	if got := int(binary.Size(&ref[0])); got != size {
+		t.Fatalf("binary.Size() = %d, want %d", got, size)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		buf := make([]byte, size)
+		rnd.Read(buf)
+
+		if err := binary.Unmarshal(buf, ubinary.NativeEndian, &ref); err != nil {
+			t.Fatal(err)
+		}
+		var x T
+		P(&x).UnmarshalBytes(buf)
+		if !reflect.DeepEqual(x, ref[0]) {
+			t.Fatalf("UnmarshalBytes(%x) = %+v, want %+v", buf, x, ref[0])
+		}
+		var y T
+		if err := binary.Unmarshal(buf, ubinary.NativeEndian, &y); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(y, ref[0]) {
+			t.Fatalf("binary.Unmarshal(%x) = %+v, want %+v", buf, y, ref[0])
+		}
+
+		want, err := binary.Marshal(nil, ubinary.NativeEndian, &ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := bytes.Repeat([]byte{0xff}, size)
+		P(&x).MarshalBytes(got)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("MarshalBytes() = %x, want %x", got, want)
+		}
+		if got, _ := binary.Marshal([]byte{1}, ubinary.NativeEndian, x); !bytes.Equal(got[1:], want) || got[0] != 1 {
+			t.Fatalf("binary.Marshal() = %x, want 01%x", got, want)
+		}
+	}
+}
+
+func TestMarshalBytes(t *testing.T) {
+	t.Run("Tms", testMarshal[Tms])
+	t.Run("SockAddrInet", testMarshal[SockAddrInet])
+	t.Run("SockAddrInet6", testMarshal[SockAddrInet6])
+	t.Run("SockAddrUnix", testMarshal[SockAddrUnix])
+	t.Run("TCPInfo", testMarshal[TCPInfo])
+	t.Run("ControlMessageHeader", testMarshal[ControlMessageHeader])
+	t.Run("ControlMessageCredentials", testMarshal[ControlMessageCredentials])
+	t.Run("EpollEvent", testMarshal[EpollEvent])
+	t.Run("MessageHeader64", testMarshal[MessageHeader64])
+	t.Run("CloneArgs", testMarshal[CloneArgs])
+}
+
+// benchUnmarshal compares binary.Unmarshal of T, which uses the generated
+// code, with reflection.
+func benchUnmarshal[T any](b *testing.B) {
+	var ref [1]T
+	buf := make([]byte, binary.Size(&ref))
+	rand.New(rand.NewSource(1)).Read(buf)
+
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var x T
+			binary.Unmarshal(buf, ubinary.NativeEndian, &x)
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var x [1]T
+			binary.Unmarshal(buf, ubinary.NativeEndian, &x)
+		}
+	})
+}
+
+func benchMarshal[T any](b *testing.B) {
+	var x [1]T
+	buf := make([]byte, 0, binary.Size(&x))
+
+	b.Run("generated", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			binary.Marshal(buf, ubinary.NativeEndian, &x[0])
+		}
+	})
+	b.Run("reflect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			binary.Marshal(buf, ubinary.NativeEndian, &x)
+		}
+	})
+}
+
+func BenchmarkUnmarshalTCPInfo(b *testing.B)              { benchUnmarshal[TCPInfo](b) }
+func BenchmarkUnmarshalControlMessageHeader(b *testing.B) { benchUnmarshal[ControlMessageHeader](b) }
+func BenchmarkUnmarshalMessageHeader64(b *testing.B)      { benchUnmarshal[MessageHeader64](b) }
+func BenchmarkMarshalTCPInfo(b *testing.B)                { benchMarshal[TCPInfo](b) }
@@ -0,0 +1,304 @@
+// Code generated by marshalgen from abi_linux.go. DO NOT EDIT.
+
+package abi
+
+import "github.com/iimos/play/stracy/ubinary"
+
+// SizeBytes implements binary.Marshaler.
+func (t Tms) SizeBytes() int {
+	return 32
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (t Tms) MarshalBytes(dst []byte) {
+	_ = dst[31] // bounds check hint
+	ubinary.NativeEndian.PutUint64(dst[0:], uint64(t.UTime))
+	ubinary.NativeEndian.PutUint64(dst[8:], uint64(t.STime))
+	ubinary.NativeEndian.PutUint64(dst[16:], uint64(t.CUTime))
+	ubinary.NativeEndian.PutUint64(dst[24:], uint64(t.CSTime))
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (t *Tms) UnmarshalBytes(src []byte) {
+	_ = src[31] // bounds check hint
+	t.UTime = ClockT(ubinary.NativeEndian.Uint64(src[0:]))
+	t.STime = ClockT(ubinary.NativeEndian.Uint64(src[8:]))
+	t.CUTime = ClockT(ubinary.NativeEndian.Uint64(src[16:]))
+	t.CSTime = ClockT(ubinary.NativeEndian.Uint64(src[24:]))
+}
+
+// SizeBytes implements binary.Marshaler.
+func (c CloneArgs) SizeBytes() int {
+	return 88
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (c CloneArgs) MarshalBytes(dst []byte) {
+	_ = dst[87] // bounds check hint
+	ubinary.NativeEndian.PutUint64(dst[0:], c.Flags)
+	ubinary.NativeEndian.PutUint64(dst[8:], c.Pidfd)
+	ubinary.NativeEndian.PutUint64(dst[16:], c.ChildTID)
+	ubinary.NativeEndian.PutUint64(dst[24:], c.ParentTID)
+	ubinary.NativeEndian.PutUint64(dst[32:], c.ExitSignal)
+	ubinary.NativeEndian.PutUint64(dst[40:], c.Stack)
+	ubinary.NativeEndian.PutUint64(dst[48:], c.StackSize)
+	ubinary.NativeEndian.PutUint64(dst[56:], c.TLS)
+	ubinary.NativeEndian.PutUint64(dst[64:], c.SetTID)
+	ubinary.NativeEndian.PutUint64(dst[72:], c.SetTIDSize)
+	ubinary.NativeEndian.PutUint64(dst[80:], c.Cgroup)
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (c *CloneArgs) UnmarshalBytes(src []byte) {
+	_ = src[87] // bounds check hint
+	c.Flags = ubinary.NativeEndian.Uint64(src[0:])
+	c.Pidfd = ubinary.NativeEndian.Uint64(src[8:])
+	c.ChildTID = ubinary.NativeEndian.Uint64(src[16:])
+	c.ParentTID = ubinary.NativeEndian.Uint64(src[24:])
+	c.ExitSignal = ubinary.NativeEndian.Uint64(src[32:])
+	c.Stack = ubinary.NativeEndian.Uint64(src[40:])
+	c.StackSize = ubinary.NativeEndian.Uint64(src[48:])
+	c.TLS = ubinary.NativeEndian.Uint64(src[56:])
+	c.SetTID = ubinary.NativeEndian.Uint64(src[64:])
+	c.SetTIDSize = ubinary.NativeEndian.Uint64(src[72:])
+	c.Cgroup = ubinary.NativeEndian.Uint64(src[80:])
+}
+
+// SizeBytes implements binary.Marshaler.
+func (s SockAddrInet) SizeBytes() int {
+	return 16
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (s SockAddrInet) MarshalBytes(dst []byte) {
+	_ = dst[15] // bounds check hint
+	ubinary.NativeEndian.PutUint16(dst[0:], s.Family)
+	ubinary.NativeEndian.PutUint16(dst[2:], s.Port)
+	copy(dst[4:8], s.Addr[:])
+	copy(dst[8:16], s.Zero[:])
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (s *SockAddrInet) UnmarshalBytes(src []byte) {
+	_ = src[15] // bounds check hint
+	s.Family = ubinary.NativeEndian.Uint16(src[0:])
+	s.Port = ubinary.NativeEndian.Uint16(src[2:])
+	copy(s.Addr[:], src[4:8])
+	copy(s.Zero[:], src[8:16])
+}
+
+// SizeBytes implements binary.Marshaler.
+func (s SockAddrInet6) SizeBytes() int {
+	return 28
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (s SockAddrInet6) MarshalBytes(dst []byte) {
+	_ = dst[27] // bounds check hint
+	ubinary.NativeEndian.PutUint16(dst[0:], s.Family)
+	ubinary.NativeEndian.PutUint16(dst[2:], s.Port)
+	ubinary.NativeEndian.PutUint32(dst[4:], s.Flowinfo)
+	copy(dst[8:24], s.Addr[:])
+	ubinary.NativeEndian.PutUint32(dst[24:], s.Scope_id)
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (s *SockAddrInet6) UnmarshalBytes(src []byte) {
+	_ = src[27] // bounds check hint
+	s.Family = ubinary.NativeEndian.Uint16(src[0:])
+	s.Port = ubinary.NativeEndian.Uint16(src[2:])
+	s.Flowinfo = ubinary.NativeEndian.Uint32(src[4:])
+	copy(s.Addr[:], src[8:24])
+	s.Scope_id = ubinary.NativeEndian.Uint32(src[24:])
+}
+
+// SizeBytes implements binary.Marshaler.
+func (s SockAddrUnix) SizeBytes() int {
+	return 110
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (s SockAddrUnix) MarshalBytes(dst []byte) {
+	_ = dst[109] // bounds check hint
+	ubinary.NativeEndian.PutUint16(dst[0:], s.Family)
+	for i := range s.Path {
+		dst[2+i] = byte(s.Path[i])
+	}
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (s *SockAddrUnix) UnmarshalBytes(src []byte) {
+	_ = src[109] // bounds check hint
+	s.Family = ubinary.NativeEndian.Uint16(src[0:])
+	for i := range s.Path {
+		s.Path[i] = int8(src[2+i])
+	}
+}
+
+// SizeBytes implements binary.Marshaler.
+func (t TCPInfo) SizeBytes() int {
+	return 192
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (t TCPInfo) MarshalBytes(dst []byte) {
+	_ = dst[191] // bounds check hint
+	dst[0] = t.State
+	dst[1] = t.CaState
+	dst[2] = t.Retransmits
+	dst[3] = t.Probes
+	dst[4] = t.Backoff
+	dst[5] = t.Options
+	dst[6] = t.WindowScale
+	dst[7] = t.DeliveryRateAppLimited
+	ubinary.NativeEndian.PutUint32(dst[8:], t.RTO)
+	ubinary.NativeEndian.PutUint32(dst[12:], t.ATO)
+	ubinary.NativeEndian.PutUint32(dst[16:], t.SndMss)
+	ubinary.NativeEndian.PutUint32(dst[20:], t.RcvMss)
+	ubinary.NativeEndian.PutUint32(dst[24:], t.Unacked)
+	ubinary.NativeEndian.PutUint32(dst[28:], t.Sacked)
+	ubinary.NativeEndian.PutUint32(dst[32:], t.Lost)
+	ubinary.NativeEndian.PutUint32(dst[36:], t.Retrans)
+	ubinary.NativeEndian.PutUint32(dst[40:], t.Fackets)
+	ubinary.NativeEndian.PutUint32(dst[44:], t.LastDataSent)
+	ubinary.NativeEndian.PutUint32(dst[48:], t.LastAckSent)
+	ubinary.NativeEndian.PutUint32(dst[52:], t.LastDataRecv)
+	ubinary.NativeEndian.PutUint32(dst[56:], t.LastAckRecv)
+	ubinary.NativeEndian.PutUint32(dst[60:], t.PMTU)
+	ubinary.NativeEndian.PutUint32(dst[64:], t.RcvSsthresh)
+	ubinary.NativeEndian.PutUint32(dst[68:], t.RTT)
+	ubinary.NativeEndian.PutUint32(dst[72:], t.RTTVar)
+	ubinary.NativeEndian.PutUint32(dst[76:], t.SndSsthresh)
+	ubinary.NativeEndian.PutUint32(dst[80:], t.SndCwnd)
+	ubinary.NativeEndian.PutUint32(dst[84:], t.Advmss)
+	ubinary.NativeEndian.PutUint32(dst[88:], t.Reordering)
+	ubinary.NativeEndian.PutUint32(dst[92:], t.RcvRTT)
+	ubinary.NativeEndian.PutUint32(dst[96:], t.RcvSpace)
+	ubinary.NativeEndian.PutUint32(dst[100:], t.TotalRetrans)
+	ubinary.NativeEndian.PutUint64(dst[104:], t.PacingRate)
+	ubinary.NativeEndian.PutUint64(dst[112:], t.MaxPacingRate)
+	ubinary.NativeEndian.PutUint64(dst[120:], t.BytesAcked)
+	ubinary.NativeEndian.PutUint64(dst[128:], t.BytesReceived)
+	ubinary.NativeEndian.PutUint32(dst[136:], t.SegsOut)
+	ubinary.NativeEndian.PutUint32(dst[140:], t.SegsIn)
+	ubinary.NativeEndian.PutUint32(dst[144:], t.NotSentBytes)
+	ubinary.NativeEndian.PutUint32(dst[148:], t.MinRTT)
+	ubinary.NativeEndian.PutUint32(dst[152:], t.DataSegsIn)
+	ubinary.NativeEndian.PutUint32(dst[156:], t.DataSegsOut)
+	ubinary.NativeEndian.PutUint64(dst[160:], t.DeliveryRate)
+	ubinary.NativeEndian.PutUint64(dst[168:], t.BusyTime)
+	ubinary.NativeEndian.PutUint64(dst[176:], t.RwndLimited)
+	ubinary.NativeEndian.PutUint64(dst[184:], t.SndBufLimited)
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (t *TCPInfo) UnmarshalBytes(src []byte) {
+	_ = src[191] // bounds check hint
+	t.State = src[0]
+	t.CaState = src[1]
+	t.Retransmits = src[2]
+	t.Probes = src[3]
+	t.Backoff = src[4]
+	t.Options = src[5]
+	t.WindowScale = src[6]
+	t.DeliveryRateAppLimited = src[7]
+	t.RTO = ubinary.NativeEndian.Uint32(src[8:])
+	t.ATO = ubinary.NativeEndian.Uint32(src[12:])
+	t.SndMss = ubinary.NativeEndian.Uint32(src[16:])
+	t.RcvMss = ubinary.NativeEndian.Uint32(src[20:])
+	t.Unacked = ubinary.NativeEndian.Uint32(src[24:])
+	t.Sacked = ubinary.NativeEndian.Uint32(src[28:])
+	t.Lost = ubinary.NativeEndian.Uint32(src[32:])
+	t.Retrans = ubinary.NativeEndian.Uint32(src[36:])
+	t.Fackets = ubinary.NativeEndian.Uint32(src[40:])
+	t.LastDataSent = ubinary.NativeEndian.Uint32(src[44:])
+	t.LastAckSent = ubinary.NativeEndian.Uint32(src[48:])
+	t.LastDataRecv = ubinary.NativeEndian.Uint32(src[52:])
+	t.LastAckRecv = ubinary.NativeEndian.Uint32(src[56:])
+	t.PMTU = ubinary.NativeEndian.Uint32(src[60:])
+	t.RcvSsthresh = ubinary.NativeEndian.Uint32(src[64:])
+	t.RTT = ubinary.NativeEndian.Uint32(src[68:])
+	t.RTTVar = ubinary.NativeEndian.Uint32(src[72:])
+	t.SndSsthresh = ubinary.NativeEndian.Uint32(src[76:])
+	t.SndCwnd = ubinary.NativeEndian.Uint32(src[80:])
+	t.Advmss = ubinary.NativeEndian.Uint32(src[84:])
+	t.Reordering = ubinary.NativeEndian.Uint32(src[88:])
+	t.RcvRTT = ubinary.NativeEndian.Uint32(src[92:])
+	t.RcvSpace = ubinary.NativeEndian.Uint32(src[96:])
+	t.TotalRetrans = ubinary.NativeEndian.Uint32(src[100:])
+	t.PacingRate = ubinary.NativeEndian.Uint64(src[104:])
+	t.MaxPacingRate = ubinary.NativeEndian.Uint64(src[112:])
+	t.BytesAcked = ubinary.NativeEndian.Uint64(src[120:])
+	t.BytesReceived = ubinary.NativeEndian.Uint64(src[128:])
+	t.SegsOut = ubinary.NativeEndian.Uint32(src[136:])
+	t.SegsIn = ubinary.NativeEndian.Uint32(src[140:])
+	t.NotSentBytes = ubinary.NativeEndian.Uint32(src[144:])
+	t.MinRTT = ubinary.NativeEndian.Uint32(src[148:])
+	t.DataSegsIn = ubinary.NativeEndian.Uint32(src[152:])
+	t.DataSegsOut = ubinary.NativeEndian.Uint32(src[156:])
+	t.DeliveryRate = ubinary.NativeEndian.Uint64(src[160:])
+	t.BusyTime = ubinary.NativeEndian.Uint64(src[168:])
+	t.RwndLimited = ubinary.NativeEndian.Uint64(src[176:])
+	t.SndBufLimited = ubinary.NativeEndian.Uint64(src[184:])
+}
+
+// SizeBytes implements binary.Marshaler.
+func (c ControlMessageHeader) SizeBytes() int {
+	return 16
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (c ControlMessageHeader) MarshalBytes(dst []byte) {
+	_ = dst[15] // bounds check hint
+	ubinary.NativeEndian.PutUint64(dst[0:], c.Length)
+	ubinary.NativeEndian.PutUint32(dst[8:], uint32(c.Level))
+	ubinary.NativeEndian.PutUint32(dst[12:], uint32(c.Type))
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (c *ControlMessageHeader) UnmarshalBytes(src []byte) {
+	_ = src[15] // bounds check hint
+	c.Length = ubinary.NativeEndian.Uint64(src[0:])
+	c.Level = int32(ubinary.NativeEndian.Uint32(src[8:]))
+	c.Type = int32(ubinary.NativeEndian.Uint32(src[12:]))
+}
+
+// SizeBytes implements binary.Marshaler.
+func (c ControlMessageCredentials) SizeBytes() int {
+	return 12
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (c ControlMessageCredentials) MarshalBytes(dst []byte) {
+	_ = dst[11] // bounds check hint
+	ubinary.NativeEndian.PutUint32(dst[0:], uint32(c.PID))
+	ubinary.NativeEndian.PutUint32(dst[4:], c.UID)
+	ubinary.NativeEndian.PutUint32(dst[8:], c.GID)
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (c *ControlMessageCredentials) UnmarshalBytes(src []byte) {
+	_ = src[11] // bounds check hint
+	c.PID = int32(ubinary.NativeEndian.Uint32(src[0:]))
+	c.UID = ubinary.NativeEndian.Uint32(src[4:])
+	c.GID = ubinary.NativeEndian.Uint32(src[8:])
+}
+
+// SizeBytes implements binary.Marshaler.
+func (e EpollEvent) SizeBytes() int {
+	return 12
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (e EpollEvent) MarshalBytes(dst []byte) {
+	_ = dst[11] // bounds check hint
+	ubinary.NativeEndian.PutUint32(dst[0:], e.Events)
+	ubinary.NativeEndian.PutUint64(dst[4:], e.Data)
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (e *EpollEvent) UnmarshalBytes(src []byte) {
+	_ = src[11] // bounds check hint
+	e.Events = ubinary.NativeEndian.Uint32(src[0:])
+	e.Data = ubinary.NativeEndian.Uint64(src[4:])
+}
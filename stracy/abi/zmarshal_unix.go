@@ -0,0 +1,36 @@
+// Code generated by marshalgen from abi_unix.go. DO NOT EDIT.
+
+package abi
+
+import "github.com/iimos/play/stracy/ubinary"
+
+// SizeBytes implements binary.Marshaler.
+func (m MessageHeader64) SizeBytes() int {
+	return 56
+}
+
+// MarshalBytes implements binary.Marshaler.
+func (m MessageHeader64) MarshalBytes(dst []byte) {
+	_ = dst[55] // bounds check hint
+	ubinary.NativeEndian.PutUint64(dst[0:], m.Name)
+	ubinary.NativeEndian.PutUint32(dst[8:], m.NameLen)
+	clear(dst[12:16])
+	ubinary.NativeEndian.PutUint64(dst[16:], m.Iov)
+	ubinary.NativeEndian.PutUint64(dst[24:], m.IovLen)
+	ubinary.NativeEndian.PutUint64(dst[32:], m.Control)
+	ubinary.NativeEndian.PutUint64(dst[40:], m.ControlLen)
+	ubinary.NativeEndian.PutUint32(dst[48:], uint32(m.Flags))
+	clear(dst[52:56])
+}
+
+// UnmarshalBytes implements binary.Unmarshaler.
+func (m *MessageHeader64) UnmarshalBytes(src []byte) {
+	_ = src[55] // bounds check hint
+	m.Name = ubinary.NativeEndian.Uint64(src[0:])
+	m.NameLen = ubinary.NativeEndian.Uint32(src[8:])
+	m.Iov = ubinary.NativeEndian.Uint64(src[16:])
+	m.IovLen = ubinary.NativeEndian.Uint64(src[24:])
+	m.Control = ubinary.NativeEndian.Uint64(src[32:])
+	m.ControlLen = ubinary.NativeEndian.Uint64(src[40:])
+	m.Flags = int32(ubinary.NativeEndian.Uint32(src[48:]))
+}
@@ -0,0 +1,226 @@
+// Code generated by uapigen from kernel UAPI headers. DO NOT EDIT.
+
+package abi
+
+import "golang.org/x/sys/unix"
+
+// FcntlCmds are fcntl(2) commands.
+// From <linux/fcntl.h>.
+var FcntlCmds = FlagSet{
+	&Value{Value: unix.F_DUPFD, Name: "F_DUPFD"},
+	&Value{Value: unix.F_GETFD, Name: "F_GETFD"},
+	&Value{Value: unix.F_SETFD, Name: "F_SETFD"},
+	&Value{Value: unix.F_GETFL, Name: "F_GETFL"},
+	&Value{Value: unix.F_SETFL, Name: "F_SETFL"},
+	&Value{Value: unix.F_GETLK, Name: "F_GETLK"},
+	&Value{Value: unix.F_SETLK, Name: "F_SETLK"},
+	&Value{Value: unix.F_SETLKW, Name: "F_SETLKW"},
+	&Value{Value: unix.F_SETOWN, Name: "F_SETOWN"},
+	&Value{Value: unix.F_GETOWN, Name: "F_GETOWN"},
+	&Value{Value: unix.F_SETSIG, Name: "F_SETSIG"},
+	&Value{Value: unix.F_GETSIG, Name: "F_GETSIG"},
+	&Value{Value: unix.F_SETOWN_EX, Name: "F_SETOWN_EX"},
+	&Value{Value: unix.F_GETOWN_EX, Name: "F_GETOWN_EX"},
+	&Value{Value: 0x11, Name: "F_GETOWNER_UIDS"},
+	&Value{Value: unix.F_OFD_GETLK, Name: "F_OFD_GETLK"},
+	&Value{Value: unix.F_OFD_SETLK, Name: "F_OFD_SETLK"},
+	&Value{Value: unix.F_OFD_SETLKW, Name: "F_OFD_SETLKW"},
+	&Value{Value: unix.F_SETLEASE, Name: "F_SETLEASE"},
+	&Value{Value: unix.F_GETLEASE, Name: "F_GETLEASE"},
+	&Value{Value: 0x405, Name: "F_CANCELLK"},
+	&Value{Value: unix.F_DUPFD_CLOEXEC, Name: "F_DUPFD_CLOEXEC"},
+	&Value{Value: unix.F_NOTIFY, Name: "F_NOTIFY"},
+	&Value{Value: unix.F_SETPIPE_SZ, Name: "F_SETPIPE_SZ"},
+	&Value{Value: unix.F_GETPIPE_SZ, Name: "F_GETPIPE_SZ"},
+	&Value{Value: unix.F_ADD_SEALS, Name: "F_ADD_SEALS"},
+	&Value{Value: unix.F_GET_SEALS, Name: "F_GET_SEALS"},
+	&Value{Value: unix.F_GET_RW_HINT, Name: "F_GET_RW_HINT"},
+	&Value{Value: unix.F_SET_RW_HINT, Name: "F_SET_RW_HINT"},
+	&Value{Value: unix.F_GET_FILE_RW_HINT, Name: "F_GET_FILE_RW_HINT"},
+	&Value{Value: unix.F_SET_FILE_RW_HINT, Name: "F_SET_FILE_RW_HINT"},
+}
+
+// FcntlSealFlagSet are file seals of fcntl(2) F_ADD_SEALS and F_GET_SEALS.
+// From <linux/fcntl.h>.
+var FcntlSealFlagSet = FlagSet{
+	&BitFlag{Value: unix.F_SEAL_SEAL, Name: "F_SEAL_SEAL"},
+	&BitFlag{Value: unix.F_SEAL_SHRINK, Name: "F_SEAL_SHRINK"},
+	&BitFlag{Value: unix.F_SEAL_GROW, Name: "F_SEAL_GROW"},
+	&BitFlag{Value: unix.F_SEAL_WRITE, Name: "F_SEAL_WRITE"},
+	&BitFlag{Value: unix.F_SEAL_FUTURE_WRITE, Name: "F_SEAL_FUTURE_WRITE"},
+}
+
+// DnotifyFlagSet are directory events of fcntl(2) F_NOTIFY.
+// From <linux/fcntl.h>.
+var DnotifyFlagSet = FlagSet{
+	&BitFlag{Value: 0x1, Name: "DN_ACCESS"},
+	&BitFlag{Value: 0x2, Name: "DN_MODIFY"},
+	&BitFlag{Value: 0x4, Name: "DN_CREATE"},
+	&BitFlag{Value: 0x8, Name: "DN_DELETE"},
+	&BitFlag{Value: 0x10, Name: "DN_RENAME"},
+	&BitFlag{Value: 0x20, Name: "DN_ATTRIB"},
+	&BitFlag{Value: 0x80000000, Name: "DN_MULTISHOT"},
+}
+
+// PrctlOptions are prctl(2) options.
+// From <linux/prctl.h>.
+var PrctlOptions = FlagSet{
+	&Value{Value: unix.PR_SET_PDEATHSIG, Name: "PR_SET_PDEATHSIG"},
+	&Value{Value: unix.PR_GET_PDEATHSIG, Name: "PR_GET_PDEATHSIG"},
+	&Value{Value: unix.PR_GET_DUMPABLE, Name: "PR_GET_DUMPABLE"},
+	&Value{Value: unix.PR_SET_DUMPABLE, Name: "PR_SET_DUMPABLE"},
+	&Value{Value: unix.PR_GET_UNALIGN, Name: "PR_GET_UNALIGN"},
+	&Value{Value: unix.PR_SET_UNALIGN, Name: "PR_SET_UNALIGN"},
+	&Value{Value: unix.PR_GET_KEEPCAPS, Name: "PR_GET_KEEPCAPS"},
+	&Value{Value: unix.PR_SET_KEEPCAPS, Name: "PR_SET_KEEPCAPS"},
+	&Value{Value: unix.PR_GET_FPEMU, Name: "PR_GET_FPEMU"},
+	&Value{Value: unix.PR_SET_FPEMU, Name: "PR_SET_FPEMU"},
+	&Value{Value: unix.PR_GET_FPEXC, Name: "PR_GET_FPEXC"},
+	&Value{Value: unix.PR_SET_FPEXC, Name: "PR_SET_FPEXC"},
+	&Value{Value: unix.PR_GET_TIMING, Name: "PR_GET_TIMING"},
+	&Value{Value: unix.PR_SET_TIMING, Name: "PR_SET_TIMING"},
+	&Value{Value: unix.PR_SET_NAME, Name: "PR_SET_NAME"},
+	&Value{Value: unix.PR_GET_NAME, Name: "PR_GET_NAME"},
+	&Value{Value: unix.PR_GET_ENDIAN, Name: "PR_GET_ENDIAN"},
+	&Value{Value: unix.PR_SET_ENDIAN, Name: "PR_SET_ENDIAN"},
+	&Value{Value: unix.PR_GET_SECCOMP, Name: "PR_GET_SECCOMP"},
+	&Value{Value: unix.PR_SET_SECCOMP, Name: "PR_SET_SECCOMP"},
+	&Value{Value: unix.PR_CAPBSET_READ, Name: "PR_CAPBSET_READ"},
+	&Value{Value: unix.PR_CAPBSET_DROP, Name: "PR_CAPBSET_DROP"},
+	&Value{Value: unix.PR_GET_TSC, Name: "PR_GET_TSC"},
+	&Value{Value: unix.PR_SET_TSC, Name: "PR_SET_TSC"},
+	&Value{Value: unix.PR_GET_SECUREBITS, Name: "PR_GET_SECUREBITS"},
+	&Value{Value: unix.PR_SET_SECUREBITS, Name: "PR_SET_SECUREBITS"},
+	&Value{Value: unix.PR_SET_TIMERSLACK, Name: "PR_SET_TIMERSLACK"},
+	&Value{Value: unix.PR_GET_TIMERSLACK, Name: "PR_GET_TIMERSLACK"},
+	&Value{Value: unix.PR_TASK_PERF_EVENTS_DISABLE, Name: "PR_TASK_PERF_EVENTS_DISABLE"},
+	&Value{Value: unix.PR_TASK_PERF_EVENTS_ENABLE, Name: "PR_TASK_PERF_EVENTS_ENABLE"},
+	&Value{Value: unix.PR_MCE_KILL, Name: "PR_MCE_KILL"},
+	&Value{Value: unix.PR_MCE_KILL_GET, Name: "PR_MCE_KILL_GET"},
+	&Value{Value: unix.PR_SET_MM, Name: "PR_SET_MM"},
+	&Value{Value: unix.PR_SET_PTRACER, Name: "PR_SET_PTRACER"},
+	&Value{Value: unix.PR_SET_CHILD_SUBREAPER, Name: "PR_SET_CHILD_SUBREAPER"},
+	&Value{Value: unix.PR_GET_CHILD_SUBREAPER, Name: "PR_GET_CHILD_SUBREAPER"},
+	&Value{Value: unix.PR_SET_NO_NEW_PRIVS, Name: "PR_SET_NO_NEW_PRIVS"},
+	&Value{Value: unix.PR_GET_NO_NEW_PRIVS, Name: "PR_GET_NO_NEW_PRIVS"},
+	&Value{Value: unix.PR_GET_TID_ADDRESS, Name: "PR_GET_TID_ADDRESS"},
+	&Value{Value: unix.PR_SET_THP_DISABLE, Name: "PR_SET_THP_DISABLE"},
+	&Value{Value: unix.PR_GET_THP_DISABLE, Name: "PR_GET_THP_DISABLE"},
+	&Value{Value: unix.PR_MPX_ENABLE_MANAGEMENT, Name: "PR_MPX_ENABLE_MANAGEMENT"},
+	&Value{Value: unix.PR_MPX_DISABLE_MANAGEMENT, Name: "PR_MPX_DISABLE_MANAGEMENT"},
+	&Value{Value: unix.PR_SET_FP_MODE, Name: "PR_SET_FP_MODE"},
+	&Value{Value: unix.PR_GET_FP_MODE, Name: "PR_GET_FP_MODE"},
+	&Value{Value: unix.PR_CAP_AMBIENT, Name: "PR_CAP_AMBIENT"},
+	&Value{Value: unix.PR_SVE_SET_VL, Name: "PR_SVE_SET_VL"},
+	&Value{Value: unix.PR_SVE_GET_VL, Name: "PR_SVE_GET_VL"},
+	&Value{Value: unix.PR_GET_SPECULATION_CTRL, Name: "PR_GET_SPECULATION_CTRL"},
+	&Value{Value: unix.PR_SET_SPECULATION_CTRL, Name: "PR_SET_SPECULATION_CTRL"},
+	&Value{Value: unix.PR_PAC_RESET_KEYS, Name: "PR_PAC_RESET_KEYS"},
+	&Value{Value: unix.PR_SET_TAGGED_ADDR_CTRL, Name: "PR_SET_TAGGED_ADDR_CTRL"},
+	&Value{Value: unix.PR_GET_TAGGED_ADDR_CTRL, Name: "PR_GET_TAGGED_ADDR_CTRL"},
+	&Value{Value: unix.PR_SET_IO_FLUSHER, Name: "PR_SET_IO_FLUSHER"},
+	&Value{Value: unix.PR_GET_IO_FLUSHER, Name: "PR_GET_IO_FLUSHER"},
+	&Value{Value: unix.PR_SET_SYSCALL_USER_DISPATCH, Name: "PR_SET_SYSCALL_USER_DISPATCH"},
+	&Value{Value: unix.PR_PAC_SET_ENABLED_KEYS, Name: "PR_PAC_SET_ENABLED_KEYS"},
+	&Value{Value: unix.PR_PAC_GET_ENABLED_KEYS, Name: "PR_PAC_GET_ENABLED_KEYS"},
+	&Value{Value: unix.PR_SCHED_CORE, Name: "PR_SCHED_CORE"},
+	&Value{Value: unix.PR_SME_SET_VL, Name: "PR_SME_SET_VL"},
+	&Value{Value: unix.PR_SME_GET_VL, Name: "PR_SME_GET_VL"},
+	&Value{Value: unix.PR_SET_VMA, Name: "PR_SET_VMA"},
+}
+
+// InotifyMaskSet are inotify_add_watch(2) events and flags.
+// From <linux/inotify.h>.
+var InotifyMaskSet = FlagSet{
+	&BitFlag{Value: unix.IN_ACCESS, Name: "IN_ACCESS"},
+	&BitFlag{Value: unix.IN_MODIFY, Name: "IN_MODIFY"},
+	&BitFlag{Value: unix.IN_ATTRIB, Name: "IN_ATTRIB"},
+	&BitFlag{Value: unix.IN_CLOSE_WRITE, Name: "IN_CLOSE_WRITE"},
+	&BitFlag{Value: unix.IN_CLOSE_NOWRITE, Name: "IN_CLOSE_NOWRITE"},
+	&BitFlag{Value: unix.IN_OPEN, Name: "IN_OPEN"},
+	&BitFlag{Value: unix.IN_MOVED_FROM, Name: "IN_MOVED_FROM"},
+	&BitFlag{Value: unix.IN_MOVED_TO, Name: "IN_MOVED_TO"},
+	&BitFlag{Value: unix.IN_CREATE, Name: "IN_CREATE"},
+	&BitFlag{Value: unix.IN_DELETE, Name: "IN_DELETE"},
+	&BitFlag{Value: unix.IN_DELETE_SELF, Name: "IN_DELETE_SELF"},
+	&BitFlag{Value: unix.IN_MOVE_SELF, Name: "IN_MOVE_SELF"},
+	&BitFlag{Value: unix.IN_UNMOUNT, Name: "IN_UNMOUNT"},
+	&BitFlag{Value: unix.IN_Q_OVERFLOW, Name: "IN_Q_OVERFLOW"},
+	&BitFlag{Value: unix.IN_IGNORED, Name: "IN_IGNORED"},
+	&BitFlag{Value: unix.IN_ONLYDIR, Name: "IN_ONLYDIR"},
+	&BitFlag{Value: unix.IN_DONT_FOLLOW, Name: "IN_DONT_FOLLOW"},
+	&BitFlag{Value: unix.IN_EXCL_UNLINK, Name: "IN_EXCL_UNLINK"},
+	&BitFlag{Value: unix.IN_MASK_CREATE, Name: "IN_MASK_CREATE"},
+	&BitFlag{Value: unix.IN_MASK_ADD, Name: "IN_MASK_ADD"},
+	&BitFlag{Value: unix.IN_ISDIR, Name: "IN_ISDIR"},
+	&BitFlag{Value: unix.IN_ONESHOT, Name: "IN_ONESHOT"},
+}
+
+// InotifyInitFlagSet are inotify_init1(2) flags.
+// From <linux/inotify.h>.
+var InotifyInitFlagSet = FlagSet{
+	&BitFlag{Value: unix.IN_CLOEXEC, Name: "IN_CLOEXEC"},
+	&BitFlag{Value: unix.IN_NONBLOCK, Name: "IN_NONBLOCK"},
+}
+
+// FanotifyInitFlagSet are fanotify_init(2) flags.
+// From <linux/fanotify.h>.
+var FanotifyInitFlagSet = FlagSet{
+	&BitFlag{Value: unix.FAN_CLOEXEC, Name: "FAN_CLOEXEC"},
+	&BitFlag{Value: unix.FAN_NONBLOCK, Name: "FAN_NONBLOCK"},
+	&Value{Value: unix.FAN_CLASS_NOTIF, Name: "FAN_CLASS_NOTIF"},
+	&BitFlag{Value: unix.FAN_CLASS_CONTENT, Name: "FAN_CLASS_CONTENT"},
+	&BitFlag{Value: unix.FAN_CLASS_PRE_CONTENT, Name: "FAN_CLASS_PRE_CONTENT"},
+	&BitFlag{Value: unix.FAN_UNLIMITED_QUEUE, Name: "FAN_UNLIMITED_QUEUE"},
+	&BitFlag{Value: unix.FAN_UNLIMITED_MARKS, Name: "FAN_UNLIMITED_MARKS"},
+	&BitFlag{Value: unix.FAN_ENABLE_AUDIT, Name: "FAN_ENABLE_AUDIT"},
+	&BitFlag{Value: unix.FAN_REPORT_PIDFD, Name: "FAN_REPORT_PIDFD"},
+	&BitFlag{Value: unix.FAN_REPORT_TID, Name: "FAN_REPORT_TID"},
+	&BitFlag{Value: unix.FAN_REPORT_FID, Name: "FAN_REPORT_FID"},
+	&BitFlag{Value: unix.FAN_REPORT_DIR_FID, Name: "FAN_REPORT_DIR_FID"},
+	&BitFlag{Value: unix.FAN_REPORT_NAME, Name: "FAN_REPORT_NAME"},
+	&BitFlag{Value: unix.FAN_REPORT_TARGET_FID, Name: "FAN_REPORT_TARGET_FID"},
+}
+
+// FanotifyMarkFlagSet are fanotify_mark(2) flags.
+// From <linux/fanotify.h>.
+var FanotifyMarkFlagSet = FlagSet{
+	&BitFlag{Value: unix.FAN_MARK_ADD, Name: "FAN_MARK_ADD"},
+	&BitFlag{Value: unix.FAN_MARK_REMOVE, Name: "FAN_MARK_REMOVE"},
+	&BitFlag{Value: unix.FAN_MARK_DONT_FOLLOW, Name: "FAN_MARK_DONT_FOLLOW"},
+	&BitFlag{Value: unix.FAN_MARK_ONLYDIR, Name: "FAN_MARK_ONLYDIR"},
+	&BitFlag{Value: unix.FAN_MARK_IGNORED_MASK, Name: "FAN_MARK_IGNORED_MASK"},
+	&BitFlag{Value: unix.FAN_MARK_IGNORED_SURV_MODIFY, Name: "FAN_MARK_IGNORED_SURV_MODIFY"},
+	&BitFlag{Value: unix.FAN_MARK_FLUSH, Name: "FAN_MARK_FLUSH"},
+	&BitFlag{Value: unix.FAN_MARK_EVICTABLE, Name: "FAN_MARK_EVICTABLE"},
+	&BitFlag{Value: unix.FAN_MARK_IGNORE, Name: "FAN_MARK_IGNORE"},
+	&Value{Value: unix.FAN_MARK_INODE, Name: "FAN_MARK_INODE"},
+	&BitFlag{Value: unix.FAN_MARK_MOUNT, Name: "FAN_MARK_MOUNT"},
+	&BitFlag{Value: unix.FAN_MARK_FILESYSTEM, Name: "FAN_MARK_FILESYSTEM"},
+}
+
+// FanotifyEventSet are fanotify_mark(2) events.
+// From <linux/fanotify.h>.
+var FanotifyEventSet = FlagSet{
+	&BitFlag{Value: unix.FAN_ACCESS, Name: "FAN_ACCESS"},
+	&BitFlag{Value: unix.FAN_MODIFY, Name: "FAN_MODIFY"},
+	&BitFlag{Value: unix.FAN_ATTRIB, Name: "FAN_ATTRIB"},
+	&BitFlag{Value: unix.FAN_CLOSE_WRITE, Name: "FAN_CLOSE_WRITE"},
+	&BitFlag{Value: unix.FAN_CLOSE_NOWRITE, Name: "FAN_CLOSE_NOWRITE"},
+	&BitFlag{Value: unix.FAN_OPEN, Name: "FAN_OPEN"},
+	&BitFlag{Value: unix.FAN_MOVED_FROM, Name: "FAN_MOVED_FROM"},
+	&BitFlag{Value: unix.FAN_MOVED_TO, Name: "FAN_MOVED_TO"},
+	&BitFlag{Value: unix.FAN_CREATE, Name: "FAN_CREATE"},
+	&BitFlag{Value: unix.FAN_DELETE, Name: "FAN_DELETE"},
+	&BitFlag{Value: unix.FAN_DELETE_SELF, Name: "FAN_DELETE_SELF"},
+	&BitFlag{Value: unix.FAN_MOVE_SELF, Name: "FAN_MOVE_SELF"},
+	&BitFlag{Value: unix.FAN_OPEN_EXEC, Name: "FAN_OPEN_EXEC"},
+	&BitFlag{Value: unix.FAN_Q_OVERFLOW, Name: "FAN_Q_OVERFLOW"},
+	&BitFlag{Value: unix.FAN_FS_ERROR, Name: "FAN_FS_ERROR"},
+	&BitFlag{Value: unix.FAN_OPEN_PERM, Name: "FAN_OPEN_PERM"},
+	&BitFlag{Value: unix.FAN_ACCESS_PERM, Name: "FAN_ACCESS_PERM"},
+	&BitFlag{Value: unix.FAN_OPEN_EXEC_PERM, Name: "FAN_OPEN_EXEC_PERM"},
+	&BitFlag{Value: unix.FAN_EVENT_ON_CHILD, Name: "FAN_EVENT_ON_CHILD"},
+	&BitFlag{Value: unix.FAN_RENAME, Name: "FAN_RENAME"},
+	&BitFlag{Value: unix.FAN_ONDIR, Name: "FAN_ONDIR"},
+}
@@ -0,0 +1,191 @@
+// Code generated by uapigen from kernel UAPI headers. DO NOT EDIT.
+
+package abi
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// uapiConstants are values of the kernel headers with x/sys/unix counterparts.
+var uapiConstants = []struct {
+	name         string
+	header, unix uint64
+}{
+	{"F_DUPFD", 0x0, unix.F_DUPFD},
+	{"F_GETFD", 0x1, unix.F_GETFD},
+	{"F_SETFD", 0x2, unix.F_SETFD},
+	{"F_GETFL", 0x3, unix.F_GETFL},
+	{"F_SETFL", 0x4, unix.F_SETFL},
+	{"F_GETLK", 0x5, unix.F_GETLK},
+	{"F_SETLK", 0x6, unix.F_SETLK},
+	{"F_SETLKW", 0x7, unix.F_SETLKW},
+	{"F_SETOWN", 0x8, unix.F_SETOWN},
+	{"F_GETOWN", 0x9, unix.F_GETOWN},
+	{"F_SETSIG", 0xa, unix.F_SETSIG},
+	{"F_GETSIG", 0xb, unix.F_GETSIG},
+	{"F_SETOWN_EX", 0xf, unix.F_SETOWN_EX},
+	{"F_GETOWN_EX", 0x10, unix.F_GETOWN_EX},
+	{"F_OFD_GETLK", 0x24, unix.F_OFD_GETLK},
+	{"F_OFD_SETLK", 0x25, unix.F_OFD_SETLK},
+	{"F_OFD_SETLKW", 0x26, unix.F_OFD_SETLKW},
+	{"F_SETLEASE", 0x400, unix.F_SETLEASE},
+	{"F_GETLEASE", 0x401, unix.F_GETLEASE},
+	{"F_DUPFD_CLOEXEC", 0x406, unix.F_DUPFD_CLOEXEC},
+	{"F_NOTIFY", 0x402, unix.F_NOTIFY},
+	{"F_SETPIPE_SZ", 0x407, unix.F_SETPIPE_SZ},
+	{"F_GETPIPE_SZ", 0x408, unix.F_GETPIPE_SZ},
+	{"F_ADD_SEALS", 0x409, unix.F_ADD_SEALS},
+	{"F_GET_SEALS", 0x40a, unix.F_GET_SEALS},
+	{"F_GET_RW_HINT", 0x40b, unix.F_GET_RW_HINT},
+	{"F_SET_RW_HINT", 0x40c, unix.F_SET_RW_HINT},
+	{"F_GET_FILE_RW_HINT", 0x40d, unix.F_GET_FILE_RW_HINT},
+	{"F_SET_FILE_RW_HINT", 0x40e, unix.F_SET_FILE_RW_HINT},
+	{"F_SEAL_SEAL", 0x1, unix.F_SEAL_SEAL},
+	{"F_SEAL_SHRINK", 0x2, unix.F_SEAL_SHRINK},
+	{"F_SEAL_GROW", 0x4, unix.F_SEAL_GROW},
+	{"F_SEAL_WRITE", 0x8, unix.F_SEAL_WRITE},
+	{"F_SEAL_FUTURE_WRITE", 0x10, unix.F_SEAL_FUTURE_WRITE},
+	{"PR_SET_PDEATHSIG", 0x1, unix.PR_SET_PDEATHSIG},
+	{"PR_GET_PDEATHSIG", 0x2, unix.PR_GET_PDEATHSIG},
+	{"PR_GET_DUMPABLE", 0x3, unix.PR_GET_DUMPABLE},
+	{"PR_SET_DUMPABLE", 0x4, unix.PR_SET_DUMPABLE},
+	{"PR_GET_UNALIGN", 0x5, unix.PR_GET_UNALIGN},
+	{"PR_SET_UNALIGN", 0x6, unix.PR_SET_UNALIGN},
+	{"PR_GET_KEEPCAPS", 0x7, unix.PR_GET_KEEPCAPS},
+	{"PR_SET_KEEPCAPS", 0x8, unix.PR_SET_KEEPCAPS},
+	{"PR_GET_FPEMU", 0x9, unix.PR_GET_FPEMU},
+	{"PR_SET_FPEMU", 0xa, unix.PR_SET_FPEMU},
+	{"PR_GET_FPEXC", 0xb, unix.PR_GET_FPEXC},
+	{"PR_SET_FPEXC", 0xc, unix.PR_SET_FPEXC},
+	{"PR_GET_TIMING", 0xd, unix.PR_GET_TIMING},
+	{"PR_SET_TIMING", 0xe, unix.PR_SET_TIMING},
+	{"PR_SET_NAME", 0xf, unix.PR_SET_NAME},
+	{"PR_GET_NAME", 0x10, unix.PR_GET_NAME},
+	{"PR_GET_ENDIAN", 0x13, unix.PR_GET_ENDIAN},
+	{"PR_SET_ENDIAN", 0x14, unix.PR_SET_ENDIAN},
+	{"PR_GET_SECCOMP", 0x15, unix.PR_GET_SECCOMP},
+	{"PR_SET_SECCOMP", 0x16, unix.PR_SET_SECCOMP},
+	{"PR_CAPBSET_READ", 0x17, unix.PR_CAPBSET_READ},
+	{"PR_CAPBSET_DROP", 0x18, unix.PR_CAPBSET_DROP},
+	{"PR_GET_TSC", 0x19, unix.PR_GET_TSC},
+	{"PR_SET_TSC", 0x1a, unix.PR_SET_TSC},
+	{"PR_GET_SECUREBITS", 0x1b, unix.PR_GET_SECUREBITS},
+	{"PR_SET_SECUREBITS", 0x1c, unix.PR_SET_SECUREBITS},
+	{"PR_SET_TIMERSLACK", 0x1d, unix.PR_SET_TIMERSLACK},
+	{"PR_GET_TIMERSLACK", 0x1e, unix.PR_GET_TIMERSLACK},
+	{"PR_TASK_PERF_EVENTS_DISABLE", 0x1f, unix.PR_TASK_PERF_EVENTS_DISABLE},
+	{"PR_TASK_PERF_EVENTS_ENABLE", 0x20, unix.PR_TASK_PERF_EVENTS_ENABLE},
+	{"PR_MCE_KILL", 0x21, unix.PR_MCE_KILL},
+	{"PR_MCE_KILL_GET", 0x22, unix.PR_MCE_KILL_GET},
+	{"PR_SET_MM", 0x23, unix.PR_SET_MM},
+	{"PR_SET_PTRACER", 0x59616d61, unix.PR_SET_PTRACER},
+	{"PR_SET_CHILD_SUBREAPER", 0x24, unix.PR_SET_CHILD_SUBREAPER},
+	{"PR_GET_CHILD_SUBREAPER", 0x25, unix.PR_GET_CHILD_SUBREAPER},
+	{"PR_SET_NO_NEW_PRIVS", 0x26, unix.PR_SET_NO_NEW_PRIVS},
+	{"PR_GET_NO_NEW_PRIVS", 0x27, unix.PR_GET_NO_NEW_PRIVS},
+	{"PR_GET_TID_ADDRESS", 0x28, unix.PR_GET_TID_ADDRESS},
+	{"PR_SET_THP_DISABLE", 0x29, unix.PR_SET_THP_DISABLE},
+	{"PR_GET_THP_DISABLE", 0x2a, unix.PR_GET_THP_DISABLE},
+	{"PR_MPX_ENABLE_MANAGEMENT", 0x2b, unix.PR_MPX_ENABLE_MANAGEMENT},
+	{"PR_MPX_DISABLE_MANAGEMENT", 0x2c, unix.PR_MPX_DISABLE_MANAGEMENT},
+	{"PR_SET_FP_MODE", 0x2d, unix.PR_SET_FP_MODE},
+	{"PR_GET_FP_MODE", 0x2e, unix.PR_GET_FP_MODE},
+	{"PR_CAP_AMBIENT", 0x2f, unix.PR_CAP_AMBIENT},
+	{"PR_SVE_SET_VL", 0x32, unix.PR_SVE_SET_VL},
+	{"PR_SVE_GET_VL", 0x33, unix.PR_SVE_GET_VL},
+	{"PR_GET_SPECULATION_CTRL", 0x34, unix.PR_GET_SPECULATION_CTRL},
+	{"PR_SET_SPECULATION_CTRL", 0x35, unix.PR_SET_SPECULATION_CTRL},
+	{"PR_PAC_RESET_KEYS", 0x36, unix.PR_PAC_RESET_KEYS},
+	{"PR_SET_TAGGED_ADDR_CTRL", 0x37, unix.PR_SET_TAGGED_ADDR_CTRL},
+	{"PR_GET_TAGGED_ADDR_CTRL", 0x38, unix.PR_GET_TAGGED_ADDR_CTRL},
+	{"PR_SET_IO_FLUSHER", 0x39, unix.PR_SET_IO_FLUSHER},
+	{"PR_GET_IO_FLUSHER", 0x3a, unix.PR_GET_IO_FLUSHER},
+	{"PR_SET_SYSCALL_USER_DISPATCH", 0x3b, unix.PR_SET_SYSCALL_USER_DISPATCH},
+	{"PR_PAC_SET_ENABLED_KEYS", 0x3c, unix.PR_PAC_SET_ENABLED_KEYS},
+	{"PR_PAC_GET_ENABLED_KEYS", 0x3d, unix.PR_PAC_GET_ENABLED_KEYS},
+	{"PR_SCHED_CORE", 0x3e, unix.PR_SCHED_CORE},
+	{"PR_SME_SET_VL", 0x3f, unix.PR_SME_SET_VL},
+	{"PR_SME_GET_VL", 0x40, unix.PR_SME_GET_VL},
+	{"PR_SET_VMA", 0x53564d41, unix.PR_SET_VMA},
+	{"IN_ACCESS", 0x1, unix.IN_ACCESS},
+	{"IN_MODIFY", 0x2, unix.IN_MODIFY},
+	{"IN_ATTRIB", 0x4, unix.IN_ATTRIB},
+	{"IN_CLOSE_WRITE", 0x8, unix.IN_CLOSE_WRITE},
+	{"IN_CLOSE_NOWRITE", 0x10, unix.IN_CLOSE_NOWRITE},
+	{"IN_OPEN", 0x20, unix.IN_OPEN},
+	{"IN_MOVED_FROM", 0x40, unix.IN_MOVED_FROM},
+	{"IN_MOVED_TO", 0x80, unix.IN_MOVED_TO},
+	{"IN_CREATE", 0x100, unix.IN_CREATE},
+	{"IN_DELETE", 0x200, unix.IN_DELETE},
+	{"IN_DELETE_SELF", 0x400, unix.IN_DELETE_SELF},
+	{"IN_MOVE_SELF", 0x800, unix.IN_MOVE_SELF},
+	{"IN_UNMOUNT", 0x2000, unix.IN_UNMOUNT},
+	{"IN_Q_OVERFLOW", 0x4000, unix.IN_Q_OVERFLOW},
+	{"IN_IGNORED", 0x8000, unix.IN_IGNORED},
+	{"IN_ONLYDIR", 0x1000000, unix.IN_ONLYDIR},
+	{"IN_DONT_FOLLOW", 0x2000000, unix.IN_DONT_FOLLOW},
+	{"IN_EXCL_UNLINK", 0x4000000, unix.IN_EXCL_UNLINK},
+	{"IN_MASK_CREATE", 0x10000000, unix.IN_MASK_CREATE},
+	{"IN_MASK_ADD", 0x20000000, unix.IN_MASK_ADD},
+	{"IN_ISDIR", 0x40000000, unix.IN_ISDIR},
+	{"IN_ONESHOT", 0x80000000, unix.IN_ONESHOT},
+	{"IN_CLOEXEC", 0x80000, unix.IN_CLOEXEC},
+	{"IN_NONBLOCK", 0x800, unix.IN_NONBLOCK},
+	{"FAN_CLOEXEC", 0x1, unix.FAN_CLOEXEC},
+	{"FAN_NONBLOCK", 0x2, unix.FAN_NONBLOCK},
+	{"FAN_CLASS_NOTIF", 0x0, unix.FAN_CLASS_NOTIF},
+	{"FAN_CLASS_CONTENT", 0x4, unix.FAN_CLASS_CONTENT},
+	{"FAN_CLASS_PRE_CONTENT", 0x8, unix.FAN_CLASS_PRE_CONTENT},
+	{"FAN_UNLIMITED_QUEUE", 0x10, unix.FAN_UNLIMITED_QUEUE},
+	{"FAN_UNLIMITED_MARKS", 0x20, unix.FAN_UNLIMITED_MARKS},
+	{"FAN_ENABLE_AUDIT", 0x40, unix.FAN_ENABLE_AUDIT},
+	{"FAN_REPORT_PIDFD", 0x80, unix.FAN_REPORT_PIDFD},
+	{"FAN_REPORT_TID", 0x100, unix.FAN_REPORT_TID},
+	{"FAN_REPORT_FID", 0x200, unix.FAN_REPORT_FID},
+	{"FAN_REPORT_DIR_FID", 0x400, unix.FAN_REPORT_DIR_FID},
+	{"FAN_REPORT_NAME", 0x800, unix.FAN_REPORT_NAME},
+	{"FAN_REPORT_TARGET_FID", 0x1000, unix.FAN_REPORT_TARGET_FID},
+	{"FAN_MARK_ADD", 0x1, unix.FAN_MARK_ADD},
+	{"FAN_MARK_REMOVE", 0x2, unix.FAN_MARK_REMOVE},
+	{"FAN_MARK_DONT_FOLLOW", 0x4, unix.FAN_MARK_DONT_FOLLOW},
+	{"FAN_MARK_ONLYDIR", 0x8, unix.FAN_MARK_ONLYDIR},
+	{"FAN_MARK_IGNORED_MASK", 0x20, unix.FAN_MARK_IGNORED_MASK},
+	{"FAN_MARK_IGNORED_SURV_MODIFY", 0x40, unix.FAN_MARK_IGNORED_SURV_MODIFY},
+	{"FAN_MARK_FLUSH", 0x80, unix.FAN_MARK_FLUSH},
+	{"FAN_MARK_EVICTABLE", 0x200, unix.FAN_MARK_EVICTABLE},
+	{"FAN_MARK_IGNORE", 0x400, unix.FAN_MARK_IGNORE},
+	{"FAN_MARK_INODE", 0x0, unix.FAN_MARK_INODE},
+	{"FAN_MARK_MOUNT", 0x10, unix.FAN_MARK_MOUNT},
+	{"FAN_MARK_FILESYSTEM", 0x100, unix.FAN_MARK_FILESYSTEM},
+	{"FAN_ACCESS", 0x1, unix.FAN_ACCESS},
+	{"FAN_MODIFY", 0x2, unix.FAN_MODIFY},
+	{"FAN_ATTRIB", 0x4, unix.FAN_ATTRIB},
+	{"FAN_CLOSE_WRITE", 0x8, unix.FAN_CLOSE_WRITE},
+	{"FAN_CLOSE_NOWRITE", 0x10, unix.FAN_CLOSE_NOWRITE},
+	{"FAN_OPEN", 0x20, unix.FAN_OPEN},
+	{"FAN_MOVED_FROM", 0x40, unix.FAN_MOVED_FROM},
+	{"FAN_MOVED_TO", 0x80, unix.FAN_MOVED_TO},
+	{"FAN_CREATE", 0x100, unix.FAN_CREATE},
+	{"FAN_DELETE", 0x200, unix.FAN_DELETE},
+	{"FAN_DELETE_SELF", 0x400, unix.FAN_DELETE_SELF},
+	{"FAN_MOVE_SELF", 0x800, unix.FAN_MOVE_SELF},
+	{"FAN_OPEN_EXEC", 0x1000, unix.FAN_OPEN_EXEC},
+	{"FAN_Q_OVERFLOW", 0x4000, unix.FAN_Q_OVERFLOW},
+	{"FAN_FS_ERROR", 0x8000, unix.FAN_FS_ERROR},
+	{"FAN_OPEN_PERM", 0x10000, unix.FAN_OPEN_PERM},
+	{"FAN_ACCESS_PERM", 0x20000, unix.FAN_ACCESS_PERM},
+	{"FAN_OPEN_EXEC_PERM", 0x40000, unix.FAN_OPEN_EXEC_PERM},
+	{"FAN_EVENT_ON_CHILD", 0x8000000, unix.FAN_EVENT_ON_CHILD},
+	{"FAN_RENAME", 0x10000000, unix.FAN_RENAME},
+	{"FAN_ONDIR", 0x40000000, unix.FAN_ONDIR},
+}
+
+func TestUAPIConstants(t *testing.T) {
+	for _, c := range uapiConstants {
+		if c.header != c.unix {
+			t.Errorf("%s is %#x in the kernel headers and %#x in x/sys/unix", c.name, c.header, c.unix)
+		}
+	}
+}
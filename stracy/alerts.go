@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// maxRunningHooks limits concurrently running alert hooks, further alerts
+// wait for a free slot.
+const maxRunningHooks = 4
+
+// hookTimeout is the time an alert hook may run before it's killed.
+const hookTimeout = 30 * time.Second
+
+// alertFlags registers latency anomaly detection flags on fs. The returned
+// function creates the detector and the hook from them after fs is parsed,
+// nil if detection or the hook is off.
+func alertFlags(fs *flag.FlagSet) func() (*tracer.LatencyDetector, *alertHook, error) {
+	def := tracer.DefaultDetectorConfig
+	factor := fs.Float64("alert-factor", def.Factor, "alert on syscalls `n` times slower than usual for the syscall and fd type, 0 disables alerts")
+	samples := fs.Int("alert-samples", def.MinSamples, "learn a baseline from `n` syscalls before alerting")
+	minDur := fs.Duration("alert-min", def.MinDuration, "never alert on syscalls faster than `duration`")
+	limit := fs.Duration("alert-limit", def.Limit, "alert on non-waiting syscalls slower than `duration` even without a baseline, 0 disables it")
+	hook := fs.String("alert-hook", "", "run shell `command` on every alert with the alert event as JSON on stdin")
+
+	return func() (*tracer.LatencyDetector, *alertHook, error) {
+		if *factor == 0 {
+			if *hook != "" {
+				return nil, nil, fmt.Errorf("-alert-hook needs alerts, -alert-factor is 0")
+			}
+			return nil, nil, nil
+		}
+		if *factor < 1 || *samples < 1 {
+			return nil, nil, fmt.Errorf("-alert-factor must be at least 1 and -alert-samples positive")
+		}
+		cfg := tracer.DetectorConfig{
+			Factor:      *factor,
+			MinSamples:  *samples,
+			MinDuration: *minDur,
+			Limit:       *limit,
+		}
+		if cfg.Limit == 0 {
+			cfg.Limit = -1
+		}
+		var h *alertHook
+		if *hook != "" {
+			var err error
+			if h, err = newAlertHook(*hook); err != nil {
+				return nil, nil, err
+			}
+		}
+		return tracer.NewLatencyDetector(cfg), h, nil
+	}
+}
+
+// alertHook runs a shell command on alerts. The alert event is passed as
+// JSON on stdin and summarized in STRACY_ALERT_* environment variables.
+//
+// Hooks are started by a helper process, "stracy alert-hook", because the
+// tracer waits for any child and would reap them.
+type alertHook struct {
+	runner *exec.Cmd
+	alerts chan tracer.Event
+	done   chan struct{}
+}
+
+// alertQueueSize is the number of alerts buffered for the hook runner.
+const alertQueueSize = 256
+
+func newAlertHook(command string) (*alertHook, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	runner := exec.Command(self, "alert-hook", command)
+	// Hooks print to stderr, stdout may carry JSON lines, see -o.
+	runner.Stdout = os.Stderr
+	runner.Stderr = os.Stderr
+	w, err := runner.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := runner.Start(); err != nil {
+		return nil, fmt.Errorf("can't start alert hook runner: %w", err)
+	}
+
+	h := &alertHook{
+		runner: runner,
+		alerts: make(chan tracer.Event, alertQueueSize),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(h.done)
+		enc := json.NewEncoder(w)
+		for e := range h.alerts {
+			if err := enc.Encode(e); err != nil {
+				fmt.Fprintf(os.Stderr, "alert hook: %s\n", err)
+			}
+		}
+		w.Close()
+		if err := runner.Wait(); err != nil {
+			fmt.Fprintf(os.Stderr, "alert hook: %s\n", err)
+		}
+	}()
+	return h, nil
+}
+
+// Run passes an alert event to the hook without waiting for it.
+func (h *alertHook) Run(e tracer.Event) {
+	select {
+	case h.alerts <- e:
+	default:
+		fmt.Fprintf(os.Stderr, "alert hook: queue is full, dropped alert on %s\n", e.Args.Syscall)
+	}
+}
+
+// Close waits for running hooks to finish. It must be called after tracing
+// is over, the runner is reaped by the tracer otherwise.
+func (h *alertHook) Close() {
+	close(h.alerts)
+	<-h.done
+}
+
+// alertHookMain runs the hook command for every alert event read from stdin
+// as a JSON line.
+func alertHookMain(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: %s alert-hook COMMAND\n", os.Args[0])
+		return 2
+	}
+	command := args[0]
+
+	sem := make(chan struct{}, maxRunningHooks)
+	var wg sync.WaitGroup
+	dec := json.NewDecoder(os.Stdin)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if err != io.EOF {
+				fmt.Printf("alert hook: %s\n", err)
+			}
+			break
+		}
+		var e tracer.Event
+		if err := json.Unmarshal(raw, &e); err != nil || e.Args.Alert == nil {
+			fmt.Printf("alert hook: bad alert %s\n", raw)
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := runHook(command, raw, e); err != nil {
+				fmt.Printf("alert hook: %s\n", err)
+			}
+		}()
+	}
+	wg.Wait()
+	return 0
+}
+
+func runHook(command string, data []byte, e tracer.Event) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"STRACY_ALERT_SYSCALL="+e.Args.Syscall,
+		"STRACY_ALERT_PID="+strconv.Itoa(e.PID),
+		"STRACY_ALERT_REASON="+e.Args.Alert.Reason,
+		"STRACY_ALERT_FD_TYPE="+e.Args.Alert.FDType,
+		"STRACY_ALERT_DURATION="+time.Duration(e.Args.Alert.Duration).String(),
+	)
+	return cmd.Run()
+}
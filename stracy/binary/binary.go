@@ -12,15 +12,22 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package binary translates between select fixed-sized types and a binary
-// representation.
+// Package binary translates between select types and a binary
+// representation. Kernel structs with padding and trailing variable-length
+// arrays are described with struct tags, see Marshal.
 package binary
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/iimos/play/stracy/ubinary"
 )
 
 // LittleEndian is the same as encoding/binary.LittleEndian.
@@ -54,17 +61,85 @@ func AppendUint64(buf []byte, order binary.ByteOrder, num uint64) []byte {
 	return buf
 }
 
+// Marshaler is a type with generated marshaling code, see
+// abi/internal/marshalgen. Marshal and Size use it instead of reflection,
+// Marshal only for the native byte order.
+type Marshaler interface {
+	// SizeBytes is the size of the binary representation.
+	SizeBytes() int
+
+	// MarshalBytes writes the binary representation to dst[:SizeBytes()].
+	MarshalBytes(dst []byte)
+}
+
+// Unmarshaler is a type with generated unmarshaling code. Unmarshal uses it
+// instead of reflection for the native byte order.
+type Unmarshaler interface {
+	// SizeBytes is the size of the binary representation.
+	SizeBytes() int
+
+	// UnmarshalBytes reads the binary representation from
+	// src[:SizeBytes()].
+	UnmarshalBytes(src []byte)
+}
+
+// ErrShortBuffer is returned by Unmarshal and Decode when the buffer ends
+// before the value.
+var ErrShortBuffer = errors.New("binary: buffer too short")
+
+// An UnsupportedTypeError is returned for types that have no binary
+// representation.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "binary: unsupported type " + e.Type.String()
+}
+
 // Marshal appends a binary representation of data to buf.
 //
-// data must only contain fixed-length signed and unsigned ints, arrays,
-// slices, structs and compositions of said types. data may be a pointer,
-// but cannot contain pointers.
-func Marshal(buf []byte, order binary.ByteOrder, data interface{}) []byte {
-	return marshal(buf, order, reflect.Indirect(reflect.ValueOf(data)))
+// data must only contain bools, floats, fixed-length signed and unsigned
+// ints, arrays, slices, structs and compositions of said types. data may be
+// a pointer, but cannot contain pointers.
+//
+// Struct fields can have a binary tag:
+//
+//	binary:"-"          the field isn't in the binary representation
+//	binary:"pad"        padding, written as zeros and skipped by Unmarshal
+//	binary:"len=Count"  a trailing slice of Count elements, Count is an
+//	                    earlier integer field
+//	binary:"total=Len"  a trailing slice up to Len bytes from the start of
+//	                    the struct, like data of struct cmsghdr
+//	binary:"term=0"     a trailing slice ended by an element equal to 0, it
+//	                    can be combined with total, like the name of
+//	                    struct linux_dirent64
+//
+// Trailing slices must be the last field of a struct. Marshal writes them
+// as they are, with the terminator, it doesn't set Count or Len fields.
+func Marshal(buf []byte, order binary.ByteOrder, data interface{}) ([]byte, error) {
+	if m, ok := data.(Marshaler); ok && order == ubinary.NativeEndian {
+		n := len(buf)
+		buf = append(buf, make([]byte, m.SizeBytes())...)
+		m.MarshalBytes(buf[n:])
+		return buf, nil
+	}
+	out, err := marshal(buf, order, reflect.Indirect(reflect.ValueOf(data)))
+	if err != nil {
+		return buf, err
+	}
+	return out, nil
 }
 
-func marshal(buf []byte, order binary.ByteOrder, data reflect.Value) []byte {
+func marshal(buf []byte, order binary.ByteOrder, data reflect.Value) ([]byte, error) {
 	switch data.Kind() {
+	case reflect.Bool:
+		var b byte
+		if data.Bool() {
+			b = 1
+		}
+		buf = append(buf, b)
+
 	case reflect.Int8:
 		buf = append(buf, byte(int8(data.Int())))
 	case reflect.Int16:
@@ -83,125 +158,451 @@ func marshal(buf []byte, order binary.ByteOrder, data reflect.Value) []byte {
 	case reflect.Uint64:
 		buf = AppendUint64(buf, order, data.Uint())
 
+	case reflect.Float32:
+		buf = AppendUint32(buf, order, math.Float32bits(float32(data.Float())))
+	case reflect.Float64:
+		buf = AppendUint64(buf, order, math.Float64bits(data.Float()))
+
 	case reflect.Array, reflect.Slice:
+		var err error
 		for i, l := 0, data.Len(); i < l; i++ {
-			buf = marshal(buf, order, data.Index(i))
+			if buf, err = marshal(buf, order, data.Index(i)); err != nil {
+				return buf, err
+			}
 		}
 
 	case reflect.Struct:
+		typ := data.Type()
 		for i, l := 0, data.NumField(); i < l; i++ {
-			buf = marshal(buf, order, data.Field(i))
+			tag, err := fieldTag(typ, i)
+			if err != nil {
+				return buf, err
+			}
+			field := data.Field(i)
+			switch {
+			case tag.skip:
+			case tag.pad:
+				size, err := sizeof(field)
+				if err != nil {
+					return buf, err
+				}
+				buf = append(buf, make([]byte, size)...)
+			default:
+				if buf, err = marshal(buf, order, field); err != nil {
+					return buf, err
+				}
+				if tag.hasTerm {
+					term := reflect.New(field.Type().Elem()).Elem()
+					setInt(term, tag.term)
+					buf, _ = marshal(buf, order, term)
+				}
+			}
 		}
 
 	default:
-		panic("invalid type: " + data.Type().String())
+		return buf, &UnsupportedTypeError{data.Type()}
 	}
-	return buf
+	return buf, nil
 }
 
 // Unmarshal unpacks buf into data.
 //
 // data must be a slice or a pointer and buf must have a length of exactly
-// Size(data). data must only contain fixed-length signed and unsigned ints,
-// arrays, slices, structs and compositions of said types.
-func Unmarshal(buf []byte, order binary.ByteOrder, data interface{}) {
+// the size of data. data must only contain the types supported by Marshal.
+// Slices, except trailing slices of structs, are filled up to their length.
+func Unmarshal(buf []byte, order binary.ByteOrder, data interface{}) error {
+	n, err := Decode(buf, order, data)
+	if err != nil {
+		return err
+	}
+	if n != len(buf) {
+		return fmt.Errorf("binary: buffer too long by %d bytes", len(buf)-n)
+	}
+	return nil
+}
+
+// Decode unpacks the beginning of buf into data like Unmarshal and returns
+// the number of bytes used.
+func Decode(buf []byte, order binary.ByteOrder, data interface{}) (int, error) {
+	if u, ok := data.(Unmarshaler); ok && order == ubinary.NativeEndian {
+		size := u.SizeBytes()
+		if len(buf) < size {
+			return 0, ErrShortBuffer
+		}
+		u.UnmarshalBytes(buf[:size])
+		return size, nil
+	}
 	value := reflect.ValueOf(data)
 	switch value.Kind() {
 	case reflect.Ptr:
+		if value.IsNil() {
+			return 0, fmt.Errorf("binary: can't unmarshal to nil %s", value.Type())
+		}
 		value = value.Elem()
 	case reflect.Slice:
 	default:
-		panic("invalid type: " + value.Type().String())
+		return 0, fmt.Errorf("binary: can't unmarshal to %s, it isn't a pointer or a slice", value.Type())
+	}
+	d := decoder{order: order, buf: buf}
+	if err := d.value(value); err != nil {
+		return 0, err
+	}
+	return d.off, nil
+}
+
+// decoder unpacks values from buf starting at off.
+type decoder struct {
+	order binary.ByteOrder
+	buf   []byte
+	off   int
+}
+
+// next returns the next n bytes.
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.buf)-d.off {
+		return nil, ErrShortBuffer
 	}
-	buf = unmarshal(buf, order, value)
-	if len(buf) != 0 {
-		panic(fmt.Sprintf("buffer too long by %d bytes", len(buf)))
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+// uint reads an unsigned int of size bytes.
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(d.order.Uint16(b)), nil
+	case 4:
+		return uint64(d.order.Uint32(b)), nil
 	}
+	return d.order.Uint64(b), nil
 }
 
-func unmarshal(buf []byte, order binary.ByteOrder, data reflect.Value) []byte {
+func (d *decoder) value(data reflect.Value) error {
 	switch data.Kind() {
-	case reflect.Int8:
-		data.SetInt(int64(int8(buf[0])))
-		buf = buf[1:]
-	case reflect.Int16:
-		data.SetInt(int64(int16(order.Uint16(buf))))
-		buf = buf[2:]
-	case reflect.Int32:
-		data.SetInt(int64(int32(order.Uint32(buf))))
-		buf = buf[4:]
-	case reflect.Int64:
-		data.SetInt(int64(order.Uint64(buf)))
-		buf = buf[8:]
+	case reflect.Bool:
+		v, err := d.uint(1)
+		data.SetBool(v != 0)
+		return err
 
-	case reflect.Uint8:
-		data.SetUint(uint64(buf[0]))
-		buf = buf[1:]
-	case reflect.Uint16:
-		data.SetUint(uint64(order.Uint16(buf)))
-		buf = buf[2:]
-	case reflect.Uint32:
-		data.SetUint(uint64(order.Uint32(buf)))
-		buf = buf[4:]
-	case reflect.Uint64:
-		data.SetUint(order.Uint64(buf))
-		buf = buf[8:]
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := d.uint(int(data.Type().Size()))
+		if err != nil {
+			return err
+		}
+		setInt(data, v)
+
+	case reflect.Float32:
+		v, err := d.uint(4)
+		data.SetFloat(float64(math.Float32frombits(uint32(v))))
+		return err
+	case reflect.Float64:
+		v, err := d.uint(8)
+		data.SetFloat(math.Float64frombits(v))
+		return err
 
 	case reflect.Array, reflect.Slice:
 		for i, l := 0, data.Len(); i < l; i++ {
-			buf = unmarshal(buf, order, data.Index(i))
+			if err := d.value(data.Index(i)); err != nil {
+				return err
+			}
 		}
 
 	case reflect.Struct:
+		start := d.off
+		typ := data.Type()
 		for i, l := 0, data.NumField(); i < l; i++ {
-			if field := data.Field(i); field.CanSet() {
-				buf = unmarshal(buf, order, field)
-			} else {
-				buf = buf[sizeof(field):]
+			tag, err := fieldTag(typ, i)
+			if err != nil {
+				return err
+			}
+			field := data.Field(i)
+			switch {
+			case tag.skip:
+			case tag.pad || !field.CanSet():
+				size, err := sizeof(field)
+				if err != nil {
+					return err
+				}
+				_, err = d.next(size)
+				if err != nil {
+					return err
+				}
+			case tag.trailing():
+				if err := d.trailing(data, field, tag, start); err != nil {
+					return err
+				}
+			default:
+				if err := d.value(field); err != nil {
+					return err
+				}
 			}
 		}
 
 	default:
-		panic("invalid type: " + data.Type().String())
+		return &UnsupportedTypeError{data.Type()}
 	}
-	return buf
+	return nil
+}
+
+// trailing unpacks a trailing slice of a struct starting at start.
+func (d *decoder) trailing(s, field reflect.Value, tag tag, start int) error {
+	elem := field.Type().Elem()
+	esize, err := typeSize(elem)
+	if err != nil {
+		return err
+	}
+
+	// bytes available to the slice
+	avail := len(d.buf) - d.off
+	switch {
+	case tag.len != "":
+		n := uintField(s, tag.len)
+		if n > uint64(avail/esize) {
+			return ErrShortBuffer
+		}
+		avail = int(n) * esize
+	case tag.total != "":
+		total := uintField(s, tag.total)
+		if total < uint64(d.off-start) {
+			return fmt.Errorf("binary: %s.%s is %d, less than %d bytes before %s", s.Type(), tag.total, total, d.off-start, tag.name)
+		}
+		if total-uint64(d.off-start) > uint64(avail) {
+			return ErrShortBuffer
+		}
+		avail = int(total) - (d.off - start)
+	}
+
+	n := avail / esize
+	used := avail
+	if tag.hasTerm {
+		sub := decoder{order: d.order, buf: d.buf[d.off : d.off+avail]}
+		found := false
+		for n = 0; sub.off+esize <= avail; n++ {
+			if v, _ := sub.uint(esize); v == tag.term {
+				found = true
+				break
+			}
+		}
+		switch {
+		case !found && tag.total == "":
+			return fmt.Errorf("binary: no terminator of %s.%s", s.Type(), tag.name)
+		case tag.total == "":
+			used = (n + 1) * esize
+		}
+	}
+
+	slice := reflect.MakeSlice(field.Type(), n, n)
+	sub := decoder{order: d.order, buf: d.buf[d.off : d.off+n*esize]}
+	if err := sub.value(slice); err != nil {
+		return err
+	}
+	field.Set(slice)
+	d.off += used
+	return nil
+}
+
+// setInt sets an integer value from its bits.
+func setInt(data reflect.Value, v uint64) {
+	switch data.Kind() {
+	case reflect.Int8:
+		data.SetInt(int64(int8(v)))
+	case reflect.Int16:
+		data.SetInt(int64(int16(v)))
+	case reflect.Int32:
+		data.SetInt(int64(int32(v)))
+	case reflect.Int64:
+		data.SetInt(int64(v))
+	default:
+		data.SetUint(v)
+	}
+}
+
+// uintField returns the value of an integer field of a struct.
+func uintField(s reflect.Value, name string) uint64 {
+	f := s.FieldByName(name)
+	if f.CanInt() {
+		return uint64(f.Int())
+	}
+	return f.Uint()
+}
+
+// tag is a parsed binary tag of a struct field.
+type tag struct {
+	name       string // of the field
+	skip, pad  bool
+	len, total string // fields with the length of a trailing slice
+	hasTerm    bool
+	term       uint64
+}
+
+// trailing reports if the field is a trailing slice.
+func (t tag) trailing() bool {
+	return t.len != "" || t.total != "" || t.hasTerm
+}
+
+// fieldTag parses and checks the binary tag of the i-th field of a struct.
+func fieldTag(typ reflect.Type, i int) (tag, error) {
+	f := typ.Field(i)
+	t := tag{name: f.Name}
+	s, ok := f.Tag.Lookup("binary")
+	if !ok {
+		return t, nil
+	}
+	bad := func(format string, args ...any) (tag, error) {
+		return t, fmt.Errorf("binary: tag of %s.%s: %s", typ, f.Name, fmt.Sprintf(format, args...))
+	}
+	for _, opt := range strings.Split(s, ",") {
+		key, val, _ := strings.Cut(opt, "=")
+		switch key {
+		case "-":
+			t.skip = true
+		case "pad":
+			t.pad = true
+		case "len":
+			t.len = val
+		case "total":
+			t.total = val
+		case "term":
+			n, err := strconv.ParseUint(val, 0, 64)
+			if err != nil {
+				return bad("bad terminator %q", val)
+			}
+			t.hasTerm, t.term = true, n
+		default:
+			return bad("unknown option %q", opt)
+		}
+	}
+	if !t.trailing() {
+		return t, nil
+	}
+
+	switch {
+	case t.skip || t.pad:
+		return bad("a trailing slice can't be skipped")
+	case f.Type.Kind() != reflect.Slice:
+		return bad("%s isn't a slice", f.Type)
+	case i != typ.NumField()-1:
+		return bad("a trailing slice isn't the last field")
+	case t.len != "" && (t.total != "" || t.hasTerm):
+		return bad("len can't be combined with total or term")
+	case t.hasTerm && !isInt(f.Type.Elem().Kind()):
+		return bad("terminated %s isn't a slice of ints", f.Type)
+	}
+	for _, name := range []string{t.len, t.total} {
+		if name == "" {
+			continue
+		}
+		ref, ok := typ.FieldByName(name)
+		if !ok || len(ref.Index) != 1 || ref.Index[0] >= i || !isInt(ref.Type.Kind()) {
+			return bad("%s isn't an earlier integer field", name)
+		}
+	}
+	return t, nil
+}
+
+func isInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
 }
 
 // Size calculates the buffer sized needed by Marshal or Unmarshal.
 //
-// Size only support the types supported by Marshal.
+// Size only support the types supported by Marshal and panics on others,
+// it's meant for types known at compile time.
 func Size(v interface{}) uintptr {
-	return sizeof(reflect.Indirect(reflect.ValueOf(v)))
+	if m, ok := v.(Marshaler); ok {
+		return uintptr(m.SizeBytes())
+	}
+	size, err := sizeof(reflect.Indirect(reflect.ValueOf(v)))
+	if err != nil {
+		panic(err)
+	}
+	return uintptr(size)
 }
 
-func sizeof(data reflect.Value) uintptr {
+// sizeof returns the size of a value, slices are counted with their
+// current length.
+func sizeof(data reflect.Value) (int, error) {
 	switch data.Kind() {
-	case reflect.Int8, reflect.Uint8:
-		return 1
-	case reflect.Int16, reflect.Uint16:
-		return 2
-	case reflect.Int32, reflect.Uint32:
-		return 4
-	case reflect.Int64, reflect.Uint64:
-		return 8
-
-	case reflect.Array, reflect.Slice:
-		var size uintptr
-		for i, l := 0, data.Len(); i < l; i++ {
-			size += sizeof(data.Index(i))
-		}
-		return size
+	case reflect.Slice:
+		esize, err := typeSize(data.Type().Elem())
+		return esize * data.Len(), err
 
 	case reflect.Struct:
-		var size uintptr
+		var size int
+		typ := data.Type()
 		for i, l := 0, data.NumField(); i < l; i++ {
-			size += sizeof(data.Field(i))
+			tag, err := fieldTag(typ, i)
+			if err != nil {
+				return 0, err
+			}
+			if tag.skip {
+				continue
+			}
+			n, err := sizeof(data.Field(i))
+			if err != nil {
+				return 0, err
+			}
+			if tag.hasTerm {
+				n += int(typ.Field(i).Type.Elem().Size())
+			}
+			size += n
 		}
-		return size
+		return size, nil
+	}
+	return typeSize(data.Type())
+}
 
-	default:
-		panic("invalid type: " + data.Type().String())
+// typeSize returns the size of a fixed-size type.
+func typeSize(typ reflect.Type) (int, error) {
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return 1, nil
+	case reflect.Int16, reflect.Uint16:
+		return 2, nil
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		return 4, nil
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return 8, nil
+
+	case reflect.Array:
+		size, err := typeSize(typ.Elem())
+		return size * typ.Len(), err
+
+	case reflect.Struct:
+		var size int
+		for i, l := 0, typ.NumField(); i < l; i++ {
+			tag, err := fieldTag(typ, i)
+			if err != nil {
+				return 0, err
+			}
+			if tag.skip {
+				continue
+			}
+			if tag.trailing() {
+				return 0, fmt.Errorf("binary: %s has variable size", typ)
+			}
+			n, err := typeSize(typ.Field(i).Type)
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+		return size, nil
 	}
+	return 0, &UnsupportedTypeError{typ}
 }
 
 // ReadUint16 reads a uint16 from r.
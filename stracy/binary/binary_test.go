@@ -0,0 +1,251 @@
+package binary
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type scalars struct {
+	B   bool
+	I8  int8
+	I16 int16
+	U32 uint32
+	F32 float32
+	F64 float64
+}
+
+type padded struct {
+	A    uint16
+	_    uint16
+	Pad  [4]byte `binary:"pad"`
+	Skip int64   `binary:"-"`
+	B    uint32
+}
+
+type counted struct {
+	N     uint8
+	Items []uint16 `binary:"len=N"`
+}
+
+// like struct cmsghdr
+type record struct {
+	Len  uint32
+	Type uint16
+	Data []byte `binary:"total=Len"`
+}
+
+type terminated struct {
+	ID   uint16
+	Name []byte `binary:"term=0"`
+}
+
+// like struct linux_dirent64
+type dirent struct {
+	Reclen uint16
+	Name   []byte `binary:"total=Reclen,term=0"`
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any // pointer
+		want []byte
+	}{
+		{
+			name: "scalars",
+			v:    &scalars{B: true, I8: -1, I16: -2, U32: 3, F32: 1.5, F64: -0.25},
+			want: []byte{
+				1, 0xff, 0xfe, 0xff, 3, 0, 0, 0,
+				0, 0, 0xc0, 0x3f,
+				0, 0, 0, 0, 0, 0, 0xd0, 0xbf,
+			},
+		},
+		{
+			name: "padded",
+			v:    &padded{A: 1, B: 2},
+			want: []byte{1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0},
+		},
+		{
+			name: "counted",
+			v:    &counted{N: 2, Items: []uint16{7, 8}},
+			want: []byte{2, 7, 0, 8, 0},
+		},
+		{
+			name: "counted empty",
+			v:    &counted{Items: []uint16{}},
+			want: []byte{0},
+		},
+		{
+			name: "total",
+			v:    &record{Len: 9, Type: 1, Data: []byte("abc")},
+			want: []byte{9, 0, 0, 0, 1, 0, 'a', 'b', 'c'},
+		},
+		{
+			name: "terminated",
+			v:    &terminated{ID: 1, Name: []byte("ab")},
+			want: []byte{1, 0, 'a', 'b', 0},
+		},
+		{
+			name: "slice",
+			v:    &[]int32{-1, 1},
+			want: []byte{0xff, 0xff, 0xff, 0xff, 1, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(nil, LittleEndian, tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Marshal() = %v, want %v", got, tt.want)
+			}
+			if size := Size(tt.v); size != uintptr(len(tt.want)) {
+				t.Errorf("Size() = %d, want %d", size, len(tt.want))
+			}
+
+			// Slices are filled up to their length.
+			v := reflect.New(reflect.TypeOf(tt.v).Elem())
+			if v.Elem().Kind() == reflect.Slice {
+				n := reflect.ValueOf(tt.v).Elem().Len()
+				v.Elem().Set(reflect.MakeSlice(v.Elem().Type(), n, n))
+			}
+			if err := Unmarshal(tt.want, LittleEndian, v.Interface()); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(v.Interface(), tt.v) {
+				t.Errorf("Unmarshal() = %+v, want %+v", v.Elem(), reflect.ValueOf(tt.v).Elem())
+			}
+		})
+	}
+}
+
+func TestBigEndian(t *testing.T) {
+	v := scalars{I16: 0x102, F32: 1}
+	b, err := Marshal(nil, BigEndian, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0, 0, 1, 2, 0, 0, 0, 0, 0x3f, 0x80, 0, 0}; !bytes.Equal(b[:12], want) {
+		t.Errorf("Marshal() = %v, want %v...", b, want)
+	}
+	var got scalars
+	if err := Unmarshal(b, BigEndian, &got); err != nil || got != v {
+		t.Errorf("Unmarshal() = %+v, %v; want %+v", got, err, v)
+	}
+}
+
+func TestUnmarshalPadding(t *testing.T) {
+	// Padding and skipped fields are left as they are.
+	v := padded{Pad: [4]byte{9}, Skip: 9}
+	if err := Unmarshal([]byte{1, 0, 5, 5, 5, 5, 5, 5, 2, 0, 0, 0}, LittleEndian, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (padded{A: 1, Pad: [4]byte{9}, Skip: 9, B: 2}); v != want {
+		t.Errorf("Unmarshal() = %+v, want %+v", v, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// Two linux_dirent64-like records padded to 8 bytes.
+	buf := []byte{
+		8, 0, '.', 0, 0, 0, 0, 0,
+		8, 0, 'a', 'b', 'c', 0, 0, 0,
+		5, 0, 'x', 'y', 'z', // no terminator within Reclen
+	}
+	var names []string
+	for len(buf) > 0 {
+		var d dirent
+		n, err := Decode(buf, LittleEndian, &d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int(d.Reclen) {
+			t.Fatalf("Decode() = %d, want %d", n, d.Reclen)
+		}
+		names = append(names, string(d.Name))
+		buf = buf[n:]
+	}
+	if got, want := strings.Join(names, ","), ".,abc,xyz"; got != want {
+		t.Errorf("names = %s, want %s", got, want)
+	}
+
+	var h terminated
+	n, err := Decode([]byte{1, 0, 'a', 0, 'b', 0}, LittleEndian, &h)
+	if err != nil || n != 4 || string(h.Name) != "a" {
+		t.Errorf("Decode() = %d, %v, %q; want 4, nil, \"a\"", n, err, h.Name)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	type unsupported struct{ P *int }
+	type badTag struct {
+		X []byte `binary:"nope"`
+	}
+	type notLast struct {
+		X []byte `binary:"term=0"`
+		Y uint8
+	}
+	type laterLen struct {
+		X []byte `binary:"len=N"`
+		N uint8
+	}
+	type lenTerm struct {
+		N uint8
+		X []byte `binary:"len=N,term=0"`
+	}
+	type termStructs struct {
+		X []scalars `binary:"term=0"`
+	}
+
+	for _, v := range []any{&unsupported{}, &badTag{}, &notLast{}, &laterLen{}, &lenTerm{}, &termStructs{}, map[int]int{}} {
+		if _, err := Marshal(nil, LittleEndian, v); err == nil {
+			t.Errorf("Marshal(%T) succeeded", v)
+		}
+		if err := Unmarshal(make([]byte, 16), LittleEndian, v); err == nil {
+			t.Errorf("Unmarshal(%T) succeeded", v)
+		}
+	}
+	var ute *UnsupportedTypeError
+	if _, err := Marshal(nil, LittleEndian, unsupported{}); !errors.As(err, &ute) {
+		t.Errorf("Marshal() = %v, want UnsupportedTypeError", err)
+	}
+	if err := Unmarshal(nil, LittleEndian, scalars{}); err == nil {
+		t.Error("Unmarshal to a non-pointer succeeded")
+	}
+
+	for _, tt := range []struct {
+		name string
+		buf  []byte
+		v    any
+		want error
+	}{
+		{"short", []byte{1, 2}, &scalars{}, ErrShortBuffer},
+		{"long", make([]byte, 21), &scalars{}, nil},
+		{"len beyond buffer", []byte{3, 1, 0, 2, 0}, &counted{}, ErrShortBuffer},
+		{"total beyond buffer", []byte{10, 0, 0, 0, 1, 0, 'a'}, &record{}, ErrShortBuffer},
+		{"total within header", []byte{2, 0, 0, 0, 1, 0, 'a'}, &record{}, nil},
+		{"no terminator", []byte{1, 0, 'a', 'b'}, &terminated{}, nil},
+	} {
+		err := Unmarshal(tt.buf, LittleEndian, tt.v)
+		if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: Unmarshal() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestFloatBits(t *testing.T) {
+	v := struct{ F float64 }{math.Inf(-1)}
+	b, err := Marshal(nil, LittleEndian, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.F = 0
+	if err := Unmarshal(b, LittleEndian, &v); err != nil || !math.IsInf(v.F, -1) {
+		t.Errorf("Unmarshal() = %v, %v; want -Inf", v.F, err)
+	}
+}
@@ -0,0 +1,14 @@
+#include <sys/syscall.h>
+#include <time.h>
+#include <unistd.h>
+
+/* Calls getppid every millisecond until killed. */
+int main()
+{
+        struct timespec ts = {0, 1000000};
+
+        for (;;) {
+                syscall(SYS_getppid);
+                nanosleep(&ts, NULL);
+        }
+}
@@ -0,0 +1,37 @@
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <sys/syscall.h>
+#include <unistd.h>
+
+/* Every thread calls getppid three times. */
+static void *run(void *arg)
+{
+        int i;
+
+        for (i = 0; i < 3; i++)
+                syscall(SYS_getppid);
+        return NULL;
+}
+
+int main(int argc, char **argv)
+{
+        pthread_t threads[16];
+        int n, i;
+
+        n = argc > 1 ? atoi(argv[1]) : 2;
+        if (n < 1 || n > 16) {
+                fprintf(stderr, "usage: %s [1-16]\n", argv[0]);
+                return 1;
+        }
+
+        for (i = 0; i < n; i++) {
+                if (pthread_create(&threads[i], NULL, run, NULL)) {
+                        perror("pthread_create");
+                        return 1;
+                }
+        }
+        for (i = 0; i < n; i++)
+                pthread_join(threads[i], NULL);
+        return 0;
+}
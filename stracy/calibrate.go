@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// calibrationCalls is how many syscalls doing nothing "stracy calibrate"
+// makes.
+const calibrationCalls = 1000
+
+// calibrate measures the overhead of tracing with a backend by tracing
+// "stracy calibrate", see tracer.Calibrate. Without a calibration syscall
+// times include the overhead.
+func calibrate(ctx context.Context, backend tracer.Backend) tracer.Overhead {
+	self, err := os.Executable()
+	if err == nil {
+		var o tracer.Overhead
+		o, err = tracer.Calibrate(ctx, exec.Command(self, "calibrate"), tracer.WithBackend(backend))
+		if err == nil {
+			return o
+		}
+	}
+	fmt.Fprintf(os.Stderr, "calibration failed, syscall times include the tracing overhead: %s\n", err)
+	return tracer.Overhead{}
+}
+
+// calibrateMain makes syscalls doing nothing for calibrate.
+func calibrateMain(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintf(os.Stderr, "usage: %s calibrate\n", os.Args[0])
+		return 2
+	}
+	for i := 0; i < calibrationCalls; i++ {
+		syscall.Getppid()
+	}
+	return 0
+}
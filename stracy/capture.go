@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+// Interfaces of the capture file.
+const (
+	captureIfaceSockets = 0 // synthesized TCP/UDP packets
+	captureIfaceFiles   = 1 // plain buffers of files, pipes, ttys etc.
+)
+
+// captureSegmentSize is the maximum payload of a synthesized packet. Bigger
+// buffers are split into several packets.
+const captureSegmentSize = 65000
+
+// PayloadCapture writes complete read/write buffers of traced processes to a
+// pcapng file. Socket traffic is wrapped into synthesized TCP or UDP packets
+// so Wireshark can dissect application protocols, other buffers are written
+// as is to a separate interface.
+type PayloadCapture struct {
+	f   *os.File
+	pw  *pcapngWriter
+	fds map[int32]bool
+
+	// conns holds synthesized connections by socket inode.
+	conns map[uint64]*captureConn
+
+	// unixPeers holds paths passed to connect(2) by socket inode.
+	unixPeers map[uint64]string
+
+	// redactor hides secrets in captured buffers.
+	redactor *tracer.Redactor
+}
+
+// NewPayloadCapture creates a capture file at path. If fds is empty, buffers
+// of all file descriptors are captured.
+func NewPayloadCapture(path string, fds []int32) (*PayloadCapture, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &PayloadCapture{
+		f:         f,
+		fds:       make(map[int32]bool, len(fds)),
+		conns:     make(map[uint64]*captureConn),
+		unixPeers: make(map[uint64]string),
+	}
+	for _, fd := range fds {
+		c.fds[fd] = true
+	}
+
+	c.pw, err = newPcapngWriter(f)
+	if err == nil {
+		err = c.pw.addInterface(linkTypeRaw, "sockets")
+	}
+	if err == nil {
+		err = c.pw.addInterface(linkTypeUser0, "files")
+	}
+	if err == nil {
+		err = c.pw.Flush()
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Observe implements tracer.Observer. It captures the buffer of the syscall
+// and points the event to it.
+func (c *PayloadCapture) Observe(t strace.Task, record *strace.TraceRecord, e *tracer.Event) []tracer.Event {
+	// Buffers are complete at the exit.
+	spans, err := c.Capture(t, record, int64(e.Timestamp+e.Duration))
+	if err != nil {
+		// Stdout may be the event stream (-o -).
+		fmt.Fprintf(os.Stderr, "capture: %s\n", err)
+	}
+	e.Args.Payload = spans
+	return nil
+}
+
+// Capture stores the buffer of a read- or write-style syscall. It must be
+// called on every syscall exit so the capture can follow connections.
+func (c *PayloadCapture) Capture(t strace.Task, record *strace.TraceRecord, tsNano int64) ([]tracer.PayloadSpan, error) {
+	call := record.Syscall
+	if call.Sysno == unix.SYS_CONNECT && (call.Errno == 0 || call.Errno == unix.EINPROGRESS) {
+		c.rememberUnixPeer(t, record.PID, call.Args)
+		return nil, nil
+	}
+	if call.Errno != 0 {
+		return nil, nil
+	}
+
+	// Other fds are skipped before their buffer is copied out of the
+	// tracee. The fd is the first argument of all transfer syscalls.
+	if len(c.fds) > 0 && !c.fds[call.Args[0].Int()] {
+		return nil, nil
+	}
+	si := syscalls.Details(call)
+	tr, err := syscalls.ReadTransfer(si, t, call.Args, call.Ret[0])
+	if err != nil || tr == nil {
+		return nil, err
+	}
+
+	tr.Data = c.redactor.RedactBytes(tr.Data)
+
+	target, _ := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", record.PID, tr.FD))
+	comment := fmt.Sprintf("pid %d fd %d %s %s", record.PID, tr.FD, si.Name, target)
+
+	var spans []tracer.PayloadSpan
+	inode, isSocket := socketInode(target)
+	if !isSocket {
+		off, err := c.pw.writePacket(captureIfaceFiles, tsNano, tr.Data, comment)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, tracer.PayloadSpan{Offset: off, Size: len(tr.Data)})
+		return spans, c.pw.Flush()
+	}
+
+	conn := c.conns[inode]
+	if conn == nil {
+		conn = newCaptureConn(record.PID, inode, c.unixPeers[inode])
+		c.conns[inode] = conn
+	}
+	for data := tr.Data; len(data) > 0; {
+		n := len(data)
+		if n > captureSegmentSize {
+			n = captureSegmentSize
+		}
+		pkt, hdrLen := conn.packet(tr.Write, data[:n])
+		off, err := c.pw.writePacket(captureIfaceSockets, tsNano, pkt, comment)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, tracer.PayloadSpan{Offset: off + int64(hdrLen), Size: n})
+		data = data[n:]
+	}
+	return spans, c.pw.Flush()
+}
+
+func (c *PayloadCapture) rememberUnixPeer(t strace.Task, pid int, args strace.SyscallArguments) {
+	target, _ := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, args[0].Int()))
+	inode, ok := socketInode(target)
+	if !ok {
+		return
+	}
+	b, err := strace.CaptureAddress(t, args[1].Pointer(), uint32(args[2].Uint64()))
+	if err != nil || len(b) < 2 || ubinary.NativeEndian.Uint16(b) != unix.AF_UNIX {
+		return
+	}
+	if fa, err := syscalls.GetAddress(t, b); err == nil {
+		c.unixPeers[inode] = string(fa.Addr)
+	}
+}
+
+func (c *PayloadCapture) Close() error {
+	return errors.Join(c.pw.Flush(), c.f.Close())
+}
+
+// socketInode extracts an inode number from a "socket:[12345]" fd link.
+func socketInode(target string) (uint64, bool) {
+	s, ok := strings.CutPrefix(target, "socket:[")
+	if !ok {
+		return 0, false
+	}
+	inode, err := strconv.ParseUint(strings.TrimSuffix(s, "]"), 10, 64)
+	return inode, err == nil
+}
+
+// captureConn is a connection as it appears in the capture file.
+type captureConn struct {
+	local, remote netip.AddrPort
+	udp           bool
+
+	// seq is the next sequence number of the local and the remote side.
+	seq [2]uint32
+}
+
+// Fake addresses of unix sockets.
+var (
+	unixLocalAddr  = netip.AddrFrom4([4]byte{127, 0, 0, 1})
+	unixRemoteAddr = netip.AddrFrom4([4]byte{127, 0, 0, 2})
+)
+
+func newCaptureConn(pid int, inode uint64, unixPeer string) *captureConn {
+	conn := &captureConn{seq: [2]uint32{1, 1}}
+	if local, remote, udp, ok := lookupInetSocket(pid, inode); ok {
+		conn.local, conn.remote, conn.udp = local, remote, udp
+		return conn
+	}
+
+	// Unix sockets have no ports, so they get fake ones. Well known services
+	// usually put the port into the socket name (e.g. /run/postgresql/.s.PGSQL.5432)
+	// which is used to let Wireshark pick the right dissector.
+	ephemeral := uint16(49152 + inode%16384)
+	path, dgram := lookupUnixSocket(pid, inode)
+	conn.udp = dgram
+	switch {
+	case unixPeer != "":
+		conn.local = netip.AddrPortFrom(unixLocalAddr, ephemeral)
+		conn.remote = netip.AddrPortFrom(unixRemoteAddr, unixPathPort(unixPeer, ephemeral+1))
+	case path != "":
+		conn.local = netip.AddrPortFrom(unixLocalAddr, unixPathPort(path, ephemeral+1))
+		conn.remote = netip.AddrPortFrom(unixRemoteAddr, ephemeral)
+	default:
+		conn.local = netip.AddrPortFrom(unixLocalAddr, ephemeral)
+		conn.remote = netip.AddrPortFrom(unixRemoteAddr, ephemeral+1)
+	}
+	return conn
+}
+
+// unixPathPort returns the number after the last dot of a socket path.
+func unixPathPort(path string, fallback uint16) uint16 {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return fallback
+	}
+	port, err := strconv.ParseUint(path[i+1:], 10, 16)
+	if err != nil || port == 0 {
+		return fallback
+	}
+	return uint16(port)
+}
+
+// packet builds an IP packet carrying payload and returns it together with
+// the length of its headers.
+func (c *captureConn) packet(write bool, payload []byte) ([]byte, int) {
+	src, dst, side := c.local, c.remote, 0
+	if !write {
+		src, dst, side = dst, src, 1
+	}
+
+	var l4 []byte
+	proto := byte(unix.IPPROTO_TCP)
+	if c.udp {
+		proto = unix.IPPROTO_UDP
+		l4 = make([]byte, 8)
+		binary.BigEndian.PutUint16(l4[0:], src.Port())
+		binary.BigEndian.PutUint16(l4[2:], dst.Port())
+		binary.BigEndian.PutUint16(l4[4:], uint16(8+len(payload)))
+	} else {
+		l4 = make([]byte, 20)
+		binary.BigEndian.PutUint16(l4[0:], src.Port())
+		binary.BigEndian.PutUint16(l4[2:], dst.Port())
+		binary.BigEndian.PutUint32(l4[4:], c.seq[side])
+		binary.BigEndian.PutUint32(l4[8:], c.seq[1-side])
+		l4[12] = 5 << 4                             // data offset
+		l4[13] = 0x08 | 0x10                        // PSH, ACK
+		binary.BigEndian.PutUint16(l4[14:], 0xffff) // window
+		c.seq[side] += uint32(len(payload))
+	}
+
+	srcIP, dstIP := src.Addr().AsSlice(), dst.Addr().AsSlice()
+	l4Len := len(l4) + len(payload)
+
+	var ip []byte
+	var pseudo []byte
+	if src.Addr().Is4() && dst.Addr().Is4() {
+		ip = make([]byte, 20)
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(len(ip)+l4Len))
+		binary.BigEndian.PutUint16(ip[6:], 0x4000) // don't fragment
+		ip[8] = 64
+		ip[9] = proto
+		copy(ip[12:], srcIP)
+		copy(ip[16:], dstIP)
+		binary.BigEndian.PutUint16(ip[10:], checksum(0, ip))
+
+		pseudo = append(append(pseudo, srcIP...), dstIP...)
+		pseudo = append(pseudo, 0, proto, byte(l4Len>>8), byte(l4Len))
+	} else {
+		srcIP, dstIP = as16(src.Addr()), as16(dst.Addr())
+		ip = make([]byte, 40)
+		ip[0] = 0x60
+		binary.BigEndian.PutUint16(ip[4:], uint16(l4Len))
+		ip[6] = proto
+		ip[7] = 64
+		copy(ip[8:], srcIP)
+		copy(ip[24:], dstIP)
+
+		pseudo = append(append(pseudo, srcIP...), dstIP...)
+		pseudo = binary.BigEndian.AppendUint32(pseudo, uint32(l4Len))
+		pseudo = append(pseudo, 0, 0, 0, proto)
+	}
+
+	sum := checksumAdd(checksumAdd(checksumAdd(0, pseudo), l4), payload)
+	csumOffset := 16
+	if c.udp {
+		csumOffset = 6
+	}
+	binary.BigEndian.PutUint16(l4[csumOffset:], checksumFold(sum))
+
+	pkt := make([]byte, 0, len(ip)+l4Len)
+	pkt = append(append(append(pkt, ip...), l4...), payload...)
+	return pkt, len(ip) + len(l4)
+}
+
+func as16(a netip.Addr) []byte {
+	b := a.As16()
+	return b[:]
+}
+
+// checksum computes the internet checksum (RFC 1071) of b.
+func checksum(sum uint32, b []byte) uint16 {
+	return checksumFold(checksumAdd(sum, b))
+}
+
+func checksumAdd(sum uint32, b []byte) uint32 {
+	for ; len(b) >= 2; b = b[2:] {
+		sum += uint32(b[0])<<8 | uint32(b[1])
+	}
+	if len(b) == 1 {
+		sum += uint32(b[0]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return sum
+}
+
+func checksumFold(sum uint32) uint16 {
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// lookupInetSocket finds a TCP or UDP socket by inode in the network
+// namespace of pid.
+func lookupInetSocket(pid int, inode uint64) (local, remote netip.AddrPort, udp, ok bool) {
+	ino := strconv.FormatUint(inode, 10)
+	for _, name := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		err := scanProcNet(pid, name, func(fields []string) bool {
+			// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+			if len(fields) < 10 || fields[9] != ino {
+				return false
+			}
+			var err1, err2 error
+			local, err1 = parseProcNetAddr(fields[1])
+			remote, err2 = parseProcNetAddr(fields[2])
+			ok = err1 == nil && err2 == nil
+			return true
+		})
+		if err == nil && ok {
+			return local, remote, strings.HasPrefix(name, "udp"), true
+		}
+	}
+	return local, remote, false, false
+}
+
+// lookupUnixSocket finds a unix socket by inode in the network namespace of
+// pid and returns its path and whether it is a datagram socket.
+func lookupUnixSocket(pid int, inode uint64) (path string, dgram bool) {
+	ino := strconv.FormatUint(inode, 10)
+	scanProcNet(pid, "unix", func(fields []string) bool {
+		// Num RefCount Protocol Flags Type St Inode Path
+		if len(fields) < 7 || fields[6] != ino {
+			return false
+		}
+		if len(fields) > 7 {
+			path = fields[7]
+		}
+		dgram = fields[4] == "0002"
+		return true
+	})
+	return path, dgram
+}
+
+// scanProcNet calls fn for every entry of /proc/PID/net/NAME until fn returns
+// true.
+func scanProcNet(pid int, name string, fn func(fields []string) bool) error {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/net/%s", pid, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Scan() // skip header
+	for sc.Scan() {
+		if fn(strings.Fields(sc.Text())) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// parseProcNetAddr parses addresses like "0100007F:1F90". The address part is
+// a sequence of 32-bit words printed in host byte order.
+func parseProcNetAddr(s string) (netip.AddrPort, error) {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	raw, err := hex.DecodeString(host)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	for i := 0; i+4 <= len(raw); i += 4 {
+		ubinary.NativeEndian.PutUint32(raw[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	addr, ok := netip.AddrFromSlice(raw)
+	if !ok {
+		return netip.AddrPort{}, fmt.Errorf("invalid address %q", s)
+	}
+	return netip.AddrPortFrom(addr.Unmap(), uint16(p)), nil
+}
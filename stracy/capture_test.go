@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/tracer"
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+// pcapngBlock is a block of a pcapng file.
+type pcapngBlock struct {
+	typ  uint32
+	off  int64 // of the body in the file
+	body []byte
+}
+
+func readPcapngBlocks(t *testing.T, data []byte) []pcapngBlock {
+	t.Helper()
+	var blocks []pcapngBlock
+	for off := 0; off < len(data); {
+		if len(data)-off < 12 {
+			t.Fatalf("truncated block at %d", off)
+		}
+		typ := ubinary.NativeEndian.Uint32(data[off:])
+		total := int(ubinary.NativeEndian.Uint32(data[off+4:]))
+		if total%4 != 0 || total < 12 || off+total > len(data) {
+			t.Fatalf("block at %d has invalid length %d", off, total)
+		}
+		if trailer := int(ubinary.NativeEndian.Uint32(data[off+total-4:])); trailer != total {
+			t.Fatalf("block at %d: trailing length %d, want %d", off, trailer, total)
+		}
+		blocks = append(blocks, pcapngBlock{typ: typ, off: int64(off + 8), body: data[off+8 : off+total-4]})
+		off += total
+	}
+	return blocks
+}
+
+func TestPayloadCapture(t *testing.T) {
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sock, err := conn.(*net.TCPConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sock.Close()
+
+	path := filepath.Join(t.TempDir(), "capture.pcapng")
+	c, err := NewPayloadCapture(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := &memTask{pid: os.Getpid()}
+	write := func(fd uintptr, data []byte) []tracer.PayloadSpan {
+		t.Helper()
+		call := &strace.SyscallEvent{Sysno: unix.SYS_WRITE}
+		call.Args[0].Value = fd
+		call.Args[1].Value = task.put(data)
+		call.Args[2].Value = uintptr(len(data))
+		call.Ret[0].Value = uintptr(len(data))
+		record := &strace.TraceRecord{PID: os.Getpid(), Event: strace.SyscallExit, Syscall: call}
+		spans, err := c.Capture(task, record, 1_700_000_000_123_456_789)
+		if err != nil {
+			t.Fatalf("Capture: %s", err)
+		}
+		return spans
+	}
+
+	file := []byte("hello")
+	fileSpans := write(null.Fd(), file)
+	big := bytes.Repeat([]byte("0123456789"), 7000) // two segments
+	sockSpans := write(sock.Fd(), big)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := readPcapngBlocks(t, data)
+	wantTypes := []uint32{
+		pcapngSectionHeaderBlock,
+		pcapngInterfaceDescBlock,  // sockets
+		pcapngInterfaceDescBlock,  // files
+		pcapngEnhancedPacketBlock, // hello
+		pcapngEnhancedPacketBlock, // first segment
+		pcapngEnhancedPacketBlock, // second segment
+	}
+	if len(blocks) != len(wantTypes) {
+		t.Fatalf("got %d blocks, want %d", len(blocks), len(wantTypes))
+	}
+	for i, b := range blocks {
+		if b.typ != wantTypes[i] {
+			t.Errorf("block %d has type %#x, want %#x", i, b.typ, wantTypes[i])
+		}
+	}
+	if magic := ubinary.NativeEndian.Uint32(blocks[0].body); magic != pcapngByteOrderMagic {
+		t.Errorf("byte order magic %#x", magic)
+	}
+	for i, linkType := range []uint16{linkTypeRaw, linkTypeUser0} {
+		if got := ubinary.NativeEndian.Uint16(blocks[1+i].body); got != linkType {
+			t.Errorf("interface %d has link type %d, want %d", i, got, linkType)
+		}
+	}
+
+	packets := blocks[3:]
+	for i, p := range packets {
+		iface := ubinary.NativeEndian.Uint32(p.body)
+		ts := uint64(ubinary.NativeEndian.Uint32(p.body[4:]))<<32 | uint64(ubinary.NativeEndian.Uint32(p.body[8:]))
+		captured := ubinary.NativeEndian.Uint32(p.body[12:])
+		wantIface := uint32(captureIfaceSockets)
+		if i == 0 {
+			wantIface = captureIfaceFiles
+		}
+		if iface != wantIface || ts != 1_700_000_000_123_456_789 {
+			t.Errorf("packet %d: interface %d at %d, want %d at the exit time", i, iface, ts, wantIface)
+		}
+		if int(captured) > len(p.body)-pcapngEnhancedPacketHeader {
+			t.Errorf("packet %d: captured length %d exceeds the block", i, captured)
+		}
+	}
+
+	// Spans point to the payload in the file.
+	payload := func(spans []tracer.PayloadSpan) []byte {
+		var b []byte
+		for _, s := range spans {
+			b = append(b, data[s.Offset:s.Offset+int64(s.Size)]...)
+		}
+		return b
+	}
+	if len(fileSpans) != 1 || fileSpans[0].Offset != packets[0].off+pcapngEnhancedPacketHeader {
+		t.Errorf("file spans %+v, want one at the packet data %d", fileSpans, packets[0].off+pcapngEnhancedPacketHeader)
+	}
+	if got := payload(fileSpans); !bytes.Equal(got, file) {
+		t.Errorf("file payload %q, want %q", got, file)
+	}
+	if len(sockSpans) != 2 || sockSpans[0].Size != captureSegmentSize {
+		t.Fatalf("socket spans %+v, want two segments", sockSpans)
+	}
+	if got := payload(sockSpans); !bytes.Equal(got, big) {
+		t.Errorf("socket payload differs, got %d bytes, want %d", len(got), len(big))
+	}
+
+	// Socket payloads are wrapped into TCP over IPv4 between the real
+	// addresses, sequence numbers continue across segments.
+	for i, s := range sockSpans {
+		pkt := data[packets[1+i].off+pcapngEnhancedPacketHeader:]
+		if pkt[0] != 0x45 || pkt[9] != unix.IPPROTO_TCP {
+			t.Errorf("segment %d: IP header %x, want IPv4 TCP", i, pkt[:20])
+		}
+		tcp := pkt[20:]
+		local := conn.LocalAddr().(*net.TCPAddr)
+		remote := conn.RemoteAddr().(*net.TCPAddr)
+		if src, dst := binary.BigEndian.Uint16(tcp), binary.BigEndian.Uint16(tcp[2:]); int(src) != local.Port || int(dst) != remote.Port {
+			t.Errorf("segment %d: ports %d > %d, want %d > %d", i, src, dst, local.Port, remote.Port)
+		}
+		if seq, want := binary.BigEndian.Uint32(tcp[4:]), uint32(1+i*captureSegmentSize); seq != want {
+			t.Errorf("segment %d: seq %d, want %d", i, seq, want)
+		}
+		if hdr := s.Offset - (packets[1+i].off + pcapngEnhancedPacketHeader); hdr != 40 {
+			t.Errorf("segment %d: payload at %d in the packet, want after 40 bytes of headers", i, hdr)
+		}
+	}
+}
+
+// countingTask counts reads of the tracee memory.
+type countingTask struct {
+	memTask
+	reads int
+}
+
+func (t *countingTask) Read(addr strace.Addr, v any) (int, error) {
+	t.reads++
+	return t.memTask.Read(addr, v)
+}
+
+func TestPayloadCaptureFDs(t *testing.T) {
+	c, err := NewPayloadCapture(filepath.Join(t.TempDir(), "capture.pcapng"), []int32{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	task := &countingTask{memTask: memTask{pid: 1 << 30}}
+	write := func(fd uintptr) []tracer.PayloadSpan {
+		t.Helper()
+		data := []byte("hello")
+		call := &strace.SyscallEvent{Sysno: unix.SYS_WRITE}
+		call.Args[0].Value = fd
+		call.Args[1].Value = task.put(data)
+		call.Args[2].Value = uintptr(len(data))
+		call.Ret[0].Value = uintptr(len(data))
+		record := &strace.TraceRecord{PID: task.pid, Event: strace.SyscallExit, Syscall: call}
+		spans, err := c.Capture(task, record, 0)
+		if err != nil {
+			t.Fatalf("Capture: %s", err)
+		}
+		return spans
+	}
+
+	// The buffer of another fd isn't read.
+	if spans := write(6); spans != nil || task.reads != 0 {
+		t.Errorf("fd 6: got spans %+v after %d reads, want none", spans, task.reads)
+	}
+	if spans := write(5); len(spans) != 1 || task.reads == 0 {
+		t.Errorf("fd 5: got spans %+v after %d reads", spans, task.reads)
+	}
+}
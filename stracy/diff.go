@@ -0,0 +1,573 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+	"golang.org/x/sys/unix"
+)
+
+// TraceDiff is the difference between two traces.
+type TraceDiff struct {
+	Syscalls    []SyscallDelta
+	NewErrors   []ErrorPath
+	GoneErrors  []ErrorPath
+	NewFiles    []string
+	GoneFiles   []string
+	NewSockets  []string
+	GoneSockets []string
+	Sequences   []SequenceDiff
+}
+
+// SyscallDelta compares calls of a single syscall.
+type SyscallDelta struct {
+	Syscall        string
+	CountA, CountB int
+	TotalA, TotalB time.Duration
+	MeanA, MeanB   time.Duration
+}
+
+// ErrorPath is a failed call identified by its syscall, errno and path.
+type ErrorPath struct {
+	Syscall string
+	Errno   string
+	Path    string `json:",omitempty"`
+}
+
+// SequenceDiff is an aligned diff of the first syscalls of a process. Processes
+// are paired by the order of their first appearance in the traces.
+type SequenceDiff struct {
+	PIDA, PIDB int
+	Ops        []SequenceOp
+}
+
+// SequenceOp is a line of a sequence diff. Op is "=" for syscalls present in
+// both traces, "-" for syscalls only in A and "+" for syscalls only in B.
+type SequenceOp struct {
+	Op      string
+	Syscall string
+}
+
+// traceCall is an Event reduced to the fields the diff needs. It's filled in
+// the same way for recorded traces and for strace logs.
+type traceCall struct {
+	PID     int
+	Syscall string
+	Args    []string
+	Errno   string
+	Latency time.Duration
+}
+
+func diffMain(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the diff as JSON")
+	seqLen := fs.Int("n", 50, "compare the first `N` syscalls of each process")
+	redactor := redactFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s diff [flags] A B\n\nA and B are recorded traces (JSON) or strace logs.\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	redact, err := redactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+
+	a, err := loadTraceCalls(fs.Arg(0), redact)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't read %s: %s\n", fs.Arg(0), err)
+		return 1
+	}
+	b, err := loadTraceCalls(fs.Arg(1), redact)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't read %s: %s\n", fs.Arg(1), err)
+		return 1
+	}
+
+	d := diffTraces(a, b, *seqLen)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(d)
+	} else {
+		err = d.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadTraceCalls reads a recorded trace or an strace log.
+func loadTraceCalls(path string, redact *tracer.Redactor) ([]traceCall, error) {
+	events, textLog, err := loadEvents(path, redact)
+	if err != nil {
+		return nil, err
+	}
+	calls := make([]traceCall, 0, len(events))
+	for _, e := range events {
+		if e.Ph != "X" || e.Args.Syscall == "" {
+			continue
+		}
+		calls = append(calls, newTraceCall(e, textLog))
+	}
+	return calls, nil
+}
+
+// loadEvents reads events from a JSON trace (an object with traceEvents, an
+// array or JSON lines) or from an strace log. Secrets are replaced by redact.
+func loadEvents(path string, redact *tracer.Redactor) (events []tracer.Event, textLog bool, err error) {
+	events, textLog, err = readEvents(path)
+	for i := range events {
+		redact.Redact(&events[i])
+	}
+	return events, textLog, err
+}
+
+func readEvents(path string) (events []tracer.Event, textLog bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		trace, err := getTracesFromFile(path)
+		return trace.Event, true, err
+	}
+
+	if data[0] == '[' {
+		err = json.Unmarshal(data, &events)
+		return events, false, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var v struct {
+			tracer.Event
+			TraceEvents []tracer.Event `json:"traceEvents"`
+		}
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return events, false, err
+		}
+		if v.TraceEvents != nil {
+			events = append(events, v.TraceEvents...)
+		} else {
+			events = append(events, v.Event)
+		}
+	}
+	return events, false, nil
+}
+
+var (
+	reLiveErrno   = regexp.MustCompile(`\((\d+)\)$`)
+	reStraceErrno = regexp.MustCompile(`^-1 (E[A-Z0-9]+)`)
+)
+
+func newTraceCall(e tracer.Event, textLog bool) traceCall {
+	c := traceCall{
+		PID:     e.PID,
+		Syscall: e.Args.Syscall,
+		Latency: time.Duration(e.Duration),
+	}
+	if c.Latency == 0 {
+		c.Latency = time.Duration(e.Args.Duration * float64(time.Second))
+	}
+
+	result := fmt.Sprint(e.Args.Result)
+	if textLog {
+		var raw strings.Builder
+		for _, a := range e.Args.SyscallArgs {
+			raw.WriteString(fmt.Sprint(a))
+		}
+		c.Args = splitStraceArgs(raw.String())
+		if m := reStraceErrno.FindStringSubmatch(result); m != nil {
+			c.Errno = m[1]
+		}
+	} else {
+		c.Args = make([]string, len(e.Args.SyscallArgs))
+		for i, a := range e.Args.SyscallArgs {
+			c.Args[i] = argString(a)
+		}
+		if e.Cat == "failed" {
+			if m := reLiveErrno.FindStringSubmatch(result); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				c.Errno = unix.ErrnoName(syscall.Errno(n))
+			}
+		}
+	}
+	return c
+}
+
+func argString(a any) string {
+	switch a := a.(type) {
+	case string:
+		return a
+	case float64:
+		return strconv.FormatFloat(a, 'f', -1, 64)
+	}
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+// splitStraceArgs splits arguments of an strace log line on top level commas.
+func splitStraceArgs(s string) []string {
+	var args []string
+	depth, start, quoted := 0, 0, false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" || len(args) > 0 {
+		args = append(args, rest)
+	}
+	return args
+}
+
+// argsOfType returns arguments of a call that have the given type.
+func (c traceCall) argsOfType(types ...syscalls.Type) []string {
+	si, ok := syscalls.ByName(c.Syscall)
+	if !ok {
+		return nil
+	}
+	var args []string
+	for i, t := range si.ArgTypes {
+		for _, want := range types {
+			if t == want && i < len(c.Args) {
+				args = append(args, c.Args[i])
+			}
+		}
+	}
+	return args
+}
+
+func (c traceCall) path() string {
+	paths := c.argsOfType(syscalls.Path)
+	if len(paths) == 0 {
+		return ""
+	}
+	p := paths[0]
+	if unquoted, err := strconv.Unquote(p); err == nil {
+		p = unquoted
+	}
+	return p
+}
+
+// files returns the path touched by a successful call.
+func (c traceCall) files() []string {
+	if p := c.path(); p != "" && c.Errno == "" {
+		return []string{p}
+	}
+	return nil
+}
+
+var reArgAddr = regexp.MustCompile(`^0x[0-9a-f]+ `)
+
+func (c traceCall) sockets() []string {
+	var socks []string
+	for _, s := range c.argsOfType(syscalls.SockAddr, syscalls.PostSockAddr) {
+		s = reArgAddr.ReplaceAllString(s, "")
+		if s != "" && s != "null" && s != "NULL" {
+			socks = append(socks, s)
+		}
+	}
+	return socks
+}
+
+func diffTraces(a, b []traceCall, seqLen int) TraceDiff {
+	var d TraceDiff
+
+	statsA, statsB := syscallStats(a), syscallStats(b)
+	for name := range union(statsA, statsB) {
+		sa, sb := statsA[name], statsB[name]
+		d.Syscalls = append(d.Syscalls, SyscallDelta{
+			Syscall: name,
+			CountA:  sa.Count,
+			CountB:  sb.Count,
+			TotalA:  sa.Total,
+			TotalB:  sb.Total,
+			MeanA:   sa.mean(),
+			MeanB:   sb.mean(),
+		})
+	}
+	sort.Slice(d.Syscalls, func(i, j int) bool {
+		di, dj := abs(d.Syscalls[i].CountB-d.Syscalls[i].CountA), abs(d.Syscalls[j].CountB-d.Syscalls[j].CountA)
+		if di != dj {
+			return di > dj
+		}
+		return d.Syscalls[i].Syscall < d.Syscalls[j].Syscall
+	})
+
+	errsA, errsB := errorPaths(a), errorPaths(b)
+	d.NewErrors, d.GoneErrors = sortedErrorPaths(errsB, errsA), sortedErrorPaths(errsA, errsB)
+
+	filesA, filesB := touched(a, traceCall.files), touched(b, traceCall.files)
+	d.NewFiles, d.GoneFiles = sortedKeys(filesB, filesA), sortedKeys(filesA, filesB)
+
+	socksA, socksB := touched(a, traceCall.sockets), touched(b, traceCall.sockets)
+	d.NewSockets, d.GoneSockets = sortedKeys(socksB, socksA), sortedKeys(socksA, socksB)
+
+	seqA, pidsA := sequences(a, seqLen)
+	seqB, pidsB := sequences(b, seqLen)
+	for i := 0; i < len(pidsA) && i < len(pidsB); i++ {
+		d.Sequences = append(d.Sequences, SequenceDiff{
+			PIDA: pidsA[i],
+			PIDB: pidsB[i],
+			Ops:  diffSequence(seqA[pidsA[i]], seqB[pidsB[i]]),
+		})
+	}
+	return d
+}
+
+// syscallStat is the count and latency of a syscall in one trace.
+type syscallStat struct {
+	Count int
+	Total time.Duration
+}
+
+func (s syscallStat) mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// syscallStats returns stats of every syscall in calls.
+func syscallStats(calls []traceCall) map[string]syscallStat {
+	stats := make(map[string]syscallStat)
+	for _, c := range calls {
+		s := stats[c.Syscall]
+		s.Count++
+		s.Total += c.Latency
+		stats[c.Syscall] = s
+	}
+	return stats
+}
+
+func errorPaths(calls []traceCall) map[ErrorPath]bool {
+	errs := make(map[ErrorPath]bool)
+	for _, c := range calls {
+		if c.Errno != "" {
+			errs[ErrorPath{Syscall: c.Syscall, Errno: c.Errno, Path: c.path()}] = true
+		}
+	}
+	return errs
+}
+
+// sortedErrorPaths returns error paths present in a and missing in b.
+func sortedErrorPaths(a, b map[ErrorPath]bool) []ErrorPath {
+	var errs []ErrorPath
+	for e := range a {
+		if !b[e] {
+			errs = append(errs, e)
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Path != errs[j].Path {
+			return errs[i].Path < errs[j].Path
+		}
+		if errs[i].Syscall != errs[j].Syscall {
+			return errs[i].Syscall < errs[j].Syscall
+		}
+		return errs[i].Errno < errs[j].Errno
+	})
+	return errs
+}
+
+func touched(calls []traceCall, fn func(traceCall) []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, c := range calls {
+		for _, s := range fn(c) {
+			set[s] = true
+		}
+	}
+	return set
+}
+
+// sortedKeys returns keys present in a and missing in b.
+func sortedKeys(a, b map[string]bool) []string {
+	var keys []string
+	for k := range a {
+		if !b[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func union[V any](a, b map[string]V) map[string]bool {
+	u := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		u[k] = true
+	}
+	for k := range b {
+		u[k] = true
+	}
+	return u
+}
+
+// sequences returns the first n syscall names of every process and the
+// processes in the order of their first appearance.
+func sequences(calls []traceCall, n int) (map[int][]string, []int) {
+	seqs := make(map[int][]string)
+	var pids []int
+	for _, c := range calls {
+		seq, ok := seqs[c.PID]
+		if !ok {
+			pids = append(pids, c.PID)
+		}
+		if len(seq) < n {
+			seqs[c.PID] = append(seq, c.Syscall)
+		}
+	}
+	return seqs, pids
+}
+
+// diffSequence aligns two sequences by their longest common subsequence.
+func diffSequence(a, b []string) []SequenceOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]SequenceOp, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, SequenceOp{Op: "=", Syscall: a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, SequenceOp{Op: "-", Syscall: a[i]})
+			i++
+		default:
+			ops = append(ops, SequenceOp{Op: "+", Syscall: b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// Changed reports whether the sequences differ.
+func (s SequenceDiff) Changed() bool {
+	for _, op := range s.Ops {
+		if op.Op != "=" {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteText writes a human readable diff.
+func (d TraceDiff) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "syscalls:")
+	tw := tabwriter.NewWriter(bw, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "syscall\tcount A\tcount B\tdelta\tmean A\tmean B\tdelta")
+	for _, s := range d.Syscalls {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%+d\t%s\t%s\t%s\n",
+			s.Syscall, s.CountA, s.CountB, s.CountB-s.CountA,
+			s.MeanA, s.MeanB, signedDuration(s.MeanB-s.MeanA))
+	}
+	tw.Flush()
+
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(bw, "\n%s:\n", title)
+		for _, item := range items {
+			fmt.Fprintf(bw, "  %s\n", item)
+		}
+	}
+	errStrings := func(errs []ErrorPath) []string {
+		var ss []string
+		for _, e := range errs {
+			ss = append(ss, strings.TrimSpace(fmt.Sprintf("%s %s %s", e.Syscall, e.Errno, e.Path)))
+		}
+		return ss
+	}
+	writeList("new errors", errStrings(d.NewErrors))
+	writeList("disappeared errors", errStrings(d.GoneErrors))
+	writeList("new files", d.NewFiles)
+	writeList("disappeared files", d.GoneFiles)
+	writeList("new sockets", d.NewSockets)
+	writeList("disappeared sockets", d.GoneSockets)
+
+	for _, s := range d.Sequences {
+		fmt.Fprintf(bw, "\nsequence of pid %d (A) vs pid %d (B):", s.PIDA, s.PIDB)
+		if !s.Changed() {
+			fmt.Fprintln(bw, " identical")
+			continue
+		}
+		fmt.Fprintln(bw)
+		for _, op := range s.Ops {
+			prefix := " "
+			if op.Op != "=" {
+				prefix = op.Op
+			}
+			fmt.Fprintf(bw, "%s %s\n", prefix, op.Syscall)
+		}
+	}
+
+	return bw.Flush()
+}
+
+func signedDuration(d time.Duration) string {
+	if d > 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
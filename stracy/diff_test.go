@@ -0,0 +1,144 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+func TestDiffTraces(t *testing.T) {
+	call := func(pid int, name string, latency time.Duration, errno string, args ...string) traceCall {
+		return traceCall{PID: pid, Syscall: name, Args: args, Errno: errno, Latency: latency}
+	}
+	a := []traceCall{
+		call(1, "openat", 10*time.Microsecond, "", "AT_FDCWD", `"/etc/passwd"`, "O_RDONLY"),
+		call(1, "read", 2*time.Microsecond, "", "3", `""`, "4096"),
+		call(1, "read", 4*time.Microsecond, "", "3", `""`, "4096"),
+		call(1, "openat", 1*time.Microsecond, "ENOENT", "AT_FDCWD", `"/etc/app.conf"`, "O_RDONLY"),
+		call(1, "connect", 5*time.Microsecond, "", "4", `{sa_family=AF_INET, sin_port=htons(80), sin_addr=inet_addr("10.0.0.1")}`, "16"),
+		call(1, "close", time.Microsecond, "", "3"),
+		call(2, "getpid", time.Microsecond, ""),
+	}
+	b := []traceCall{
+		call(7, "openat", 30*time.Microsecond, "", "AT_FDCWD", `"/etc/passwd"`, "O_RDONLY"),
+		call(7, "read", 6*time.Microsecond, "", "3", `""`, "4096"),
+		call(7, "openat", 1*time.Microsecond, "EACCES", "AT_FDCWD", `"/etc/shadow"`, "O_RDONLY"),
+		call(7, "openat", 2*time.Microsecond, "", "AT_FDCWD", `"/etc/hosts"`, "O_RDONLY"),
+		call(7, "mmap", time.Microsecond, "", "0", "4096"),
+		call(7, "close", time.Microsecond, "", "3"),
+		call(8, "getpid", 3*time.Microsecond, ""),
+		call(9, "exit_group", 0, "", "0"),
+	}
+	d := diffTraces(a, b, 50)
+
+	// Sorted by the count delta, then by name.
+	wantSyscalls := []SyscallDelta{
+		{Syscall: "connect", CountA: 1, TotalA: 5 * time.Microsecond, MeanA: 5 * time.Microsecond},
+		{Syscall: "exit_group", CountB: 1},
+		{Syscall: "mmap", CountB: 1, TotalB: time.Microsecond, MeanB: time.Microsecond},
+		{Syscall: "openat", CountA: 2, CountB: 3, TotalA: 11 * time.Microsecond, TotalB: 33 * time.Microsecond,
+			MeanA: 5500 * time.Nanosecond, MeanB: 11 * time.Microsecond},
+		{Syscall: "read", CountA: 2, CountB: 1, TotalA: 6 * time.Microsecond, TotalB: 6 * time.Microsecond,
+			MeanA: 3 * time.Microsecond, MeanB: 6 * time.Microsecond},
+		{Syscall: "close", CountA: 1, CountB: 1, TotalA: time.Microsecond, TotalB: time.Microsecond,
+			MeanA: time.Microsecond, MeanB: time.Microsecond},
+		{Syscall: "getpid", CountA: 1, CountB: 1, TotalA: time.Microsecond, TotalB: 3 * time.Microsecond,
+			MeanA: time.Microsecond, MeanB: 3 * time.Microsecond},
+	}
+	if !reflect.DeepEqual(d.Syscalls, wantSyscalls) {
+		t.Errorf("syscalls\n%+v\nwant\n%+v", d.Syscalls, wantSyscalls)
+	}
+
+	if want := []ErrorPath{{Syscall: "openat", Errno: "EACCES", Path: "/etc/shadow"}}; !reflect.DeepEqual(d.NewErrors, want) {
+		t.Errorf("new errors %+v, want %+v", d.NewErrors, want)
+	}
+	if want := []ErrorPath{{Syscall: "openat", Errno: "ENOENT", Path: "/etc/app.conf"}}; !reflect.DeepEqual(d.GoneErrors, want) {
+		t.Errorf("gone errors %+v, want %+v", d.GoneErrors, want)
+	}
+	// Failed opens don't touch files.
+	if want := []string{"/etc/hosts"}; !reflect.DeepEqual(d.NewFiles, want) {
+		t.Errorf("new files %v, want %v", d.NewFiles, want)
+	}
+	if len(d.GoneFiles) != 0 {
+		t.Errorf("gone files %v, want none", d.GoneFiles)
+	}
+	if len(d.NewSockets) != 0 || len(d.GoneSockets) != 1 || !strings.Contains(d.GoneSockets[0], "10.0.0.1") {
+		t.Errorf("sockets: new %v, gone %v, want the connect of A gone", d.NewSockets, d.GoneSockets)
+	}
+
+	// Processes pair by their order, the third process of B has no pair.
+	wantSeqs := []SequenceDiff{
+		{PIDA: 1, PIDB: 7, Ops: []SequenceOp{
+			{"=", "openat"}, {"=", "read"}, {"-", "read"}, {"=", "openat"},
+			{"-", "connect"}, {"+", "openat"}, {"+", "mmap"}, {"=", "close"},
+		}},
+		{PIDA: 2, PIDB: 8, Ops: []SequenceOp{{"=", "getpid"}}},
+	}
+	if !reflect.DeepEqual(d.Sequences, wantSeqs) {
+		t.Errorf("sequences\n%+v\nwant\n%+v", d.Sequences, wantSeqs)
+	}
+	if !d.Sequences[0].Changed() || d.Sequences[1].Changed() {
+		t.Errorf("changed %v, %v, want true, false", d.Sequences[0].Changed(), d.Sequences[1].Changed())
+	}
+
+	// Only the first n syscalls of a process are compared.
+	d = diffTraces(a, b, 1)
+	if want := []SequenceOp{{"=", "openat"}}; !reflect.DeepEqual(d.Sequences[0].Ops, want) {
+		t.Errorf("sequence of 1 syscall %+v, want %+v", d.Sequences[0].Ops, want)
+	}
+}
+
+func TestDiffSequence(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", ""},
+		{"a b c", "a b c", "=a =b =c"},
+		{"a b c", "", "-a -b -c"},
+		{"", "a b", "+a +b"},
+		{"a b c", "a c", "=a -b =c"},
+		{"a c", "a b c", "=a +b =c"},
+		{"a b c d", "b c e", "-a =b =c -d +e"},
+		{"x a b", "a b y", "-x =a =b +y"},
+	}
+	for _, tt := range tests {
+		var ops []string
+		for _, op := range diffSequence(strings.Fields(tt.a), strings.Fields(tt.b)) {
+			ops = append(ops, op.Op+op.Syscall)
+		}
+		if got := strings.Join(ops, " "); got != tt.want {
+			t.Errorf("%q vs %q: got %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNewTraceCall(t *testing.T) {
+	live := tracer.Event{Cat: "failed", PID: 5, Duration: int(3 * time.Microsecond), Args: tracer.Args{
+		Syscall:     "openat",
+		SyscallArgs: []any{"AT_FDCWD", "/etc/shadow", "O_RDONLY"},
+		Result:      `"permission denied" (13)`,
+	}}
+	c := newTraceCall(live, false)
+	if c.PID != 5 || c.Errno != "EACCES" || c.Latency != 3*time.Microsecond || c.path() != "/etc/shadow" {
+		t.Errorf("live: got %+v, path %q", c, c.path())
+	}
+
+	// strace logs have the args as one string and the duration in seconds.
+	text := tracer.Event{Cat: "failed", PID: 5, Args: tracer.Args{
+		Syscall:     "openat",
+		SyscallArgs: []any{`AT_FDCWD, "/etc/a, b", O_RDONLY`},
+		Result:      "-1 ENOENT (No such file or directory)",
+		Duration:    0.000002,
+	}}
+	c = newTraceCall(text, true)
+	if want := []string{"AT_FDCWD", `"/etc/a, b"`, "O_RDONLY"}; !reflect.DeepEqual(c.Args, want) {
+		t.Errorf("text: args %q, want %q", c.Args, want)
+	}
+	if c.Errno != "ENOENT" || c.Latency != 2*time.Microsecond || c.path() != "/etc/a, b" {
+		t.Errorf("text: got %+v, path %q", c, c.path())
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/iimos/play/stracy/tracer"
 )
 
 var (
@@ -29,36 +31,17 @@ var regexes = []struct {
 	{"etc", regexp.MustCompile(reEtc)},
 }
 
-// Format https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU/preview
-type Event struct {
-	Name      string `json:"name"`
-	Cat       string `json:"cat"`
-	Ph        string `json:"ph"`
-	PID       int    `json:"pid"`
-	TID       int    `json:"tid"`
-	Timestamp int    `json:"ts"`
-	Duration  int    `json:"dur,omitempty"`
-	Args      Args   `json:"args"`
-}
-
-type Args struct {
-	Syscall     string
-	SyscallArgs []interface{}
-	Result      interface{}
-	Duration    float64
-}
-
 type StraceParser struct {
-	prevUnfinished map[int]Event
+	prevUnfinished map[int]tracer.Event
 }
 
 func NewStraceParser() *StraceParser {
 	return &StraceParser{
-		prevUnfinished: make(map[int]Event),
+		prevUnfinished: make(map[int]tracer.Event),
 	}
 }
 
-func (p *StraceParser) ParseLine(line string) (e Event, complete bool, err error) {
+func (p *StraceParser) ParseLine(line string) (e tracer.Event, complete bool, err error) {
 	var cat, pid, ts, syscall, args, ret, duration string
 	var m []string
 
@@ -142,8 +125,8 @@ func (p *StraceParser) ParseLine(line string) (e Event, complete bool, err error
 }
 
 type TraceEvents struct {
-	Event           []Event `json:"traceEvents"`
-	DisplayTimeUnit string  `json:"displayTimeUnit"` // “ms” or “ns”
+	Event           []tracer.Event `json:"traceEvents"`
+	DisplayTimeUnit string         `json:"displayTimeUnit"` // “ms” or “ns”
 }
 
 func parseTime(ts string) (time.Time, error) {
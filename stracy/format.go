@@ -0,0 +1,609 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+	"golang.org/x/sys/unix"
+)
+
+// TimeFormat selects the timestamp printed at the start of strace lines.
+type TimeFormat int
+
+const (
+	NoTime    TimeFormat = iota
+	WallTime             // -tt: 15:04:05.123456
+	EpochTime            // -ttt: 1669729913.123456
+)
+
+// StraceFormatter renders events in the text syntax of strace, so the output
+// is familiar to strace users. Lines formatted with PIDs and EpochTime can be
+// read back by StraceParser.
+type StraceFormatter struct {
+	PIDs      bool       // -f: prefix lines with the thread id
+	Time      TimeFormat // -tt, -ttt: print the syscall start time
+	Durations bool       // -T: append the syscall duration
+	FDPaths   bool       // -y: print paths of file descriptors
+}
+
+// straceFlags registers strace output flags on fs. The returned function
+// creates a formatter from them after fs is parsed.
+func straceFlags(fs *flag.FlagSet) func() StraceFormatter {
+	pids := fs.Bool("f", false, "strace format: prefix lines with the thread id (children are always traced)")
+	tt := fs.Bool("tt", false, "strace format: print the wall clock time with microseconds")
+	ttt := fs.Bool("ttt", false, "strace format: print the time since the epoch with microseconds")
+	durations := fs.Bool("T", false, "strace format: print syscall durations")
+	paths := fs.Bool("y", false, "strace format: print paths of file descriptors")
+
+	return func() StraceFormatter {
+		f := StraceFormatter{PIDs: *pids, Durations: *durations, FDPaths: *paths}
+		switch {
+		case *ttt:
+			f.Time = EpochTime
+		case *tt:
+			f.Time = WallTime
+		}
+		return f
+	}
+}
+
+// addressResults are syscalls returning an address, strace prints their
+// results in hex and other results in decimal.
+var addressResults = map[string]bool{"mmap": true, "mremap": true, "brk": true, "shmat": true}
+
+// Format renders a syscall event as a line without the trailing newline.
+// Other events (counters, instant events) have no strace counterpart, ok is
+// false for them.
+func (f StraceFormatter) Format(e tracer.Event) (line string, ok bool) {
+	if e.Ph != "X" || e.Args.Syscall == "" {
+		return "", false
+	}
+	si, known := syscalls.ByName(e.Args.Syscall)
+
+	var b strings.Builder
+	if f.PIDs {
+		fmt.Fprintf(&b, "%-5d ", e.TID)
+	}
+	start, dur := eventTimes(e)
+	switch f.Time {
+	case WallTime:
+		b.WriteString(start.Format("15:04:05.000000 "))
+	case EpochTime:
+		fmt.Fprintf(&b, "%d.%06d ", start.Unix(), start.Nanosecond()/1000)
+	}
+
+	b.WriteString(e.Args.Syscall)
+	b.WriteByte('(')
+	for i, v := range e.Args.SyscallArgs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		typ := syscalls.Hex
+		if known && i < len(si.ArgTypes) {
+			typ = si.ArgTypes[i]
+		}
+		if data, ok := generic(v).(string); ok && (typ == syscalls.ReadBuffer || typ == syscalls.WriteBuffer) {
+			b.WriteString(buffer(data, transferSize(e, typ, i)))
+			continue
+		}
+		b.WriteString(f.arg(typ, v))
+	}
+	b.WriteString(") = ")
+	b.WriteString(f.result(e, si, known))
+
+	if f.Durations {
+		fmt.Fprintf(&b, " <%d.%06d>", dur/time.Second, dur%time.Second/time.Microsecond)
+	}
+	return b.String(), true
+}
+
+// eventTimes returns the start and the duration of a syscall. Events are
+// stamped at the start, events of the tracer have Duration, events of strace
+// logs have Args.Duration in seconds.
+func eventTimes(e tracer.Event) (time.Time, time.Duration) {
+	start := time.Unix(0, int64(e.Timestamp))
+	if e.Duration != 0 {
+		return start, time.Duration(e.Duration)
+	}
+	return start, time.Duration(math.Round(e.Args.Duration * float64(time.Second)))
+}
+
+func (f StraceFormatter) result(e tracer.Event, si syscalls.SyscallInfo, known bool) string {
+	if e.Failed() || e.Args.Errno != 0 {
+		errno := e.Args.Errno
+		if m := reLiveErrno.FindStringSubmatch(fmt.Sprint(e.Args.Result)); errno == 0 && m != nil {
+			n, _ := strconv.Atoi(m[1])
+			errno = syscall.Errno(n)
+		}
+		if name := unix.ErrnoName(errno); name != "" {
+			msg := []rune(errno.Error())
+			msg[0] = unicode.ToUpper(msg[0])
+			return fmt.Sprintf("-1 %s (%s)", name, string(msg))
+		}
+		return fmt.Sprint(e.Args.Result) // an strace log keeps "-1 ENOENT (...)"
+	}
+
+	typ := syscalls.Hex
+	if known {
+		typ = si.ReturnType
+	}
+	if s, ok := e.Args.Result.(string); ok && typ == syscalls.Hex && !addressResults[e.Args.Syscall] {
+		if n, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64); err == nil {
+			return strconv.FormatInt(int64(n), 10)
+		}
+	}
+	return f.arg(typ, e.Args.Result)
+}
+
+var (
+	rePipeFDs       = regexp.MustCompile(`^0x[0-9a-f]+ \[(-?\d+) (-?\d+)\]$`)
+	reUtimeTimespec = regexp.MustCompile(`^\{sec=(-?\d+) nsec=(\w+)\}$`)
+	reItimer        = regexp.MustCompile(`^\{interval=(\S+), value=(\S+)\}$`)
+	reIOVecs        = regexp.MustCompile(`^0x[0-9a-f]+ (\{.*\})$`)
+	reIOVec         = regexp.MustCompile(`\{base=(0x[0-9a-f]+), len=(\d+)(?:, ("(?:[^"\\]|\\.)*")(\.\.\.)?)?[^{}]*\}(?:, |$)`)
+)
+
+// arg renders a decoded argument of the given type.
+func (f StraceFormatter) arg(typ syscalls.Type, v any) string {
+	v = generic(v)
+	if s, ok := v.(string); ok {
+		switch typ {
+		case syscalls.Path, syscalls.PostPath, syscalls.String:
+			return quoteString(s)
+		case syscalls.Oct:
+			if oct, ok := strings.CutPrefix(s, "0o"); ok {
+				return "0" + oct
+			}
+		case syscalls.Mode:
+			if perm, ok := parsePerm(s); ok {
+				return fmt.Sprintf("%#o", perm)
+			}
+		case syscalls.Timespec, syscalls.PostTimespec:
+			if d, err := time.ParseDuration(s); err == nil {
+				return timeStruct(d, "tv_nsec")
+			}
+		case syscalls.UTimeTimespec:
+			if m := reUtimeTimespec.FindStringSubmatch(s); m != nil {
+				return fmt.Sprintf("{tv_sec=%s, tv_nsec=%s}", m[1], m[2])
+			}
+		case syscalls.ItimerVal, syscalls.PostItimerVal, syscalls.ItimerSpec, syscalls.PostItimerSpec:
+			if m := reItimer.FindStringSubmatch(s); m != nil {
+				unit := "tv_nsec"
+				if typ == syscalls.ItimerVal || typ == syscalls.PostItimerVal {
+					unit = "tv_usec"
+				}
+				interval, err1 := time.ParseDuration(m[1])
+				value, err2 := time.ParseDuration(m[2])
+				if err1 == nil && err2 == nil {
+					return fmt.Sprintf("{it_interval=%s, it_value=%s}", timeStruct(interval, unit), timeStruct(value, unit))
+				}
+			}
+		case syscalls.PipeFDs:
+			if m := rePipeFDs.FindStringSubmatch(s); m != nil {
+				return fmt.Sprintf("[%s, %s]", m[1], m[2])
+			}
+		case syscalls.ReadIOVec, syscalls.WriteIOVec, syscalls.IOVec:
+			if iov, ok := iovecs(s); ok {
+				return iov
+			}
+		}
+	}
+	if m, ok := v.(map[string]any); ok {
+		switch typ {
+		case syscalls.Timeval:
+			return fmt.Sprintf("{tv_sec=%s, tv_usec=%s}", f.value(m["Sec"]), f.value(m["Usec"]))
+		case syscalls.Utimbuf:
+			return fmt.Sprintf("{actime=%s, modtime=%s}", f.value(m["Actime"]), f.value(m["Modtime"]))
+		}
+	}
+	return f.value(v)
+}
+
+// iovecs renders an iovec array decoded as
+// `0x1010 {base=0x1000, len=3, "abc"}, {base=0x1008, len=3}` like
+// `[{iov_base="abc", iov_len=3}, {iov_base=0x1008, iov_len=3}]`.
+func iovecs(s string) (string, bool) {
+	m := reIOVecs.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	items := reIOVec.FindAllStringSubmatch(m[1], -1)
+	var n int
+	iov := make([]string, len(items))
+	for i, it := range items {
+		n += len(it[0])
+		base := it[1]
+		if it[3] != "" {
+			data, err := strconv.Unquote(it[3])
+			if err != nil {
+				return "", false
+			}
+			base = quoteString(data) + it[4]
+		}
+		iov[i] = fmt.Sprintf("{iov_base=%s, iov_len=%s}", base, it[2])
+	}
+	if n != len(m[1]) {
+		return "", false
+	}
+	return "[" + strings.Join(iov, ", ") + "]", true
+}
+
+// timeStruct renders a duration as struct timespec (unit tv_nsec) or struct
+// timeval (unit tv_usec).
+func timeStruct(d time.Duration, unit string) string {
+	frac := d % time.Second
+	if unit == "tv_usec" {
+		frac /= time.Microsecond
+	}
+	return fmt.Sprintf("{tv_sec=%d, %s=%d}", d/time.Second, unit, frac)
+}
+
+// value renders a decoded value by its shape, decoded arguments ("Type" and
+// "Value" objects) by their type.
+func (f StraceFormatter) value(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		if v == "null" {
+			return "NULL"
+		}
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		return "[" + f.values(v, ", ") + "]"
+	case map[string]any:
+		if typ, ok := v["Type"].(string); ok {
+			if val, ok := v["Value"]; ok {
+				formated, _ := v["Formated"].(map[string]any)
+				return f.typed(typ, val, formated)
+			}
+		}
+		return f.structure(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func (f StraceFormatter) values(vs []any, sep string) string {
+	s := make([]string, len(vs))
+	for i, v := range vs {
+		s[i] = f.value(v)
+	}
+	return strings.Join(s, sep)
+}
+
+// typed renders a decoded argument, see syscalls.Arg.
+func (f StraceFormatter) typed(typ string, v any, formated map[string]any) string {
+	switch typ {
+	case "flags":
+		vs, _ := v.([]any)
+		if len(vs) == 0 {
+			return "0"
+		}
+		return f.values(vs, "|")
+	case "fd":
+		fd := f.value(v)
+		if fd == "-100" {
+			fd = "AT_FDCWD"
+		}
+		if p, ok := formated["path"].(string); ok && f.FDPaths {
+			fd += "<" + p + ">"
+		}
+		return fd
+	case "string_vector":
+		vs, _ := v.([]any)
+		s := make([]string, len(vs))
+		for i, x := range vs {
+			s[i] = quoteString(fmt.Sprint(x))
+		}
+		if more, ok := formated["more"]; ok {
+			s = append(s, fmt.Sprintf("... /* %s more */", f.value(more)))
+		}
+		return "[" + strings.Join(s, ", ") + "]"
+	case "fd_set":
+		vs, _ := v.([]any)
+		return "[" + f.values(vs, " ") + "]"
+	case "stat":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return f.value(v)
+		}
+		mode, _ := intValue(m["Mode"])
+		return fmt.Sprintf("{st_mode=%s, st_size=%s, ...}", statMode(uint32(mode)), f.value(m["Size"]))
+	case "dirents":
+		vs, _ := v.([]any)
+		s := make([]string, len(vs))
+		for i, d := range vs {
+			m, ok := d.(map[string]any)
+			if !ok {
+				s[i] = f.value(d)
+				continue
+			}
+			name, _ := m["Name"].(string)
+			s[i] = fmt.Sprintf("{d_ino=%s, d_off=%s, d_reclen=%s, d_type=%s, d_name=%s}",
+				f.value(m["Ino"]), f.value(m["Off"]), f.value(m["Reclen"]), f.value(m["Type"]), quoteString(name))
+		}
+		return "[" + strings.Join(s, ", ") + "]"
+	case "msghdr":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return f.value(v)
+		}
+		iov := f.value(formated["Iov"])
+		if s, ok := iovecs(iov); ok {
+			iov = s
+		}
+		return fmt.Sprintf("{msg_name=%s, msg_namelen=%s, msg_iov=%s, msg_iovlen=%s, msg_control=%s, msg_controllen=%s, msg_flags=%s}",
+			f.value(formated["Name"]), f.value(m["NameLen"]), iov, f.value(m["IovLen"]),
+			f.value(formated["Control"]), f.value(m["ControlLen"]), f.value(m["Flags"]))
+	case "stack_t":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return f.value(v)
+		}
+		sp, _ := intValue(m["Ss_sp"])
+		flags, _ := intValue(m["Ss_flags"])
+		return fmt.Sprintf("{ss_sp=%#x, ss_flags=%s, ss_size=%s}", sp, stackFlags(flags), f.value(m["Ss_size"]))
+	case "utsname":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return f.value(v)
+		}
+		s := make([]string, len(utsnameFields))
+		for i, k := range utsnameFields {
+			name, _ := m[k].(string)
+			s[i] = strings.ToLower(k) + "=" + quoteString(name)
+		}
+		return "{" + strings.Join(s, ", ") + "}"
+	case "rusage":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return f.value(v)
+		}
+		s := make([]string, len(rusageFields))
+		for i, k := range rusageFields {
+			x := f.value(m[k])
+			if tv, ok := m[k].(map[string]any); ok {
+				x = fmt.Sprintf("{tv_sec=%s, tv_usec=%s}", f.value(tv["Sec"]), f.value(tv["Usec"]))
+			}
+			s[i] = "ru_" + strings.ToLower(k) + "=" + x
+		}
+		return "{" + strings.Join(s, ", ") + "}"
+	case "tcp_info":
+		m, ok := v.(map[string]any)
+		if !ok {
+			return f.value(v)
+		}
+		fields := make(map[string]any, len(m))
+		for k, x := range m {
+			fields["Tcpi"+k] = x
+		}
+		return f.structure(fields)
+	}
+	return f.value(v)
+}
+
+// utsnameFields and rusageFields are the fields of struct utsname and
+// struct rusage in the order strace prints them.
+var (
+	utsnameFields = []string{"Sysname", "Nodename", "Release", "Version", "Machine", "Domainname"}
+	rusageFields  = []string{"Utime", "Stime", "Maxrss", "Ixrss", "Idrss", "Isrss", "Minflt", "Majflt",
+		"Nswap", "Inblock", "Oublock", "Msgsnd", "Msgrcv", "Nsignals", "Nvcsw", "Nivcsw"}
+)
+
+// stackFlags renders ss_flags of stack_t, SS_ONSTACK and SS_DISABLE are
+// from uapi/linux/signal.h.
+func stackFlags(flags int64) string {
+	const ssOnStack, ssDisable = 1, 2
+	if flags == 0 {
+		return "0"
+	}
+	var s []string
+	if flags&ssOnStack != 0 {
+		s = append(s, "SS_ONSTACK")
+	}
+	if flags&ssDisable != 0 {
+		s = append(s, "SS_DISABLE")
+	}
+	if rest := flags &^ (ssOnStack | ssDisable); rest != 0 {
+		s = append(s, fmt.Sprintf("%#x", rest))
+	}
+	return strings.Join(s, "|")
+}
+
+// structure renders an object like strace prints structs: {key=value, ...}
+// with snake_case keys, sorted as the field order is lost in JSON.
+func (f StraceFormatter) structure(m map[string]any) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = snakeCase(k) + "=" + f.value(m[k])
+	}
+	return "{" + strings.Join(s, ", ") + "}"
+}
+
+// generic converts a decoded value to its JSON form, so events of the tracer
+// and events read from a recorded trace are rendered the same way.
+func generic(v any) any {
+	switch v.(type) {
+	case nil, string, bool, float64, json.Number:
+		return v
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var g any
+	if err := dec.Decode(&g); err != nil {
+		return fmt.Sprint(v)
+	}
+	return g
+}
+
+func intValue(v any) (int64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// buffer renders the data of a read or write of size bytes. The decoder
+// marks buffers cut at the blob size with "...", such a buffer is shorter
+// than the transfer whatever the blob size was, while data ending with dots
+// has all size bytes. Nothing is cut if the size is unknown (-1).
+func buffer(s string, size int64) string {
+	if trimmed, ok := strings.CutSuffix(s, "..."); ok && int64(len(trimmed)) < size && int64(len(s)) != size {
+		return quoteString(trimmed) + "..."
+	}
+	return quoteString(s)
+}
+
+// transferSize returns the number of bytes in the buffer argument i of a
+// syscall: the count after it for writes, the result for reads. It's -1 if
+// the size is unknown.
+func transferSize(e tracer.Event, typ syscalls.Type, i int) int64 {
+	v := e.Args.Result
+	if typ == syscalls.WriteBuffer {
+		if i+1 >= len(e.Args.SyscallArgs) {
+			return -1
+		}
+		v = e.Args.SyscallArgs[i+1]
+	}
+	v = generic(v)
+	if s, ok := v.(string); ok {
+		if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return n
+		}
+		return -1
+	}
+	if n, ok := intValue(v); ok {
+		return n
+	}
+	return -1
+}
+
+// quoteString quotes a string the way strace does: C escapes for control
+// characters and octal escapes for other unprintable bytes.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c >= ' ' && c <= '~' {
+				b.WriteByte(c)
+				continue
+			}
+			// Short octal escapes are ambiguous before digits.
+			if i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '7' {
+				fmt.Fprintf(&b, `\%03o`, c)
+			} else {
+				fmt.Fprintf(&b, `\%o`, c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// parsePerm parses permission bits of an os.FileMode string, e.g.
+// "-rw-r--r--".
+func parsePerm(s string) (uint32, bool) {
+	if len(s) < 9 {
+		return 0, false
+	}
+	var perm uint32
+	s = s[len(s)-9:]
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == "rwxrwxrwx"[i]:
+			perm |= 1 << (8 - i)
+		case c != '-':
+			return 0, false
+		}
+	}
+	return perm, true
+}
+
+var fileTypes = []struct {
+	mode uint32
+	name string
+}{
+	{unix.S_IFREG, "S_IFREG"},
+	{unix.S_IFDIR, "S_IFDIR"},
+	{unix.S_IFLNK, "S_IFLNK"},
+	{unix.S_IFCHR, "S_IFCHR"},
+	{unix.S_IFBLK, "S_IFBLK"},
+	{unix.S_IFIFO, "S_IFIFO"},
+	{unix.S_IFSOCK, "S_IFSOCK"},
+}
+
+// statMode renders st_mode like strace: S_IFREG|0644.
+func statMode(mode uint32) string {
+	perm := fmt.Sprintf("%#o", mode&07777)
+	for _, t := range fileTypes {
+		if mode&unix.S_IFMT == t.mode {
+			return t.name + "|" + perm
+		}
+	}
+	return perm
+}
+
+// snakeCase converts Go field names to C ones: SamplePeriod to sample_period,
+// FD to fd.
+func snakeCase(s string) string {
+	r := []rune(s)
+	var b strings.Builder
+	for i, c := range r {
+		if i > 0 && unicode.IsUpper(c) {
+			prev := r[i-1]
+			nextLower := i+1 < len(r) && unicode.IsLower(r[i+1])
+			if prev != '_' && (unicode.IsLower(prev) || unicode.IsDigit(prev) || nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
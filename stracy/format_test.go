@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+	"golang.org/x/sys/unix"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// memTask is a task with a fake address space, values are laid out by put.
+type memTask struct {
+	pid int
+	mem []byte
+}
+
+const memBase = 0x1000
+
+func (t *memTask) Name() string { return "test" }
+func (t *memTask) PID() int     { return t.pid }
+
+func (t *memTask) Read(addr strace.Addr, v any) (int, error) {
+	off := int(addr) - memBase
+	if off < 0 || off >= len(t.mem) {
+		return 0, unix.EFAULT
+	}
+	if err := binary.Read(bytes.NewReader(t.mem[off:]), binary.LittleEndian, v); err != nil {
+		return 0, err
+	}
+	return binary.Size(v), nil
+}
+
+// put stores a value in the address space and returns its address. Strings
+// are stored NUL-terminated.
+func (t *memTask) put(v any) uintptr {
+	for len(t.mem)%8 != 0 {
+		t.mem = append(t.mem, 0)
+	}
+	addr := uintptr(memBase + len(t.mem))
+	var b bytes.Buffer
+	if s, ok := v.(string); ok {
+		b.WriteString(s)
+		b.WriteByte(0)
+	} else if err := binary.Write(&b, binary.LittleEndian, v); err != nil {
+		panic(err)
+	}
+	t.mem = append(t.mem, b.Bytes()...)
+	return addr
+}
+
+// putIOVec stores an iovec array pointing to bufs.
+func (t *memTask) putIOVec(bufs ...string) uintptr {
+	iov := make([]uint64, 0, 2*len(bufs))
+	for _, b := range bufs {
+		iov = append(iov, uint64(t.put([]byte(b))), uint64(len(b)))
+	}
+	return t.put(iov)
+}
+
+// putDirents stores struct linux_dirent64 entries, "." is a directory and
+// other names are regular files.
+func (t *memTask) putDirents(names ...string) uintptr {
+	var b []byte
+	for i, name := range names {
+		d := make([]byte, (19+len(name)+1+7)&^7)
+		binary.LittleEndian.PutUint64(d[0:], uint64(1000+i))
+		binary.LittleEndian.PutUint64(d[8:], uint64(i+1))
+		binary.LittleEndian.PutUint16(d[16:], uint16(len(d)))
+		d[18] = unix.DT_REG
+		if name == "." {
+			d[18] = unix.DT_DIR
+		}
+		copy(d[19:], name)
+		b = append(b, d...)
+	}
+	return t.put(b)
+}
+
+// putStrings stores a NULL-terminated array of strings.
+func (t *memTask) putStrings(ss ...string) uintptr {
+	ptrs := make([]uint64, 0, len(ss)+1)
+	for _, s := range ss {
+		ptrs = append(ptrs, uint64(t.put(s)))
+	}
+	return t.put(append(ptrs, 0))
+}
+
+// testFD is a file descriptor of the test process open on /dev/null, so -y
+// paths are the same on every machine. Other fds in the cases are above 200
+// and not open.
+const testFD = 100
+
+type formatCase struct {
+	name  string
+	args  func(t *memTask) []uintptr
+	ret   int64
+	errno syscall.Errno
+}
+
+func formatCases() []formatCase {
+	ts := func(sec, nsec int64) unix.Timespec { return unix.Timespec{Sec: sec, Nsec: nsec} }
+	tv := func(sec, usec int64) unix.Timeval { return unix.Timeval{Sec: sec, Usec: usec} }
+
+	return []formatCase{
+		{name: "read", ret: 7, args: func(t *memTask) []uintptr {
+			return []uintptr{testFD, t.put([]byte("hi\n\x00\x01\"7")), 16}
+		}},
+		{name: "write", ret: 1024, args: func(t *memTask) []uintptr {
+			return []uintptr{testFD, t.put(bytes.Repeat([]byte("ab"), 1024)), 2048}
+		}},
+		{name: "readv", ret: 6, args: func(t *memTask) []uintptr { return []uintptr{testFD, t.putIOVec("abc", "def"), 2} }},
+		{name: "writev", ret: 3, args: func(t *memTask) []uintptr { return []uintptr{testFD, t.putIOVec("xyz"), 1} }},
+		{name: "process_vm_readv", ret: 4, args: func(t *memTask) []uintptr {
+			return []uintptr{1234, t.putIOVec("data"), 1, t.putIOVec("data"), 1, 0}
+		}},
+		{name: "sendmsg", ret: 5, args: func(t *memTask) []uintptr {
+			msg := abi.MessageHeader64{Iov: uint64(t.putIOVec("hello")), IovLen: 1}
+			return []uintptr{testFD, t.put(msg), 0}
+		}},
+		{name: "recvmsg", ret: 2, args: func(t *memTask) []uintptr {
+			msg := abi.MessageHeader64{Iov: uint64(t.putIOVec("ok")), IovLen: 1, Flags: unix.MSG_TRUNC}
+			return []uintptr{testFD, t.put(msg), 0}
+		}},
+		{name: "getcwd", ret: 9, args: func(t *memTask) []uintptr { return []uintptr{t.put("/home/me"), 4096} }},
+		{name: "execve", args: func(t *memTask) []uintptr {
+			return []uintptr{t.put("/bin/echo"), t.putStrings("echo", "a b", "tab\there"), t.putStrings("HOME=/root")}
+		}},
+		{name: "dup2", ret: 101, args: func(t *memTask) []uintptr { return []uintptr{testFD, 101} }},
+		{name: "pipe", args: func(t *memTask) []uintptr { return []uintptr{t.put([2]int32{3, 4})} }},
+		{name: "uname", args: func(t *memTask) []uintptr {
+			var u unix.Utsname
+			copy(u.Sysname[:], "Linux")
+			copy(u.Release[:], "6.1.0")
+			copy(u.Machine[:], "x86_64")
+			return []uintptr{t.put(u)}
+		}},
+		{name: "stat", args: func(t *memTask) []uintptr {
+			st := unix.Stat_t{Mode: unix.S_IFREG | 0o644, Size: 1234, Nlink: 1}
+			return []uintptr{t.put("/etc/passwd"), t.put(st)}
+		}},
+		{name: "connect", args: func(t *memTask) []uintptr {
+			sa := unix.RawSockaddrInet4{Family: unix.AF_INET, Port: 0x5000, Addr: [4]byte{127, 0, 0, 1}}
+			return []uintptr{testFD, t.put(sa), unix.SizeofSockaddrInet4}
+		}},
+		{name: "accept", ret: 5, args: func(t *memTask) []uintptr {
+			sa := unix.RawSockaddrUnix{Family: unix.AF_UNIX}
+			copy(sa.Path[:], []int8{'/', 's'})
+			return []uintptr{testFD, t.put(sa), t.put(uint32(5))}
+		}},
+		{name: "socket", ret: 203, args: func(t *memTask) []uintptr {
+			return []uintptr{unix.AF_INET, unix.SOCK_STREAM | unix.SOCK_CLOEXEC, unix.IPPROTO_TCP}
+		}},
+		{name: "dup3", ret: 102, args: func(t *memTask) []uintptr { return []uintptr{testFD, 102, unix.O_CLOEXEC} }},
+		{name: "nanosleep", args: func(t *memTask) []uintptr {
+			return []uintptr{t.put(ts(1, 500000000)), t.put(ts(0, 0))}
+		}},
+		{name: "utimensat", args: func(t *memTask) []uintptr {
+			times := [2]unix.Timespec{ts(0, unix.UTIME_NOW), ts(0, unix.UTIME_OMIT)}
+			return []uintptr{uintptr(math.MaxUint64 - 99), t.put("f"), t.put(times), 0} // AT_FDCWD
+		}},
+		{name: "setitimer", args: func(t *memTask) []uintptr {
+			return []uintptr{unix.ITIMER_REAL, t.put([2]unix.Timeval{tv(0, 100000), tv(2, 0)}), t.put([2]unix.Timeval{})}
+		}},
+		{name: "timer_settime", args: func(t *memTask) []uintptr {
+			return []uintptr{0, unix.TIMER_ABSTIME, t.put([2]unix.Timespec{ts(1, 0), ts(5, 250)}), 0}
+		}},
+		{name: "select", ret: 1, args: func(t *memTask) []uintptr {
+			var set unix.FdSet
+			set.Set(0)
+			set.Set(3)
+			return []uintptr{4, t.put(set), 0, 0, t.put(tv(1, 250000))}
+		}},
+		{name: "utime", args: func(t *memTask) []uintptr {
+			return []uintptr{t.put("f"), t.put(unix.Utimbuf{Actime: 1700000000, Modtime: 1700000001})}
+		}},
+		{name: "wait4", ret: 4321, args: func(t *memTask) []uintptr {
+			return []uintptr{uintptr(math.MaxUint64), 0, 0, t.put(unix.Rusage{Utime: tv(0, 1500), Maxrss: 2048})}
+		}},
+		{name: "clone", ret: 4321, args: func(t *memTask) []uintptr {
+			return []uintptr{unix.CLONE_CHILD_CLEARTID | unix.CLONE_CHILD_SETTID | uintptr(unix.SIGCHLD), 0, 0, 0, 0}
+		}},
+		{name: "open", errno: unix.ENOENT, args: func(t *memTask) []uintptr {
+			return []uintptr{t.put("/nonexistent"), unix.O_RDWR | unix.O_CREAT | unix.O_CLOEXEC, 0o640}
+		}},
+		{name: "futex", errno: unix.ETIMEDOUT, args: func(t *memTask) []uintptr {
+			const waitPrivate = 0x80 // FUTEX_WAIT|FUTEX_PRIVATE_FLAG
+			return []uintptr{0x7f0000001000, waitPrivate, 0, t.put(ts(0, 1000)), 0, 0}
+		}},
+		{name: "ptrace", args: func(t *memTask) []uintptr { return []uintptr{unix.PTRACE_SEIZE, 1234, 0, 0} }},
+		{name: "sched_setaffinity", args: func(t *memTask) []uintptr {
+			var set unix.CPUSet
+			set.Set(0)
+			set.Set(2)
+			return []uintptr{0, 128, t.put(set)}
+		}},
+		{name: "mmap", ret: 0x7f0000000000, args: func(t *memTask) []uintptr {
+			return []uintptr{0, 0x2000, unix.PROT_READ | unix.PROT_WRITE, unix.MAP_PRIVATE | unix.MAP_ANONYMOUS, uintptr(math.MaxUint64), 0}
+		}},
+		{name: "madvise", args: func(t *memTask) []uintptr { return []uintptr{0x7f0000000000, 0x2000, unix.MADV_DONTNEED} }},
+		{name: "arch_prctl", args: func(t *memTask) []uintptr { return []uintptr{0x1002, 0x7f0000000740} }}, // ARCH_SET_FS
+		{name: "rt_sigaction", args: func(t *memTask) []uintptr { return []uintptr{uintptr(unix.SIGINT), 0, 0} }},
+		{name: "sigaltstack", args: func(t *memTask) []uintptr {
+			return []uintptr{t.put(syscalls.Stackt{Ss_sp: 0x7f0000010000, Ss_size: 8192}), 0}
+		}},
+		{name: "poll", ret: 1, args: func(t *memTask) []uintptr {
+			fds := []unix.PollFd{{Fd: 3, Events: unix.POLLIN, Revents: unix.POLLIN}, {Fd: 4, Events: unix.POLLOUT}}
+			return []uintptr{t.put(fds), 2, 1000}
+		}},
+		{name: "epoll_ctl", args: func(t *memTask) []uintptr {
+			return []uintptr{205, unix.EPOLL_CTL_ADD, testFD, t.put(unix.EpollEvent{Events: unix.EPOLLIN | unix.EPOLLET, Fd: testFD})}
+		}},
+		{name: "epoll_wait", ret: 1, args: func(t *memTask) []uintptr {
+			return []uintptr{205, t.put([]unix.EpollEvent{{Events: unix.EPOLLIN, Fd: testFD}}), 8, uintptr(math.MaxUint64)}
+		}},
+		{name: "epoll_create1", ret: 205, args: func(t *memTask) []uintptr { return []uintptr{unix.EPOLL_CLOEXEC} }},
+		{name: "eventfd2", ret: 206, args: func(t *memTask) []uintptr { return []uintptr{0, unix.EFD_NONBLOCK | unix.EFD_CLOEXEC} }},
+		{name: "timerfd_create", ret: 207, args: func(t *memTask) []uintptr { return []uintptr{unix.CLOCK_MONOTONIC, unix.TFD_CLOEXEC} }},
+		{name: "timerfd_settime", args: func(t *memTask) []uintptr {
+			return []uintptr{207, unix.TFD_TIMER_ABSTIME, t.put([2]unix.Timespec{ts(0, 0), ts(3, 0)}), t.put([2]unix.Timespec{})}
+		}},
+		{name: "timer_create", args: func(t *memTask) []uintptr { return []uintptr{unix.CLOCK_REALTIME, 0, t.put(int32(0))} }},
+		{name: "bpf", ret: 3, args: func(t *memTask) []uintptr {
+			attr := make([]byte, 72)
+			binary.LittleEndian.PutUint32(attr[0:], unix.BPF_MAP_TYPE_HASH)
+			binary.LittleEndian.PutUint32(attr[4:], 4)
+			binary.LittleEndian.PutUint32(attr[8:], 8)
+			binary.LittleEndian.PutUint32(attr[12:], 1024)
+			copy(attr[28:], "counts")
+			return []uintptr{unix.BPF_MAP_CREATE, t.put(attr), uintptr(len(attr))}
+		}},
+		{name: "perf_event_open", ret: 208, args: func(t *memTask) []uintptr {
+			attr := unix.PerfEventAttr{
+				Type:        unix.PERF_TYPE_HARDWARE,
+				Size:        unix.PERF_ATTR_SIZE_VER5,
+				Config:      unix.PERF_COUNT_HW_INSTRUCTIONS,
+				Sample_type: unix.PERF_SAMPLE_IP | unix.PERF_SAMPLE_TID,
+				Bits:        unix.PerfBitDisabled | unix.PerfBitExcludeKernel,
+			}
+			return []uintptr{t.put(attr), 0, uintptr(math.MaxUint64), uintptr(math.MaxUint64), unix.PERF_FLAG_FD_CLOEXEC}
+		}},
+		{name: "access", errno: unix.EACCES, args: func(t *memTask) []uintptr { return []uintptr{t.put("/root"), unix.W_OK | unix.X_OK} }},
+		{name: "kill", args: func(t *memTask) []uintptr { return []uintptr{1234, uintptr(unix.SIGTERM)} }},
+		{name: "fcntl", ret: 209, args: func(t *memTask) []uintptr { return []uintptr{testFD, unix.F_DUPFD_CLOEXEC, 209} }},
+		{name: "prctl", args: func(t *memTask) []uintptr { return []uintptr{unix.PR_SET_NAME, t.put("worker")} }},
+		{name: "inotify_init1", ret: 210, args: func(t *memTask) []uintptr { return []uintptr{unix.IN_CLOEXEC} }},
+		{name: "inotify_add_watch", ret: 1, args: func(t *memTask) []uintptr {
+			return []uintptr{210, t.put("/tmp"), unix.IN_CREATE | unix.IN_DELETE | unix.IN_ONLYDIR}
+		}},
+		{name: "fanotify_init", ret: 211, args: func(t *memTask) []uintptr {
+			return []uintptr{unix.FAN_CLOEXEC | unix.FAN_CLASS_CONTENT | unix.FAN_REPORT_FID, unix.O_RDONLY}
+		}},
+		{name: "fanotify_mark", args: func(t *memTask) []uintptr {
+			return []uintptr{211, unix.FAN_MARK_ADD | unix.FAN_MARK_FILESYSTEM, unix.FAN_OPEN_PERM | unix.FAN_ONDIR, testFD, t.put("mnt")}
+		}},
+		{name: "getdents64", ret: 56, args: func(t *memTask) []uintptr { return []uintptr{testFD, t.putDirents(".", "a.txt"), 32768} }},
+		{name: "clone3", ret: 4322, args: func(t *memTask) []uintptr {
+			args := abi.CloneArgs{
+				Flags:      unix.CLONE_NEWPID | unix.CLONE_NEWTIME | unix.CLONE_INTO_CGROUP,
+				ExitSignal: uint64(unix.SIGCHLD),
+				Cgroup:     212,
+			}
+			return []uintptr{t.put(args), uintptr(binary.Size(args))}
+		}},
+		{name: "unshare", args: func(t *memTask) []uintptr { return []uintptr{unix.CLONE_NEWNS | unix.CLONE_NEWUTS} }},
+		{name: "setns", args: func(t *memTask) []uintptr { return []uintptr{213, unix.CLONE_NEWNET} }},
+		{name: "getpid", ret: 1234, args: func(t *memTask) []uintptr { return nil }},
+		{name: "getsockopt", args: func(t *memTask) []uintptr {
+			info := abi.TCPInfo{State: 1, RTT: 1500, RTTVar: 750, SndCwnd: 10, Advmss: 65483}
+			return []uintptr{testFD, unix.IPPROTO_TCP, unix.TCP_INFO, t.put(info), t.put(uint32(abi.SizeOfTCPInfo))}
+		}},
+		{name: "memfd_create", ret: 214, args: func(t *memTask) []uintptr { return []uintptr{t.put("jit"), unix.MFD_CLOEXEC} }},
+	}
+}
+
+// testEvent decodes a syscall like the tracer does.
+func testEvent(t *testing.T, i int, c formatCase) tracer.Event {
+	si, ok := syscalls.ByName(c.name)
+	if !ok {
+		t.Fatalf("unknown syscall %s", c.name)
+	}
+	task := &memTask{pid: os.Getpid()}
+	var args strace.SyscallArguments
+	for j, a := range c.args(task) {
+		args[j] = strace.SyscallArgument{Value: a}
+	}
+	ret := strace.SyscallArgument{Value: uintptr(c.ret)}
+
+	start := time.Unix(1700000000, 0).Add(time.Duration(i) * 1001 * time.Microsecond)
+	dur := time.Duration(1+i) * 1500 * time.Nanosecond
+	e := tracer.Event{
+		Name:      si.Name,
+		Cat:       "successful",
+		Ph:        "X",
+		PID:       4000 + i%3,
+		TID:       4000 + i%3,
+		Timestamp: int(start.UnixNano()),
+		Duration:  int(dur),
+		Args:      tracer.Args{Syscall: si.Name},
+	}
+	if c.errno == 0 {
+		e.Args.Result = syscalls.ArgumentSimple(task, si.ReturnType, ret, tracer.DefaultMaxBlobSize)
+	} else {
+		e.Args.Result = fmt.Sprintf("%q (%d)", c.errno, c.errno)
+		e.Args.Errno = c.errno
+		e.Cat = "failed"
+	}
+	e.Args.SyscallArgs = syscalls.ArgumentsStrings(si, task, args, ret, tracer.DefaultMaxBlobSize)
+	return e
+}
+
+func TestStraceFormatter(t *testing.T) {
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	if err := unix.Dup2(int(null.Fd()), testFD); err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(testFD)
+
+	// AT_FDCWD is printed with the working directory.
+	golden, err := filepath.Abs(filepath.Join("testdata", "format.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	f := StraceFormatter{PIDs: true, Time: EpochTime, Durations: true, FDPaths: true}
+	covered := make(map[syscalls.Type]bool)
+	var out strings.Builder
+	for i, c := range formatCases() {
+		si, _ := syscalls.ByName(c.name)
+		covered[si.ReturnType] = true
+		for _, typ := range si.ArgTypes {
+			covered[typ] = true
+		}
+
+		e := testEvent(t, i, c)
+		line, ok := f.Format(e)
+		if !ok {
+			t.Fatalf("%s: not formatted", c.name)
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+
+		// The line can be read back.
+		p, complete, err := NewStraceParser().ParseLine(line)
+		if err != nil || !complete {
+			t.Errorf("%s: can't parse %q: %v", c.name, line, err)
+			continue
+		}
+		start, dur := eventTimes(e)
+		if p.Args.Syscall != c.name || p.PID != e.PID || p.Cat != e.Cat {
+			t.Errorf("%s: parsed %s of %d (%s), want %s of %d (%s)", c.name, p.Args.Syscall, p.PID, p.Cat, c.name, e.PID, e.Cat)
+		}
+		if p.Timestamp != int(start.UnixNano()) || p.Args.Duration != dur.Truncate(time.Microsecond).Seconds() {
+			t.Errorf("%s: parsed start %d and duration %v, want %d and %v", c.name, p.Timestamp, p.Args.Duration, start.UnixNano(), dur.Seconds())
+		}
+	}
+
+	for _, typ := range syscalls.Types() {
+		if !covered[typ] {
+			t.Errorf("no case for syscalls.Type %d", typ)
+		}
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, []byte(out.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotLines, wantLines := strings.Split(out.String(), "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var got, want string
+		if i < len(gotLines) {
+			got = gotLines[i]
+		}
+		if i < len(wantLines) {
+			want = wantLines[i]
+		}
+		if got != want {
+			t.Errorf("line %d:\n got %s\nwant %s", i+1, got, want)
+		}
+	}
+}
+
+func TestStraceFormatterTime(t *testing.T) {
+	e := tracer.Event{
+		Name: "close", Cat: "successful", Ph: "X", PID: 7, TID: 8,
+		Timestamp: int(time.Date(2023, 1, 2, 15, 4, 5, 123456789, time.Local).UnixNano()),
+		Duration:  int(5 * time.Millisecond),
+		Args:      tracer.Args{Syscall: "close", SyscallArgs: []any{syscalls.Arg{Type: "fd", Value: 3}}, Result: "0"},
+	}
+	tests := []struct {
+		f    StraceFormatter
+		want string
+	}{
+		{StraceFormatter{}, "close(3) = 0"},
+		{StraceFormatter{PIDs: true}, "8     close(3) = 0"},
+		{StraceFormatter{Time: WallTime}, "15:04:05.123456 close(3) = 0"},
+		{StraceFormatter{Durations: true}, "close(3) = 0 <0.005000>"},
+	}
+	for _, tt := range tests {
+		if got, _ := tt.f.Format(e); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.f, got, tt.want)
+		}
+	}
+
+	// strace logs are stamped at the start too
+	p, _, err := NewStraceParser().ParseLine(`12 1700000000.000100 close(3) = -1 EBADF (Bad file descriptor) <0.000020>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `12    1700000000.000100 close(3) = -1 EBADF (Bad file descriptor) <0.000020>`
+	if got, _ := (StraceFormatter{PIDs: true, Time: EpochTime, Durations: true}).Format(p); got != want {
+		t.Errorf("strace log event:\n got %s\nwant %s", got, want)
+	}
+
+	if _, ok := (StraceFormatter{}).Format(tracer.Event{Name: "memory", Ph: "C"}); ok {
+		t.Error("counter event formatted")
+	}
+}
+
+func TestStraceFormatterBuffer(t *testing.T) {
+	event := func(name string, result any, args ...any) tracer.Event {
+		return tracer.Event{Name: name, Ph: "X", Args: tracer.Args{Syscall: name, SyscallArgs: args, Result: result}}
+	}
+	tests := []struct {
+		e    tracer.Event
+		want string
+	}{
+		// cut at any blob size, or shortened by redaction
+		{event("write", "0x40", "1", "0123456789abcdef...", "0x40"), `write(1, "0123456789abcdef"..., 0x40) = 64`},
+		{event("write", "0x800", "3", "Authorization: Bearer [redacted:bearer]...", "0x800"), `write(3, "Authorization: Bearer [redacted:bearer]"..., 0x800) = 2048`},
+		{event("read", float64(100), float64(3), "abc...", float64(100)), `read(3, "abc"..., 100) = 100`},
+		// data ending with dots
+		{event("write", "0x7", "1", "wait...", "0x7"), `write(1, "wait...", 0x7) = 7`},
+		{event("read", "6", "3", "abc...", "0x10"), `read(3, "abc...", 0x10) = 6`},
+		{event("write", "0x7", "1", "wait..."), `write(1, "wait...") = 7`},
+	}
+	for _, tt := range tests {
+		if got, _ := (StraceFormatter{}).Format(tt.e); got != tt.want {
+			t.Errorf("got  %s\nwant %s", got, tt.want)
+		}
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	tests := map[string]string{
+		"":               `""`,
+		"a\"b\\c":        `"a\"b\\c"`,
+		"\t\n\v\f\r":     `"\t\n\v\f\r"`,
+		"\x00\x01x":      `"\0\1x"`,
+		"\x001":          `"\0001"`,
+		"\x7f\xff":       `"\177\377"`,
+		"plain text 123": `"plain text 123"`,
+	}
+	for in, want := range tests {
+		if got := quoteString(in); got != want {
+			t.Errorf("quoteString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"FD":           "fd",
+		"SamplePeriod": "sample_period",
+		"ProgFD":       "prog_fd",
+		"BTFKeyTypeID": "btf_key_type_id",
+		"Ss_sp":        "ss_sp",
+		"Config1":      "config1",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
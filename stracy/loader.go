@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// LoaderReport reconstructs what the dynamic loader (ld.so) did when programs
+// started: libraries it loaded, paths it searched and the time it took.
+type LoaderReport struct {
+	Programs []ProgramLoad
+}
+
+// ProgramLoad is the start of a program by a process, from its exec to the
+// first syscall of the program itself.
+type ProgramLoad struct {
+	PID  int
+	Path string `json:",omitempty"` // the executable, empty if unknown
+
+	// Env is the LD_* environment in effect, from envp of the exec. It's
+	// nil if the exec isn't in the trace.
+	Env []string
+
+	Libraries   []LoadedLibrary
+	SearchPaths []SearchPath
+
+	// Missing are libraries the loader didn't find.
+	Missing []string `json:",omitempty"`
+
+	// BeforeMain is the time from the exec to the first syscall that isn't
+	// the loader's. It includes the exec itself if it's in the trace, and
+	// constructors of libraries that make no syscalls.
+	BeforeMain time.Duration
+
+	// Syscalls is the number of syscalls made by the loader.
+	Syscalls int
+
+	// Main is false if the program never got to main: a library is missing
+	// and the program made no syscalls after the loader reported it.
+	Main bool
+}
+
+// LoadedLibrary is a library the loader found.
+type LoadedLibrary struct {
+	Name string // as searched for, like libc.so.6
+	Path string
+
+	// Probes is the number of paths that didn't have the library.
+	Probes int
+
+	// Time is from the first probe to the close of the library file.
+	Time time.Duration
+}
+
+// SearchPath is a directory the loader looked for libraries in.
+type SearchPath struct {
+	Dir    string
+	Probes int // failed lookups
+	Found  int // libraries found
+	Time   time.Duration
+}
+
+func loaderMain(args []string) int {
+	fs := flag.NewFlagSet("loader", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	tracePath := fs.String("trace", "", "read a recorded trace (JSON) or an strace log from `file` instead of running PROG")
+	redactor := redactFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s loader [flags] PROG [ARGS]\n       %s loader [flags] -trace FILE\n\n", os.Args[0], os.Args[0])
+		fmt.Fprint(fs.Output(), "Reports libraries the dynamic loader loaded, paths it searched, the\ntime before main and the LD_* environment.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if (*tracePath == "") == (fs.NArg() == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	redact, err := redactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+
+	var r LoaderReport
+	if *tracePath != "" {
+		events, textLog, err := loadEvents(*tracePath, redact)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read %s: %s\n", *tracePath, err)
+			return 1
+		}
+		r = loaderReport(events, textLog, "", nil)
+	} else {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		cmd := passthroughCommand(fs.Args())
+		events, err := tracer.New(tracer.WithRedactor(redact)).Run(ctx, cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "trace: %s\n", err)
+			return 1
+		}
+		// The exec of the command itself isn't traced, its environment is
+		// redacted here like envp of traced execs.
+		env := os.Environ()
+		for i, kv := range env {
+			env[i], _ = redact.String(kv)
+		}
+		r = loaderReport(events, false, cmd.Path, env)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	} else {
+		err = r.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// Syscalls of the loader. The loader opens a library, reads its headers,
+// maps it and closes it; then it sets up TLS and protects relocations.
+var (
+	// loaderPathSyscalls look up files, libraries are probed with them.
+	loaderPathSyscalls = map[string]bool{
+		"open": true, "openat": true, "openat2": true,
+		"access": true, "faccessat": true, "faccessat2": true,
+		"stat": true, "lstat": true, "newfstatat": true, "statx": true,
+	}
+
+	// loaderFileSyscalls work on the open library file.
+	loaderFileSyscalls = map[string]bool{
+		"read": true, "pread64": true, "fstat": true, "newfstatat": true, "statx": true, "close": true,
+	}
+
+	// loaderMemorySyscalls map libraries and set up the process.
+	loaderMemorySyscalls = map[string]bool{
+		"mmap": true, "munmap": true, "mprotect": true, "brk": true,
+		"arch_prctl": true, "set_tid_address": true, "set_robust_list": true, "rseq": true,
+		"prlimit64": true,
+	}
+)
+
+// reLibrary matches file names of shared libraries, like libc.so.6.
+var reLibrary = regexp.MustCompile(`\.so(\.\d+)*$`)
+
+// loaderFiles are files of the loader itself.
+var loaderFiles = map[string]bool{
+	"/etc/ld.so.cache":   true,
+	"/etc/ld.so.preload": true,
+}
+
+// loaderState follows a process while its loader runs.
+type loaderState struct {
+	prog  *ProgramLoad
+	start int // of the exec, ns
+	end   int // of the last loader syscall, ns
+	dirs  map[string]*SearchPath
+
+	inFile bool // a library or the cache is open
+	lib    int  // index of the open library, -1 if none
+
+	// probes is the number of failed probes of the library being
+	// searched for, since probeStart.
+	probes     int
+	probeStart int
+	probed     []string // names of the failed probes
+}
+
+func newLoaderState(pid int, path string, env []string, start int) *loaderState {
+	return &loaderState{
+		prog:  &ProgramLoad{PID: pid, Path: path, Env: env},
+		start: start,
+		end:   start,
+		dirs:  make(map[string]*SearchPath),
+		lib:   -1,
+	}
+}
+
+// loaderReport reconstructs program loads from a trace. The first process
+// of the trace is assumed to run rootPath with rootEnv if its exec isn't in
+// the trace, rootEnv is nil if it's unknown. Other processes are followed
+// from their execs.
+//
+// The loader is told apart from the program by its syscalls: the loader
+// only looks up libraries, reads and maps them, the first other syscall is
+// the program's.
+func loaderReport(events []tracer.Event, textLog bool, rootPath string, rootEnv []string) LoaderReport {
+	var (
+		r      LoaderReport
+		states = make(map[int]*loaderState)
+		seen   = make(map[int]bool)
+	)
+	finish := func(s *loaderState, reached bool) {
+		p := s.prog
+		p.BeforeMain = time.Duration(s.end - s.start)
+		p.Missing = appendUnique(p.Missing, s.probed...)
+		// A program making no syscalls exits while the loader seems
+		// to run.
+		p.Main = reached || len(p.Missing) == 0
+		for _, sp := range s.dirs {
+			p.SearchPaths = append(p.SearchPaths, *sp)
+		}
+		sort.Slice(p.SearchPaths, func(i, j int) bool {
+			a, b := p.SearchPaths[i], p.SearchPaths[j]
+			if a.Probes != b.Probes {
+				return a.Probes > b.Probes
+			}
+			return a.Dir < b.Dir
+		})
+		r.Programs = append(r.Programs, *p)
+		delete(states, p.PID)
+	}
+
+	for _, e := range events {
+		if e.Ph != "X" || e.Args.Syscall == "" {
+			continue
+		}
+		c := newTraceCall(e, textLog)
+		first := !seen[c.PID]
+		seen[c.PID] = true
+
+		if isExecCall(c.Syscall) {
+			if c.Errno != "" {
+				continue // a lookup in PATH
+			}
+			if s, ok := states[c.PID]; ok {
+				finish(s, false)
+			}
+			path := c.path()
+			if e.Args.Exec != nil && e.Args.Exec.Path != "" {
+				path = e.Args.Exec.Path
+			}
+			var env []string
+			if len(c.Args) > 2 {
+				env = ldEnv(stringVector(c.Args[2]))
+			}
+			states[c.PID] = newLoaderState(c.PID, path, env, e.Timestamp)
+			continue
+		}
+
+		s, ok := states[c.PID]
+		if !ok {
+			if !first || len(seen) > 1 {
+				continue // runs its program or a fork of it
+			}
+			var env []string
+			if rootEnv != nil {
+				env = ldEnv(rootEnv)
+			}
+			s = newLoaderState(c.PID, rootPath, env, e.Timestamp)
+			states[c.PID] = s
+		}
+
+		if !s.step(c, e) {
+			if len(s.probed) > 0 && (c.Syscall == "write" || c.Syscall == "writev") {
+				// The loader reports the missing library, it's fatal
+				// unless the library is from LD_PRELOAD.
+				s.prog.Missing = appendUnique(s.prog.Missing, s.probed...)
+				s.probes, s.probed = 0, nil
+				s.prog.Syscalls++
+				s.end = e.Timestamp + e.Duration
+				continue
+			}
+			s.end = e.Timestamp
+			finish(s, true)
+		}
+	}
+
+	pids := make([]int, 0, len(states))
+	for pid := range states {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+	for _, pid := range pids {
+		finish(states[pid], false)
+	}
+	return r
+}
+
+// step follows a syscall of the loader, false if it's the program's.
+func (s *loaderState) step(c traceCall, e tracer.Event) bool {
+	p := c.path()
+	switch {
+	case loaderPathSyscalls[c.Syscall] && p != "":
+		if loaderFiles[p] {
+			s.inFile = c.Errno == "" && strings.HasPrefix(c.Syscall, "open")
+			break
+		}
+		if dir := s.dirs[p]; dir != nil {
+			// The loader checks if a search path exists after a
+			// library isn't there.
+			dir.Time += c.Latency
+			break
+		}
+		name := path.Base(p)
+		if !reLibrary.MatchString(name) {
+			return false
+		}
+		dir := s.dirs[path.Dir(p)]
+		if dir == nil {
+			dir = &SearchPath{Dir: path.Dir(p)}
+			s.dirs[dir.Dir] = dir
+		}
+		if s.probes == 0 {
+			s.probeStart = e.Timestamp
+		}
+		if c.Errno != "" {
+			dir.Probes++
+			dir.Time += c.Latency
+			s.probes++
+			s.probed = appendUnique(s.probed, name)
+			break
+		}
+		if !strings.HasPrefix(c.Syscall, "open") {
+			break // e.g. a stat of a found library
+		}
+		dir.Found++
+		lib := LoadedLibrary{Name: name, Path: p, Probes: s.probes}
+		if s.probes > 0 {
+			lib.Name = s.probed[len(s.probed)-1]
+		}
+		s.prog.Libraries = append(s.prog.Libraries, lib)
+		s.lib = len(s.prog.Libraries) - 1
+		s.inFile = true
+		s.probes, s.probed = 0, nil
+	case loaderFileSyscalls[c.Syscall] && s.inFile:
+		if c.Syscall != "close" {
+			break
+		}
+		s.inFile = false
+		if s.lib >= 0 {
+			lib := &s.prog.Libraries[s.lib]
+			lib.Time = time.Duration(e.Timestamp + e.Duration - s.probeStart)
+			s.lib = -1
+		}
+	case loaderMemorySyscalls[c.Syscall]:
+	default:
+		return false
+	}
+	s.prog.Syscalls++
+	s.end = e.Timestamp + e.Duration
+	return true
+}
+
+func isExecCall(name string) bool {
+	return name == "execve" || name == "execveat"
+}
+
+// ldEnv returns LD_* variables of an environment.
+func ldEnv(env []string) []string {
+	ld := []string{}
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "LD_") {
+			ld = append(ld, kv)
+		}
+	}
+	return ld
+}
+
+// stringVector parses a string vector argument, like envp of execve: a
+// decoded tracer argument as JSON or a list of an strace log.
+func stringVector(s string) []string {
+	if strings.HasPrefix(s, "{") {
+		var arg struct{ Value []string }
+		json.Unmarshal([]byte(s), &arg)
+		return arg.Value
+	}
+	s = strings.TrimPrefix(strings.TrimSuffix(s, "]"), "[")
+	var vec []string
+	for _, item := range splitStraceArgs(s) {
+		if unquoted, err := strconv.Unquote(item); err == nil {
+			vec = append(vec, unquoted)
+		}
+	}
+	return vec
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if !slices.Contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// WriteText writes the report in a human-readable form.
+func (r LoaderReport) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, p := range r.Programs {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		name := p.Path
+		if name == "" {
+			name = "(exec not traced)"
+		}
+		state := "before main"
+		if !p.Main {
+			state = "loading, main not reached"
+		}
+		fmt.Fprintf(bw, "pid %d %s: %s %s, %d loader syscalls\n", p.PID, name, p.BeforeMain, state, p.Syscalls)
+
+		switch {
+		case p.Env == nil:
+			fmt.Fprintln(bw, "LD_* environment: unknown, the exec isn't traced")
+		case len(p.Env) == 0:
+			fmt.Fprintln(bw, "LD_* environment: none")
+		default:
+			fmt.Fprintln(bw, "LD_* environment:")
+			for _, kv := range p.Env {
+				fmt.Fprintf(bw, "  %s\n", kv)
+			}
+		}
+
+		if len(p.Libraries) > 0 {
+			fmt.Fprintln(bw, "libraries:")
+			tw := tabwriter.NewWriter(bw, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "  library\tpath\tfailed probes\ttime")
+			for _, l := range p.Libraries {
+				fmt.Fprintf(tw, "  %s\t%s\t%d\t%s\n", l.Name, l.Path, l.Probes, l.Time)
+			}
+			tw.Flush()
+		}
+		if len(p.SearchPaths) > 0 {
+			fmt.Fprintln(bw, "search paths:")
+			tw := tabwriter.NewWriter(bw, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(tw, "  dir\tfailed probes\tfound\ttime of failed probes")
+			for _, sp := range p.SearchPaths {
+				fmt.Fprintf(tw, "  %s\t%d\t%d\t%s\n", sp.Dir, sp.Probes, sp.Found, sp.Time)
+			}
+			tw.Flush()
+		}
+		if len(p.Missing) > 0 {
+			fmt.Fprintf(bw, "missing: %s\n", strings.Join(p.Missing, ", "))
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+)
+
+func TestLoaderReport(t *testing.T) {
+	var events []tracer.Event
+	ts := 1000
+	call := func(pid int, name string, failed bool, args ...any) {
+		e := tracer.Event{Name: name, Cat: "successful", Ph: "X", PID: pid, TID: pid, Timestamp: ts, Duration: 10,
+			Args: tracer.Args{Syscall: name, SyscallArgs: args, Result: "0"}}
+		if failed {
+			e.Cat = "failed"
+			e.Args.Result = `"no such file or directory" (2)`
+		}
+		events = append(events, e)
+		ts += 100
+	}
+
+	// the shell, its exec isn't traced
+	call(1, "brk", false, "0")
+	call(1, "openat", false, "AT_FDCWD", "/etc/ld.so.cache", "O_RDONLY")
+	call(1, "close", false, "3")
+	call(1, "openat", false, "AT_FDCWD", "/lib/libc.so.6", "O_RDONLY")
+	call(1, "read", false, "3", "\x7fELF")
+	call(1, "mmap", false, "0", "0x1000")
+	call(1, "close", false, "3")
+	call(1, "mprotect", false, "0x1000", "0x1000", "PROT_READ")
+	call(1, "getpid", false) // main
+	call(1, "clone", false)
+
+	// a fork execs a program with LD_LIBRARY_PATH
+	call(2, "read", false, "0", "x") // before the exec, not the loader
+	call(2, "execve", true, "/usr/local/bin/app")
+	call(2, "execve", false, "/usr/bin/app", syscalls.Arg{Type: "string_vector", Value: []string{"app"}},
+		syscalls.Arg{Type: "string_vector", Value: []string{"HOME=/root", "LD_LIBRARY_PATH=/opt/x", "LD_BIND_NOW=1"}})
+	call(2, "openat", true, "AT_FDCWD", "/opt/x/tls/libfoo.so.1", "O_RDONLY")
+	call(2, "newfstatat", true, "AT_FDCWD", "/opt/x/tls", "0x0", "0")
+	call(2, "openat", true, "AT_FDCWD", "/opt/x/libfoo.so.1", "O_RDONLY")
+	call(2, "newfstatat", false, "AT_FDCWD", "/opt/x", "0x0", "0")
+	call(2, "openat", false, "AT_FDCWD", "/etc/ld.so.cache", "O_RDONLY")
+	call(2, "close", false, "3")
+	call(2, "openat", false, "AT_FDCWD", "/usr/lib/libfoo.so.1", "O_RDONLY")
+	call(2, "pread64", false, "3", "x", "0x310", "0x40")
+	call(2, "close", false, "3")
+	call(2, "openat", true, "AT_FDCWD", "/opt/x/libbar.so", "O_RDONLY")
+	call(2, "writev", false, "2", "error while loading shared libraries")
+
+	r := loaderReport(events, false, "/bin/sh", []string{"PATH=/bin"})
+	want := LoaderReport{Programs: []ProgramLoad{
+		{
+			PID:         1,
+			Path:        "/bin/sh",
+			Env:         []string{},
+			Libraries:   []LoadedLibrary{{Name: "libc.so.6", Path: "/lib/libc.so.6", Time: 310 * time.Nanosecond}},
+			SearchPaths: []SearchPath{{Dir: "/lib", Found: 1}},
+			BeforeMain:  800 * time.Nanosecond,
+			Syscalls:    8,
+			Main:        true,
+		},
+		{
+			PID:       2,
+			Path:      "/usr/bin/app",
+			Env:       []string{"LD_LIBRARY_PATH=/opt/x", "LD_BIND_NOW=1"},
+			Libraries: []LoadedLibrary{{Name: "libfoo.so.1", Path: "/usr/lib/libfoo.so.1", Probes: 2, Time: 810 * time.Nanosecond}},
+			SearchPaths: []SearchPath{
+				{Dir: "/opt/x", Probes: 2, Time: 30},
+				{Dir: "/opt/x/tls", Probes: 1, Time: 20},
+				{Dir: "/usr/lib", Found: 1},
+			},
+			Missing:    []string{"libbar.so"},
+			BeforeMain: 1110 * time.Nanosecond,
+			Syscalls:   11,
+		},
+	}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("loaderReport =\n%+v\nwant\n%+v", r, want)
+	}
+}
@@ -3,194 +3,290 @@ package main
 import (
 	"bufio"
 	"context"
-	_ "embed"
-	"encoding/json"
+	"embed"
+	"flag"
 	"fmt"
 	"io"
-	"log"
+	"net"
 	"os"
 	"os/exec"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/hugelgupf/go-strace/strace"
-	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/otlp"
+	"github.com/iimos/play/stracy/tracer"
 )
 
-//go:embed template.html
-var templateHTML string
+// assets are the web UI files.
+//
+//go:embed template.html script.js style.css syscalls.json
+var assets embed.FS
 
-//go:embed script.js
-var scriptJS string
+// go run -ldflags "-X main.debug=1" .
+var debug = ""
+
+func isDebug() bool {
+	return debug != ""
+}
 
-//go:embed style.css
-var styleCSS string
+var (
+	captureFile = flag.String("capture", "", "write complete read/write buffers to a pcapng `file`")
+	captureFDs  = flag.String("capture-fds", "", "comma-separated `list` of fds to capture (default all)")
 
-//go:embed syscalls.json
-var syscallsJSON string
+	listenHost = flag.String("host", "localhost", "`address` to serve the UI on, use 0.0.0.0 to expose it to the network")
+	listenPort = flag.Int("port", 8080, "`port` to serve the UI on, 0 picks a free one")
+	authToken  = flag.String("token", "", "require a bearer `token` on the UI, \"random\" generates one")
+	tlsCert    = flag.String("tls-cert", "", "serve the UI over TLS with the certificate `file`")
+	tlsKey     = flag.String("tls-key", "", "private key `file` for -tls-cert")
+	openUI     = flag.Bool("open", false, "open the UI in a browser")
 
-func main_v1() {
-	if len(os.Args) != 2 {
-		fmt.Printf("Usage: %s <path to file>\n", os.Args[0])
-		os.Exit(1)
+	outputFile   = flag.String("o", "", "write events to `file` instead of serving the UI, \"-\" for stdout; the program's stdio is passed through")
+	outputFormat = flag.String("format", "json", "`format` of -o: json (JSON lines) or strace (text, see -f, -tt, -ttt, -T, -y)")
+	straceFormat = straceFlags(flag.CommandLine)
+
+	backendName = flag.String("backend", "go-strace", "ptrace `backend`: go-strace or native")
+	sampleEvery = flag.Duration("sample", 100*time.Millisecond, "sample CPU usage, context switches and RSS of traced processes every `interval`, 0 disables it")
+	calibration = flag.Bool("calibrate", true, "subtract the tracing overhead measured by a calibration run from syscall times")
+
+	redactor = redactFlags(flag.CommandLine)
+	exporter = otlpFlags(flag.CommandLine)
+	alerter  = alertFlags(flag.CommandLine)
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(diffMain(os.Args[2:]))
+		case "query":
+			os.Exit(queryMain(os.Args[2:]))
+		case "policy":
+			os.Exit(policyMain(os.Args[2:]))
+		case "enforce":
+			os.Exit(enforceMain(os.Args[2:]))
+		case "loader":
+			os.Exit(loaderMain(os.Args[2:]))
+		case "alert-hook": // internal, see alertHook
+			os.Exit(alertHookMain(os.Args[2:]))
+		case "calibrate": // internal, see calibrate
+			os.Exit(calibrateMain(os.Args[2:]))
+		}
 	}
-	path := os.Args[1]
 
-	events, err := getTracesFromFile(path)
-	if err != nil {
-		panic(err)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] PROG [ARGS]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s diff [flags] A B\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s query [flags] FILE EXPR\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s policy [flags] PROG [ARGS]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s enforce [flags] PROG [ARGS]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "       %s loader [flags] PROG [ARGS]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
 	}
 
-	outpath := "strace.html"
-	out, err := NewHTMLWriter(outpath)
-	if err != nil {
-		panic(err)
+	cfg := serverConfig{
+		Addr:     net.JoinHostPort(*listenHost, strconv.Itoa(*listenPort)),
+		Token:    *authToken,
+		CertFile: *tlsCert,
+		KeyFile:  *tlsKey,
+		Open:     *openUI,
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		fmt.Printf("-tls-cert and -tls-key must be set together\n")
+		os.Exit(1)
+	}
+	if cfg.Token == "random" {
+		token, err := newToken()
+		if err != nil {
+			fmt.Printf("can't generate token: %s\n", err)
+			os.Exit(1)
+		}
+		cfg.Token = token
 	}
-	defer out.Close()
 
-	jsonEvents, err := json.Marshal(events)
+	redact, err := redactor()
 	if err != nil {
-		panic(err)
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
 	}
 
-	html := strings.Replace(templateHTML, "{{events}}", string(jsonEvents), 1)
-	html = strings.Replace(html, "{{js}}", scriptJS, 1)
-	html = strings.Replace(html, "{{css}}", scriptJS, 1)
-	html = strings.Replace(html, "{{syscalls}}", syscallsJSON, 1)
-	err = out.WriteString(html)
+	export, err := exporter(redact)
 	if err != nil {
-		panic(err)
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("result written in %q\n", outpath)
-}
-
-// go run -ldflags "-X main.debug=1" .
-var debug = ""
+	var text *StraceFormatter
+	switch *outputFormat {
+	case "json":
+	case "strace":
+		f := straceFormat()
+		text = &f
+	default:
+		fmt.Printf("unknown -format %q, want json or strace\n", *outputFormat)
+		os.Exit(1)
+	}
 
-func isDebug() bool {
-	return debug != ""
-}
+	var backend tracer.Backend
+	switch *backendName {
+	case "go-strace":
+		backend = tracer.GoStrace
+	case "native":
+		backend = tracer.Native
+	default:
+		fmt.Printf("unknown -backend %q, want go-strace or native\n", *backendName)
+		os.Exit(1)
+	}
 
-func main() {
-	if len(os.Args) < 2 {
-		fmt.Printf("Usage: %s PROG [ARGS]\n", os.Args[0])
+	detector, hook, err := alerter()
+	if err != nil {
+		fmt.Printf("%s\n", err)
 		os.Exit(1)
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, os.Args[1], os.Args[2:]...)
+	var capture *PayloadCapture
+	if *captureFile != "" {
+		fds, err := parseFDList(*captureFDs)
+		if err != nil {
+			fmt.Printf("invalid -capture-fds: %s\n", err)
+			os.Exit(1)
+		}
+		capture, err = NewPayloadCapture(*captureFile, fds)
+		if err != nil {
+			fmt.Printf("can't create capture file: %s\n", err)
+			os.Exit(1)
+		}
+		capture.redactor = redact
+		defer capture.Close()
+	}
+
+	cmd := exec.CommandContext(ctx, flag.Arg(0), flag.Args()[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
+	if *outputFile != "" {
+		// Headless mode, stdout is kept for events if they go there.
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if *outputFile == "-" {
+			cmd.Stdout = os.Stderr
+		}
+	}
 
-	events, done := trace(cmd)
+	var overhead tracer.Overhead
+	if *calibration {
+		overhead = calibrate(ctx, backend)
+	}
+
+	events, done := trace(cmd, backend, overhead, capture, redact, export, detector, hook)
+	if *outputFile != "" {
+		err := writeOutput(*outputFile, text, events)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "output: %s\n", err)
+			cancel()
+			for range events {
+			}
+		}
+		<-done
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
 	go func() {
 		<-done
 		cancel()
 	}()
 
-	// html := strings.Replace(templateHTML, "{{events}}", string(jsonEvents), 1)
-	html := templateHTML
-	html = strings.Replace(html, "{{js}}", scriptJS, 1)
-	html = strings.Replace(html, "{{css}}", styleCSS, 1)
-	html = strings.Replace(html, "{{syscalls}}", syscallsJSON, 1)
+	if err := startServer(ctx, cfg, events); err != nil {
+		fmt.Printf("server: %s\n", err)
+		cancel()
+		<-done
+		os.Exit(1)
+	}
+}
 
-	addr := ":80"
-	fmt.Printf("listen on %s\n", addr)
-	startServer(ctx, addr, html, events)
+func parseFDList(s string) ([]int32, error) {
+	var fds []int32
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		fd, err := strconv.ParseInt(f, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		fds = append(fds, int32(fd))
+	}
+	return fds, nil
 }
 
-func trace(cmd *exec.Cmd) (events <-chan Event, done chan struct{}) {
-	ch := make(chan Event, 32768)
+func trace(cmd *exec.Cmd, backend tracer.Backend, overhead tracer.Overhead, capture *PayloadCapture, redact *tracer.Redactor, export *otlp.Exporter, detector *tracer.LatencyDetector, hook *alertHook) (events <-chan tracer.Event, done chan struct{}) {
+	ch := make(chan tracer.Event, 32768)
 	done = make(chan struct{})
+
+	opts := []tracer.Option{
+		tracer.WithBackend(backend),
+		tracer.WithOverhead(overhead),
+		tracer.WithObserver(NewMemoryTracker()),
+		tracer.WithObserver(tracer.NewGoRuntime()),
+		tracer.WithRedactor(redact),
+		tracer.OnEvent(func(e tracer.Event) {
+			if export != nil {
+				export.Export(e)
+			}
+			if hook != nil && e.Cat == "alert" {
+				hook.Run(e)
+			}
+			ch <- e
+			if isDebug() {
+				fmt.Printf("%#v\n", e)
+			}
+		}),
+	}
+	if capture != nil {
+		opts = append(opts, tracer.WithObserver(capture))
+	}
+	if export != nil {
+		opts = append(opts, tracer.WithObserver(export))
+	}
+	if *sampleEvery > 0 {
+		opts = append(opts, tracer.WithSampler(*sampleEvery))
+	}
+	if detector != nil {
+		opts = append(opts, tracer.WithObserver(detector))
+	}
+	if isDebug() {
+		opts = append(opts, tracer.WithDebugLog(os.Stdout))
+	}
+
 	go func() {
 		defer close(done)
 		defer close(ch)
 
-		err := strace.Trace(cmd, func(t strace.Task, record *strace.TraceRecord) error {
-			switch record.Event {
-			case strace.SyscallExit:
-				e := newTraceEvent(t, record)
-				if e.Name == "" {
-					fmt.Printf("empty syscall: %v", record.Syscall.Sysno)
-					return nil
-				}
-
-				ch <- e
-				if isDebug() {
-					fmt.Printf("%#v\n", e)
-				}
-
-			case strace.SignalExit:
-				if isDebug() {
-					log.Default()
-					fmt.Printf("PID %d exited from signal %s\n", record.PID, syscalls.SignalString(record.SignalExit.Signal))
-				}
-			case strace.Exit:
-				if isDebug() {
-					fmt.Printf("PID %d exited from exit status %d (code = %d)\n", record.PID, record.Exit.WaitStatus, record.Exit.WaitStatus.ExitStatus())
-				}
-			case strace.SignalStop:
-				if isDebug() {
-					fmt.Printf("PID %d got signal %s\n", record.PID, syscalls.SignalString(record.SignalStop.Signal))
-				}
-			case strace.NewChild:
-				if isDebug() {
-					fmt.Printf("PID %d spawned new child %d\n", record.PID, record.NewChild.PID)
-				}
-			}
-			return nil
-		})
+		_, err := tracer.New(opts...).Run(context.Background(), cmd)
 		if err != nil {
-			fmt.Printf("error: %s", err)
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		}
+		if export != nil {
+			if err := export.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "otlp: %s\n", err)
+			}
+		}
+		if hook != nil {
+			hook.Close()
 		}
 	}()
 	return ch, done
 }
 
-const LogMaximumSize = 1024
-
-func newTraceEvent(t strace.Task, record *strace.TraceRecord) Event {
-	call := record.Syscall
-	syscallInfo := syscalls.Details(call)
-
-	e := Event{
-		Name:      syscallInfo.Name,
-		Cat:       "successful",
-		Ph:        "X", // Complete event
-		PID:       record.PID,
-		TID:       record.PID,
-		Timestamp: int(time.Now().UnixNano()),
-		Duration:  int(call.Duration.Nanoseconds()),
-		Args: Args{
-			Syscall: syscallInfo.Name,
-		},
-	}
-
-	if call.Errno == 0 {
-		e.Args.Result = syscalls.ArgumentSimple(t, syscallInfo.ReturnType, call.Ret[0], LogMaximumSize)
-		// if call.Ret[0].Value < 128 {
-		// 	e.Args.Result = strconv.Itoa(int(call.Ret[0].Int()))
-		// } else {
-		// 	e.Args.Result = fmt.Sprintf("%#x", call.Ret[0].Uint64())
-		// }
-	} else {
-		e.Args.Result = fmt.Sprintf("%q (%d)", call.Errno, call.Errno)
-		e.Cat = "failed"
-	}
-
-	args := syscalls.ArgumentsStrings(syscallInfo, t, call.Args, call.Ret[0], LogMaximumSize)
-	// e.Args.SyscallArgs = strings.Join(args, ", ")
-	e.Args.SyscallArgs = args
-
-	return e
-}
-
 func getTracesFromFile(filepath string) (TraceEvents, error) {
 	f, err := os.OpenFile(filepath, os.O_RDONLY, os.ModePerm)
 	if err != nil {
@@ -199,7 +295,7 @@ func getTracesFromFile(filepath string) (TraceEvents, error) {
 	defer f.Close()
 
 	events := TraceEvents{
-		Event:           make([]Event, 0, 64),
+		Event:           make([]tracer.Event, 0, 64),
 		DisplayTimeUnit: "ns",
 	}
 
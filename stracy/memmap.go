@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/tracer"
+	"golang.org/x/sys/unix"
+)
+
+// Memory categories of a mapping.
+const (
+	memAnon  = "anon"
+	memFile  = "file"
+	memStack = "stack"
+	memHeap  = "heap"
+)
+
+// Reasons of "jit" events.
+const (
+	jitRWX       = "writable and executable mapping"
+	jitWriteExec = "writable memory made executable"
+	jitExecWrite = "executable memory made writable"
+)
+
+// mremapDontUnmap is MREMAP_DONTUNMAP, missing in x/sys/unix.
+const mremapDontUnmap = 4
+
+var pageSize = uint64(os.Getpagesize())
+
+// mapping is a contiguous range of the address space with the same
+// protection and origin.
+type mapping struct {
+	Start, End uint64
+	Prot       uint64
+	Kind       string
+	Path       string
+}
+
+// addressSpace is a model of process mappings. Mappings are sorted by start
+// address and don't overlap.
+type addressSpace struct {
+	maps     []mapping
+	brkStart uint64
+	brkEnd   uint64
+}
+
+// MemoryTracker maintains a model of the address space of every traced
+// process from mmap, munmap, mremap, mprotect and brk calls and reports
+// mapped bytes by category as counter events.
+type MemoryTracker struct {
+	// spaces holds address spaces by thread group id, threads share one.
+	spaces map[int]*addressSpace
+
+	// tgids caches thread group ids by thread id.
+	tgids map[int]int
+
+	// threads counts threads in tgids by thread group id.
+	threads map[int]int
+}
+
+func NewMemoryTracker() *MemoryTracker {
+	return &MemoryTracker{
+		spaces:  make(map[int]*addressSpace),
+		tgids:   make(map[int]int),
+		threads: make(map[int]int),
+	}
+}
+
+// Observe implements tracer.Observer. It updates the model after a syscall
+// exit and returns the events to emit after e: a "memory" counter event when
+// the address space changed and a "jit" instant event when an mprotect
+// pattern typical for JIT compilers is seen.
+func (m *MemoryTracker) Observe(_ strace.Task, record *strace.TraceRecord, e *tracer.Event) []tracer.Event {
+	call := record.Syscall
+	if call == nil || call.Errno != 0 {
+		return nil
+	}
+
+	tgid := m.tgid(record.PID)
+	as, known := m.spaces[tgid]
+	if e.Name == "execve" || e.Name == "execveat" {
+		// The exec killed other threads of the process.
+		for tid, g := range m.tgids {
+			if g == tgid && tid != record.PID {
+				m.forget(tid)
+			}
+		}
+		known = false
+	}
+	if !known {
+		as = loadAddressSpace(tgid)
+		m.spaces[tgid] = as
+	}
+
+	args, ret := call.Args, call.Ret[0].Uint64()
+	var jit string
+	switch e.Name {
+	case "mmap":
+		addr, length, prot, flags, fd := ret, args[1].Uint64(), args[2].Uint64(), args[3].Uint64(), args[4].Int()
+		mp := mapping{Start: addr, End: addr + pageAlign(length), Prot: prot, Kind: memAnon}
+		switch {
+		case flags&unix.MAP_ANONYMOUS == 0 && fd >= 0:
+			mp.Kind = memFile
+			mp.Path, _ = os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", record.PID, fd))
+		case flags&(unix.MAP_GROWSDOWN|unix.MAP_STACK) != 0:
+			mp.Kind = memStack
+		}
+		if isWX(prot) {
+			jit = jitRWX
+		}
+		as.unmap(mp.Start, mp.End)
+		as.insert(mp)
+	case "munmap":
+		addr := args[0].Uint64()
+		as.unmap(addr, addr+pageAlign(args[1].Uint64()))
+	case "mremap":
+		oldAddr, oldLen, newLen := args[0].Uint64(), pageAlign(args[1].Uint64()), pageAlign(args[2].Uint64())
+		mp := mapping{Kind: memAnon}
+		if old := as.find(oldAddr); old != nil {
+			mp = *old
+		}
+		mp.Start, mp.End = ret, ret+newLen
+		if args[3].Uint64()&mremapDontUnmap == 0 {
+			as.unmap(oldAddr, oldAddr+oldLen)
+		}
+		as.unmap(mp.Start, mp.End)
+		as.insert(mp)
+	case "mprotect", "pkey_mprotect":
+		addr, prot := args[0].Uint64(), args[2].Uint64()
+		jit = as.protect(addr, addr+pageAlign(args[1].Uint64()), prot)
+	case "brk":
+		as.setBrk(ret)
+	case "execve", "execveat":
+	default:
+		return nil
+	}
+
+	counters := as.usage()
+	if rss, ok := readRSS(tgid); ok {
+		counters["rss"] = rss
+	}
+	events := []tracer.Event{{
+		Name:      "memory",
+		Cat:       "memory",
+		Ph:        "C", // Counter event
+		PID:       e.PID,
+		TID:       e.TID,
+		Timestamp: e.Timestamp + e.Duration, // at the exit
+		Args:      tracer.Args{Counters: counters},
+	}}
+	if jit != "" {
+		events = append(events, tracer.Event{
+			Name:      "jit",
+			Cat:       "memory",
+			Ph:        "i", // Instant event
+			PID:       e.PID,
+			TID:       e.TID,
+			Timestamp: e.Timestamp + e.Duration,
+			Args: tracer.Args{
+				Syscall:     e.Name,
+				SyscallArgs: []interface{}{fmt.Sprintf("%#x", args[0].Uint64()), args[1].Uint64()},
+				Result:      jit,
+			},
+		})
+	}
+	return events
+}
+
+func (m *MemoryTracker) tgid(pid int) int {
+	if tgid, ok := m.tgids[pid]; ok {
+		return tgid
+	}
+	tgid := pid
+	if status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid)); err == nil {
+		sc := bufio.NewScanner(bytes.NewReader(status))
+		for sc.Scan() {
+			if v, ok := strings.CutPrefix(sc.Text(), "Tgid:"); ok {
+				if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+					tgid = n
+				}
+				break
+			}
+		}
+	}
+	m.tgids[pid] = tgid
+	m.threads[tgid]++
+	return tgid
+}
+
+// Exited implements tracer.ExitObserver. The address space is dropped with
+// the last known thread of the process.
+func (m *MemoryTracker) Exited(tid int) {
+	m.forget(tid)
+}
+
+func (m *MemoryTracker) forget(tid int) {
+	tgid, ok := m.tgids[tid]
+	if !ok {
+		return
+	}
+	delete(m.tgids, tid)
+	if m.threads[tgid]--; m.threads[tgid] <= 0 {
+		delete(m.threads, tgid)
+		delete(m.spaces, tgid)
+	}
+}
+
+// loadAddressSpace reads the current mappings from /proc/PID/maps. An empty
+// address space is returned if the process is already gone.
+func loadAddressSpace(pid int) *addressSpace {
+	as := &addressSpace{}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return as
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// 7f2c4e1d5000-7f2c4e1f7000 r-xp 00000000 08:01 1050 /usr/lib/libc.so.6
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		start, end, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			continue
+		}
+		mp := mapping{Kind: memAnon}
+		mp.Start, _ = strconv.ParseUint(start, 16, 64)
+		mp.End, _ = strconv.ParseUint(end, 16, 64)
+		perms := fields[1]
+		for i, p := range []uint64{unix.PROT_READ, unix.PROT_WRITE, unix.PROT_EXEC} {
+			if i < len(perms) && perms[i] != '-' {
+				mp.Prot |= p
+			}
+		}
+		if len(fields) > 5 {
+			mp.Path = strings.Join(fields[5:], " ")
+		}
+		switch {
+		case mp.Path == "[heap]":
+			mp.Kind = memHeap
+			as.brkStart, as.brkEnd = mp.Start, mp.End
+		case strings.HasPrefix(mp.Path, "[stack"):
+			mp.Kind = memStack
+		case mp.Path != "" && !strings.HasPrefix(mp.Path, "["):
+			mp.Kind = memFile
+		}
+		as.insert(mp)
+	}
+	return as
+}
+
+// readRSS returns the resident set size of a process in bytes.
+func readRSS(pid int) (uint64, bool) {
+	statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * pageSize, true
+}
+
+// insert adds a mapping that must not overlap existing ones.
+func (as *addressSpace) insert(mp mapping) {
+	if mp.End <= mp.Start {
+		return
+	}
+	i := sort.Search(len(as.maps), func(i int) bool { return as.maps[i].Start >= mp.Start })
+	as.maps = append(as.maps, mapping{})
+	copy(as.maps[i+1:], as.maps[i:])
+	as.maps[i] = mp
+}
+
+// unmap removes the [start, end) range, splitting mappings on the edges.
+func (as *addressSpace) unmap(start, end uint64) {
+	if end <= start {
+		return
+	}
+	maps := as.maps[:0:0]
+	for _, mp := range as.maps {
+		if mp.End <= start || mp.Start >= end {
+			maps = append(maps, mp)
+			continue
+		}
+		if mp.Start < start {
+			left := mp
+			left.End = start
+			maps = append(maps, left)
+		}
+		if mp.End > end {
+			right := mp
+			right.Start = end
+			maps = append(maps, right)
+		}
+	}
+	as.maps = maps
+}
+
+// find returns the mapping containing addr.
+func (as *addressSpace) find(addr uint64) *mapping {
+	for i := range as.maps {
+		if as.maps[i].Start <= addr && addr < as.maps[i].End {
+			return &as.maps[i]
+		}
+	}
+	return nil
+}
+
+// protect changes protection of the [start, end) range and returns a reason
+// if the change looks like JIT code generation.
+func (as *addressSpace) protect(start, end, prot uint64) string {
+	var jit string
+	if isWX(prot) {
+		jit = jitRWX
+	}
+
+	var changed []mapping
+	for _, mp := range as.maps {
+		if mp.End <= start || mp.Start >= end {
+			continue
+		}
+		switch {
+		case jit != "":
+		case mp.Prot&unix.PROT_WRITE != 0 && mp.Prot&unix.PROT_EXEC == 0 && prot&unix.PROT_EXEC != 0:
+			jit = jitWriteExec
+		case mp.Prot&unix.PROT_EXEC != 0 && mp.Prot&unix.PROT_WRITE == 0 && prot&unix.PROT_WRITE != 0:
+			jit = jitExecWrite
+		}
+		mp.Start, mp.End = max(mp.Start, start), min(mp.End, end)
+		mp.Prot = prot
+		changed = append(changed, mp)
+	}
+	as.unmap(start, end)
+	for _, mp := range changed {
+		as.insert(mp)
+	}
+	return jit
+}
+
+// setBrk moves the program break. The first brk(0) call of a process returns
+// the start of the heap.
+func (as *addressSpace) setBrk(brk uint64) {
+	if as.brkStart == 0 {
+		as.brkStart, as.brkEnd = brk, brk
+	}
+	end := pageAlign(brk)
+	as.unmap(as.brkStart, max(as.brkEnd, end))
+	as.insert(mapping{Start: as.brkStart, End: end, Prot: unix.PROT_READ | unix.PROT_WRITE, Kind: memHeap, Path: "[heap]"})
+	as.brkEnd = end
+}
+
+// usage returns mapped bytes by category.
+func (as *addressSpace) usage() map[string]uint64 {
+	usage := map[string]uint64{memAnon: 0, memFile: 0, memStack: 0, memHeap: 0}
+	for _, mp := range as.maps {
+		usage[mp.Kind] += mp.End - mp.Start
+	}
+	return usage
+}
+
+func isWX(prot uint64) bool {
+	return prot&unix.PROT_WRITE != 0 && prot&unix.PROT_EXEC != 0
+}
+
+func pageAlign(n uint64) uint64 {
+	return (n + pageSize - 1) &^ (pageSize - 1)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/tracer"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	rw  = unix.PROT_READ | unix.PROT_WRITE
+	rx  = unix.PROT_READ | unix.PROT_EXEC
+	rwx = rw | unix.PROT_EXEC
+
+	mremapMayMove = 1 // MREMAP_MAYMOVE
+)
+
+// pages returns the address of page n.
+func pages(n uint64) uint64 { return 0x10000000 + n*pageSize }
+
+func TestAddressSpaceUnmap(t *testing.T) {
+	as := &addressSpace{}
+	as.insert(mapping{Start: pages(4), End: pages(6), Prot: rw, Kind: memAnon})
+	as.insert(mapping{Start: pages(0), End: pages(2), Prot: unix.PROT_READ, Kind: memFile, Path: "/lib/libc.so.6"})
+	as.insert(mapping{Start: pages(8), End: pages(8)}) // empty
+	want := []mapping{
+		{Start: pages(0), End: pages(2), Prot: unix.PROT_READ, Kind: memFile, Path: "/lib/libc.so.6"},
+		{Start: pages(4), End: pages(6), Prot: rw, Kind: memAnon},
+	}
+	if !reflect.DeepEqual(as.maps, want) {
+		t.Fatalf("insert: got %+v, want sorted %+v", as.maps, want)
+	}
+
+	// A hole in the middle of a mapping splits it, a range over several
+	// mappings trims them.
+	as.insert(mapping{Start: pages(10), End: pages(14), Prot: rw, Kind: memAnon})
+	as.unmap(pages(11), pages(12))
+	as.unmap(pages(1), pages(5))
+	want = []mapping{
+		{Start: pages(0), End: pages(1), Prot: unix.PROT_READ, Kind: memFile, Path: "/lib/libc.so.6"},
+		{Start: pages(5), End: pages(6), Prot: rw, Kind: memAnon},
+		{Start: pages(10), End: pages(11), Prot: rw, Kind: memAnon},
+		{Start: pages(12), End: pages(14), Prot: rw, Kind: memAnon},
+	}
+	if !reflect.DeepEqual(as.maps, want) {
+		t.Fatalf("unmap: got %+v, want %+v", as.maps, want)
+	}
+	if mp := as.find(pages(13)); mp == nil || mp.Start != pages(12) {
+		t.Errorf("find: got %+v", mp)
+	}
+	if mp := as.find(pages(11)); mp != nil {
+		t.Errorf("find in a hole: got %+v", mp)
+	}
+
+	usage := as.usage()
+	if want := map[string]uint64{memAnon: 4 * pageSize, memFile: pageSize, memStack: 0, memHeap: 0}; !reflect.DeepEqual(usage, want) {
+		t.Errorf("usage: got %v, want %v", usage, want)
+	}
+}
+
+func TestAddressSpaceProtect(t *testing.T) {
+	tests := []struct {
+		name string
+		prot uint64 // of the mapping
+		to   uint64
+		jit  string
+	}{
+		{"read only", rw, unix.PROT_READ, ""},
+		{"code", unix.PROT_READ, rx, ""},
+		{"write then exec", rw, rx, jitWriteExec},
+		{"exec then write", rx, rw, jitExecWrite},
+		{"rwx", unix.PROT_READ, rwx, jitRWX},
+		{"already rwx", rwx, rx, ""},
+	}
+	for _, tt := range tests {
+		as := &addressSpace{}
+		as.insert(mapping{Start: pages(0), End: pages(4), Prot: tt.prot, Kind: memAnon})
+		if jit := as.protect(pages(1), pages(2), tt.to); jit != tt.jit {
+			t.Errorf("%s: jit %q, want %q", tt.name, jit, tt.jit)
+		}
+		// The range is split out of the mapping.
+		want := []mapping{
+			{Start: pages(0), End: pages(1), Prot: tt.prot, Kind: memAnon},
+			{Start: pages(1), End: pages(2), Prot: tt.to, Kind: memAnon},
+			{Start: pages(2), End: pages(4), Prot: tt.prot, Kind: memAnon},
+		}
+		if !reflect.DeepEqual(as.maps, want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, as.maps, want)
+		}
+	}
+
+	// Protecting across mappings keeps their origin, holes stay unmapped.
+	as := &addressSpace{}
+	as.insert(mapping{Start: pages(0), End: pages(2), Prot: unix.PROT_READ, Kind: memFile, Path: "/bin/app"})
+	as.insert(mapping{Start: pages(3), End: pages(5), Prot: rw, Kind: memAnon})
+	as.protect(pages(1), pages(4), unix.PROT_NONE)
+	want := []mapping{
+		{Start: pages(0), End: pages(1), Prot: unix.PROT_READ, Kind: memFile, Path: "/bin/app"},
+		{Start: pages(1), End: pages(2), Prot: unix.PROT_NONE, Kind: memFile, Path: "/bin/app"},
+		{Start: pages(3), End: pages(4), Prot: unix.PROT_NONE, Kind: memAnon},
+		{Start: pages(4), End: pages(5), Prot: rw, Kind: memAnon},
+	}
+	if !reflect.DeepEqual(as.maps, want) {
+		t.Errorf("across mappings: got %+v, want %+v", as.maps, want)
+	}
+}
+
+func TestAddressSpaceBrk(t *testing.T) {
+	as := &addressSpace{}
+	as.setBrk(pages(100))
+	as.setBrk(pages(102) + 10)
+	if got := as.usage()[memHeap]; got != 3*pageSize {
+		t.Errorf("grown heap: %d bytes, want %d", got, 3*pageSize)
+	}
+	as.setBrk(pages(101))
+	if got := as.usage()[memHeap]; got != pageSize {
+		t.Errorf("shrunk heap: %d bytes, want %d", got, pageSize)
+	}
+	if len(as.maps) != 1 || as.maps[0].Start != pages(100) || as.maps[0].Path != "[heap]" {
+		t.Errorf("got %+v", as.maps)
+	}
+}
+
+// memCall makes the exit record of a successful call of pid.
+func memCall(pid int, name string, sysno int, ret uint64, args ...uint64) (*strace.TraceRecord, *tracer.Event) {
+	call := &strace.SyscallEvent{Sysno: sysno}
+	for i, a := range args {
+		call.Args[i].Value = uintptr(a)
+	}
+	call.Ret[0].Value = uintptr(ret)
+	return &strace.TraceRecord{PID: pid, Event: strace.SyscallExit, Syscall: call}, &tracer.Event{Name: name, PID: pid, TID: pid}
+}
+
+func TestMemoryTracker(t *testing.T) {
+	const pid = 1 << 30 // not in /proc
+	m := NewMemoryTracker()
+	m.tgids[pid] = pid
+	m.threads[pid] = 1
+	m.spaces[pid] = &addressSpace{}
+	observe := func(name string, sysno int, ret uint64, args ...uint64) []tracer.Event {
+		t.Helper()
+		record, e := memCall(pid, name, sysno, ret, args...)
+		return m.Observe(nil, record, e)
+	}
+	anon := uint64(unix.MAP_PRIVATE | unix.MAP_ANONYMOUS)
+	fd := uint64(1<<64 - 1) // -1
+
+	events := observe("mmap", unix.SYS_MMAP, pages(0), 0, 4*pageSize, rw, anon, fd, 0)
+	if len(events) != 1 || events[0].Ph != "C" || events[0].Args.Counters[memAnon] != 4*pageSize {
+		t.Fatalf("mmap: got %+v", events)
+	}
+	observe("mmap", unix.SYS_MMAP, pages(8), 0, pageSize, rw, anon|unix.MAP_STACK, fd, 0)
+
+	// A moved mapping keeps its kind, the old range is unmapped.
+	events = observe("mremap", unix.SYS_MREMAP, pages(20), pages(0), 4*pageSize, 6*pageSize, mremapMayMove)
+	counters := events[0].Args.Counters
+	if counters[memAnon] != 6*pageSize || counters[memStack] != pageSize {
+		t.Errorf("mremap: got %v", counters)
+	}
+	if m.spaces[pid].find(pages(0)) != nil {
+		t.Errorf("mremap: the old range is still mapped")
+	}
+	// MREMAP_DONTUNMAP keeps the old range.
+	observe("mremap", unix.SYS_MREMAP, pages(40), pages(8), pageSize, pageSize, mremapMayMove|mremapDontUnmap)
+	if counters := m.spaces[pid].usage(); counters[memStack] != 2*pageSize {
+		t.Errorf("mremap with MREMAP_DONTUNMAP: got %v", counters)
+	}
+
+	// Code written and then made executable is reported.
+	events = observe("mprotect", unix.SYS_MPROTECT, 0, pages(21), pageSize, rx)
+	if len(events) != 2 || events[1].Name != "jit" || events[1].Args.Result != jitWriteExec {
+		t.Errorf("mprotect: got %+v", events)
+	}
+	events = observe("mmap", unix.SYS_MMAP, pages(60), 0, pageSize, rwx, anon, fd, 0)
+	if len(events) != 2 || events[1].Args.Result != jitRWX {
+		t.Errorf("rwx mmap: got %+v", events)
+	}
+
+	if events := observe("getpid", unix.SYS_GETPID, pid); events != nil {
+		t.Errorf("getpid: got %+v", events)
+	}
+	record, e := memCall(pid, "munmap", unix.SYS_MUNMAP, 0, pages(20), 6*pageSize)
+	record.Syscall.Errno = unix.EINVAL
+	if events := m.Observe(nil, record, e); events != nil {
+		t.Errorf("failed munmap: got %+v", events)
+	}
+}
+
+func TestMemoryTrackerPrune(t *testing.T) {
+	m := NewMemoryTracker()
+	// Threads 11 and 12 of process 10, process 20.
+	for tid, tgid := range map[int]int{10: 10, 11: 10, 12: 10, 20: 20} {
+		m.tgids[tid] = tgid
+		m.threads[tgid]++
+	}
+	m.spaces[10] = &addressSpace{}
+	m.spaces[20] = &addressSpace{}
+
+	m.Exited(10)
+	m.Exited(11)
+	if _, ok := m.spaces[10]; !ok {
+		t.Errorf("the address space is dropped while thread 12 runs")
+	}
+	m.Exited(12)
+	m.Exited(12)
+	m.Exited(99)
+	if _, ok := m.spaces[10]; ok {
+		t.Errorf("the address space outlived its threads")
+	}
+	if want := map[int]int{20: 20}; !reflect.DeepEqual(m.tgids, want) {
+		t.Errorf("tgids %v, want %v", m.tgids, want)
+	}
+
+	// An exec kills other threads of the process.
+	for _, tid := range []int{21, 22} {
+		m.tgids[tid] = 20
+		m.threads[20]++
+	}
+	record, e := memCall(20, "execve", unix.SYS_EXECVE, 0)
+	m.Observe(nil, record, e)
+	if want := map[int]int{20: 20}; !reflect.DeepEqual(m.tgids, want) || m.threads[20] != 1 {
+		t.Errorf("after exec: tgids %v, threads %v", m.tgids, m.threads)
+	}
+	m.Exited(20)
+	if len(m.spaces) != 0 || len(m.tgids) != 0 || len(m.threads) != 0 {
+		t.Errorf("left state: %v, %v, %v", m.spaces, m.tgids, m.threads)
+	}
+}
@@ -0,0 +1,517 @@
+// Package otlp exports stracy events to an OpenTelemetry collector as spans.
+//
+// Every traced process is a resource and every syscall lasting longer than a
+// threshold is a span with its decoded arguments as attributes. If the
+// process was started with a TRACEPARENT environment variable (W3C trace
+// context), its spans belong to that trace and are children of that span.
+//
+// Spans are sent with OTLP/HTTP using the JSON encoding, which collectors
+// accept on the same endpoint as protobuf (port 4318 by default).
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/tracer"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultEndpoint is the traces endpoint of a local collector.
+const DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+// Config configures an Exporter.
+type Config struct {
+	// Endpoint is the URL of the collector. If it has no path, the
+	// standard /v1/traces is used.
+	Endpoint string
+
+	// Headers are added to export requests, e.g. for authentication.
+	Headers map[string]string
+
+	// Threshold is the minimum duration of a syscall exported as a span.
+	Threshold time.Duration
+
+	// ServiceName is the service.name of all resources. The executable
+	// name of a process is used if it's empty.
+	ServiceName string
+
+	// BatchSize is the number of spans sent in one request, 512 by default.
+	BatchSize int
+
+	// FlushInterval is the maximum time a span waits before it's sent, 1s
+	// by default.
+	FlushInterval time.Duration
+
+	// Client sends requests, http.DefaultClient by default.
+	Client *http.Client
+
+	// Redact hides secrets in process attributes read from /proc, such as
+	// the command line. Events are expected to be redacted already.
+	Redact func(string) string
+}
+
+// Exporter batches events and sends them to a collector in the background.
+type Exporter struct {
+	cfg      Config
+	endpoint string
+	queue    chan span
+	done     chan struct{}
+
+	// environ reads the environment of a process, it's replaced in tests.
+	environ func(pid int) []string
+
+	mu        sync.Mutex
+	processes map[int]*process // by thread id
+	rnd       *mrand.Rand
+	dropped   int
+	err       error
+}
+
+// queueSize is the number of spans buffered before new ones are dropped.
+const queueSize = 16384
+
+// New creates an exporter and starts sending spans. Close must be called to
+// flush the last batch.
+func New(cfg Config) (*Exporter, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultEndpoint
+	}
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %q: scheme must be http or https", cfg.Endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Redact == nil {
+		cfg.Redact = func(s string) string { return s }
+	}
+
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	x := &Exporter{
+		cfg:       cfg,
+		endpoint:  u.String(),
+		queue:     make(chan span, queueSize),
+		done:      make(chan struct{}),
+		environ:   procEnviron,
+		processes: make(map[int]*process),
+		rnd:       mrand.New(mrand.NewSource(int64(binary.LittleEndian.Uint64(seed[:])))),
+	}
+	go x.run()
+	return x, nil
+}
+
+// Export queues a syscall event as a span if it lasted at least the
+// threshold. Other events are ignored. It must be called while the thread of
+// the event still exists, e.g. from tracer.OnEvent, because the process is
+// inspected through /proc.
+func (x *Exporter) Export(e tracer.Event) {
+	if e.Ph != "X" || e.Args.Syscall == "" || time.Duration(e.Duration) < x.cfg.Threshold {
+		return
+	}
+
+	x.mu.Lock()
+	p := x.process(e.TID)
+	s := span{
+		proc:   p,
+		id:     x.spanID(),
+		name:   e.Name,
+		start:  int64(e.Timestamp),
+		end:    int64(e.Timestamp) + int64(e.Duration),
+		tid:    e.TID,
+		args:   e.Args.SyscallArgs,
+		result: e.Args.Result,
+		failed: e.Failed(),
+	}
+	if e.Failed() {
+		s.errno = unix.ErrnoName(e.Args.Errno)
+	}
+	// The next event of the thread comes from the new program.
+	if (e.Name == "execve" || e.Name == "execveat") && !e.Failed() {
+		delete(x.processes, e.TID)
+	}
+	x.mu.Unlock()
+
+	select {
+	case x.queue <- s:
+	default:
+		x.mu.Lock()
+		x.dropped++
+		x.mu.Unlock()
+	}
+}
+
+// Observe implements tracer.Observer. Spans are made from emitted events by
+// Export, the exporter only observes exits.
+func (x *Exporter) Observe(strace.Task, *strace.TraceRecord, *tracer.Event) []tracer.Event {
+	return nil
+}
+
+// Exited implements tracer.ExitObserver. The thread is forgotten, so a
+// process reusing its id gets its own resource and trace.
+func (x *Exporter) Exited(tid int) {
+	x.mu.Lock()
+	delete(x.processes, tid)
+	x.mu.Unlock()
+}
+
+// Close sends queued spans and stops the exporter. It returns the first
+// export error.
+func (x *Exporter) Close() error {
+	close(x.queue)
+	<-x.done
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.dropped > 0 {
+		x.err = errors.Join(x.err, fmt.Errorf("%d spans dropped: queue is full", x.dropped))
+	}
+	return x.err
+}
+
+func (x *Exporter) run() {
+	defer close(x.done)
+	ticker := time.NewTicker(x.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var batch []span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := x.send(batch); err != nil {
+			x.mu.Lock()
+			if x.err == nil {
+				x.err = err
+			}
+			x.mu.Unlock()
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case s, ok := <-x.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) >= x.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (x *Exporter) send(batch []span) error {
+	body, err := json.Marshal(x.request(batch))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, x.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range x.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := x.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("export spans: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// process is a traced program, the resource of its spans.
+type process struct {
+	pid      int
+	attrs    []keyValue
+	traceID  [16]byte
+	parentID [8]byte // zero if there's no parent span
+}
+
+// process returns the process of a thread. x.mu must be held.
+func (x *Exporter) process(tid int) *process {
+	if p, ok := x.processes[tid]; ok {
+		return p
+	}
+	pid := tgid(tid)
+	for _, p := range x.processes {
+		if p.pid == pid {
+			x.processes[tid] = p
+			return p
+		}
+	}
+
+	p := &process{pid: pid}
+	exe, _ := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	cmdline, _ := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	args := strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00")
+	exe = x.cfg.Redact(exe)
+	service := x.cfg.ServiceName
+	if service == "" {
+		service = filepath.Base(exe)
+	}
+	p.attrs = []keyValue{
+		stringAttr("service.name", service),
+		intAttr("process.pid", int64(pid)),
+		stringAttr("process.executable.path", exe),
+		stringAttr("process.executable.name", filepath.Base(exe)),
+		stringAttr("process.command_line", x.cfg.Redact(strings.Join(args, " "))),
+	}
+
+	if tp, ok := traceParent(x.environ(pid)); ok {
+		p.traceID, p.parentID = tp.traceID, tp.spanID
+	} else {
+		x.rnd.Read(p.traceID[:])
+	}
+	x.processes[tid] = p
+	return p
+}
+
+// spanID returns a random span id. x.mu must be held.
+func (x *Exporter) spanID() [8]byte {
+	var id [8]byte
+	for id == [8]byte{} {
+		x.rnd.Read(id[:])
+	}
+	return id
+}
+
+// tgid returns the process id of a thread.
+func tgid(tid int) int {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", tid))
+	if err != nil {
+		return tid
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if v, ok := strings.CutPrefix(line, "Tgid:"); ok {
+			if pid, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return pid
+			}
+		}
+	}
+	return tid
+}
+
+func procEnviron(pid int) []string {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\x00"), "\x00")
+}
+
+type traceContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+// traceParent finds and parses a TRACEPARENT variable:
+// version-traceid-parentid-flags, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func traceParent(env []string) (traceContext, bool) {
+	var tc traceContext
+	for _, kv := range env {
+		v, ok := strings.CutPrefix(kv, "TRACEPARENT=")
+		if !ok {
+			continue
+		}
+		parts := strings.Split(strings.TrimSpace(strings.ToLower(v)), "-")
+		if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+			return tc, false
+		}
+		if _, err := hex.Decode(tc.traceID[:], []byte(parts[1])); err != nil {
+			return tc, false
+		}
+		if _, err := hex.Decode(tc.spanID[:], []byte(parts[2])); err != nil {
+			return tc, false
+		}
+		if tc.traceID == [16]byte{} || tc.spanID == [8]byte{} {
+			return tc, false
+		}
+		return tc, true
+	}
+	return tc, false
+}
+
+// span is a syscall queued for export.
+type span struct {
+	proc       *process
+	id         [8]byte
+	name       string
+	start, end int64
+	tid        int
+	args       []any
+	result     any
+	failed     bool
+	errno      string
+}
+
+func (x *Exporter) request(batch []span) exportRequest {
+	var req exportRequest
+	byProc := make(map[*process]int)
+	for _, s := range batch {
+		i, ok := byProc[s.proc]
+		if !ok {
+			i = len(req.ResourceSpans)
+			byProc[s.proc] = i
+			req.ResourceSpans = append(req.ResourceSpans, resourceSpans{
+				Resource:   resource{Attributes: s.proc.attrs},
+				ScopeSpans: []scopeSpans{{Scope: scope{Name: "stracy"}}},
+			})
+		}
+		ss := &req.ResourceSpans[i].ScopeSpans[0]
+		ss.Spans = append(ss.Spans, s.otlp())
+	}
+	return req
+}
+
+func (s span) otlp() otlpSpan {
+	o := otlpSpan{
+		TraceID:           hex.EncodeToString(s.proc.traceID[:]),
+		SpanID:            hex.EncodeToString(s.id[:]),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start, 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.end, 10),
+		Attributes: []keyValue{
+			stringAttr("syscall.name", s.name),
+			intAttr("thread.id", int64(s.tid)),
+		},
+	}
+	if s.proc.parentID != [8]byte{} {
+		o.ParentSpanID = hex.EncodeToString(s.proc.parentID[:])
+	}
+	for i, a := range s.args {
+		o.Attributes = append(o.Attributes, stringAttr("syscall.arg."+strconv.Itoa(i), attrString(a)))
+	}
+	o.Attributes = append(o.Attributes, stringAttr("syscall.result", attrString(s.result)))
+	if s.failed {
+		o.Attributes = append(o.Attributes, stringAttr("syscall.errno", s.errno))
+		o.Status = &status{Code: statusCodeError, Message: s.errno}
+	}
+	return o
+}
+
+// attrString formats a decoded argument: strings as is, structured values as
+// JSON.
+func attrString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// The JSON encoding of OTLP (opentelemetry/proto/collector/trace/v1). Ids are
+// hex strings and 64-bit integers are decimal strings.
+
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func stringAttr(k, v string) keyValue {
+	return keyValue{Key: k, Value: anyValue{StringValue: &v}}
+}
+
+func intAttr(k string, v int64) keyValue {
+	s := strconv.FormatInt(v, 10)
+	return keyValue{Key: k, Value: anyValue{IntValue: &s}}
+}
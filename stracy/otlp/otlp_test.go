@@ -0,0 +1,300 @@
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+// collector is a fake OTLP/HTTP collector.
+type collector struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []exportRequest
+	headers  []http.Header
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, req)
+		c.headers = append(c.headers, r.Header)
+		c.mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+// spans returns received spans with their resources.
+func (c *collector) spans() (spans []otlpSpan, resources []resource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans = append(spans, s)
+					resources = append(resources, rs.Resource)
+				}
+			}
+		}
+	}
+	return spans, resources
+}
+
+func attr(attrs []keyValue, key string) string {
+	for _, kv := range attrs {
+		if kv.Key != key {
+			continue
+		}
+		switch {
+		case kv.Value.StringValue != nil:
+			return *kv.Value.StringValue
+		case kv.Value.IntValue != nil:
+			return *kv.Value.IntValue
+		}
+	}
+	return ""
+}
+
+func TestExport(t *testing.T) {
+	c := newCollector(t)
+	x, err := New(Config{
+		Endpoint:    c.URL,
+		Headers:     map[string]string{"Authorization": "Bearer t0ken"},
+		Threshold:   time.Millisecond,
+		ServiceName: "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.environ = func(int) []string {
+		return []string{"HOME=/root", "TRACEPARENT=00-" + testTraceID + "-" + testSpanID + "-01"}
+	}
+
+	pid := os.Getpid()
+	now := int(time.Now().UnixNano())
+	x.Export(tracer.Event{
+		Name: "read", Cat: "successful", Ph: "X", PID: pid, TID: pid,
+		Timestamp: now, Duration: int(time.Microsecond),
+		Args: tracer.Args{Syscall: "read"},
+	})
+	x.Export(tracer.Event{
+		Name: "fsync", Cat: "successful", Ph: "X", PID: pid, TID: pid,
+		Timestamp: now, Duration: int(5 * time.Millisecond),
+		Args: tracer.Args{Syscall: "fsync", SyscallArgs: []any{map[string]any{"Type": "fd", "Value": 3}}, Result: "0"},
+	})
+	x.Export(tracer.Event{
+		Name: "openat", Cat: "failed", Ph: "X", PID: pid, TID: pid,
+		Timestamp: now, Duration: int(2 * time.Millisecond),
+		Args: tracer.Args{Syscall: "openat", SyscallArgs: []any{"AT_FDCWD", "/missing"}, Errno: syscall.ENOENT},
+	})
+	x.Export(tracer.Event{Name: "memory", Cat: "memory", Ph: "C", PID: pid, TID: pid, Timestamp: now})
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	spans, resources := c.spans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2 (fsync and openat)", len(spans))
+	}
+	if got := c.headers[0].Get("Authorization"); got != "Bearer t0ken" {
+		t.Errorf("Authorization = %q", got)
+	}
+	for i, s := range spans {
+		if s.TraceID != testTraceID || s.ParentSpanID != testSpanID {
+			t.Errorf("span %s: trace %s, parent %s, want %s, %s", s.Name, s.TraceID, s.ParentSpanID, testTraceID, testSpanID)
+		}
+		if got := attr(resources[i].Attributes, "service.name"); got != "app" {
+			t.Errorf("service.name = %q", got)
+		}
+		if got := attr(resources[i].Attributes, "process.pid"); got != strconv.Itoa(pid) {
+			t.Errorf("process.pid = %q, want %d", got, pid)
+		}
+	}
+
+	fsync, open := spans[0], spans[1]
+	if fsync.Name != "fsync" || open.Name != "openat" {
+		t.Fatalf("got spans %s, %s", fsync.Name, open.Name)
+	}
+	if got, want := fsync.StartTimeUnixNano, strconv.Itoa(now); got != want {
+		t.Errorf("start = %s, want %s", got, want)
+	}
+	if got := attr(fsync.Attributes, "syscall.arg.0"); got != `{"Type":"fd","Value":3}` {
+		t.Errorf("syscall.arg.0 = %s", got)
+	}
+	if fsync.Status != nil {
+		t.Errorf("fsync status = %+v, want unset", fsync.Status)
+	}
+	if got := attr(open.Attributes, "syscall.arg.1"); got != "/missing" {
+		t.Errorf("syscall.arg.1 = %s", got)
+	}
+	if open.Status == nil || open.Status.Code != statusCodeError || open.Status.Message != "ENOENT" {
+		t.Errorf("openat status = %+v, want error ENOENT", open.Status)
+	}
+}
+
+func TestExportTraced(t *testing.T) {
+	c := newCollector(t)
+	x, err := New(Config{Endpoint: c.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("true")
+	cmd.Env = append(os.Environ(), "TRACEPARENT=00-"+testTraceID+"-"+testSpanID+"-01")
+	_, err = tracer.New(tracer.OnEvent(x.Export)).Run(context.Background(), cmd)
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("ptrace is not permitted: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Run: %s", err)
+	}
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	spans, resources := c.spans()
+	if len(spans) == 0 {
+		t.Fatal("no spans exported")
+	}
+	for i, s := range spans {
+		if s.TraceID != testTraceID || s.ParentSpanID != testSpanID {
+			t.Fatalf("span %s: trace %s, parent %s, want %s, %s", s.Name, s.TraceID, s.ParentSpanID, testTraceID, testSpanID)
+		}
+		if got := attr(resources[i].Attributes, "process.executable.name"); got != "true" {
+			t.Fatalf("process.executable.name = %q, want true", got)
+		}
+	}
+}
+
+func TestExportRedact(t *testing.T) {
+	const secret = "eyJhbGciOi.J9.x-y_z"
+	cmd := exec.Command("sh", "-c", "sleep 10", "curl", "-H", "Authorization: Bearer "+secret)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	c := newCollector(t)
+	rules, _ := tracer.NamedRedactRules("bearer")
+	redact := tracer.NewRedactor(rules...)
+	x, err := New(Config{
+		Endpoint: c.URL,
+		Redact: func(s string) string {
+			s, _ = redact.String(s)
+			return s
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := cmd.Process.Pid
+	x.Export(tracer.Event{Name: "read", Ph: "X", PID: pid, TID: pid, Args: tracer.Args{Syscall: "read"}})
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	_, resources := c.spans()
+	if len(resources) != 1 {
+		t.Fatalf("got %d spans, want 1", len(resources))
+	}
+	want := "sh -c sleep 10 curl -H Authorization: Bearer [redacted:bearer]"
+	if got := attr(resources[0].Attributes, "process.command_line"); got != want {
+		t.Errorf("process.command_line = %q, want %q", got, want)
+	}
+}
+
+func TestExportExited(t *testing.T) {
+	c := newCollector(t)
+	x, err := New(Config{Endpoint: c.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.environ = func(int) []string { return nil }
+
+	// The thread id is reused by another process after the exit.
+	pid := os.Getpid()
+	x.Export(tracer.Event{Name: "read", Ph: "X", PID: pid, TID: pid, Args: tracer.Args{Syscall: "read"}})
+	x.Exited(pid)
+	if len(x.processes) != 0 {
+		t.Errorf("processes left after the exit: %v", x.processes)
+	}
+	x.Export(tracer.Event{Name: "read", Ph: "X", PID: pid, TID: pid, Args: tracer.Args{Syscall: "read"}})
+	x.Exited(pid + 1) // not traced
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+
+	spans, _ := c.spans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if spans[0].TraceID == spans[1].TraceID {
+		t.Errorf("both processes are in trace %s", spans[0].TraceID)
+	}
+}
+
+func TestExportCollectorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	x, err := New(Config{Endpoint: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid := os.Getpid()
+	x.Export(tracer.Event{Name: "read", Ph: "X", PID: pid, TID: pid, Args: tracer.Args{Syscall: "read"}})
+	if err := x.Close(); err == nil {
+		t.Fatal("Close succeeded, want the collector error")
+	}
+}
+
+func TestTraceParent(t *testing.T) {
+	for _, tc := range []struct {
+		env []string
+		ok  bool
+	}{
+		{[]string{"TRACEPARENT=00-" + testTraceID + "-" + testSpanID + "-01"}, true},
+		{[]string{"A=1", "TRACEPARENT=00-" + testTraceID + "-" + testSpanID + "-00"}, true},
+		{[]string{"TRACEPARENT=00-" + testTraceID + "-0000000000000000-01"}, false},
+		{[]string{"TRACEPARENT=ff-" + testTraceID + "-" + testSpanID + "-01"}, false},
+		{[]string{"TRACEPARENT=00-xyz-" + testSpanID + "-01"}, false},
+		{[]string{"OTHER=00-" + testTraceID + "-" + testSpanID + "-01"}, false},
+	} {
+		if _, ok := traceParent(tc.env); ok != tc.ok {
+			t.Errorf("traceParent(%q) ok = %v, want %v", tc.env, ok, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// writeOutput writes events to the file at path, "-" is stdout, as JSON lines
+// or, if text is set, as strace lines. The output is flushed whenever the
+// tracer is idle, so it can be piped to jq and the like while the program
+// runs. Both can be read back by stracy diff and query.
+func writeOutput(path string, text *StraceFormatter, events <-chan tracer.Event) error {
+	var out io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for e := range events {
+		if text != nil {
+			if line, ok := text.Format(e); ok {
+				w.WriteString(line)
+				w.WriteByte('\n')
+			}
+		} else if err := enc.Encode(e); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
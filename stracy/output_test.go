@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+)
+
+var outputEvents = []tracer.Event{
+	{Name: "openat", Cat: "successful", Ph: "X", PID: 7, TID: 7, Timestamp: 1_700_000_000_000_000_000, Duration: 2000,
+		Args: tracer.Args{Syscall: "openat", SyscallArgs: []any{"AT_FDCWD", "/etc/<hosts>", "O_RDONLY"}, Result: "3"}},
+	{Name: "memory", Cat: "memory", Ph: "C", PID: 7, TID: 7, Timestamp: 1_700_000_000_000_002_000,
+		Args: tracer.Args{Counters: map[string]uint64{"anon": 4096}}},
+	{Name: "close", Cat: "successful", Ph: "X", PID: 7, TID: 7, Timestamp: 1_700_000_000_000_003_000, Duration: 1000,
+		Args: tracer.Args{Syscall: "close", SyscallArgs: []any{syscalls.Arg{Type: "fd", Value: 3}}, Result: "0"}},
+}
+
+// runOutput writes events with writeOutput and returns the file.
+func runOutput(t *testing.T, text *StraceFormatter) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out")
+	events := make(chan tracer.Event, len(outputEvents))
+	for _, e := range outputEvents {
+		events <- e
+	}
+	close(events)
+	if err := writeOutput(path, text, events); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteOutputJSON(t *testing.T) {
+	data := runOutput(t, nil)
+	lines := strings.Split(strings.TrimSuffix(data, "\n"), "\n")
+	if len(lines) != len(outputEvents) {
+		t.Fatalf("got %d lines, want one per event:\n%s", len(lines), data)
+	}
+	if !strings.Contains(lines[0], `"/etc/<hosts>"`) {
+		t.Errorf("HTML is escaped: %s", lines[0])
+	}
+
+	// The output reads back as a recorded trace.
+	path := filepath.Join(t.TempDir(), "trace.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, textLog, err := loadEvents(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if textLog || len(events) != len(outputEvents) {
+		t.Fatalf("read back %d events, text log %v", len(events), textLog)
+	}
+	for i, e := range events {
+		if e.Name != outputEvents[i].Name || e.Timestamp != outputEvents[i].Timestamp || e.Args.Syscall != outputEvents[i].Args.Syscall {
+			t.Errorf("event %d: got %+v, want %+v", i, e, outputEvents[i])
+		}
+	}
+}
+
+func TestWriteOutputStrace(t *testing.T) {
+	data := runOutput(t, &StraceFormatter{PIDs: true, Time: EpochTime, Durations: true})
+	// Counter events have no strace line.
+	want := `7     1700000000.000000 openat(AT_FDCWD, "/etc/<hosts>", O_RDONLY) = 3 <0.000002>
+7     1700000000.000003 close(3) = 0 <0.000001>
+`
+	if data != want {
+		t.Fatalf("got\n%s\nwant\n%s", data, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "strace.log")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	events, textLog, err := loadEvents(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !textLog || len(events) != 2 || events[1].Args.Syscall != "close" {
+		t.Errorf("read back %+v, text log %v", events, textLog)
+	}
+}
+
+func TestWriteOutputFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	events := make(chan tracer.Event)
+	done := make(chan error, 1)
+	go func() { done <- writeOutput(path, nil, events) }()
+
+	// The event is written out while the tracer is idle, before the end of
+	// the trace.
+	events <- outputEvents[0]
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), `"openat"`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the event isn't flushed while the channel is idle")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(events)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteOutputCreateError(t *testing.T) {
+	events := make(chan tracer.Event)
+	close(events)
+	if err := writeOutput(filepath.Join(t.TempDir(), "missing", "out"), nil, events); err == nil {
+		t.Error("no error for a file in a missing directory")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
+)
+
+// pcapng block types, see https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-05.html
+const (
+	pcapngSectionHeaderBlock   = 0x0a0d0d0a
+	pcapngInterfaceDescBlock   = 0x00000001
+	pcapngEnhancedPacketBlock  = 0x00000006
+	pcapngByteOrderMagic       = 0x1a2b3c4d
+	pcapngOptEndOfOpt          = 0
+	pcapngOptComment           = 1
+	pcapngOptShbUserAppl       = 4
+	pcapngOptIfName            = 2
+	pcapngOptIfTsresol         = 9
+	pcapngEnhancedPacketHeader = 20 // interface id, timestamp (2), captured and original length
+)
+
+// Link types from https://www.tcpdump.org/linktypes.html.
+const (
+	linkTypeRaw   = 101 // raw IPv4 or IPv6 packets
+	linkTypeUser0 = 147 // reserved for private use
+)
+
+var pcapngOrder = ubinary.NativeEndian
+
+// pcapngWriter writes a pcapng stream and keeps track of the stream offset so
+// callers can point into the written packets.
+type pcapngWriter struct {
+	w   *bufio.Writer
+	off int64
+}
+
+func newPcapngWriter(w io.Writer) (*pcapngWriter, error) {
+	pw := &pcapngWriter{w: bufio.NewWriter(w)}
+
+	body := binary.AppendUint32(nil, pcapngOrder, pcapngByteOrderMagic)
+	body = binary.AppendUint16(body, pcapngOrder, 1) // major version
+	body = binary.AppendUint16(body, pcapngOrder, 0) // minor version
+	body = binary.AppendUint64(body, pcapngOrder, ^uint64(0))
+	body = appendPcapngOption(body, pcapngOptShbUserAppl, []byte("stracy"))
+	body = appendPcapngOption(body, pcapngOptEndOfOpt, nil)
+	if _, err := pw.block(pcapngSectionHeaderBlock, body); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// addInterface writes an Interface Description Block. Interfaces are numbered
+// in the order they are added, starting from zero. Timestamps are always in
+// nanoseconds.
+func (pw *pcapngWriter) addInterface(linkType uint16, name string) error {
+	body := binary.AppendUint16(nil, pcapngOrder, linkType)
+	body = binary.AppendUint16(body, pcapngOrder, 0) // reserved
+	body = binary.AppendUint32(body, pcapngOrder, 0) // no snaplen
+	body = appendPcapngOption(body, pcapngOptIfName, []byte(name))
+	body = appendPcapngOption(body, pcapngOptIfTsresol, []byte{9})
+	body = appendPcapngOption(body, pcapngOptEndOfOpt, nil)
+	_, err := pw.block(pcapngInterfaceDescBlock, body)
+	return err
+}
+
+// writePacket writes an Enhanced Packet Block and returns the stream offset
+// of the packet data.
+func (pw *pcapngWriter) writePacket(iface uint32, tsNano int64, data []byte, comment string) (int64, error) {
+	body := make([]byte, 0, pcapngEnhancedPacketHeader+len(data)+len(comment)+16)
+	body = binary.AppendUint32(body, pcapngOrder, iface)
+	body = binary.AppendUint32(body, pcapngOrder, uint32(uint64(tsNano)>>32))
+	body = binary.AppendUint32(body, pcapngOrder, uint32(tsNano))
+	body = binary.AppendUint32(body, pcapngOrder, uint32(len(data)))
+	body = binary.AppendUint32(body, pcapngOrder, uint32(len(data)))
+	body = append(body, data...)
+	body = append(body, make([]byte, pad4(len(data)))...)
+	if comment != "" {
+		body = appendPcapngOption(body, pcapngOptComment, []byte(comment))
+		body = appendPcapngOption(body, pcapngOptEndOfOpt, nil)
+	}
+	off, err := pw.block(pcapngEnhancedPacketBlock, body)
+	return off + pcapngEnhancedPacketHeader, err
+}
+
+// block writes a generic block and returns the stream offset of its body.
+func (pw *pcapngWriter) block(typ uint32, body []byte) (int64, error) {
+	total := uint32(12 + len(body))
+	buf := make([]byte, 0, total)
+	buf = binary.AppendUint32(buf, pcapngOrder, typ)
+	buf = binary.AppendUint32(buf, pcapngOrder, total)
+	buf = append(buf, body...)
+	buf = binary.AppendUint32(buf, pcapngOrder, total)
+
+	off := pw.off + 8
+	n, err := pw.w.Write(buf)
+	pw.off += int64(n)
+	return off, err
+}
+
+func (pw *pcapngWriter) Flush() error {
+	return pw.w.Flush()
+}
+
+func appendPcapngOption(buf []byte, code uint16, value []byte) []byte {
+	buf = binary.AppendUint16(buf, pcapngOrder, code)
+	buf = binary.AppendUint16(buf, pcapngOrder, uint16(len(value)))
+	buf = append(buf, value...)
+	return append(buf, make([]byte, pad4(len(value)))...)
+}
+
+// pad4 returns the number of bytes needed to align n to 32 bits.
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// SeccompProfile is a Docker/OCI seccomp profile.
+type SeccompProfile struct {
+	DefaultAction   string           `json:"defaultAction"`
+	DefaultErrnoRet *uint            `json:"defaultErrnoRet,omitempty"`
+	Architectures   []string         `json:"architectures,omitempty"`
+	Syscalls        []SeccompSyscall `json:"syscalls"`
+}
+
+// SeccompSyscall is a rule of a seccomp profile. The rule matches if the
+// syscall has one of Names and all Args conditions hold.
+type SeccompSyscall struct {
+	Names   []string     `json:"names,omitempty"`
+	Name    string       `json:"name,omitempty"` // old Docker format
+	Action  string       `json:"action"`
+	Args    []SeccompArg `json:"args,omitempty"`
+	Comment string       `json:"comment,omitempty"`
+}
+
+// SeccompArg is a condition on a syscall argument.
+type SeccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+// policyMaxTuples is the maximum number of distinct argument combinations of a
+// syscall turned into separate rules. Syscalls with more combinations are
+// allowed unconditionally.
+const policyMaxTuples = 8
+
+// policyArgTypes are argument types worth constraining: they select a
+// behaviour of a syscall rather than pass data.
+var policyArgTypes = map[syscalls.Type]bool{
+	syscalls.SockFamily:    true,
+	syscalls.SockType:      true,
+	syscalls.SockProtocol:  true,
+	syscalls.CloneFlags:    true,
+	syscalls.FutexOp:       true,
+	syscalls.PtraceRequest: true,
+	syscalls.ItimerType:    true,
+	syscalls.ArchPrctl:     true,
+	syscalls.MADVFlags:     true,
+	syscalls.EpollCtlOp:    true,
+	syscalls.ClockID:       true,
+	syscalls.BPFCmd:        true,
+}
+
+// policyCommandArgs are command arguments of syscalls whose types are not
+// decoded yet.
+var policyCommandArgs = map[string]int{
+	"ioctl": 1,
+	"fcntl": 1,
+	"prctl": 0,
+}
+
+// policyAlwaysAllowed are syscalls the container runtime needs to start the
+// program; the tracer does not see them.
+var policyAlwaysAllowed = []string{"execve"}
+
+// policyArgs returns indexes of arguments to constrain.
+func policyArgs(name string) []int {
+	var idx []int
+	if i, ok := policyCommandArgs[name]; ok {
+		idx = append(idx, i)
+	}
+	si, ok := syscalls.ByName(name)
+	if !ok {
+		return idx
+	}
+	for i, t := range si.ArgTypes {
+		if policyArgTypes[t] {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// policyRecorder collects syscalls and values of their stable arguments.
+type policyRecorder struct {
+	tuples map[string]map[[6]uint64]bool
+}
+
+func newPolicyRecorder() *policyRecorder {
+	return &policyRecorder{tuples: make(map[string]map[[6]uint64]bool)}
+}
+
+func (r *policyRecorder) record(call *strace.SyscallEvent) {
+	name := syscalls.Details(call).Name
+	var tuple [6]uint64
+	for _, i := range policyArgs(name) {
+		tuple[i] = call.Args[i].Uint64()
+	}
+
+	if r.tuples[name] == nil {
+		r.tuples[name] = make(map[[6]uint64]bool)
+	}
+	r.tuples[name][tuple] = true
+}
+
+// Profile returns a profile allowing recorded syscalls only.
+func (r *policyRecorder) Profile() SeccompProfile {
+	errnoRet := uint(syscall.EPERM)
+	p := SeccompProfile{
+		DefaultAction:   "SCMP_ACT_ERRNO",
+		DefaultErrnoRet: &errnoRet,
+		Architectures:   []string{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86", "SCMP_ARCH_X32"},
+	}
+
+	allowed := append([]string(nil), policyAlwaysAllowed...)
+	var constrained []SeccompSyscall
+	for name, tuples := range r.tuples {
+		if !syscallNameKnown(name) {
+			fmt.Fprintf(os.Stderr, "stracy: syscall %s has no name, it's not in the profile\n", name)
+			continue
+		}
+		idx := policyArgs(name)
+		if len(idx) == 0 || len(tuples) > policyMaxTuples {
+			allowed = append(allowed, name)
+			continue
+		}
+		for tuple := range tuples {
+			rule := SeccompSyscall{Names: []string{name}, Action: "SCMP_ACT_ALLOW"}
+			for _, i := range idx {
+				rule.Args = append(rule.Args, SeccompArg{Index: uint(i), Value: tuple[i], Op: "SCMP_CMP_EQ"})
+			}
+			constrained = append(constrained, rule)
+		}
+	}
+
+	allowed = dedup(allowed)
+	if len(allowed) > 0 {
+		p.Syscalls = append(p.Syscalls, SeccompSyscall{Names: allowed, Action: "SCMP_ACT_ALLOW"})
+	}
+	sort.Slice(constrained, func(i, j int) bool {
+		a, b := constrained[i], constrained[j]
+		if a.Names[0] != b.Names[0] {
+			return a.Names[0] < b.Names[0]
+		}
+		for k := range a.Args {
+			if a.Args[k].Value != b.Args[k].Value {
+				return a.Args[k].Value < b.Args[k].Value
+			}
+		}
+		return false
+	})
+	p.Syscalls = append(p.Syscalls, constrained...)
+	return p
+}
+
+// syscallNameKnown filters out syscalls the table has no name for, they are
+// reported by number.
+func syscallNameKnown(name string) bool {
+	_, ok := syscalls.ByName(name)
+	return ok
+}
+
+func dedup(ss []string) []string {
+	sort.Strings(ss)
+	res := ss[:0]
+	for i, s := range ss {
+		if i == 0 || s != ss[i-1] {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+var seccompGoTemplate = template.Must(template.New("seccomp").Funcs(template.FuncMap{"op": seccompGoOp}).Parse(`// Code generated by stracy policy. DO NOT EDIT.
+
+package main
+
+import (
+	"syscall"
+
+	seccomp "github.com/seccomp/libseccomp-golang"
+)
+
+// loadSeccompFilter allows only the syscalls used by a traced run of the
+// program, other syscalls fail with EPERM.
+func loadSeccompFilter() error {
+	filter, err := seccomp.NewFilter(seccomp.ActErrno.SetReturnCode(int16(syscall.EPERM)))
+	if err != nil {
+		return err
+	}
+	defer filter.Release()
+
+	rules := []struct {
+		name  string
+		conds []seccomp.ScmpCondition
+	}{
+{{- range .Syscalls}}{{$rule := .}}{{range .Names}}
+		{"{{.}}", {{if $rule.Args}}[]seccomp.ScmpCondition{
+{{- range $rule.Args}}
+			{Argument: {{.Index}}, Op: seccomp.{{op .Op}}, Operand1: {{printf "%#x" .Value}}, Operand2: {{printf "%#x" .ValueTwo}}},
+{{- end}}
+		}{{else}}nil{{end}}},
+{{- end}}{{end}}
+	}
+	for _, r := range rules {
+		call, err := seccomp.GetSyscallFromName(r.name)
+		if err != nil {
+			return err
+		}
+		if len(r.conds) == 0 {
+			err = filter.AddRule(call, seccomp.ActAllow)
+		} else {
+			err = filter.AddRuleConditional(call, seccomp.ActAllow, r.conds)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return filter.Load()
+}
+`))
+
+var seccompGoOps = map[string]string{
+	"SCMP_CMP_NE":        "CompareNotEqual",
+	"SCMP_CMP_LT":        "CompareLess",
+	"SCMP_CMP_LE":        "CompareLessOrEqual",
+	"SCMP_CMP_EQ":        "CompareEqual",
+	"SCMP_CMP_GE":        "CompareGreaterEqual",
+	"SCMP_CMP_GT":        "CompareGreater",
+	"SCMP_CMP_MASKED_EQ": "CompareMaskedEqual",
+}
+
+func seccompGoOp(op string) string {
+	if s, ok := seccompGoOps[op]; ok {
+		return s
+	}
+	return "CompareInvalid"
+}
+
+// WriteGo writes a libseccomp-golang snippet loading the profile.
+func (p SeccompProfile) WriteGo(w io.Writer) error {
+	return seccompGoTemplate.Execute(w, p)
+}
+
+func policyMain(args []string) int {
+	fs := flag.NewFlagSet("policy", flag.ExitOnError)
+	format := fs.String("format", "oci", "profile `format`: oci (Docker/OCI JSON) or go (libseccomp-golang)")
+	out := fs.String("o", "seccomp.json", "write the profile to `file`, - for stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s policy [flags] PROG [ARGS]\n\nRuns PROG and writes a seccomp profile allowing the syscalls it used.\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 || (*format != "oci" && *format != "go") {
+		fs.Usage()
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	rec := newPolicyRecorder()
+	t := tracer.New(
+		tracer.OnSyscallEnter(func(_ strace.Task, record *strace.TraceRecord) {
+			rec.record(record.Syscall)
+		}),
+		tracer.OnEvent(func(tracer.Event) {}),
+	)
+	if _, err := t.Run(ctx, passthroughCommand(fs.Args())); err != nil {
+		fmt.Fprintf(os.Stderr, "trace: %s\n", err)
+		return 1
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	profile := rec.Profile()
+	var err error
+	if *format == "go" {
+		err = profile.WriteGo(w)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		err = enc.Encode(profile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func enforceMain(args []string) int {
+	fs := flag.NewFlagSet("enforce", flag.ExitOnError)
+	profilePath := fs.String("profile", "seccomp.json", "Docker/OCI seccomp profile `file`")
+	kill := fs.Bool("kill", false, "kill the program on the first violation instead of reporting")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s enforce [flags] PROG [ARGS]\n\nRuns PROG and reports syscalls not allowed by a seccomp profile.\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	profile, err := loadSeccompProfile(*profilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't load profile: %s\n", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	violations := 0
+	t := tracer.New(
+		tracer.OnSyscallEnter(func(_ strace.Task, record *strace.TraceRecord) {
+			call := record.Syscall
+			name := syscalls.Details(call).Name
+			action := profile.Action(name, call.Args)
+			if seccompAllows(action) {
+				return
+			}
+			violations++
+			fmt.Fprintf(os.Stderr, "stracy: pid %d: %s not allowed by the profile (%s)\n", record.PID, formatRawCall(name, call.Args), action)
+			if *kill {
+				syscall.Kill(record.PID, syscall.SIGKILL)
+				cancel()
+			}
+		}),
+		tracer.OnEvent(func(tracer.Event) {}),
+	)
+	if _, err := t.Run(ctx, passthroughCommand(fs.Args())); err != nil && err != context.Canceled {
+		fmt.Fprintf(os.Stderr, "trace: %s\n", err)
+		return 1
+	}
+	if violations > 0 {
+		fmt.Fprintf(os.Stderr, "stracy: %d violations\n", violations)
+		return 1
+	}
+	return 0
+}
+
+// passthroughCommand creates a command sharing stdio with stracy.
+func passthroughCommand(args []string) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+func loadSeccompProfile(path string) (*SeccompProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p SeccompProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Action returns the action of the first rule matching the syscall, or the
+// default action.
+func (p *SeccompProfile) Action(name string, args strace.SyscallArguments) string {
+	for _, rule := range p.Syscalls {
+		if !rule.hasName(name) {
+			continue
+		}
+		match := true
+		for _, a := range rule.Args {
+			if a.Index >= uint(len(args)) || !a.match(args[a.Index].Uint64()) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return rule.Action
+		}
+	}
+	return p.DefaultAction
+}
+
+func (r SeccompSyscall) hasName(name string) bool {
+	if r.Name == name {
+		return true
+	}
+	for _, n := range r.Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a SeccompArg) match(v uint64) bool {
+	switch a.Op {
+	case "SCMP_CMP_NE":
+		return v != a.Value
+	case "SCMP_CMP_LT":
+		return v < a.Value
+	case "SCMP_CMP_LE":
+		return v <= a.Value
+	case "SCMP_CMP_EQ":
+		return v == a.Value
+	case "SCMP_CMP_GE":
+		return v >= a.Value
+	case "SCMP_CMP_GT":
+		return v > a.Value
+	case "SCMP_CMP_MASKED_EQ":
+		return v&a.Value == a.ValueTwo
+	}
+	return false
+}
+
+func seccompAllows(action string) bool {
+	return action == "SCMP_ACT_ALLOW" || action == "SCMP_ACT_LOG"
+}
+
+func formatRawCall(name string, args strace.SyscallArguments) string {
+	si, _ := syscalls.ByName(name)
+	n := len(si.ArgTypes)
+	if n == 0 || n > len(args) {
+		n = len(args)
+	}
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%#x", args[i].Uint64())
+	}
+	return name + "(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/syscalls"
+	"golang.org/x/sys/unix"
+)
+
+func policyCall(sysno uintptr, args ...uintptr) *strace.SyscallEvent {
+	call := &strace.SyscallEvent{Sysno: int(sysno)}
+	for i, a := range args {
+		call.Args[i].Value = a
+	}
+	return call
+}
+
+func TestPolicyProfile(t *testing.T) {
+	rec := newPolicyRecorder()
+	calls := []*strace.SyscallEvent{
+		policyCall(unix.SYS_READ, 3, 0x1000, 16),
+		policyCall(unix.SYS_READ, 4, 0x2000, 32),
+		policyCall(unix.SYS_SOCKET, unix.AF_INET, unix.SOCK_STREAM, 0),
+		policyCall(unix.SYS_SOCKET, unix.AF_INET, unix.SOCK_STREAM, 0),
+		policyCall(unix.SYS_SOCKET, unix.AF_UNIX, unix.SOCK_DGRAM, 0),
+		policyCall(unix.SYS_FCNTL, 3, unix.F_GETFL, 0),
+	}
+	// More combinations than policyMaxTuples, futex is allowed unconditionally.
+	for op := uintptr(0); op <= policyMaxTuples; op++ {
+		calls = append(calls, policyCall(unix.SYS_FUTEX, 0x7f0000001000, op))
+	}
+	for _, c := range calls {
+		rec.record(c)
+	}
+
+	p := rec.Profile()
+	if p.DefaultAction != "SCMP_ACT_ERRNO" || p.DefaultErrnoRet == nil || *p.DefaultErrnoRet != uint(unix.EPERM) {
+		t.Errorf("default %s (%v), want EPERM", p.DefaultAction, p.DefaultErrnoRet)
+	}
+	want := []SeccompSyscall{
+		{Names: []string{"execve", "futex", "read"}, Action: "SCMP_ACT_ALLOW"},
+		{Names: []string{"fcntl"}, Action: "SCMP_ACT_ALLOW", Args: []SeccompArg{
+			{Index: 1, Value: unix.F_GETFL, Op: "SCMP_CMP_EQ"},
+		}},
+		{Names: []string{"socket"}, Action: "SCMP_ACT_ALLOW", Args: []SeccompArg{
+			{Index: 0, Value: unix.AF_UNIX, Op: "SCMP_CMP_EQ"},
+			{Index: 1, Value: unix.SOCK_DGRAM, Op: "SCMP_CMP_EQ"},
+			{Index: 2, Value: 0, Op: "SCMP_CMP_EQ"},
+		}},
+		{Names: []string{"socket"}, Action: "SCMP_ACT_ALLOW", Args: []SeccompArg{
+			{Index: 0, Value: unix.AF_INET, Op: "SCMP_CMP_EQ"},
+			{Index: 1, Value: unix.SOCK_STREAM, Op: "SCMP_CMP_EQ"},
+			{Index: 2, Value: 0, Op: "SCMP_CMP_EQ"},
+		}},
+	}
+	if !reflect.DeepEqual(p.Syscalls, want) {
+		t.Errorf("rules\n%+v\nwant\n%+v", p.Syscalls, want)
+	}
+
+	// The profile allows the recorded calls only.
+	for _, c := range calls {
+		name := syscalls.Details(c).Name
+		if a := p.Action(name, c.Args); a != "SCMP_ACT_ALLOW" {
+			t.Errorf("%s: %s", formatRawCall(name, c.Args), a)
+		}
+	}
+	for _, c := range []*strace.SyscallEvent{
+		policyCall(unix.SYS_SOCKET, unix.AF_INET6, unix.SOCK_STREAM, 0),
+		policyCall(unix.SYS_FCNTL, 3, unix.F_SETFL, unix.O_NONBLOCK),
+		policyCall(unix.SYS_WRITE, 1, 0x1000, 1),
+	} {
+		name := syscalls.Details(c).Name
+		if a := p.Action(name, c.Args); a != "SCMP_ACT_ERRNO" {
+			t.Errorf("%s: %s, want SCMP_ACT_ERRNO", formatRawCall(name, c.Args), a)
+		}
+	}
+}
+
+func TestPolicyWriteGo(t *testing.T) {
+	rec := newPolicyRecorder()
+	rec.record(policyCall(unix.SYS_READ, 3, 0x1000, 16))
+	rec.record(policyCall(unix.SYS_SOCKET, unix.AF_INET, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, unix.IPPROTO_TCP))
+	var b strings.Builder
+	if err := rec.Profile().WriteGo(&b); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "seccomp.go", src, 0); err != nil {
+		t.Fatalf("the snippet doesn't parse: %s\n%s", err, src)
+	}
+	for _, want := range []string{
+		`{"execve", nil},`,
+		`{"read", nil},`,
+		`{"socket", []seccomp.ScmpCondition{
+			{Argument: 0, Op: seccomp.CompareEqual, Operand1: 0x2, Operand2: 0x0},
+			{Argument: 1, Op: seccomp.CompareEqual, Operand1: 0x80001, Operand2: 0x0},
+			{Argument: 2, Op: seccomp.CompareEqual, Operand1: 0x6, Operand2: 0x0},
+		}},`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("the snippet has no\n%s\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,713 @@
+// Package ptrace traces Linux threads with raw ptrace(2) requests. Unlike
+// go-strace it controls every thread on its own, attaches to running
+// processes with PTRACE_SEIZE and tells syscall entries from exits with
+// PTRACE_GET_SYSCALL_INFO, falling back to registers on kernels before 5.3.
+//
+// The kernel only accepts ptrace requests from the thread that attached to
+// a tracee, so Start and Seize lock the calling goroutine to its OS thread
+// until the trace ends, and a Tracer must only be used by that goroutine.
+//
+//	t, err := ptrace.Start(cmd)
+//	if err != nil {
+//		return err
+//	}
+//	for {
+//		s, err := t.Wait()
+//		if err == io.EOF {
+//			return nil // all threads exited
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		if s.Kind == ptrace.SyscallExit {
+//			fmt.Println(s.Thread.TID, s.Syscall.Nr, s.Syscall.Ret)
+//		}
+//		if err := t.Resume(s); err != nil {
+//			return err
+//		}
+//	}
+package ptrace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+// Kind is the kind of a Stop.
+type Kind int
+
+const (
+	// SyscallEnter is a syscall-enter-stop, the thread is about to execute
+	// Stop.Syscall.
+	SyscallEnter Kind = iota + 1
+
+	// SyscallExit is a syscall-exit-stop, Stop.Syscall has the result.
+	SyscallExit
+
+	// Signal is a signal-delivery-stop of Stop.Signal. The signal is
+	// delivered on Resume unless Stop.Signal is reset.
+	Signal
+
+	// GroupStop is a group-stop by Stop.Signal, like SIGSTOP. Seized
+	// threads stay stopped after Resume until they get SIGCONT.
+	GroupStop
+
+	// NewChild reports a thread or process Stop.Child created by clone,
+	// fork or vfork. The child is traced too.
+	NewChild
+
+	// Exec is reported when execve loaded the new program, before the
+	// syscall exit. Stop.FormerTID is the thread that called execve, which
+	// takes the thread group ID if it isn't the group leader.
+	Exec
+
+	// Interrupted is reported after Interrupt and for every thread after
+	// Seize.
+	Interrupted
+
+	// Exited is reported when a thread exits or is killed, Stop.Status is
+	// its wait status. The thread is gone and can't be resumed.
+	Exited
+)
+
+func (k Kind) String() string {
+	switch k {
+	case SyscallEnter:
+		return "syscall enter"
+	case SyscallExit:
+		return "syscall exit"
+	case Signal:
+		return "signal"
+	case GroupStop:
+		return "group stop"
+	case NewChild:
+		return "new child"
+	case Exec:
+		return "exec"
+	case Interrupted:
+		return "interrupted"
+	case Exited:
+		return "exited"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Stop is a state change of a traced thread. Apart from Exited, the thread
+// is stopped until Resume.
+type Stop struct {
+	Kind   Kind
+	Thread *Thread
+	Time   time.Time
+
+	Syscall   *Syscall        // SyscallEnter, SyscallExit
+	Signal    unix.Signal     // Signal, GroupStop
+	Child     int             // NewChild
+	FormerTID int             // Exec
+	Status    unix.WaitStatus // Exited
+}
+
+// Syscall is a syscall of a thread.
+type Syscall struct {
+	// Nr is the syscall number, -1 if it's unknown, like for an exit
+	// of a syscall entered before Seize on kernels without
+	// PTRACE_GET_SYSCALL_INFO.
+	Nr   int
+	Args [6]uint64
+
+	// Ret and Errno are the result, they are only set on exit. Errno is
+	// set if the syscall failed, Ret is -Errno then.
+	Ret   int64
+	Errno unix.Errno
+
+	// Duration is the time from the entry to the exit.
+	Duration time.Duration
+
+	// IP and SP are the instruction and stack pointers of the thread, they
+	// are 0 without PTRACE_GET_SYSCALL_INFO.
+	IP, SP uint64
+}
+
+// Thread is a traced thread.
+type Thread struct {
+	TID int // thread ID
+	PID int // thread group ID, the process ID
+
+	seized  bool
+	started bool // false until the first stop of an auto-attached thread
+	detach  bool
+
+	// entry is the syscall the thread is in.
+	entry     *Syscall
+	enterTime time.Time
+}
+
+// Detach marks the thread to be detached when it's resumed. It runs
+// untraced then, and its new children aren't traced.
+func (th *Thread) Detach() {
+	th.detach = true
+}
+
+// ReadMemory reads memory of the thread's process at addr into b.
+func (th *Thread) ReadMemory(addr uintptr, b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	local := []unix.Iovec{{Base: &b[0]}}
+	local[0].SetLen(len(b))
+	remote := []unix.RemoteIovec{{Base: addr, Len: len(b)}}
+	n, err := unix.ProcessVMReadv(th.TID, local, remote, 0)
+	switch {
+	case err == nil && n < len(b):
+		return n, unix.EFAULT
+	case err == nil, err == unix.EFAULT:
+		return n, err
+	}
+	// process_vm_readv is not available, like in some sandboxes.
+	return unix.PtracePeekData(th.TID, addr, b)
+}
+
+// Read decodes v from the memory of the thread's process at addr, like
+// encoding/binary.Read in the native byte order. It returns the number of
+// bytes read.
+func (th *Thread) Read(addr uintptr, v any) (int, error) {
+	size := binary.Size(v)
+	if size < 0 {
+		return 0, fmt.Errorf("ptrace: can't read %T", v)
+	}
+	buf := make([]byte, size)
+	n, err := th.ReadMemory(addr, buf)
+	if err != nil {
+		return n, err
+	}
+	return n, binary.Read(bytes.NewReader(buf), ubinary.NativeEndian, v)
+}
+
+// Tracer traces threads of one or more processes.
+type Tracer struct {
+	threads map[int]*Thread
+	options int
+	seized  bool
+	locked  bool
+
+	// detaching is set by Detach, new threads are detached right away.
+	detaching bool
+
+	// noSyscallInfo is set if the kernel has no PTRACE_GET_SYSCALL_INFO,
+	// entries and exits are told apart by counting then.
+	noSyscallInfo bool
+}
+
+// defaultOptions make syscall-stops distinguishable from SIGTRAPs and trace
+// new threads and children.
+const defaultOptions = unix.PTRACE_O_TRACESYSGOOD |
+	unix.PTRACE_O_TRACECLONE | unix.PTRACE_O_TRACEFORK | unix.PTRACE_O_TRACEVFORK |
+	unix.PTRACE_O_TRACEEXEC
+
+// Option configures a Tracer created by Seize.
+type Option func(*Tracer)
+
+// KillOnExit kills seized processes if the tracer exits
+// (PTRACE_O_EXITKILL). Commands run by Start are always killed.
+func KillOnExit() Option {
+	return func(t *Tracer) {
+		t.options |= unix.PTRACE_O_EXITKILL
+	}
+}
+
+// Start starts cmd traced from its first instruction. The command is killed
+// if the tracer exits.
+//
+// Wait reaps any child of the calling process, so no other children should
+// be started while tracing.
+func Start(cmd *exec.Cmd) (*Tracer, error) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Ptrace = true
+
+	t := newTracer(defaultOptions | unix.PTRACE_O_EXITKILL)
+	if err := cmd.Start(); err != nil {
+		t.unlock()
+		return nil, err
+	}
+	pid := cmd.Process.Pid
+
+	// The child stops with SIGTRAP after execve.
+	ws, err := wait4(pid)
+	if err != nil {
+		t.unlock()
+		return nil, err
+	}
+	if !ws.Stopped() {
+		t.unlock()
+		return nil, fmt.Errorf("ptrace: pid %d didn't stop after exec: %v", pid, ws)
+	}
+	if err := unix.PtraceSetOptions(pid, t.options); err != nil {
+		unix.Kill(pid, unix.SIGKILL)
+		t.unlock()
+		return nil, os.NewSyscallError("ptrace(PTRACE_SETOPTIONS)", err)
+	}
+	th := t.add(pid, pid, false)
+	th.started = true
+	if err := t.resume(th, 0); err != nil {
+		unix.Kill(pid, unix.SIGKILL)
+		t.unlock()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Seize attaches to all threads of a running process. Every thread reports
+// an Interrupted stop first.
+func Seize(pid int, opts ...Option) (*Tracer, error) {
+	t := newTracer(defaultOptions)
+	t.seized = true
+	for _, opt := range opts {
+		opt(t)
+	}
+	// Threads may start while attaching, repeat until all are seized.
+	for {
+		tids, err := threadIDs(pid)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+		n := 0
+		for _, tid := range tids {
+			if _, ok := t.threads[tid]; ok {
+				continue
+			}
+			err := ptrace(unix.PTRACE_SEIZE, tid, 0, uintptr(t.options))
+			switch {
+			case err == unix.ESRCH:
+				continue // exited
+			case err == unix.EPERM && procStatus(tid, "TracerPid") == os.Getpid():
+				// Auto-attached as a new thread of a seized one, its
+				// first stop isn't reported yet.
+				t.add(tid, pid, true)
+				continue
+			case err != nil:
+				t.Close()
+				return nil, os.NewSyscallError("ptrace(PTRACE_SEIZE)", err)
+			}
+			th := t.add(tid, pid, true)
+			th.started = true
+			if err := ptrace(unix.PTRACE_INTERRUPT, tid, 0, 0); err != nil && err != unix.ESRCH {
+				t.Close()
+				return nil, os.NewSyscallError("ptrace(PTRACE_INTERRUPT)", err)
+			}
+			n++
+		}
+		if n == 0 {
+			break
+		}
+	}
+	if len(t.threads) == 0 {
+		t.unlock()
+		return nil, fmt.Errorf("ptrace: no threads of pid %d", pid)
+	}
+	return t, nil
+}
+
+func newTracer(options int) *Tracer {
+	runtime.LockOSThread()
+	return &Tracer{
+		threads: make(map[int]*Thread),
+		options: options,
+		locked:  true,
+	}
+}
+
+func (t *Tracer) unlock() {
+	if t.locked {
+		t.locked = false
+		runtime.UnlockOSThread()
+	}
+}
+
+func (t *Tracer) add(tid, pid int, seized bool) *Thread {
+	th := &Thread{TID: tid, PID: pid, seized: seized, detach: t.detaching}
+	t.threads[tid] = th
+	return th
+}
+
+// Thread returns a traced thread, nil if it's not traced.
+func (t *Tracer) Thread(tid int) *Thread {
+	return t.threads[tid]
+}
+
+// Threads returns traced threads ordered by TID.
+func (t *Tracer) Threads() []*Thread {
+	ths := make([]*Thread, 0, len(t.threads))
+	for _, th := range t.threads {
+		ths = append(ths, th)
+	}
+	sort.Slice(ths, func(i, j int) bool { return ths[i].TID < ths[j].TID })
+	return ths
+}
+
+// Wait waits for the next stop of any traced thread. It returns io.EOF when
+// no threads are traced anymore, the goroutine is unlocked from its thread
+// then.
+func (t *Tracer) Wait() (*Stop, error) {
+	for len(t.threads) > 0 {
+		var ws unix.WaitStatus
+		tid, err := unix.Wait4(-1, &ws, unix.WALL, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err == unix.ECHILD {
+			clear(t.threads)
+			break
+		}
+		if err != nil {
+			return nil, os.NewSyscallError("wait4", err)
+		}
+		s, err := t.stop(tid, ws)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil {
+			return s, nil
+		}
+	}
+	t.unlock()
+	return nil, io.EOF
+}
+
+// stop decodes a wait status of a thread, it returns nil for stops that are
+// handled internally.
+func (t *Tracer) stop(tid int, ws unix.WaitStatus) (*Stop, error) {
+	th, ok := t.threads[tid]
+	if !ok {
+		if !ws.Stopped() {
+			return nil, nil // not a tracee
+		}
+		// A new child reported before the clone event of its parent.
+		th = t.add(tid, tgid(tid), t.seized)
+	}
+	s := &Stop{Thread: th, Time: time.Now()}
+	if ws.Exited() || ws.Signaled() {
+		delete(t.threads, tid)
+		s.Kind, s.Status = Exited, ws
+		return s, nil
+	}
+	if !ws.Stopped() {
+		return nil, nil
+	}
+
+	sig := ws.StopSignal()
+	switch event := int(ws) >> 16; {
+	case sig == unix.SIGTRAP|0x80:
+		return t.syscallStop(th, s)
+
+	case event == unix.PTRACE_EVENT_STOP:
+		if !th.started {
+			th.started = true
+			return nil, t.resume(th, 0)
+		}
+		switch sig {
+		case unix.SIGSTOP, unix.SIGTSTP, unix.SIGTTIN, unix.SIGTTOU:
+			s.Kind, s.Signal = GroupStop, sig
+		default:
+			s.Kind = Interrupted
+		}
+
+	case event == unix.PTRACE_EVENT_CLONE, event == unix.PTRACE_EVENT_FORK, event == unix.PTRACE_EVENT_VFORK:
+		msg, err := unix.PtraceGetEventMsg(tid)
+		if err != nil {
+			return nil, os.NewSyscallError("ptrace(PTRACE_GETEVENTMSG)", err)
+		}
+		s.Kind, s.Child = NewChild, int(msg)
+		if _, ok := t.threads[s.Child]; !ok {
+			pid := s.Child
+			if event == unix.PTRACE_EVENT_CLONE {
+				pid = tgid(s.Child)
+			}
+			t.add(s.Child, pid, th.seized)
+		}
+
+	case event == unix.PTRACE_EVENT_EXEC:
+		msg, err := unix.PtraceGetEventMsg(tid)
+		if err != nil {
+			return nil, os.NewSyscallError("ptrace(PTRACE_GETEVENTMSG)", err)
+		}
+		s.Kind, s.FormerTID = Exec, int(msg)
+		if former, ok := t.threads[s.FormerTID]; ok && s.FormerTID != tid {
+			// A non-leader thread took over the thread group ID.
+			delete(t.threads, s.FormerTID)
+			former.TID = tid
+			t.threads[tid] = former
+			s.Thread = former
+		}
+
+	case event != 0:
+		return nil, t.resume(th, 0)
+
+	case !th.started && sig == unix.SIGSTOP:
+		th.started = true
+		return nil, t.resume(th, 0)
+
+	default:
+		// There's no siginfo in group-stops.
+		var info [128]byte
+		if ptrace(unix.PTRACE_GETSIGINFO, tid, 0, uintptr(unsafe.Pointer(&info))) == unix.EINVAL {
+			s.Kind = GroupStop
+		} else {
+			s.Kind = Signal
+		}
+		s.Signal = sig
+	}
+	return s, nil
+}
+
+// syscallInfo is struct ptrace_syscall_info.
+type syscallInfo struct {
+	Op   uint8
+	_    [3]uint8
+	Arch uint32
+	IP   uint64
+	SP   uint64
+
+	// The union of entry {nr, args[6]}, exit {rval, is_error} and
+	// seccomp {nr, args[6], ret_data}.
+	Data [8]uint64
+}
+
+func (t *Tracer) syscallStop(th *Thread, s *Stop) (*Stop, error) {
+	sc := Syscall{Nr: -1}
+	var enter bool
+	if !t.noSyscallInfo {
+		var info syscallInfo
+		err := ptrace(unix.PTRACE_GET_SYSCALL_INFO, th.TID, unsafe.Sizeof(info), uintptr(unsafe.Pointer(&info)))
+		switch {
+		case err == unix.EIO || err == unix.EINVAL:
+			t.noSyscallInfo = true
+		case err != nil:
+			return nil, os.NewSyscallError("ptrace(PTRACE_GET_SYSCALL_INFO)", err)
+		case info.Op == unix.PTRACE_SYSCALL_INFO_ENTRY, info.Op == unix.PTRACE_SYSCALL_INFO_SECCOMP:
+			enter = true
+			sc.Nr = int(info.Data[0])
+			copy(sc.Args[:], info.Data[1:])
+		case info.Op == unix.PTRACE_SYSCALL_INFO_EXIT:
+			sc.Ret = int64(info.Data[0])
+			if uint8(info.Data[1]) != 0 {
+				sc.Errno = unix.Errno(-sc.Ret)
+			}
+		default:
+			return nil, t.resume(th, 0)
+		}
+		sc.IP, sc.SP = info.IP, info.SP
+	}
+	if t.noSyscallInfo {
+		nr, args, ret, err := syscallRegs(th.TID)
+		if err != nil {
+			return nil, err
+		}
+		enter = th.entry == nil
+		if enter {
+			sc.Nr, sc.Args = nr, args
+		} else {
+			sc.Ret = ret
+			if -4095 <= ret && ret < 0 {
+				sc.Errno = unix.Errno(-ret)
+			}
+		}
+	}
+
+	if enter {
+		s.Kind = SyscallEnter
+		th.entry, th.enterTime = &sc, s.Time
+	} else {
+		s.Kind = SyscallExit
+		if e := th.entry; e != nil {
+			sc.Nr, sc.Args = e.Nr, e.Args
+			sc.Duration = s.Time.Sub(th.enterTime)
+		} else if nr, args, _, err := syscallRegs(th.TID); err == nil {
+			// Entered before the thread was seized.
+			sc.Nr, sc.Args = nr, args
+		}
+		th.entry = nil
+	}
+	s.Syscall = &sc
+	return s, nil
+}
+
+// Resume restarts the thread stopped at s. The signal of a Signal stop is
+// delivered unless s.Signal is reset to 0. Threads marked with Detach are
+// detached.
+func (t *Tracer) Resume(s *Stop) error {
+	var sig unix.Signal
+	switch s.Kind {
+	case Exited:
+		return nil
+	case Signal:
+		sig = s.Signal
+	case GroupStop:
+		if s.Thread.seized && !s.Thread.detach {
+			// Keep it stopped, but report SIGCONT.
+			return ignoreESRCH(ptrace(unix.PTRACE_LISTEN, s.Thread.TID, 0, 0), "PTRACE_LISTEN")
+		}
+	}
+	return t.resume(s.Thread, sig)
+}
+
+func (t *Tracer) resume(th *Thread, sig unix.Signal) error {
+	if th.detach {
+		delete(t.threads, th.TID)
+		return ignoreESRCH(unix.PtraceDetach(th.TID), "PTRACE_DETACH")
+	}
+	return ignoreESRCH(unix.PtraceSyscall(th.TID, int(sig)), "PTRACE_SYSCALL")
+}
+
+// ignoreESRCH ignores errors of threads killed while they were stopped,
+// their exit is reported by Wait.
+func ignoreESRCH(err error, req string) error {
+	if err == nil || err == unix.ESRCH {
+		return nil
+	}
+	return os.NewSyscallError("ptrace("+req+")", err)
+}
+
+// Interrupt stops a running seized thread at its next stop. Any stop
+// satisfies an interrupt, only a thread that doesn't stop otherwise, like
+// one blocked in a syscall or running in user space, reports an Interrupted
+// stop.
+func (t *Tracer) Interrupt(tid int) error {
+	th, ok := t.threads[tid]
+	if !ok {
+		return fmt.Errorf("ptrace: thread %d is not traced", tid)
+	}
+	if !th.seized {
+		return fmt.Errorf("ptrace: thread %d is not seized", tid)
+	}
+	return ignoreESRCH(ptrace(unix.PTRACE_INTERRUPT, tid, 0, 0), "PTRACE_INTERRUPT")
+}
+
+// Detach detaches from all threads when they stop next, seized threads are
+// interrupted for that. Detached threads run untraced.
+func (t *Tracer) Detach() error {
+	t.detaching = true
+	for _, th := range t.threads {
+		th.detach = true
+		if th.seized && th.started {
+			if err := t.Interrupt(th.TID); err != nil {
+				return err
+			}
+		}
+	}
+	for {
+		s, err := t.Wait()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// New children are detached on their first stop.
+		if err := t.Resume(s); err != nil {
+			return err
+		}
+	}
+}
+
+// Close ends the trace: processes run by Start are killed, seized ones are
+// detached.
+func (t *Tracer) Close() error {
+	defer t.unlock()
+	if t.seized {
+		return t.Detach()
+	}
+	for _, th := range t.threads {
+		unix.Kill(th.PID, unix.SIGKILL)
+	}
+	for {
+		if _, err := t.Wait(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func ptrace(req, pid int, addr, data uintptr) error {
+	_, _, errno := unix.Syscall6(unix.SYS_PTRACE, uintptr(req), uintptr(pid), addr, data, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func wait4(pid int) (unix.WaitStatus, error) {
+	for {
+		var ws unix.WaitStatus
+		_, err := unix.Wait4(pid, &ws, unix.WALL, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return ws, os.NewSyscallError("wait4", err)
+		}
+		return ws, nil
+	}
+}
+
+// threadIDs lists threads of a process.
+func threadIDs(pid int) ([]int, error) {
+	entries, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", pid))
+	if err != nil {
+		return nil, err
+	}
+	tids := make([]int, 0, len(entries))
+	for _, e := range entries {
+		if tid, err := strconv.Atoi(e.Name()); err == nil {
+			tids = append(tids, tid)
+		}
+	}
+	return tids, nil
+}
+
+// procStatus returns a numeric field of /proc/TID/status, like "Tgid", -1
+// if it's not readable.
+func procStatus(tid int, field string) int {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", tid))
+	if err != nil {
+		return -1
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if v, ok := strings.CutPrefix(line, field+":"); ok {
+			if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				return n
+			}
+		}
+	}
+	return -1
+}
+
+// tgid returns the thread group ID of a thread.
+func tgid(tid int) int {
+	if pid := procStatus(tid, "Tgid"); pid > 0 {
+		return pid
+	}
+	return tid
+}
+
+// errNoRegs is returned by syscallRegs on architectures without the
+// register fallback.
+var errNoRegs = errors.New("ptrace: no PTRACE_GET_SYSCALL_INFO and no register fallback for " + runtime.GOARCH)
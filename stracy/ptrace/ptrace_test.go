@@ -0,0 +1,339 @@
+package ptrace_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/ptrace"
+	"golang.org/x/sys/unix"
+)
+
+// build compiles a program of stracy/c.
+func build(t *testing.T, name string) string {
+	t.Helper()
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	bin := filepath.Join(t.TempDir(), name)
+	out, err := exec.Command(cc, "-pthread", "-o", bin, filepath.Join("..", "c", name+".c")).CombinedOutput()
+	if err != nil {
+		t.Fatalf("cc %s: %s\n%s", name, err, out)
+	}
+	return bin
+}
+
+func start(t *testing.T, cmd *exec.Cmd) *ptrace.Tracer {
+	t.Helper()
+	tr, err := ptrace.Start(cmd)
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("ptrace is not permitted: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Start: %s", err)
+	}
+	return tr
+}
+
+// stop is a copy of a ptrace.Stop.
+type stop struct {
+	ptrace.Stop
+	tid, pid int
+	args     []byte // read at entry by read
+}
+
+// collect resumes all stops until the trace ends. read is called on
+// syscall entries.
+func collect(t *testing.T, tr *ptrace.Tracer, read func(s *ptrace.Stop) []byte) []stop {
+	t.Helper()
+	var stops []stop
+	for {
+		s, err := tr.Wait()
+		if err == io.EOF {
+			return stops
+		}
+		if err != nil {
+			t.Fatalf("Wait: %s", err)
+		}
+		st := stop{Stop: *s, tid: s.Thread.TID, pid: s.Thread.PID}
+		if s.Kind == ptrace.SyscallEnter && read != nil {
+			st.args = read(s)
+		}
+		stops = append(stops, st)
+		if err := tr.Resume(s); err != nil {
+			t.Fatalf("Resume: %s", err)
+		}
+	}
+}
+
+// iovecs reads struct iovec arrays of readv and writev.
+func iovecs(t *testing.T) func(s *ptrace.Stop) []byte {
+	return func(s *ptrace.Stop) []byte {
+		if s.Syscall.Nr != unix.SYS_READV && s.Syscall.Nr != unix.SYS_WRITEV {
+			return nil
+		}
+		iov := make([]struct{ Base, Len uint64 }, s.Syscall.Args[2])
+		if _, err := s.Thread.Read(uintptr(s.Syscall.Args[1]), iov); err != nil {
+			t.Errorf("Read: %s", err)
+			return nil
+		}
+		var lens []byte
+		for _, v := range iov {
+			lens = append(lens, byte(v.Len))
+		}
+		return lens
+	}
+}
+
+func syscalls(stops []stop, nr int) (enter, exit []stop) {
+	for _, s := range stops {
+		if s.Syscall == nil || s.Syscall.Nr != nr {
+			continue
+		}
+		if s.Kind == ptrace.SyscallEnter {
+			enter = append(enter, s)
+		} else {
+			exit = append(exit, s)
+		}
+	}
+	return enter, exit
+}
+
+func TestStart(t *testing.T) {
+	for _, tt := range []struct {
+		prog string
+		nr   int
+		want []byte // iov_len of the buffers
+		ret  int64
+	}{
+		{"readv", unix.SYS_READV, []byte{48, 51, 49}, 0},
+		{"writev", unix.SYS_WRITEV, []byte{48, 51, 49}, 148},
+	} {
+		t.Run(tt.prog, func(t *testing.T) {
+			cmd := exec.Command(build(t, tt.prog))
+			stops := collect(t, start(t, cmd), iovecs(t))
+
+			enter, exit := syscalls(stops, tt.nr)
+			if len(enter) != 1 || len(exit) != 1 {
+				t.Fatalf("got %d entries and %d exits of %s, want 1", len(enter), len(exit), tt.prog)
+			}
+			if string(enter[0].args) != string(tt.want) {
+				t.Errorf("iov_len = %v, want %v", enter[0].args, tt.want)
+			}
+			sc := exit[0].Syscall
+			if sc.Ret != tt.ret || sc.Errno != 0 {
+				t.Errorf("%s = %d (errno %v), want %d", tt.prog, sc.Ret, sc.Errno, tt.ret)
+			}
+			if sc.Args != enter[0].Syscall.Args || sc.Duration <= 0 {
+				t.Errorf("exit = %+v, want arguments of the entry %+v and a duration", sc, enter[0].Syscall)
+			}
+
+			// Entries and exits alternate.
+			var in *ptrace.Syscall
+			for _, s := range stops {
+				switch s.Kind {
+				case ptrace.SyscallEnter:
+					if in != nil {
+						t.Fatalf("entry of %d inside of %d", s.Syscall.Nr, in.Nr)
+					}
+					in = s.Syscall
+				case ptrace.SyscallExit:
+					if in != nil && in.Nr != s.Syscall.Nr {
+						t.Fatalf("exit of %d inside of %d", s.Syscall.Nr, in.Nr)
+					}
+					in = nil
+				}
+			}
+
+			last := stops[len(stops)-1]
+			if last.Kind != ptrace.Exited || last.Status.ExitStatus() != 0 || last.pid != cmd.Process.Pid {
+				t.Errorf("last stop = %v %v, want exit of %d", last.Kind, last.Status, cmd.Process.Pid)
+			}
+		})
+	}
+}
+
+func TestStartFailed(t *testing.T) {
+	stops := collect(t, start(t, exec.Command("cat", "/nonexistent")), nil)
+	_, exit := syscalls(stops, unix.SYS_OPENAT)
+	var failed bool
+	for _, s := range exit {
+		if s.Syscall.Errno == unix.ENOENT && s.Syscall.Ret == -int64(unix.ENOENT) {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Errorf("no openat failed with ENOENT in %d exits", len(exit))
+	}
+}
+
+func TestThreads(t *testing.T) {
+	cmd := exec.Command(build(t, "threads"), "3")
+	stops := collect(t, start(t, cmd), nil)
+
+	children := map[int]bool{}
+	calls := map[int]int{}
+	for _, s := range stops {
+		switch {
+		case s.Kind == ptrace.NewChild:
+			children[s.Child] = true
+		case s.Kind == ptrace.SyscallExit && s.Syscall.Nr == unix.SYS_GETPPID:
+			calls[s.tid]++
+			if s.pid != cmd.Process.Pid {
+				t.Errorf("thread %d has PID %d, want %d", s.tid, s.pid, cmd.Process.Pid)
+			}
+			if s.Syscall.Ret != int64(os.Getpid()) {
+				t.Errorf("getppid() = %d, want %d", s.Syscall.Ret, os.Getpid())
+			}
+		}
+	}
+	if len(children) != 3 {
+		t.Errorf("got %d new threads, want 3", len(children))
+	}
+	for tid := range children {
+		if calls[tid] != 3 {
+			t.Errorf("thread %d called getppid %d times, want 3", tid, calls[tid])
+		}
+	}
+	if len(calls) != 3 {
+		t.Errorf("getppid was called by %d threads, want 3", len(calls))
+	}
+}
+
+func TestSeize(t *testing.T) {
+	cmd := exec.Command(build(t, "loop"))
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	// Let the exec complete, the program sleeps most of the time.
+	for i := 0; state(cmd.Process.Pid) != "S"; i++ {
+		if i == 100 {
+			t.Fatal("the program doesn't sleep")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tr, err := ptrace.Seize(cmd.Process.Pid)
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("ptrace is not permitted: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Seize: %s", err)
+	}
+	if ths := tr.Threads(); len(ths) != 1 || ths[0].TID != cmd.Process.Pid {
+		t.Fatalf("Threads() = %v, want one thread %d", ths, cmd.Process.Pid)
+	}
+
+	kinds := map[ptrace.Kind]int{}
+	for kinds[ptrace.SyscallExit] < 10 {
+		s, err := tr.Wait()
+		if err != nil {
+			t.Fatalf("Wait: %s", err)
+		}
+		if kinds[s.Kind] == 0 && s.Kind != ptrace.Interrupted && kinds[ptrace.Interrupted] == 0 {
+			t.Fatalf("first stop is %v, want %v", s.Kind, ptrace.Interrupted)
+		}
+		kinds[s.Kind]++
+		if s.Kind == ptrace.SyscallExit && s.Syscall.Nr < 0 {
+			t.Errorf("exit of an unknown syscall")
+		}
+		if err := tr.Resume(s); err != nil {
+			t.Fatalf("Resume: %s", err)
+		}
+	}
+
+	if err := tr.Detach(); err != nil {
+		t.Fatalf("Detach: %s", err)
+	}
+	// The process runs on untraced.
+	time.Sleep(10 * time.Millisecond)
+	if err := cmd.Process.Signal(syscall.Signal(0)); err != nil {
+		t.Errorf("process is gone after Detach: %s", err)
+	}
+	if pid := tracerPID(t, cmd.Process.Pid); pid != 0 {
+		t.Errorf("TracerPid = %d after Detach, want 0", pid)
+	}
+}
+
+// TestExitKill traces a program from a subprocess that exits while the
+// program is traced.
+func TestExitKill(t *testing.T) {
+	if prog := os.Getenv("STRACY_EXITKILL"); prog != "" {
+		cmd := exec.Command(prog)
+		if _, err := ptrace.Start(cmd); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(cmd.Process.Pid)
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExitKill$")
+	cmd.Env = append(os.Environ(), "STRACY_EXITKILL="+build(t, "loop"))
+	out, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(string(out), "operation not permitted") {
+			t.Skipf("ptrace is not permitted: %s", out)
+		}
+		t.Fatalf("%s: %s", err, out)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		if st := state(pid); st == "" || st == "Z" {
+			break
+		}
+		if i == 100 {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatal("tracee is alive after the tracer exited")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func tracerPID(t *testing.T, pid int) int {
+	t.Helper()
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if v, ok := strings.CutPrefix(line, "TracerPid:"); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return n
+		}
+	}
+	t.Fatal("no TracerPid")
+	return 0
+}
+
+// state returns the state of a process from /proc/PID/stat, "" if the
+// process is gone.
+func state(pid int) string {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ""
+	}
+	// The state follows the command name in parentheses.
+	_, rest, _ := strings.Cut(string(stat), ") ")
+	st, _, _ := strings.Cut(rest, " ")
+	return st
+}
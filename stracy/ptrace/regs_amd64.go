@@ -0,0 +1,18 @@
+package ptrace
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// syscallRegs reads the syscall number, arguments and the return value of a
+// thread in a syscall-stop from its registers.
+func syscallRegs(tid int) (nr int, args [6]uint64, ret int64, err error) {
+	var r unix.PtraceRegs
+	if err := unix.PtraceGetRegs(tid, &r); err != nil {
+		return -1, args, 0, os.NewSyscallError("ptrace(PTRACE_GETREGS)", err)
+	}
+	args = [6]uint64{r.Rdi, r.Rsi, r.Rdx, r.R10, r.R8, r.R9}
+	return int(int64(r.Orig_rax)), args, int64(r.Rax), nil
+}
@@ -0,0 +1,7 @@
+//go:build !amd64
+
+package ptrace
+
+func syscallRegs(tid int) (nr int, args [6]uint64, ret int64, err error) {
+	return -1, args, 0, errNoRegs
+}
@@ -0,0 +1,673 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+const queryHelp = `EXPR is a filter over event fields:
+
+  syscall = openat and errno = EACCES and path under /etc
+  syscall = write and result > 1MB and arg0 ~ socket
+  syscall = newfstatat and arg2.Size >= 4KiB
+  dur > 10ms or (cat = failed and not errno = EAGAIN)
+
+Fields: name, cat, pid, tid, ts, dur, syscall, result, errno, path, args,
+arg0..arg5 and goruntime (the Go runtime activity like netpoll or gc, empty
+for syscalls of the program). Decoded args can be descended into with dots
+(arg1.Mode), fields of Arg.Formated take precedence over raw values.
+
+Operators: = != < <= > >= ~ (regexp) !~ contains under (path prefix),
+combined with and, or, not and parentheses. Numbers may have size (KB, MiB)
+or duration (ms, s) suffixes. Words that are not fields are strings.
+`
+
+func queryMain(args []string) int {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	format := fs.String("o", "json", "output `format`: json or table")
+	limit := fs.Int("limit", 0, "print at most `N` events")
+	redactor := redactFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s query [flags] FILE EXPR\n\nFILE is a recorded trace (JSON) or an strace log.\n\n", os.Args[0])
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), "\n"+queryHelp)
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+
+	q, err := ParseQuery(strings.Join(fs.Args()[1:], " "))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid query: %s\n", err)
+		return 2
+	}
+
+	redact, err := redactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 2
+	}
+
+	events, textLog, err := loadEvents(fs.Arg(0), redact)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't read %s: %s\n", fs.Arg(0), err)
+		return 1
+	}
+
+	var out queryWriter
+	switch *format {
+	case "json":
+		out = &jsonQueryWriter{enc: json.NewEncoder(os.Stdout)}
+	case "table":
+		out = newTableQueryWriter(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown output format %q\n", *format)
+		return 2
+	}
+
+	n := 0
+	for _, e := range events {
+		if *limit > 0 && n >= *limit {
+			break
+		}
+		if !q.Match(e, textLog) {
+			continue
+		}
+		n++
+		if err := out.Write(e); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			return 1
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// Query is a compiled filter expression.
+type Query struct {
+	expr queryExpr
+}
+
+// ParseQuery compiles a filter expression.
+func ParseQuery(s string) (*Query, error) {
+	tokens, err := lexQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return &Query{expr: expr}, nil
+}
+
+// Match reports whether an event matches the query. textLog tells that the
+// event came from an strace log, its args are split and unquoted then.
+func (q *Query) Match(e tracer.Event, textLog bool) bool {
+	return q.expr.eval(newQueryRecord(e, textLog))
+}
+
+// queryRecord holds event fields available to queries.
+type queryRecord map[string]any
+
+func newQueryRecord(e tracer.Event, textLog bool) queryRecord {
+	c := newTraceCall(e, textLog)
+	r := queryRecord{
+		"name":    e.Name,
+		"cat":     e.Cat,
+		"ph":      e.Ph,
+		"pid":     float64(e.PID),
+		"tid":     float64(e.TID),
+		"ts":      float64(e.Timestamp),
+		"dur":     float64(c.Latency),
+		"syscall": e.Args.Syscall,
+		"result":  e.Args.Result,
+		"errno":   c.Errno,
+		"path":    c.path(),
+
+		"goruntime": e.Args.GoRuntime,
+	}
+
+	var args []any
+	if textLog {
+		for _, a := range c.Args {
+			if unquoted, err := strconv.Unquote(a); err == nil {
+				a = unquoted
+			}
+			args = append(args, a)
+		}
+	} else {
+		// round trip through JSON to get the same shape as recorded traces
+		b, _ := json.Marshal(e.Args.SyscallArgs)
+		json.Unmarshal(b, &args)
+		var result any
+		b, _ = json.Marshal(e.Args.Result)
+		json.Unmarshal(b, &result)
+		r["result"] = result
+	}
+	r["args"] = args
+	for i, a := range args {
+		r["arg"+strconv.Itoa(i)] = a
+	}
+	return r
+}
+
+var reArgField = regexp.MustCompile(`^arg\d+$`)
+
+func isQueryField(name string) bool {
+	root, _, _ := strings.Cut(name, ".")
+	switch root {
+	case "name", "cat", "ph", "pid", "tid", "ts", "dur", "syscall", "result", "errno", "path", "args", "goruntime":
+		return true
+	}
+	return reArgField.MatchString(root)
+}
+
+// lookup resolves a dotted field path. Fields of decoded args are taken from
+// Arg.Formated unless raw is set or the field is not formatted.
+func (r queryRecord) lookup(name string, raw bool) any {
+	parts := strings.Split(name, ".")
+	v, ok := r[parts[0]]
+	if !ok {
+		return nil
+	}
+	for _, part := range parts[1:] {
+		v = queryField(v, part, raw)
+	}
+	return v
+}
+
+func queryField(v any, name string, raw bool) any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if isDecodedArg(m) {
+		if f, ok := m["Formated"].(map[string]any); ok && !raw {
+			if x := queryField(f, name, raw); x != nil {
+				return x
+			}
+		}
+		return queryField(m["Value"], name, raw)
+	}
+	if x, ok := m[name]; ok {
+		return x
+	}
+	for k, x := range m {
+		if strings.EqualFold(k, name) {
+			return x
+		}
+	}
+	return nil
+}
+
+// isDecodedArg reports whether m is a marshalled syscalls.Arg.
+func isDecodedArg(m map[string]any) bool {
+	_, hasType := m["Type"]
+	_, hasValue := m["Value"]
+	return hasType && hasValue
+}
+
+// queryString converts a field value to a string for comparisons.
+func queryString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		parts := make([]string, len(v))
+		for i, x := range v {
+			parts[i] = queryString(x)
+		}
+		return strings.Join(parts, "|")
+	case map[string]any:
+		if isDecodedArg(v) {
+			return queryString(v["Value"])
+		}
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+var reNumberPrefix = regexp.MustCompile(`^-?(0x[0-9a-fA-F]+|\d+(\.\d+)?)\b`)
+
+// queryNumber converts a field value to a number. Strings starting with a
+// number, like "3</etc/passwd>" in strace logs, are converted too.
+func queryNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		m := reNumberPrefix.FindString(strings.TrimSpace(v))
+		if m == "" {
+			return 0, false
+		}
+		if n, err := strconv.ParseInt(m, 0, 64); err == nil {
+			return float64(n), true
+		}
+		n, err := strconv.ParseFloat(m, 64)
+		return n, err == nil
+	case map[string]any:
+		if isDecodedArg(v) {
+			return queryNumber(v["Value"])
+		}
+	}
+	return 0, false
+}
+
+type queryExpr interface {
+	eval(r queryRecord) bool
+}
+
+type queryAnd struct{ l, r queryExpr }
+
+func (e queryAnd) eval(r queryRecord) bool { return e.l.eval(r) && e.r.eval(r) }
+
+type queryOr struct{ l, r queryExpr }
+
+func (e queryOr) eval(r queryRecord) bool { return e.l.eval(r) || e.r.eval(r) }
+
+type queryNot struct{ x queryExpr }
+
+func (e queryNot) eval(r queryRecord) bool { return !e.x.eval(r) }
+
+// queryExists matches events having a non-empty field.
+type queryExists struct{ field string }
+
+func (e queryExists) eval(r queryRecord) bool {
+	v := r.lookup(e.field, false)
+	return v != nil && queryString(v) != ""
+}
+
+type queryCompare struct {
+	field string
+	op    string
+	value string
+	num   float64
+	isNum bool
+	re    *regexp.Regexp
+}
+
+func (e queryCompare) eval(r queryRecord) bool {
+	v := r.lookup(e.field, false)
+	switch e.op {
+	case "~":
+		return e.re.MatchString(queryString(v))
+	case "!~":
+		return !e.re.MatchString(queryString(v))
+	case "contains":
+		if list, ok := unwrapValue(v).([]any); ok {
+			for _, x := range list {
+				if queryString(x) == e.value {
+					return true
+				}
+			}
+			return false
+		}
+		return strings.Contains(queryString(v), e.value)
+	case "under":
+		p := queryString(v)
+		dir := strings.TrimSuffix(e.value, "/")
+		return p == dir || strings.HasPrefix(p, dir+"/")
+	}
+
+	if e.isNum {
+		if n, ok := queryNumber(r.lookup(e.field, true)); ok {
+			return compareOrdered(n, e.num, e.op)
+		}
+	}
+	return compareOrdered(queryString(v), e.value, e.op)
+}
+
+func unwrapValue(v any) any {
+	if m, ok := v.(map[string]any); ok && isDecodedArg(m) {
+		return m["Value"]
+	}
+	return v
+}
+
+func compareOrdered[T float64 | string](a, b T, op string) bool {
+	switch op {
+	case "=", "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// sizeSuffixes are multipliers of number literals.
+var sizeSuffixes = map[string]float64{
+	"K": 1e3, "KB": 1e3, "KiB": 1 << 10,
+	"M": 1e6, "MB": 1e6, "MiB": 1 << 20,
+	"G": 1e9, "GB": 1e9, "GiB": 1 << 30,
+}
+
+var reSizeLiteral = regexp.MustCompile(`^(\d+(?:\.\d+)?)([KMG](?:B|iB)?)$`)
+
+// parseQueryNumber parses number literals, including sizes (1MB) and
+// durations (10ms, in nanoseconds).
+func parseQueryNumber(s string) (float64, bool) {
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(n), true
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, true
+	}
+	if m := reSizeLiteral.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		return n * sizeSuffixes[m[2]], true
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return float64(d), true
+	}
+	return 0, false
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+}
+
+func lexQuery(s string) ([]queryToken, error) {
+	var tokens []queryToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{tokLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, queryToken{tokRParen, ")"})
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && s[j] != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return nil, errors.New("unterminated string")
+			}
+			str := s[i+1 : j]
+			if c == '"' {
+				if unquoted, err := strconv.Unquote(s[i : j+1]); err == nil {
+					str = unquoted
+				}
+			}
+			tokens = append(tokens, queryToken{tokString, str})
+			i = j + 1
+		case strings.ContainsRune("=!<>~&|", rune(c)):
+			j := i + 1
+			for j < len(s) && strings.ContainsRune("=!<>~&|", rune(s[j])) {
+				j++
+			}
+			op := s[i:j]
+			switch op {
+			case "&&":
+				op = "and"
+			case "||":
+				op = "or"
+			case "!":
+				op = "not"
+			case "=", "==", "!=", "<", "<=", ">", ">=", "~", "!~":
+			default:
+				return nil, fmt.Errorf("unknown operator %q", op)
+			}
+			tokens = append(tokens, queryToken{tokOp, op})
+			i = j
+		default:
+			j := i
+			for j < len(s) && !unicode.IsSpace(rune(s[j])) && !strings.ContainsRune("()\"'=!<>~&|", rune(s[j])) {
+				j++
+			}
+			word := s[i:j]
+			switch strings.ToLower(word) {
+			case "and", "or", "not", "contains", "under":
+				tokens = append(tokens, queryToken{tokOp, strings.ToLower(word)})
+			default:
+				tokens = append(tokens, queryToken{tokWord, word})
+			}
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return queryToken{kind: tokEOF}
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *queryParser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("or") {
+		p.next()
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = queryOr{l, r}
+	}
+	return l, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("and") {
+		p.next()
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = queryAnd{l, r}
+	}
+	return l, nil
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	if p.isOp("not") {
+		p.next()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{x}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *queryParser) parseCompare() (queryExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, errors.New("missing )")
+		}
+		return x, nil
+	case tokWord:
+	case tokEOF:
+		return nil, errors.New("unexpected end of query")
+	default:
+		return nil, fmt.Errorf("expected field, got %q", tok.text)
+	}
+
+	field := tok.text
+	if !isQueryField(field) {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	op := p.peek()
+	if op.kind != tokOp || op.text == "and" || op.text == "or" || op.text == "not" {
+		return queryExists{field}, nil
+	}
+	p.next()
+
+	val := p.next()
+	if val.kind != tokWord && val.kind != tokString {
+		return nil, fmt.Errorf("expected value after %s %s", field, op.text)
+	}
+
+	cmp := queryCompare{field: field, op: op.text, value: val.text}
+	switch cmp.op {
+	case "~", "!~":
+		re, err := regexp.Compile(val.text)
+		if err != nil {
+			return nil, err
+		}
+		cmp.re = re
+	case "contains", "under":
+	default:
+		if val.kind == tokWord {
+			cmp.num, cmp.isNum = parseQueryNumber(val.text)
+		}
+		if !cmp.isNum && cmp.op != "=" && cmp.op != "==" && cmp.op != "!=" {
+			return nil, fmt.Errorf("%s needs a number, got %q", cmp.op, val.text)
+		}
+	}
+	return cmp, nil
+}
+
+type queryWriter interface {
+	Write(e tracer.Event) error
+	Flush() error
+}
+
+type jsonQueryWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonQueryWriter) Write(e tracer.Event) error { return w.enc.Encode(e) }
+func (w *jsonQueryWriter) Flush() error               { return nil }
+
+type tableQueryWriter struct {
+	bw *bufio.Writer
+	tw *tabwriter.Writer
+}
+
+func newTableQueryWriter(w io.Writer) *tableQueryWriter {
+	bw := bufio.NewWriter(w)
+	tw := tabwriter.NewWriter(bw, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "time\tpid\tsyscall\targs\tresult\tdur")
+	return &tableQueryWriter{bw: bw, tw: tw}
+}
+
+func (w *tableQueryWriter) Write(e tracer.Event) error {
+	args := make([]string, len(e.Args.SyscallArgs))
+	for i, a := range e.Args.SyscallArgs {
+		args[i] = queryString(unwrapJSON(a))
+	}
+	dur := time.Duration(e.Duration)
+	if dur == 0 {
+		dur = time.Duration(e.Args.Duration * float64(time.Second))
+	}
+	_, err := fmt.Fprintf(w.tw, "%s\t%d\t%s\t%s\t%s\t%s\n",
+		time.Unix(0, int64(e.Timestamp)).Format("15:04:05.000000"),
+		e.PID, e.Args.Syscall,
+		truncate(strings.Join(args, ", "), 80),
+		truncate(queryString(unwrapJSON(e.Args.Result)), 40),
+		dur)
+	return err
+}
+
+func (w *tableQueryWriter) Flush() error {
+	if err := w.tw.Flush(); err != nil {
+		return err
+	}
+	return w.bw.Flush()
+}
+
+// unwrapJSON converts a value to its JSON shape.
+func unwrapJSON(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var x any
+	json.Unmarshal(b, &x)
+	return x
+}
+
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
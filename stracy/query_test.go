@@ -0,0 +1,200 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+)
+
+func queryEvent(pid int, name string, dur time.Duration, result any, args ...any) tracer.Event {
+	cat := "successful"
+	if s, ok := result.(string); ok && strings.HasSuffix(s, ")") {
+		cat = "failed"
+	}
+	return tracer.Event{Name: name, Cat: cat, Ph: "X", PID: pid, TID: pid, Timestamp: 1000, Duration: int(dur),
+		Args: tracer.Args{Syscall: name, SyscallArgs: args, Result: result}}
+}
+
+func TestQuery(t *testing.T) {
+	stat := syscalls.Arg{
+		Type:     "stat",
+		Value:    map[string]any{"Size": 8192, "Mode": 0o100644, "Uid": 0},
+		Formated: map[string]any{"Mode": "S_IFREG|0644"},
+	}
+	events := []tracer.Event{
+		queryEvent(1, "openat", time.Microsecond, `"permission denied" (13)`, "AT_FDCWD", "/etc/shadow", "O_RDONLY"),
+		queryEvent(1, "openat", 20*time.Millisecond, "3", "AT_FDCWD", "/etc/passwd", "O_RDONLY|O_CLOEXEC"),
+		queryEvent(2, "write", time.Millisecond, float64(2<<20), "4<socket:[123]>", "...", float64(2<<20)),
+		queryEvent(2, "write", time.Microsecond, `"resource temporarily unavailable" (11)`, "4<socket:[123]>", "...", float64(100)),
+		queryEvent(3, "newfstatat", time.Microsecond, "0", "AT_FDCWD", "/var/log/syslog", stat, "0"),
+		queryEvent(3, "mmap", time.Microsecond, "0x7f0000000000", "0", "4096", []any{"PROT_READ", "PROT_WRITE"}),
+	}
+
+	tests := []struct {
+		query string
+		want  []int // indices of matching events
+	}{
+		// fields and operators
+		{"syscall = openat", []int{0, 1}},
+		{"syscall == openat", []int{0, 1}},
+		{"syscall != openat", []int{2, 3, 4, 5}},
+		{"pid >= 2", []int{2, 3, 4, 5}},
+		{"pid < 2", []int{0, 1}},
+		{"path under /etc", []int{0, 1}},
+		{"path under /etc/", []int{0, 1}},
+		{"path under /et", nil},
+		{"path ~ ^/etc/pa", []int{1}},
+		{"path !~ ^/etc", []int{2, 3, 4, 5}},
+		{"arg0 ~ socket", []int{2, 3}},
+		{"arg2 contains O_CLOEXEC", []int{1}},
+		{"arg2 contains PROT_WRITE", []int{5}},
+		{"arg2 contains PROT", nil}, // lists match whole elements
+		{`path = "/etc/passwd"`, []int{1}},
+		{`path = '/etc/passwd'`, []int{1}},
+		{"cat = failed", []int{0, 3}},
+		{"path", []int{0, 1, 4}},
+
+		// precedence: not binds tighter than and, and than or
+		{"syscall = write or syscall = openat and pid = 1", []int{0, 1, 2, 3}},
+		{"(syscall = write or syscall = openat) and pid = 1", []int{0, 1}},
+		{"syscall = openat and not errno = EACCES", []int{1}},
+		{"not syscall = openat and not syscall = write", []int{4, 5}},
+		{"not not pid = 3", []int{4, 5}},
+		{"syscall = write && pid = 2 || path under /var", []int{2, 3, 4}},
+		{"! (pid = 1 or pid = 2)", []int{4, 5}},
+
+		// sizes, durations and errnos
+		{"syscall = write and result > 1MB", []int{2}},
+		{"syscall = write and result >= 2MiB", []int{2}},
+		{"syscall = write and result > 2MiB", nil},
+		{"result > 1GB", []int{5}}, // addresses are numbers too
+		{"arg2 < 1K", []int{3}},
+		{"dur > 10ms", []int{1}},
+		{"dur >= 1ms and dur < 1s", []int{1, 2}},
+		{"result = 0x7f0000000000", []int{5}},
+		{"arg0 = 4", []int{2, 3}}, // fds with paths compare by number
+		{"errno = EACCES", []int{0}},
+		{"errno = EAGAIN or errno = EACCES", []int{0, 3}},
+		{"errno != EACCES and cat = failed", []int{3}},
+
+		// nested fields of decoded args
+		{"arg2.Size >= 4KiB", []int{4}},
+		{"arg2.size = 8192", []int{4}},
+		{`arg2.Mode = "S_IFREG|0644"`, []int{4}},
+		{"arg2.Mode ~ ^S_IFREG", []int{4}},
+		{"arg2.Mode = 33188", []int{4}}, // numbers compare with raw values
+		{"arg2.Uid = 0", []int{4}},
+		{"arg2.Missing", nil},
+		{"arg2.Size.Deeper = 1", nil},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("%s: %s", tt.query, err)
+			continue
+		}
+		var got []int
+		for i, e := range events {
+			if q.Match(e, false) {
+				got = append(got, i)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryTextLog(t *testing.T) {
+	// Events of strace logs keep the raw argument string and result.
+	events := []tracer.Event{
+		{Name: "openat", Cat: "failed", PID: 1, Args: tracer.Args{Syscall: "openat",
+			SyscallArgs: []any{`AT_FDCWD, "/etc/shadow", O_RDONLY`}, Result: "-1 EACCES (Permission denied)"}},
+		{Name: "read", Cat: "successful", PID: 1, Args: tracer.Args{Syscall: "read",
+			SyscallArgs: []any{`3</etc/passwd>, "root:x:0:0"..., 4096`}, Result: "4096"}},
+	}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"errno = EACCES", []int{0}},
+		{"path = /etc/shadow", []int{0}},
+		{"arg0 = 3", []int{1}},
+		{"arg0 ~ passwd", []int{1}},
+		{"arg2 = 4096 and result >= 4KiB", []int{1}},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.query, err)
+		}
+		var got []int
+		for i, e := range events {
+			if q.Match(e, true) {
+				got = append(got, i)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: matched %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{"", "unexpected end of query"},
+		{"syscall = openat and", "unexpected end of query"},
+		{"(syscall = openat", "missing )"},
+		{"syscall = openat)", `unexpected ")"`},
+		{"foo = 1", `unknown field "foo"`},
+		{"= 1", `expected field, got "="`},
+		{"syscall =", "expected value after syscall ="},
+		{"syscall = (", "expected value after syscall ="},
+		{"result > big", `> needs a number, got "big"`},
+		{`result < "1"`, `< needs a number, got "1"`},
+		{`path ~ "("`, "missing closing )"},
+		{"path => /etc", `unknown operator "=>"`},
+		{`path = "/etc`, "unterminated string"},
+		{"pid = 1 pid = 2", `unexpected "pid"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: got error %v, want %q", tt.query, err, tt.err)
+		}
+	}
+}
+
+func TestParseQueryNumber(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+		ok   bool
+	}{
+		{"42", 42, true},
+		{"0x10", 16, true},
+		{"1.5", 1.5, true},
+		{"4K", 4000, true},
+		{"4KB", 4000, true},
+		{"4KiB", 4096, true},
+		{"1.5MiB", 1.5 * (1 << 20), true},
+		{"2GB", 2e9, true},
+		{"10ms", float64(10 * time.Millisecond), true},
+		{"1m30s", float64(90 * time.Second), true},
+		{"4kb", 0, false},
+		{"EACCES", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseQueryNumber(tt.s)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %v, %v, want %v, %v", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+}
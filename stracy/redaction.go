@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// listFlag is a flag that may be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// redactFlags registers redaction flags on fs. The returned function builds a
+// redactor from them after fs is parsed.
+func redactFlags(fs *flag.FlagSet) func() (*tracer.Redactor, error) {
+	names := fs.String("redact", strings.Join(tracer.RedactRuleNames(), ","),
+		"comma-separated `rules` hiding secrets in buffers and arguments, none to disable")
+	var exprs, envs listFlag
+	fs.Var(&exprs, "redact-re", "also redact matches of a `regexp`, the last group only if it has groups (repeatable)")
+	fs.Var(&envs, "redact-env", "also redact values of environment variables matching a `glob` (repeatable)")
+
+	return func() (*tracer.Redactor, error) {
+		var rules []tracer.RedactRule
+		for _, name := range strings.Split(*names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || name == "none" {
+				continue
+			}
+			named, ok := tracer.NamedRedactRules(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown redaction rule %q, known rules: %s", name, strings.Join(tracer.RedactRuleNames(), ", "))
+			}
+			rules = append(rules, named...)
+		}
+		for _, expr := range exprs {
+			rule, err := tracer.RegexpRedactRule(expr)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+		if len(envs) > 0 {
+			rules = append(rules, tracer.EnvRedactRule(envs...))
+		}
+		return tracer.NewRedactor(rules...), nil
+	}
+}
@@ -51,6 +51,38 @@ function renderArg(arg) {
         case "msghdr":
             child = renderStruct(arg.Value, arg.Formated)
             break
+        case "pollfd":
+            html = renderPollFDs(arg.Value)
+            break
+        case "fd":
+            html = renderFD(arg.Value, arg.Formated)
+            break
+        case "fd_set":
+            html = escapeHtml("[" + (arg.Value || []).join(" ") + "]")
+            break
+        case "epoll_event":
+            html = renderEpollEvent(arg.Value)
+            break
+        case "epoll_events":
+            html = "[" + (arg.Value || []).map(renderEpollEvent).join(", ") + "]"
+            break
+        case "dirents":
+            html = renderDirents(arg.Value)
+            break
+        case "string_vector":
+            child = renderStringVector(arg.Value, arg.Formated)
+            break
+        case "netlink":
+            child = renderNetlink(arg.Value, arg.Formated)
+            break
+        case "bpf_attr":
+        case "clone_args":
+        case "perf_event_attr":
+        case "tcp_info":
+        case "utsname":
+        case "rusage":
+            child = renderStruct(arg.Value, arg.Formated)
+            break
         default:
             html = renderAnything(arg.Value, arg.Formated)
             break
@@ -96,19 +128,65 @@ function renderFlags(arr, formated) {
     return escapeHtml(arr.join("|"))
 }
 
-function renderString(str) {
+// renderFD shows a file descriptor with the path it refers to on hover.
+function renderFD(fd, formated) {
+    const path = formated && formated.path
+    if (!path) {
+        return escapeHtml(String(fd))
+    }
+    return `<span title="${escapeHtml(path)}">${escapeHtml(String(fd))}</span>`
+}
+
+function renderPollFDs(fds) {
+    const items = (fds || []).map(x => {
+        let s = `{fd=${x.FD}, events=${renderFlags(x.Events.Value)}`
+        if (x.Revents.Value) {
+            s += `, revents=${renderFlags(x.Revents.Value)}`
+        }
+        return s + "}"
+    })
+    return "[" + items.join(", ") + "]"
+}
+
+function renderEpollEvent(ev) {
+    return `{events=${renderFlags(ev.Events.Value)}, data=${escapeHtml(ev.Data)}}`
+}
+
+function renderDirents(ents) {
+    const items = (ents || []).map(x =>
+        `{d_ino=${x.Ino}, d_type=${renderFlags(x.Type.Value)}, d_name=${escapeHtml(JSON.stringify(x.Name))}}`)
+    return "[" + items.join(", ") + "]"
+}
+
+function renderString(str, formated, maxLen = 40) {
     str = String(str)
     if (str.startsWith("\x7fELF")) {
         str = "<binary>"
     }
-    if (str.length > 40) {
-        str = str.substr(0, 40) + "..."
-    }
     const span = document.createElement('span')
-    span.textContent = str
+    if (RedactedMarker.test(str)) {
+        span.className = 'has_redacted'
+    }
+    if (str.length > maxLen) {
+        str = str.substr(0, maxLen) + "..."
+    }
+    // Secrets replaced by the redactor are highlighted.
+    str.split(RedactedMarkerGroup).forEach((part, i) => {
+        if (i % 2 === 0) {
+            span.appendChild(document.createTextNode(part))
+            return
+        }
+        const marker = document.createElement('span')
+        marker.className = 'redacted'
+        marker.textContent = part
+        span.appendChild(marker)
+    })
     return span
 }
 
+const RedactedMarker = /\[redacted:[a-z]+\]/
+const RedactedMarkerGroup = /(\[redacted:[a-z]+\])/
+
 function renderStruct(obj, formated, header) {
     let empty = false
     if (!header) {
@@ -145,16 +223,95 @@ function renderStruct(obj, formated, header) {
     return container
 }
 
+function renderNetlink(msgs, formated) {
+    msgs = msgs || []
+    const types = msgs.map(m => (m.Type.Value || []).join("|") + (m.Payload && m.Payload.Error ? " " + m.Payload.Error : ""))
+    const header = "[" + types.join(", ") + "]"
+    return renderStruct(msgs, formated, header.length <= 60 ? header : header.substr(0, 60) + "...]")
+}
+
+function renderStringVector(arr, formated) {
+    arr = arr || []
+    const more = (formated && formated.more) || 0
+    const quoted = arr.map(x => JSON.stringify(x))
+    let header = "[" + quoted.join(", ") + (more ? `, ... ${more} more` : "") + "]"
+    if (header.length <= 60) {
+        return renderString(header, null, 60)
+    }
+    header = `[${arr.length + more} items]`
+    const rows = arr.map(x => `<div class="strace_struct_row">${renderString(x, null, 200).outerHTML}</div>`)
+    if (more) {
+        rows.push(`<div class="strace_struct_row">... ${more} more</div>`)
+    }
+    return renderPopup(header, rows.join(""))
+}
+
+function renderExec(exec) {
+    let header = "→ " + (exec.Path || "?")
+    if (exec.Interpreter) {
+        header += ` (${exec.Interpreter.join(" ")})`
+    }
+    const changes = [].concat(
+        (exec.EnvAdded || []).map(x => ["+", x]),
+        (exec.EnvChanged || []).map(x => ["~", x]),
+        (exec.EnvRemoved || []).map(x => ["-", x]),
+    )
+    if (!changes.length) {
+        const elem = el('strace_exec')
+        elem.textContent = header
+        return elem
+    }
+    header += `, env ${changes.length} changes`
+    const rows = changes.map(([op, x]) =>
+        `<div class="strace_env_row strace_env_${op === "+" ? "added" : op === "-" ? "removed" : "changed"}">${op} ${renderString(x, null, 200).outerHTML}</div>`
+    )
+    const elem = renderPopup(header, rows.join(""))
+    elem.classList.add('strace_exec')
+    return elem
+}
+
+// renderPopup renders a header showing html on hover.
+function renderPopup(header, html) {
+    const container = el('strace_struct')
+    const head = el('strace_struct_header')
+    head.textContent = header
+    container.append(head)
+    tippy(container, {
+        content: `<div class="strace_struct_content_popup">${html}</div>`,
+        appendTo: () => document.body,
+        allowHTML: true,
+        interactive: true,
+        placement: 'bottom-start',
+        offset: [0, 0],
+        arrow: false,
+    })
+    return container
+}
+
 function renderStructPopup(obj, formated) {
     formated = formated || {}
     let html = ''
     for (let key in obj) {
         let val = formated[key] || obj[key]
-        html += `<div class="st111race_struct_row">${escapeHtml(key)}: ${renderAnything(val)}</div>`
+        html += `<div class="st111race_struct_row">${escapeHtml(key)}: ${renderPopupValue(val)}</div>`
     }
     return `<div class="str111ace_struct_content">${html}</div>`
 }
 
+// renderPopupValue renders a struct field as html, nested structs (like
+// netlink messages and their attributes) are expanded in place.
+function renderPopupValue(val) {
+    const nested = val && typeof val === 'object'
+        && !('Type' in val && 'Value' in val)
+        && !('Sec' in val && 'Nsec' in val)
+        && !(Array.isArray(val) && val.every(x => typeof x !== 'object'))
+    if (nested) {
+        return renderStructPopup(val)
+    }
+    const html = renderAnything(val)
+    return html instanceof Node ? html.outerHTML : html
+}
+
 function renderStat(obj, formated) {
     let mode = formated.Mode
     let size = formated.Size
@@ -162,7 +319,13 @@ function renderStat(obj, formated) {
 }
 
 function renderStraceItem(e) {
+    if (e.ph === "i") {
+        return renderInstantItem(e)
+    }
     const item = el('strace_item')
+    if (e.args.GoRuntime) {
+        item.classList.add('strace_item_runtime')
+    }
 
     const a = document.createElement('a')
     a.classList.add('strace_syscall_name')
@@ -173,8 +336,12 @@ function renderStraceItem(e) {
     item.append(a)
     
     const args = el('strace_syscall_args')
-    for (let x of e.args.SyscallArgs) {
+    for (let [i, x] of e.args.SyscallArgs.entries()) {
         let arg = renderArg(x)
+        const host = e.args.HostPaths && e.args.HostPaths[i]
+        if (host) {
+            arg.title = "on the host: " + host
+        }
         args.append(arg)
     }
     item.append(args)
@@ -184,6 +351,46 @@ function renderStraceItem(e) {
         res.append(renderArg(e.args.Result))
         item.append(res)
     }
+    if (e.args.Exec) {
+        item.append(renderExec(e.args.Exec))
+    }
+    if (e.args.Timing) {
+        item.append(renderTiming(e.dur, e.args.Timing))
+    }
+    if (e.args.GoRuntime) {
+        appendChild(item, 'strace_runtime', `go:${e.args.GoRuntime}`)
+    }
+    return item
+}
+
+// renderTiming shows how a syscall spent its time: on a CPU, waiting for
+// one or blocked.
+function renderTiming(dur, timing) {
+    const parts = [`cpu ${humanDuration(timing.CPU)}`]
+    if (timing.RunQueue) {
+        parts.push(`runqueue ${humanDuration(timing.RunQueue)}`)
+    }
+    if (timing.Blocked) {
+        parts.push(`blocked ${humanDuration(timing.Blocked)}`)
+    }
+    const elem = el('strace_timing')
+    elem.textContent = `${humanDuration(dur || 0)}: ${parts.join(", ")}`
+    if (timing.Blocked > timing.CPU + timing.RunQueue) {
+        elem.classList.add('strace_timing_blocked')
+    }
+    return elem
+}
+
+function renderInstantItem(e) {
+    const item = el('strace_item')
+    item.classList.add('strace_item_instant')
+    item.title = JSON.stringify(e)
+    if (e.cat === "alert") {
+        item.classList.add('strace_item_alert')
+        item.textContent = `${e.args.Syscall}: ${e.args.Result}`
+        return item
+    }
+    item.textContent = `${e.name}: ${e.args.Result} (${e.args.Syscall} ${(e.args.SyscallArgs || []).join(", ")})`
     return item
 }
 
@@ -202,6 +409,21 @@ function renderStraceItem(e) {
     }, 1000)
 })();
 
+// processGroup returns the container a process runs in, or its namespaces
+// or cgroup if they differ from stracy's ones, "" if they don't.
+function processGroup(ns) {
+    if (!ns) {
+        return ""
+    }
+    if (ns.Container) {
+        return "container " + ns.Container.slice(0, 12)
+    }
+    if (ns.IDs) {
+        return Object.keys(ns.IDs).sort().map(t => `${t}:[${ns.IDs[t]}]`).join(" ")
+    }
+    return "cgroup " + ns.Cgroup
+}
+
 const UI = {
     rowHeight: 24,
     cellHeightMin: 5,
@@ -219,6 +441,7 @@ class Timeline {
     #timeslotDuration = 10_000_000; // 10ms (10e6 ns)
     #PIDOrder = [];
     #PIDIndexes = {};
+    #PIDGroups = {}; // pid -> processGroup
     #minTimeslot = 0;
     #currentTimeslot = 0;
 
@@ -270,7 +493,7 @@ class Timeline {
             return
         }
 
-        this.addPID(e.pid)
+        this.addPID(e.pid, e.args && e.args.Namespaces)
         for (let slot = this.#currentTimeslot; slot <= timeslot; slot += 1) {
             this.#adjustPlaceholder(slot)
         }
@@ -284,11 +507,42 @@ class Timeline {
         }
     }
 
-    addPID(pid) {
-        if (!(pid in this.#PIDIndexes)) {
-            this.#PIDIndexes[pid] = this.#PIDOrder.length
-            this.#PIDOrder.push(pid)
-            appendChild(this.#headerNode, 'timeline_head_cell', String(pid))
+    // addPID adds a column of a process. Processes of the same container
+    // (or other namespaces) get adjacent columns.
+    addPID(pid, ns) {
+        if (pid in this.#PIDIndexes) {
+            return
+        }
+        const group = processGroup(ns)
+        let i = this.#PIDOrder.length
+        if (group) {
+            const last = this.#PIDOrder.findLastIndex(p => this.#PIDGroups[p] === group)
+            if (last >= 0) {
+                i = last + 1
+            }
+        }
+        this.#PIDGroups[pid] = group
+        this.#PIDOrder.splice(i, 0, pid)
+        this.#PIDOrder.forEach((p, j) => this.#PIDIndexes[p] = j)
+
+        const head = el('timeline_head_cell')
+        head.textContent = String(pid)
+        if (ns && ns.PID) {
+            head.textContent += ` (${ns.PID} in ns)`
+        }
+        if (group) {
+            const label = el('timeline_head_group')
+            label.textContent = group
+            label.title = JSON.stringify(ns)
+            head.append(label)
+        }
+        this.#headerNode.insertBefore(head, this.#headerNode.childNodes[i] || null)
+        // Shift cells of already rendered timeslots.
+        for (const slot in this.#slotNodes) {
+            const node = this.#slotNodes[slot]
+            if (node.childNodes.length > i) {
+                node.insertBefore(el('timeline_cell'), node.childNodes[i])
+            }
         }
     }
 
@@ -397,15 +651,207 @@ class Timeline {
     }
 }
 
-(function main(){
+const MemoryCategories = ["heap", "stack", "anon", "file"];
+const MemoryColors = {heap: "#d9534f", stack: "#f0ad4e", anon: "#5bc0de", file: "#5cb85c"};
+
+// memoryLegend describes the last sample of "memory" counters.
+function memoryLegend(last) {
+    let html = MemoryCategories.map(c =>
+        `<span style="color:${MemoryColors[c]}">${c}</span> ${humanFileSize(last[c] || 0)}`
+    ).join(", ")
+    if ('rss' in last) {
+        html += `, rss ${humanFileSize(last.rss)}`
+    }
+    return html
+}
+
+const SchedCategories = ["cpu", "runqueue"];
+const SchedColors = {cpu: "#5cb85c", runqueue: "#d9534f"};
+
+// schedLegend describes the last sample of "sched" counters, CPU times are
+// in percent of one CPU and context switches per second.
+function schedLegend(last) {
+    return [
+        `<span style="color:${SchedColors.cpu}">cpu</span> ${last.cpu}% (user ${last.user}%, system ${last.system}%)`,
+        `<span style="color:${SchedColors.runqueue}">runqueue</span> ${last.runqueue}%`,
+        `switches ${last.voluntary}/s voluntary, ${last.involuntary}/s involuntary`,
+        `rss ${humanFileSize(last.rss)}`,
+        `threads ${last.threads}`,
+    ].join(", ")
+}
+
+// CounterView draws counter events of every process over time as stacked
+// step charts of the categories. The scale is shared by all processes, it's
+// at least minScale.
+class CounterView {
+    #rootNode;
+    #categories;
+    #colors;
+    #legend;
+    #minScale;
+    #rows = {}; // pid -> {node, canvas, legend, samples}
+    #dirty = false;
+
+    constructor(rootNode, categories, colors, legend, minScale) {
+        this.#rootNode = rootNode
+        this.#categories = categories
+        this.#colors = colors
+        this.#legend = legend
+        this.#minScale = minScale || 1
+    }
+
+    appendEvent(e) {
+        let row = this.#rows[e.pid]
+        if (!row) {
+            const node = el('memory_row')
+            const label = el('memory_label')
+            label.textContent = String(e.pid)
+            const canvas = document.createElement('canvas')
+            canvas.width = 600
+            canvas.height = 60
+            const legend = el('memory_legend')
+            node.append(label, canvas, legend)
+            this.#rootNode.append(node)
+            row = this.#rows[e.pid] = {node, canvas, legend, samples: []}
+        }
+        row.samples.push({ts: e.ts, counters: e.args.Counters || {}})
+
+        if (!this.#dirty) {
+            this.#dirty = true
+            window.requestAnimationFrame(() => {
+                this.#dirty = false
+                this.#render()
+            })
+        }
+    }
+
+    #render() {
+        const samples = Object.values(this.#rows).flatMap(row => row.samples)
+        if (!samples.length) {
+            return
+        }
+        const minTs = Math.min(...samples.map(s => s.ts))
+        const maxTs = Math.max(...samples.map(s => s.ts))
+        const total = s => this.#categories.reduce((sum, c) => sum + (s.counters[c] || 0), 0)
+        const scale = Math.max(...samples.map(total), this.#minScale)
+
+        for (const row of Object.values(this.#rows)) {
+            this.#renderRow(row, minTs, Math.max(maxTs - minTs, 1), scale)
+        }
+    }
+
+    #renderRow(row, minTs, span, scale) {
+        const ctx = row.canvas.getContext('2d')
+        const w = row.canvas.width, h = row.canvas.height
+        ctx.clearRect(0, 0, w, h)
+
+        // stacked step chart, each sample lasts until the next one
+        row.samples.forEach((s, i) => {
+            const next = row.samples[i + 1]
+            const x0 = Math.floor(w * (s.ts - minTs) / span)
+            const x1 = next ? Math.ceil(w * (next.ts - minTs) / span) : w
+            let y = h
+            for (const c of this.#categories) {
+                const dy = h * (s.counters[c] || 0) / scale
+                ctx.fillStyle = this.#colors[c]
+                ctx.fillRect(x0, y - dy, Math.max(x1 - x0, 1), dy)
+                y -= dy
+            }
+        })
+
+        row.legend.innerHTML = this.#legend(row.samples[row.samples.length - 1].counters)
+    }
+}
+
+// AlertView lists latency anomalies, the newest first.
+class AlertView {
+    #rootNode;
+    #rowsNode;
+    #countNode;
+    #count = 0;
+
+    constructor(rootNode) {
+        this.#rootNode = rootNode
+        this.#rowsNode = rootNode.querySelector('.alert_rows')
+        this.#countNode = rootNode.querySelector('.alerts_count')
+    }
+
+    appendEvent(e) {
+        const a = e.args.Alert || {}
+        const row = el('alert_row')
+        row.title = JSON.stringify(e)
+
+        const link = document.createElement('a')
+        link.classList.add('strace_syscall_name')
+        link.href = getSyscallLink(e.args.Syscall)
+        link.target = '_blank'
+        link.textContent = e.args.Syscall
+        const fd = a.FDType ? ` on ${a.FDType}` : ""
+        const args = el('strace_syscall_args')
+        for (let x of e.args.SyscallArgs || []) {
+            args.append(renderArg(x))
+        }
+        row.append(
+            el('alert_pid', escapeHtml(e.pid)),
+            link,
+            args,
+            el('alert_reason', escapeHtml(`${humanDuration(a.Duration)}${fd}, ${a.Reason}`)),
+        )
+        this.#rowsNode.prepend(row)
+
+        this.#count++
+        this.#countNode.textContent = String(this.#count)
+        this.#rootNode.hidden = false
+    }
+}
+
+function humanDuration(ns) {
+    if (ns >= 1e9) {
+        return (ns / 1e9).toFixed(2) + "s"
+    }
+    if (ns >= 1e6) {
+        return (ns / 1e6).toFixed(2) + "ms"
+    }
+    return (ns / 1e3).toFixed(0) + "µs"
+}
+
+(async function main(){
+    window.__syscalls__ = await fetch("syscalls.json")
+        .then(resp => resp.json())
+        .catch(err => {
+            console.error("can't load syscalls.json:", err)
+            return {}
+        })
+
     const root = document.querySelector('#main .timeline')
     const timeline = new Timeline(root)
+    const memory = new CounterView(document.querySelector('#memory .memory_rows'), MemoryCategories, MemoryColors, memoryLegend)
+    const sched = new CounterView(document.querySelector('#sched .memory_rows'), SchedCategories, SchedColors, schedLegend, 100)
+    const alerts = new AlertView(document.querySelector('#alerts'))
     const eventSource = new EventSource("/events")
     window.timeline = timeline
 
+    const hideRuntime = document.querySelector('#hide_runtime')
+    hideRuntime.addEventListener('change', () => {
+        root.classList.toggle('hide_runtime', hideRuntime.checked)
+    })
+
     eventSource.addEventListener('message', (event) => {
         const e = JSON.parse(event.data)
         // console.log('got eventSource message', e)
+        if (e.ph === "C") {
+            if (e.name === "sched") {
+                sched.appendEvent(e)
+            } else {
+                memory.appendEvent(e)
+            }
+            return
+        }
+        timeline.appendEvent(e)
+    })
+    eventSource.addEventListener('alert', (event) => {
+        const e = JSON.parse(event.data)
+        alerts.appendEvent(e)
         timeline.appendEvent(e)
     })
     eventSource.addEventListener('fin', () => {
@@ -2,18 +2,28 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
 
 	"github.com/go-chi/chi"
+	"github.com/iimos/play/stracy/tracer"
 	"golang.org/x/sync/errgroup"
 )
 
 // eventsEndpoint returns an http.HandlerFunc that processes an http.Request
 // to server sent event.
-func eventsEndpoint(events <-chan Event) http.HandlerFunc {
+func eventsEndpoint(events <-chan tracer.Event) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		h := w.Header()
@@ -44,6 +54,9 @@ func eventsEndpoint(events <-chan Event) http.HandlerFunc {
 				}
 				eventJSON, err := json.Marshal(e)
 				if err == nil {
+					if e.Cat == "alert" {
+						io.WriteString(w, "event:alert\n")
+					}
 					io.WriteString(w, "data:"+string(eventJSON)+"\n\n")
 				} else {
 					io.WriteString(w, "error:"+err.Error()+"\n\n")
@@ -54,33 +67,158 @@ func eventsEndpoint(events <-chan Event) http.HandlerFunc {
 	}
 }
 
-func startServer(ctx context.Context, addr, html string, events <-chan Event) {
-	r := chi.NewRouter()
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		h := w.Header()
-		h.Set("Content-Type", "text/html")
-		fmt.Fprint(w, html)
+// serverConfig configures the web UI server.
+type serverConfig struct {
+	Addr     string
+	Token    string // bearer token required on / and /events, if set
+	CertFile string // TLS certificate, TLS is off if empty
+	KeyFile  string
+	Open     bool // open the UI in a browser once listening
+}
+
+// tokenCookie keeps the token after the first visit, so the page and the
+// event stream don't need it in the URL.
+const tokenCookie = "stracy_token"
+
+// requireToken allows requests with the token in the Authorization header, in
+// the cookie or in the token query parameter. A valid query parameter is moved
+// into the cookie.
+func requireToken(token string, next http.Handler) http.Handler {
+	valid := func(s string) bool {
+		return subtle.ConstantTimeCompare([]byte(s), []byte(token)) == 1
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && valid(auth) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if c, err := r.Cookie(tokenCookie); err == nil && valid(c.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if q := r.URL.Query(); q.Has("token") && valid(q.Get("token")) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+			q.Del("token")
+			u := *r.URL
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.String(), http.StatusSeeOther)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="stracy"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
 	})
-	r.Get("/events", eventsEndpoint(events))
+}
 
+// newToken generates a random bearer token.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// uiURL returns the address to open the UI at.
+func uiURL(cfg serverConfig, addr net.Addr) string {
+	u := url.URL{Scheme: "http", Host: addr.String(), Path: "/"}
+	if cfg.CertFile != "" {
+		u.Scheme = "https"
+	}
+	if host, port, err := net.SplitHostPort(addr.String()); err == nil {
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			u.Host = net.JoinHostPort("localhost", port)
+		}
+	}
+	if cfg.Token != "" {
+		u.RawQuery = url.Values{"token": {cfg.Token}}.Encode()
+	}
+	return u.String()
+}
+
+// openBrowser opens url in the default browser.
+func openBrowser(url string) error {
+	cmd := exec.Command("xdg-open", url)
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("open", url)
+	}
+	return cmd.Start()
+}
+
+// newHandler routes the page, the event stream and the static assets of the
+// UI. The page and the stream require cfg.Token, if set.
+func newHandler(cfg serverConfig, events <-chan tracer.Event) (http.Handler, error) {
+	index, err := assets.ReadFile("template.html")
+	if err != nil {
+		return nil, err
+	}
+
+	protect := func(h http.Handler) http.Handler { return h }
+	if cfg.Token != "" {
+		protect = func(h http.Handler) http.Handler { return requireToken(cfg.Token, h) }
+	}
+
+	r := chi.NewRouter()
+	r.Method(http.MethodGet, "/", protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	})))
+	r.Method(http.MethodGet, "/events", protect(eventsEndpoint(events)))
+	static := http.FileServer(http.FS(assets))
+	for _, name := range []string{"/script.js", "/style.css", "/syscalls.json"} {
+		r.Method(http.MethodGet, name, static)
+	}
+	return r, nil
+}
+
+func startServer(ctx context.Context, cfg serverConfig, events <-chan tracer.Event) error {
+	handler, err := newHandler(cfg, events)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return err
+	}
 	srv := &http.Server{
-		Addr: addr,
 		// ReadTimeout: 75 * time.Second,
-		Handler: r,
+		Handler: handler,
 	}
 
-	var g errgroup.Group
+	u := uiURL(cfg, ln.Addr())
+	fmt.Printf("listen on %s\n", u)
+	if cfg.Open {
+		if err := openBrowser(u); err != nil {
+			fmt.Printf("can't open browser: %s\n", err)
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
 
 	g.Go(func() error {
-		return srv.ListenAndServe()
+		var err error
+		if cfg.CertFile != "" {
+			err = srv.ServeTLS(ln, cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = srv.Serve(ln)
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
 	})
 
 	g.Go(func() error {
-		<-ctx.Done()
-		return srv.Shutdown(ctx)
+		<-gctx.Done()
+		return srv.Shutdown(context.Background())
 	})
 
-	if err := g.Wait(); err != nil {
-		fmt.Printf("shutdown server: %s", err)
-	}
+	return g.Wait()
 }
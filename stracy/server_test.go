@@ -0,0 +1,145 @@
+package main
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+func TestRequireToken(t *testing.T) {
+	const token = "secret"
+	h := requireToken(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+
+	tests := []struct {
+		name     string
+		target   string
+		header   string
+		cookie   string
+		code     int
+		location string
+	}{
+		{name: "header", target: "/", header: "Bearer secret", code: http.StatusOK},
+		{name: "cookie", target: "/events", cookie: "secret", code: http.StatusOK},
+		{name: "query", target: "/?x=1&token=secret", code: http.StatusSeeOther, location: "/?x=1"},
+		{name: "query only", target: "/events?token=secret", code: http.StatusSeeOther, location: "/events"},
+		{name: "none", target: "/", code: http.StatusUnauthorized},
+		{name: "wrong header", target: "/", header: "Bearer wrong", code: http.StatusUnauthorized},
+		{name: "not bearer", target: "/", header: "Basic secret", code: http.StatusUnauthorized},
+		{name: "wrong cookie", target: "/", cookie: "wrong", code: http.StatusUnauthorized},
+		{name: "wrong query", target: "/?token=wrong", code: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: tokenCookie, Value: tt.cookie})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		res := w.Result()
+
+		if res.StatusCode != tt.code {
+			t.Errorf("%s: got %d, want %d", tt.name, res.StatusCode, tt.code)
+			continue
+		}
+		switch tt.code {
+		case http.StatusOK:
+			if w.Body.String() != "ok" {
+				t.Errorf("%s: got body %q", tt.name, w.Body)
+			}
+		case http.StatusSeeOther:
+			if loc := res.Header.Get("Location"); loc != tt.location {
+				t.Errorf("%s: redirect to %q, want %q", tt.name, loc, tt.location)
+			}
+			cookies := res.Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("%s: got cookies %v", tt.name, cookies)
+			}
+			c := cookies[0]
+			if c.Name != tokenCookie || c.Value != token || c.Path != "/" || !c.HttpOnly || c.SameSite != http.SameSiteStrictMode {
+				t.Errorf("%s: got cookie %v", tt.name, c)
+			}
+		case http.StatusUnauthorized:
+			if got := res.Header.Get("WWW-Authenticate"); got != `Bearer realm="stracy"` {
+				t.Errorf("%s: WWW-Authenticate %q", tt.name, got)
+			}
+			if len(res.Cookies()) != 0 {
+				t.Errorf("%s: got cookies %v", tt.name, res.Cookies())
+			}
+		}
+	}
+}
+
+func TestServerHandler(t *testing.T) {
+	events := make(chan tracer.Event)
+	close(events)
+	h, err := newHandler(serverConfig{Token: "secret"}, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(path string, auth bool) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	tests := []struct {
+		path     string
+		auth     bool
+		code     int
+		mimeType string
+		body     string
+	}{
+		{path: "/", auth: true, code: http.StatusOK, mimeType: "text/html", body: "<html"},
+		{path: "/", code: http.StatusUnauthorized},
+		{path: "/events", code: http.StatusUnauthorized},
+		{path: "/events", auth: true, code: http.StatusOK, mimeType: "text/event-stream", body: "event:fin\n\n"},
+		// Assets are served without the token.
+		{path: "/script.js", code: http.StatusOK, mimeType: "text/javascript"},
+		{path: "/style.css", code: http.StatusOK, mimeType: "text/css"},
+		{path: "/syscalls.json", code: http.StatusOK, mimeType: "application/json"},
+		{path: "/template.html", auth: true, code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		res := get(tt.path, tt.auth)
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", tt.path, err)
+		}
+		if res.StatusCode != tt.code {
+			t.Errorf("%s (auth %v): got %d, want %d", tt.path, tt.auth, res.StatusCode, tt.code)
+			continue
+		}
+		if tt.mimeType != "" {
+			if typ, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); typ != tt.mimeType {
+				t.Errorf("%s: content type %q, want %s", tt.path, res.Header.Get("Content-Type"), tt.mimeType)
+			}
+		}
+		if !strings.Contains(string(body), tt.body) {
+			t.Errorf("%s: body %.100q has no %q", tt.path, body, tt.body)
+		}
+	}
+}
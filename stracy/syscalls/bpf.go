@@ -0,0 +1,291 @@
+package syscalls
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+// Members of union bpf_attr from uapi/linux/bpf.h, only the fields printed
+// by the decoder are declared.
+
+type bpfMapCreateAttr struct {
+	MapType        uint32
+	KeySize        uint32
+	ValueSize      uint32
+	MaxEntries     uint32
+	MapFlags       uint32
+	InnerMapFD     uint32
+	NumaNode       uint32
+	MapName        [unix.BPF_OBJ_NAME_LEN]byte
+	MapIfindex     uint32
+	BTFFD          uint32
+	BTFKeyTypeID   uint32
+	BTFValueTypeID uint32
+}
+
+type bpfMapElemAttr struct {
+	MapFD uint32
+	_     uint32
+	Key   uint64
+	Value uint64 // or next_key
+	Flags uint64
+}
+
+type bpfProgLoadAttr struct {
+	ProgType           uint32
+	InsnCnt            uint32
+	Insns              uint64
+	License            uint64
+	LogLevel           uint32
+	LogSize            uint32
+	LogBuf             uint64
+	KernVersion        uint32
+	ProgFlags          uint32
+	ProgName           [unix.BPF_OBJ_NAME_LEN]byte
+	ProgIfindex        uint32
+	ExpectedAttachType uint32
+}
+
+type bpfObjAttr struct {
+	Pathname  uint64
+	BPFFD     uint32
+	FileFlags uint32
+}
+
+type bpfProgAttachAttr struct {
+	TargetFD    uint32
+	AttachBPFFD uint32
+	AttachType  uint32
+	AttachFlags uint32
+}
+
+type bpfGetIDAttr struct {
+	ID        uint32 // start_id, prog_id, map_id, btf_id or link_id
+	NextID    uint32
+	OpenFlags uint32
+}
+
+type bpfInfoAttr struct {
+	BPFFD   uint32
+	InfoLen uint32
+	Info    uint64
+}
+
+type bpfRawTracepointAttr struct {
+	Name   uint64
+	ProgFD uint32
+	_      uint32
+}
+
+type bpfBTFLoadAttr struct {
+	BTF         uint64
+	BTFLogBuf   uint64
+	BTFSize     uint32
+	BTFLogSize  uint32
+	BTFLogLevel uint32
+}
+
+type bpfLinkCreateAttr struct {
+	ProgFD     uint32
+	TargetFD   uint32
+	AttachType uint32
+	Flags      uint32
+}
+
+// readUnion reads a union member of size bytes. Fields beyond size, which
+// older programs don't know about, are left zero, like the kernel does.
+func readUnion[T any](t strace.Task, addr strace.Addr, size uint64) (*T, error) {
+	var x T
+	buf := make([]byte, binary.Size(&x))
+	n := uint64(len(buf))
+	if size < n {
+		n = size
+	}
+	if _, err := t.Read(addr, buf[:n]); err != nil {
+		return nil, err
+	}
+	if err := binary.Unmarshal(buf, ubinary.NativeEndian, &x); err != nil {
+		return nil, err
+	}
+	return &x, nil
+}
+
+// cString returns a NUL-terminated string of a fixed size array.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func bpfAttr(t strace.Task, cmd int32, addr strace.Addr, size uint64) any {
+	if addr == 0 {
+		return "null"
+	}
+	a, err := decodeBPFAttr(t, cmd, addr, size)
+	if err != nil {
+		return fmt.Sprintf("%#x (error decoding bpf_attr: %s)", addr, err)
+	}
+	if a == nil {
+		return fmt.Sprintf("%#x", addr)
+	}
+	return Arg{Type: "bpf_attr", Value: a}
+}
+
+func decodeBPFAttr(t strace.Task, cmd int32, addr strace.Addr, size uint64) (map[string]any, error) {
+	switch cmd {
+	case unix.BPF_MAP_CREATE:
+		a, err := readUnion[bpfMapCreateAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"map_type":    abi.BPFMapTypes.Parse(uint64(a.MapType)),
+			"key_size":    a.KeySize,
+			"value_size":  a.ValueSize,
+			"max_entries": a.MaxEntries,
+			"map_flags":   abi.BPFMapFlagSet.Parse(uint64(a.MapFlags)),
+			"map_name":    cString(a.MapName[:]),
+			"btf_fd":      a.BTFFD,
+		}, nil
+
+	case unix.BPF_MAP_LOOKUP_ELEM, unix.BPF_MAP_UPDATE_ELEM, unix.BPF_MAP_DELETE_ELEM,
+		unix.BPF_MAP_GET_NEXT_KEY, unix.BPF_MAP_LOOKUP_AND_DELETE_ELEM:
+		a, err := readUnion[bpfMapElemAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]any{
+			"map_fd": Arg{Type: "fd", Value: int32(a.MapFD)},
+			"key":    fmt.Sprintf("%#x", a.Key),
+		}
+		switch cmd {
+		case unix.BPF_MAP_GET_NEXT_KEY:
+			m["next_key"] = fmt.Sprintf("%#x", a.Value)
+		case unix.BPF_MAP_DELETE_ELEM:
+		case unix.BPF_MAP_UPDATE_ELEM:
+			flags := abi.BPFMapUpdateModes.Parse(a.Flags & 3)
+			flags.AddFlags(abi.BPFMapUpdateFlagSet.Parse(a.Flags &^ 3))
+			m["value"] = fmt.Sprintf("%#x", a.Value)
+			m["flags"] = flags
+		default:
+			m["value"] = fmt.Sprintf("%#x", a.Value)
+			m["flags"] = abi.BPFMapUpdateFlagSet.Parse(a.Flags)
+		}
+		return m, nil
+
+	case unix.BPF_PROG_LOAD:
+		a, err := readUnion[bpfProgLoadAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"prog_type":            abi.BPFProgTypes.Parse(uint64(a.ProgType)),
+			"insn_cnt":             a.InsnCnt,
+			"insns":                fmt.Sprintf("%#x", a.Insns),
+			"license":              path(t, strace.Addr(a.License)),
+			"log_level":            a.LogLevel,
+			"log_size":             a.LogSize,
+			"kern_version":         a.KernVersion,
+			"prog_flags":           fmt.Sprintf("%#x", a.ProgFlags),
+			"prog_name":            cString(a.ProgName[:]),
+			"expected_attach_type": abi.BPFAttachTypes.Parse(uint64(a.ExpectedAttachType)),
+		}, nil
+
+	case unix.BPF_OBJ_PIN, unix.BPF_OBJ_GET:
+		a, err := readUnion[bpfObjAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]any{
+			"pathname":   path(t, strace.Addr(a.Pathname)),
+			"file_flags": abi.Open(uint64(a.FileFlags)),
+		}
+		if cmd == unix.BPF_OBJ_PIN {
+			m["bpf_fd"] = Arg{Type: "fd", Value: int32(a.BPFFD)}
+		}
+		return m, nil
+
+	case unix.BPF_PROG_ATTACH, unix.BPF_PROG_DETACH:
+		a, err := readUnion[bpfProgAttachAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"target_fd":     Arg{Type: "fd", Value: int32(a.TargetFD)},
+			"attach_bpf_fd": Arg{Type: "fd", Value: int32(a.AttachBPFFD)},
+			"attach_type":   abi.BPFAttachTypes.Parse(uint64(a.AttachType)),
+			"attach_flags":  fmt.Sprintf("%#x", a.AttachFlags),
+		}, nil
+
+	case unix.BPF_PROG_GET_NEXT_ID, unix.BPF_MAP_GET_NEXT_ID, unix.BPF_BTF_GET_NEXT_ID, unix.BPF_LINK_GET_NEXT_ID:
+		a, err := readUnion[bpfGetIDAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"start_id": a.ID, "next_id": a.NextID}, nil
+
+	case unix.BPF_PROG_GET_FD_BY_ID, unix.BPF_MAP_GET_FD_BY_ID, unix.BPF_BTF_GET_FD_BY_ID, unix.BPF_LINK_GET_FD_BY_ID:
+		a, err := readUnion[bpfGetIDAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"id": a.ID, "open_flags": abi.Open(uint64(a.OpenFlags))}, nil
+
+	case unix.BPF_OBJ_GET_INFO_BY_FD:
+		a, err := readUnion[bpfInfoAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"bpf_fd":   Arg{Type: "fd", Value: int32(a.BPFFD)},
+			"info_len": a.InfoLen,
+			"info":     fmt.Sprintf("%#x", a.Info),
+		}, nil
+
+	case unix.BPF_RAW_TRACEPOINT_OPEN:
+		a, err := readUnion[bpfRawTracepointAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		name := "null"
+		if a.Name != 0 {
+			name = path(t, strace.Addr(a.Name))
+		}
+		return map[string]any{
+			"name":    name,
+			"prog_fd": Arg{Type: "fd", Value: int32(a.ProgFD)},
+		}, nil
+
+	case unix.BPF_BTF_LOAD:
+		a, err := readUnion[bpfBTFLoadAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"btf":           fmt.Sprintf("%#x", a.BTF),
+			"btf_size":      a.BTFSize,
+			"btf_log_size":  a.BTFLogSize,
+			"btf_log_level": a.BTFLogLevel,
+		}, nil
+
+	case unix.BPF_LINK_CREATE:
+		a, err := readUnion[bpfLinkCreateAttr](t, addr, size)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"prog_fd":     Arg{Type: "fd", Value: int32(a.ProgFD)},
+			"target_fd":   Arg{Type: "fd", Value: int32(a.TargetFD)},
+			"attach_type": abi.BPFAttachTypes.Parse(uint64(a.AttachType)),
+			"flags":       fmt.Sprintf("%#x", a.Flags),
+		}, nil
+	}
+	return nil, nil
+}
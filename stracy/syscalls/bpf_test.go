@@ -0,0 +1,105 @@
+package syscalls
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestBPFAttr(t *testing.T) {
+	task := &memTask{}
+	name := func(s string) (b [unix.BPF_OBJ_NAME_LEN]byte) {
+		copy(b[:], s)
+		return b
+	}
+	pin := uint64(task.put(append([]byte("/sys/fs/bpf/m"), 0)))
+	license := uint64(task.put(append([]byte("GPL"), 0)))
+	tracepoint := uint64(task.put(append([]byte("sys_enter"), 0)))
+
+	tests := []struct {
+		cmd  int32
+		attr any
+		want map[string]string
+	}{
+		{unix.BPF_MAP_CREATE, bpfMapCreateAttr{MapType: unix.BPF_MAP_TYPE_HASH, KeySize: 4, ValueSize: 8, MaxEntries: 1024,
+			MapFlags: unix.BPF_F_NO_PREALLOC, MapName: name("counts"), BTFFD: 5},
+			map[string]string{"map_type": "BPF_MAP_TYPE_HASH", "key_size": "4", "value_size": "8", "max_entries": "1024",
+				"map_flags": "BPF_F_NO_PREALLOC", "map_name": "counts", "btf_fd": "5"}},
+		{unix.BPF_MAP_LOOKUP_ELEM, bpfMapElemAttr{MapFD: 3, Key: 0x1000, Value: 0x2000},
+			map[string]string{"map_fd": "{fd 3 map[]}", "key": "0x1000", "value": "0x2000", "flags": ""}},
+		{unix.BPF_MAP_UPDATE_ELEM, bpfMapElemAttr{MapFD: 3, Key: 0x1000, Value: 0x2000, Flags: unix.BPF_NOEXIST | unix.BPF_F_LOCK},
+			map[string]string{"map_fd": "{fd 3 map[]}", "key": "0x1000", "value": "0x2000", "flags": "BPF_NOEXIST|BPF_F_LOCK"}},
+		{unix.BPF_MAP_DELETE_ELEM, bpfMapElemAttr{MapFD: 3, Key: 0x1000},
+			map[string]string{"map_fd": "{fd 3 map[]}", "key": "0x1000"}},
+		{unix.BPF_MAP_GET_NEXT_KEY, bpfMapElemAttr{MapFD: 3, Key: 0x1000, Value: 0x2000},
+			map[string]string{"map_fd": "{fd 3 map[]}", "key": "0x1000", "next_key": "0x2000"}},
+		{unix.BPF_PROG_LOAD, bpfProgLoadAttr{ProgType: unix.BPF_PROG_TYPE_KPROBE, InsnCnt: 2, Insns: 0x3000, License: license,
+			LogLevel: 1, LogSize: 4096, ProgName: name("probe")},
+			map[string]string{"prog_type": "BPF_PROG_TYPE_KPROBE", "insn_cnt": "2", "insns": "0x3000", "license": "GPL",
+				"log_level": "1", "log_size": "4096", "kern_version": "0", "prog_flags": "0x0", "prog_name": "probe",
+				"expected_attach_type": "BPF_CGROUP_INET_INGRESS"}},
+		{unix.BPF_OBJ_PIN, bpfObjAttr{Pathname: pin, BPFFD: 4},
+			map[string]string{"pathname": "/sys/fs/bpf/m", "bpf_fd": "{fd 4 map[]}", "file_flags": "O_RDONLY"}},
+		{unix.BPF_OBJ_GET, bpfObjAttr{Pathname: pin, FileFlags: unix.O_RDWR},
+			map[string]string{"pathname": "/sys/fs/bpf/m", "file_flags": "O_RDWR"}},
+		{unix.BPF_PROG_ATTACH, bpfProgAttachAttr{TargetFD: 6, AttachBPFFD: 7, AttachType: unix.BPF_CGROUP_INET_EGRESS},
+			map[string]string{"target_fd": "{fd 6 map[]}", "attach_bpf_fd": "{fd 7 map[]}", "attach_type": "BPF_CGROUP_INET_EGRESS",
+				"attach_flags": "0x0"}},
+		{unix.BPF_PROG_GET_NEXT_ID, bpfGetIDAttr{ID: 10, NextID: 11},
+			map[string]string{"start_id": "10", "next_id": "11"}},
+		{unix.BPF_MAP_GET_FD_BY_ID, bpfGetIDAttr{ID: 10, OpenFlags: unix.O_RDONLY},
+			map[string]string{"id": "10", "open_flags": "O_RDONLY"}},
+		{unix.BPF_OBJ_GET_INFO_BY_FD, bpfInfoAttr{BPFFD: 4, InfoLen: 80, Info: 0x4000},
+			map[string]string{"bpf_fd": "{fd 4 map[]}", "info_len": "80", "info": "0x4000"}},
+		{unix.BPF_RAW_TRACEPOINT_OPEN, bpfRawTracepointAttr{Name: tracepoint, ProgFD: 4},
+			map[string]string{"name": "sys_enter", "prog_fd": "{fd 4 map[]}"}},
+		{unix.BPF_BTF_LOAD, bpfBTFLoadAttr{BTF: 0x5000, BTFSize: 512},
+			map[string]string{"btf": "0x5000", "btf_size": "512", "btf_log_size": "0", "btf_log_level": "0"}},
+		{unix.BPF_LINK_CREATE, bpfLinkCreateAttr{ProgFD: 4, TargetFD: 6, AttachType: unix.BPF_TRACE_ITER},
+			map[string]string{"prog_fd": "{fd 4 map[]}", "target_fd": "{fd 6 map[]}", "attach_type": "BPF_TRACE_ITER", "flags": "0x0"}},
+	}
+	for _, tt := range tests {
+		size := uint64(binary.Size(tt.attr))
+		addr := task.put(tt.attr)
+		got, err := decodeBPFAttr(task, tt.cmd, addr, size)
+		if err != nil {
+			t.Errorf("cmd %d of size %d: %s", tt.cmd, size, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("cmd %d: got %v, want %v", tt.cmd, got, tt.want)
+		}
+		for k, w := range tt.want {
+			if g := fmt.Sprint(got[k]); g != w {
+				t.Errorf("cmd %d: %s = %s, want %s", tt.cmd, k, g, w)
+			}
+		}
+
+		// Programs built against newer headers pass a larger union.
+		if _, err := decodeBPFAttr(task, tt.cmd, addr, size+64); err != nil {
+			t.Errorf("cmd %d of size %d: %s", tt.cmd, size+64, err)
+		}
+	}
+}
+
+func TestBPFAttrShortUnion(t *testing.T) {
+	// Fields beyond the size passed by older programs are not read.
+	task := &memTask{}
+	addr := task.put(bpfMapCreateAttr{MapType: unix.BPF_MAP_TYPE_ARRAY, KeySize: 4, ValueSize: 4, MaxEntries: 1, MapFlags: unix.BPF_F_RDONLY})
+	got, err := decodeBPFAttr(task, unix.BPF_MAP_CREATE, addr, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got["max_entries"]) != "1" || fmt.Sprint(got["map_flags"]) != "" {
+		t.Errorf("got %v, want map_flags beyond the size left zero", got)
+	}
+
+	if got := bpfAttr(task, unix.BPF_MAP_CREATE, 0x10, 72); got != "0x10 (error decoding bpf_attr: bad address)" {
+		t.Errorf("unreadable attr: got %v", got)
+	}
+	if got := bpfAttr(task, unix.BPF_ENABLE_STATS, addr, 8); got != fmt.Sprintf("%#x", addr) {
+		t.Errorf("command without a decoder: got %v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package syscalls
+
+import (
+	"fmt"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"golang.org/x/sys/unix"
+)
+
+// CloneArgsArg is a decoded struct clone_args.
+type CloneArgsArg struct {
+	Flags      abi.Flags
+	ExitSignal string
+	Pidfd      string `json:",omitempty"`
+	ChildTID   string `json:",omitempty"`
+	ParentTID  string `json:",omitempty"`
+	Stack      string `json:",omitempty"`
+	StackSize  uint64 `json:",omitempty"`
+	TLS        string `json:",omitempty"`
+	Cgroup     uint64 `json:",omitempty"` // fd of CLONE_INTO_CGROUP
+}
+
+// cloneFlags decodes flags of clone(2), their lowest byte is the signal sent
+// to the parent when the child exits.
+func cloneFlags(v uint64) abi.Flags {
+	flags := abi.CloneFlagSet.Parse(v &^ unix.CSIGNAL)
+	if sig := v & unix.CSIGNAL; sig != 0 {
+		flags.Add(SignalString(unix.Signal(sig)))
+	}
+	return flags
+}
+
+func cloneArgs(t strace.Task, addr strace.Addr, size uint64) any {
+	if addr == 0 {
+		return nil
+	}
+	a, err := readUnion[abi.CloneArgs](t, addr, size)
+	if err != nil {
+		return fmt.Sprintf("%#x (error decoding clone_args: %s)", addr, err)
+	}
+	hex := func(v uint64) string {
+		if v == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%#x", v)
+	}
+	arg := CloneArgsArg{
+		Flags:      abi.CloneFlagSet.Parse(a.Flags),
+		ExitSignal: "0",
+		Pidfd:      hex(a.Pidfd),
+		ChildTID:   hex(a.ChildTID),
+		ParentTID:  hex(a.ParentTID),
+		Stack:      hex(a.Stack),
+		StackSize:  a.StackSize,
+		TLS:        hex(a.TLS),
+	}
+	if a.ExitSignal != 0 {
+		arg.ExitSignal = SignalString(unix.Signal(a.ExitSignal))
+	}
+	if a.Flags&unix.CLONE_INTO_CGROUP != 0 {
+		arg.Cgroup = a.Cgroup
+	}
+	return Arg{Type: "clone_args", Value: arg}
+}
@@ -0,0 +1,57 @@
+package syscalls
+
+import (
+	"fmt"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
+)
+
+// maxDirentsSize limits the size of decoded getdents64 results.
+const maxDirentsSize = 1 << 20
+
+// DirentArg is a decoded struct linux_dirent64.
+type DirentArg struct {
+	Ino    uint64
+	Off    int64
+	Reclen uint16
+	Type   abi.Flags
+	Name   string
+}
+
+// dirents decodes size bytes of struct linux_dirent64 entries.
+func dirents(t strace.Task, addr strace.Addr, size int64) any {
+	if addr == 0 {
+		return nil
+	}
+	if size < 0 {
+		size = 0
+	}
+	if size > maxDirentsSize {
+		return fmt.Sprintf("%#x (error decoding dirents: invalid size %d)", addr, size)
+	}
+
+	buf := make([]byte, size)
+	if _, err := t.Read(addr, buf); err != nil {
+		return fmt.Sprintf("%#x (error decoding dirents: %s)", addr, err)
+	}
+	v := []DirentArg{}
+	for len(buf) > 0 {
+		var d abi.Dirent64
+		n, err := binary.Decode(buf, ubinary.NativeEndian, &d)
+		if err != nil {
+			return fmt.Sprintf("%#x (error decoding dirents: %s)", addr, err)
+		}
+		buf = buf[n:]
+		v = append(v, DirentArg{
+			Ino:    d.Ino,
+			Off:    d.Off,
+			Reclen: d.Reclen,
+			Type:   abi.DirentTypes.Parse(uint64(d.Type)),
+			Name:   string(d.Name),
+		})
+	}
+	return Arg{Type: "dirents", Value: v}
+}
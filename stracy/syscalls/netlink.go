@@ -0,0 +1,481 @@
+package syscalls
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// maxNetlinkMessages limits the number of decoded messages of a buffer.
+	maxNetlinkMessages = 64
+
+	// maxAttrDump limits dumps of attributes without a decoder, like
+	// interface statistics.
+	maxAttrDump = 32
+
+	// maxNetlinkBuffer caps the number of bytes read for decoding, a dump
+	// reply rarely exceeds it.
+	maxNetlinkBuffer = 64 << 10
+)
+
+// NetlinkMessage is a decoded netlink message.
+type NetlinkMessage struct {
+	Len   uint32
+	Type  abi.Flags
+	Flags abi.Flags
+	Seq   uint32
+	PID   uint32
+
+	// Payload is a NetlinkError, LinkMessage, AddrMessage, RouteMessage or
+	// a dump of a message body of unknown type.
+	Payload any `json:",omitempty"`
+}
+
+// NetlinkError is the payload of NLMSG_ERROR, Error is empty for an ACK.
+type NetlinkError struct {
+	Error string `json:",omitempty"`
+	Msg   NetlinkMessage
+}
+
+// LinkMessage is a struct ifinfomsg with attributes (RTM_*LINK).
+type LinkMessage struct {
+	Family abi.Flags
+	Type   uint16 // ARPHRD_*
+	Index  int32
+	Flags  abi.Flags
+	Change string
+	Attrs  map[string]any `json:",omitempty"`
+}
+
+// AddrMessage is a struct ifaddrmsg with attributes (RTM_*ADDR).
+type AddrMessage struct {
+	Family    abi.Flags
+	PrefixLen uint8
+	Flags     abi.Flags
+	Scope     abi.Flags
+	Index     uint32
+	Attrs     map[string]any `json:",omitempty"`
+}
+
+// RouteMessage is a struct rtmsg with attributes (RTM_*ROUTE).
+type RouteMessage struct {
+	Family   abi.Flags
+	DstLen   uint8
+	SrcLen   uint8
+	Tos      uint8
+	Table    abi.Flags
+	Protocol abi.Flags
+	Scope    abi.Flags
+	Type     abi.Flags
+	Flags    string
+	Attrs    map[string]any `json:",omitempty"`
+}
+
+// netlinkProtocol returns the protocol of fd if it is a netlink socket. It
+// needs a ProcessTask to find the socket.
+func netlinkProtocol(t strace.Task, fd int32) (int, bool) {
+	pt, ok := t.(ProcessTask)
+	if !ok {
+		return 0, false
+	}
+	link, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pt.PID(), fd))
+	if err != nil {
+		return 0, false
+	}
+	inode, ok := strings.CutPrefix(link, "socket:[")
+	if !ok {
+		return 0, false
+	}
+	inode = strings.TrimSuffix(inode, "]")
+	state := traceState(t)
+	if state != nil {
+		if p, ok := state.netlinkSockets.Load(inode); ok {
+			return p.(int), p.(int) >= 0
+		}
+	}
+
+	f, err := os.Open(fmt.Sprintf("/proc/%d/net/netlink", pt.PID()))
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+	// sk Eth Pid Groups Rmem Wmem Dump Locks Drops Inode
+	proto := -1
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 10 || fields[len(fields)-1] != inode {
+			continue
+		}
+		if n, err := strconv.Atoi(fields[1]); err == nil {
+			proto = n
+		}
+		break
+	}
+	if state != nil {
+		state.netlinkSockets.Store(inode, proto)
+	}
+	return proto, proto >= 0
+}
+
+// netlinkBuffer decodes a buffer of netlink messages.
+func netlinkBuffer(t strace.Task, addr strace.Addr, size uint, protocol int, maximumBlobSize uint) any {
+	if size > maxNetlinkBuffer {
+		size = maxNetlinkBuffer
+	}
+	buf := make([]byte, size)
+	if _, err := t.Read(addr, buf); err != nil {
+		return fmt.Sprintf("%#x (error decoding netlink: %s)", addr, err)
+	}
+	return netlinkMessages(buf, protocol, maximumBlobSize)
+}
+
+// netlinkIOVecs decodes netlink messages in the buffers of a struct msghdr.
+func netlinkIOVecs(t strace.Task, addr strace.Addr, iovcnt int, size int64, protocol int, maximumBlobSize uint) any {
+	if size > maxNetlinkBuffer {
+		size = maxNetlinkBuffer
+	}
+	buf, err := readIOVecs(t, addr, iovcnt, size)
+	if err != nil {
+		return fmt.Sprintf("%#x (error decoding netlink: %s)", addr, err)
+	}
+	return netlinkMessages(buf, protocol, maximumBlobSize)
+}
+
+func netlinkMessages(b []byte, protocol int, maximumBlobSize uint) Arg {
+	msgs := []NetlinkMessage{}
+	for len(b) >= unix.NLMSG_HDRLEN {
+		var h unix.NlMsghdr
+		if _, err := binary.Decode(b, ubinary.NativeEndian, &h); err != nil {
+			break
+		}
+		if h.Len < unix.NLMSG_HDRLEN || int(h.Len) > len(b) || len(msgs) == maxNetlinkMessages {
+			break
+		}
+		msgs = append(msgs, netlinkMessage(h, b[unix.NLMSG_HDRLEN:h.Len], protocol, maximumBlobSize))
+		n := alignUp(int(h.Len), unix.NLMSG_ALIGNTO)
+		if n > len(b) {
+			n = len(b)
+		}
+		b = b[n:]
+	}
+
+	formated := map[string]any{"protocol": abi.NetlinkProtocols.Parse(uint64(protocol))}
+	if len(b) > 0 {
+		formated["undecoded"] = len(b)
+	}
+	return Arg{Type: "netlink", Value: msgs, Formated: formated}
+}
+
+func netlinkMessage(h unix.NlMsghdr, body []byte, protocol int, maximumBlobSize uint) NetlinkMessage {
+	m := NetlinkMessage{Len: h.Len, Seq: h.Seq, PID: h.Pid}
+	if h.Type < unix.NLMSG_MIN_TYPE {
+		m.Type = abi.NetlinkMessageTypes.Parse(uint64(h.Type))
+		m.Flags = abi.NetlinkFlagSet.Parse(uint64(h.Flags))
+		switch h.Type {
+		case unix.NLMSG_ERROR:
+			m.Payload = netlinkError(body, protocol, maximumBlobSize)
+		case unix.NLMSG_DONE, unix.NLMSG_NOOP:
+		default:
+			m.Payload = dumpBytes(body, maximumBlobSize)
+		}
+		return m
+	}
+	if protocol != unix.NETLINK_ROUTE {
+		m.Type.Add(strconv.Itoa(int(h.Type)))
+		m.Flags = abi.NetlinkFlagSet.Parse(uint64(h.Flags))
+		m.Payload = dumpBytes(body, maximumBlobSize)
+		return m
+	}
+
+	m.Type = abi.RouteMessageTypes.Parse(uint64(h.Type))
+	m.Flags = routeMessageFlags(h.Type, h.Flags)
+	m.Payload = routeMessage(h.Type, body, maximumBlobSize)
+	return m
+}
+
+// routeMessageFlags parses flags of an rtnetlink message, their meaning
+// depends on the request kind: the low bits of a message type are NEW, DEL,
+// GET and SET.
+func routeMessageFlags(typ, flags uint16) abi.Flags {
+	switch (typ - unix.RTM_BASE) % 4 {
+	case 0:
+		return abi.NetlinkNewFlagSet.Parse(uint64(flags))
+	case 2:
+		return abi.NetlinkGetFlagSet.Parse(uint64(flags))
+	}
+	return abi.NetlinkFlagSet.Parse(uint64(flags))
+}
+
+func netlinkError(body []byte, protocol int, maximumBlobSize uint) any {
+	var e unix.NlMsgerr
+	if _, err := binary.Decode(body, ubinary.NativeEndian, &e); err != nil {
+		return dumpBytes(body, maximumBlobSize)
+	}
+	ne := NetlinkError{
+		Msg: NetlinkMessage{
+			Len:   e.Msg.Len,
+			Type:  abi.NetlinkMessageTypes.Parse(uint64(e.Msg.Type)),
+			Flags: abi.NetlinkFlagSet.Parse(uint64(e.Msg.Flags)),
+			Seq:   e.Msg.Seq,
+			PID:   e.Msg.Pid,
+		},
+	}
+	if protocol == unix.NETLINK_ROUTE && e.Msg.Type >= unix.NLMSG_MIN_TYPE {
+		ne.Msg.Type = abi.RouteMessageTypes.Parse(uint64(e.Msg.Type))
+		ne.Msg.Flags = routeMessageFlags(e.Msg.Type, e.Msg.Flags)
+	}
+	if e.Error != 0 {
+		ne.Error = unix.ErrnoName(syscall.Errno(-e.Error))
+		if ne.Error == "" {
+			ne.Error = strconv.Itoa(int(e.Error))
+		}
+	}
+	return ne
+}
+
+func routeMessage(typ uint16, body []byte, maximumBlobSize uint) any {
+	switch typ {
+	case unix.RTM_NEWLINK, unix.RTM_DELLINK, unix.RTM_GETLINK, unix.RTM_SETLINK:
+		var ifi unix.IfInfomsg
+		if _, err := binary.Decode(body, ubinary.NativeEndian, &ifi); err != nil {
+			break
+		}
+		return LinkMessage{
+			Family: abi.SocketFamily.Parse(uint64(ifi.Family)),
+			Type:   ifi.Type,
+			Index:  ifi.Index,
+			Flags:  abi.InterfaceFlagSet.Parse(uint64(ifi.Flags)),
+			Change: fmt.Sprintf("%#x", ifi.Change),
+			Attrs:  netlinkAttrs(body[unix.SizeofIfInfomsg:], abi.LinkAttrTypes, linkAttrs, maximumBlobSize),
+		}
+	case unix.RTM_NEWADDR, unix.RTM_DELADDR, unix.RTM_GETADDR:
+		var ifa unix.IfAddrmsg
+		if _, err := binary.Decode(body, ubinary.NativeEndian, &ifa); err != nil {
+			break
+		}
+		return AddrMessage{
+			Family:    abi.SocketFamily.Parse(uint64(ifa.Family)),
+			PrefixLen: ifa.Prefixlen,
+			Flags:     abi.InterfaceAddrFlagSet.Parse(uint64(ifa.Flags)),
+			Scope:     abi.RouteScopes.Parse(uint64(ifa.Scope)),
+			Index:     ifa.Index,
+			Attrs:     netlinkAttrs(body[unix.SizeofIfAddrmsg:], abi.AddrAttrTypes, addrAttrs, maximumBlobSize),
+		}
+	case unix.RTM_NEWROUTE, unix.RTM_DELROUTE, unix.RTM_GETROUTE:
+		var rtm unix.RtMsg
+		if _, err := binary.Decode(body, ubinary.NativeEndian, &rtm); err != nil {
+			break
+		}
+		return RouteMessage{
+			Family:   abi.SocketFamily.Parse(uint64(rtm.Family)),
+			DstLen:   rtm.Dst_len,
+			SrcLen:   rtm.Src_len,
+			Tos:      rtm.Tos,
+			Table:    abi.RouteTables.Parse(uint64(rtm.Table)),
+			Protocol: abi.RouteProtocols.Parse(uint64(rtm.Protocol)),
+			Scope:    abi.RouteScopes.Parse(uint64(rtm.Scope)),
+			Type:     abi.RouteTypes.Parse(uint64(rtm.Type)),
+			Flags:    fmt.Sprintf("%#x", rtm.Flags),
+			Attrs:    netlinkAttrs(body[unix.SizeofRtMsg:], abi.RouteAttrTypes, routeAttrs, maximumBlobSize),
+		}
+	}
+	return dumpBytes(body, maximumBlobSize)
+}
+
+// nlaTypeMask clears NLA_F_NESTED and NLA_F_NET_BYTEORDER.
+const nlaTypeMask = 0x3fff
+
+// attrDecoder decodes the payload of an attribute.
+type attrDecoder func(b []byte) any
+
+func attrString(b []byte) any {
+	return strings.TrimRight(string(b), "\x00")
+}
+
+func attrU8(b []byte) any {
+	if len(b) != 1 {
+		return fmt.Sprintf("%x", b)
+	}
+	return b[0]
+}
+
+func attrU32(b []byte) any {
+	if len(b) != 4 {
+		return fmt.Sprintf("%x", b)
+	}
+	return ubinary.NativeEndian.Uint32(b)
+}
+
+func attrMAC(b []byte) any {
+	return net.HardwareAddr(b).String()
+}
+
+func attrIP(b []byte) any {
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return fmt.Sprintf("%x", b)
+	}
+	return net.IP(b).String()
+}
+
+func attrFlags(set abi.FlagSet, size int) attrDecoder {
+	return func(b []byte) any {
+		switch {
+		case size == 1 && len(b) == 1:
+			return set.Parse(uint64(b[0]))
+		case size == 4 && len(b) == 4:
+			return set.Parse(uint64(ubinary.NativeEndian.Uint32(b)))
+		}
+		return fmt.Sprintf("%x", b)
+	}
+}
+
+var linkAttrs = map[uint16]attrDecoder{
+	unix.IFLA_ADDRESS:         attrMAC,
+	unix.IFLA_BROADCAST:       attrMAC,
+	unix.IFLA_PERM_ADDRESS:    attrMAC,
+	unix.IFLA_IFNAME:          attrString,
+	unix.IFLA_QDISC:           attrString,
+	unix.IFLA_IFALIAS:         attrString,
+	unix.IFLA_ALT_IFNAME:      attrString,
+	unix.IFLA_PHYS_PORT_NAME:  attrString,
+	unix.IFLA_MTU:             attrU32,
+	unix.IFLA_MIN_MTU:         attrU32,
+	unix.IFLA_MAX_MTU:         attrU32,
+	unix.IFLA_LINK:            attrU32,
+	unix.IFLA_MASTER:          attrU32,
+	unix.IFLA_TXQLEN:          attrU32,
+	unix.IFLA_GROUP:           attrU32,
+	unix.IFLA_PROMISCUITY:     attrU32,
+	unix.IFLA_NUM_TX_QUEUES:   attrU32,
+	unix.IFLA_NUM_RX_QUEUES:   attrU32,
+	unix.IFLA_CARRIER_CHANGES: attrU32,
+	unix.IFLA_EXT_MASK:        attrU32,
+	unix.IFLA_NET_NS_PID:      attrU32,
+	unix.IFLA_NET_NS_FD:       attrU32,
+	unix.IFLA_OPERSTATE:       attrFlags(abi.OperStates, 1),
+	unix.IFLA_LINKMODE:        attrU8,
+	unix.IFLA_CARRIER:         attrU8,
+	unix.IFLA_PROTO_DOWN:      attrU8,
+	unix.IFLA_LINKINFO:        linkInfo,
+}
+
+var addrAttrs = map[uint16]attrDecoder{
+	unix.IFA_ADDRESS:     attrIP,
+	unix.IFA_LOCAL:       attrIP,
+	unix.IFA_BROADCAST:   attrIP,
+	unix.IFA_ANYCAST:     attrIP,
+	unix.IFA_MULTICAST:   attrIP,
+	unix.IFA_LABEL:       attrString,
+	unix.IFA_FLAGS:       attrFlags(abi.InterfaceAddrFlagSet, 4),
+	unix.IFA_RT_PRIORITY: attrU32,
+}
+
+var routeAttrs = map[uint16]attrDecoder{
+	unix.RTA_DST:      attrIP,
+	unix.RTA_SRC:      attrIP,
+	unix.RTA_GATEWAY:  attrIP,
+	unix.RTA_PREFSRC:  attrIP,
+	unix.RTA_IIF:      attrU32,
+	unix.RTA_OIF:      attrU32,
+	unix.RTA_PRIORITY: attrU32,
+	unix.RTA_MARK:     attrU32,
+	unix.RTA_UID:      attrU32,
+	unix.RTA_TABLE:    attrFlags(abi.RouteTables, 4),
+	unix.RTA_PREF:     attrU8,
+}
+
+// linkInfo decodes the nested IFLA_LINKINFO attribute, only the kind of the
+// device is interpreted.
+func linkInfo(b []byte) any {
+	info := map[string]any{}
+	walkAttrs(b, func(typ uint16, payload []byte) {
+		switch typ {
+		case unix.IFLA_INFO_KIND, unix.IFLA_INFO_SLAVE_KIND:
+			info[abi.LinkInfoAttrTypes.Parse(uint64(typ)).String()] = attrString(payload)
+		default:
+			info[abi.LinkInfoAttrTypes.Parse(uint64(typ)).String()] = fmt.Sprintf("%d bytes", len(payload))
+		}
+	})
+	return info
+}
+
+// netlinkAttrs decodes a list of struct rtattr. Attributes without a decoder
+// are dumped.
+func netlinkAttrs(b []byte, types abi.FlagSet, decoders map[uint16]attrDecoder, maximumBlobSize uint) map[string]any {
+	attrs := map[string]any{}
+	walkAttrs(b, func(typ uint16, payload []byte) {
+		name := types.Parse(uint64(typ)).String()
+		if dec, ok := decoders[typ]; ok {
+			attrs[name] = dec(payload)
+		} else {
+			attrs[name] = dumpBytes(payload, min(maximumBlobSize, maxAttrDump))
+		}
+	})
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
+func walkAttrs(b []byte, fn func(typ uint16, payload []byte)) {
+	for len(b) >= unix.SizeofRtAttr {
+		var a unix.RtAttr
+		if _, err := binary.Decode(b, ubinary.NativeEndian, &a); err != nil {
+			return
+		}
+		if int(a.Len) < unix.SizeofRtAttr || int(a.Len) > len(b) {
+			return
+		}
+		fn(a.Type&nlaTypeMask, b[unix.SizeofRtAttr:a.Len])
+		n := alignUp(int(a.Len), unix.RTA_ALIGNTO)
+		if n > len(b) {
+			n = len(b)
+		}
+		b = b[n:]
+	}
+}
+
+// dumpBytes formats a buffer which is already copied out of the tracee.
+func dumpBytes(b []byte, maximumBlobSize uint) string {
+	if uint(len(b)) > maximumBlobSize {
+		return fmt.Sprintf("%q...", b[:maximumBlobSize])
+	}
+	return fmt.Sprintf("%q", b)
+}
+
+// netlinkFD returns the protocol of the first argument of a syscall if it is
+// a netlink socket.
+func netlinkFD(si SyscallInfo, t strace.Task, args strace.SyscallArguments) (int, bool) {
+	if len(si.ArgTypes) == 0 || si.ArgTypes[0] != FD {
+		return 0, false
+	}
+	return netlinkProtocol(t, args[0].Int())
+}
+
+// netlinkMsghdr decodes a struct msghdr whose buffers hold size bytes of
+// netlink messages.
+func netlinkMsghdr(t strace.Task, addr strace.Addr, size int64, protocol int, maximumBlobSize uint) any {
+	v := msghdr(t, addr, false /* content */, uint64(maximumBlobSize))
+	arg, ok := v.(Arg)
+	if !ok {
+		return v
+	}
+	msg, ok := arg.Value.(abi.MessageHeader64)
+	if !ok {
+		return arg
+	}
+	arg.Formated["Iov"] = netlinkIOVecs(t, strace.Addr(msg.Iov), int(msg.IovLen), size, protocol, maximumBlobSize)
+	return arg
+}
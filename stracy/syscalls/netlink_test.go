@@ -0,0 +1,233 @@
+package syscalls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/iimos/play/stracy/abi"
+	"golang.org/x/sys/unix"
+)
+
+// nlMsg lays out a netlink message with a fixed size header struct and
+// attributes.
+func nlMsg(typ, flags uint16, hdr any, attrs ...[]byte) []byte {
+	body := structBytes(hdr)
+	for _, a := range attrs {
+		body = append(body, a...)
+	}
+	b := make([]byte, unix.NLMSG_HDRLEN, unix.NLMSG_HDRLEN+len(body))
+	binary.LittleEndian.PutUint32(b[0:], uint32(unix.NLMSG_HDRLEN+len(body)))
+	binary.LittleEndian.PutUint16(b[4:], typ)
+	binary.LittleEndian.PutUint16(b[6:], flags)
+	binary.LittleEndian.PutUint32(b[8:], 1) // seq
+	return append(b, body...)
+}
+
+// rtAttr lays out an attribute padded to RTA_ALIGNTO.
+func rtAttr(typ uint16, payload any) []byte {
+	p := structBytes(payload)
+	b := make([]byte, unix.SizeofRtAttr, alignUp(unix.SizeofRtAttr+len(p), unix.RTA_ALIGNTO))
+	binary.LittleEndian.PutUint16(b[0:], uint16(unix.SizeofRtAttr+len(p)))
+	binary.LittleEndian.PutUint16(b[2:], typ)
+	b = append(b, p...)
+	return b[:cap(b)]
+}
+
+func structBytes(v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return append([]byte(v), 0)
+	}
+	var b bytes.Buffer
+	if err := binary.Write(&b, binary.LittleEndian, v); err != nil {
+		panic(err)
+	}
+	return b.Bytes()
+}
+
+func TestNetlinkMessages(t *testing.T) {
+	mac := net.HardwareAddr{0x02, 0x42, 0xac, 0x11, 0x00, 0x02}
+	var buf []byte
+	buf = append(buf, nlMsg(unix.RTM_NEWLINK, unix.NLM_F_MULTI,
+		unix.IfInfomsg{Family: unix.AF_UNSPEC, Type: unix.ARPHRD_ETHER, Index: 2, Flags: unix.IFF_UP},
+		rtAttr(unix.IFLA_IFNAME, "eth0"),
+		rtAttr(unix.IFLA_MTU, uint32(1500)),
+		rtAttr(unix.IFLA_ADDRESS, []byte(mac)),
+		rtAttr(unix.IFLA_OPERSTATE, []byte{6}),
+		rtAttr(unix.IFLA_LINKINFO|unix.NLA_F_NESTED, rtAttr(unix.IFLA_INFO_KIND, "veth")),
+		rtAttr(unix.IFLA_STATS64, make([]byte, 64)),
+	)...)
+	buf = append(buf, nlMsg(unix.RTM_NEWADDR, unix.NLM_F_REQUEST|unix.NLM_F_CREATE,
+		unix.IfAddrmsg{Family: unix.AF_INET, Prefixlen: 24, Scope: unix.RT_SCOPE_UNIVERSE, Index: 2},
+		rtAttr(unix.IFA_ADDRESS, []byte{10, 0, 0, 1}),
+		rtAttr(unix.IFA_LABEL, "eth0"),
+	)...)
+	buf = append(buf, nlMsg(unix.RTM_GETROUTE, unix.NLM_F_REQUEST|unix.NLM_F_DUMP,
+		unix.RtMsg{Family: unix.AF_INET, Dst_len: 24, Table: unix.RT_TABLE_MAIN, Protocol: unix.RTPROT_KERNEL,
+			Scope: unix.RT_SCOPE_LINK, Type: unix.RTN_UNICAST},
+		rtAttr(unix.RTA_DST, []byte{10, 0, 0, 0}),
+		rtAttr(unix.RTA_OIF, uint32(2)),
+		rtAttr(unix.RTA_TABLE, uint32(unix.RT_TABLE_MAIN)),
+	)...)
+	buf = append(buf, nlMsg(unix.NLMSG_ERROR, 0,
+		unix.NlMsgerr{Error: -int32(unix.EPERM), Msg: unix.NlMsghdr{Len: 32, Type: unix.RTM_NEWADDR, Flags: unix.NLM_F_REQUEST, Seq: 1}},
+	)...)
+	buf = append(buf, nlMsg(unix.NLMSG_DONE, unix.NLM_F_MULTI, int32(0))...)
+	buf = append(buf, 1, 2, 3) // trailing garbage
+
+	arg := netlinkMessages(buf, unix.NETLINK_ROUTE, 1024)
+	msgs, ok := arg.Value.([]NetlinkMessage)
+	if !ok || len(msgs) != 5 {
+		t.Fatalf("got %+v, want 5 messages", arg.Value)
+	}
+	if got := fmt.Sprint(arg.Formated["protocol"]); got != "NETLINK_ROUTE" {
+		t.Errorf("protocol %s", got)
+	}
+	if got := arg.Formated["undecoded"]; got != 3 {
+		t.Errorf("undecoded %v, want 3", got)
+	}
+
+	link, ok := msgs[0].Payload.(LinkMessage)
+	if !ok {
+		t.Fatalf("RTM_NEWLINK payload %#v", msgs[0].Payload)
+	}
+	if msgs[0].Type.String() != "RTM_NEWLINK" || msgs[0].Flags.String() != "NLM_F_MULTI" || msgs[0].Seq != 1 {
+		t.Errorf("RTM_NEWLINK header %+v", msgs[0])
+	}
+	if link.Family.String() != "AF_UNSPEC" || link.Type != unix.ARPHRD_ETHER || link.Index != 2 || link.Flags.String() != "IFF_UP" {
+		t.Errorf("ifinfomsg %+v", link)
+	}
+	wantLink := map[string]string{
+		"IFLA_IFNAME":    "eth0",
+		"IFLA_MTU":       "1500",
+		"IFLA_ADDRESS":   mac.String(),
+		"IFLA_OPERSTATE": "IF_OPER_UP",
+		"IFLA_LINKINFO":  "map[IFLA_INFO_KIND:veth]",
+		"IFLA_STATS64":   fmt.Sprintf("%q...", make([]byte, maxAttrDump)),
+	}
+	checkAttrs(t, "link", link.Attrs, wantLink)
+
+	addr, ok := msgs[1].Payload.(AddrMessage)
+	if !ok {
+		t.Fatalf("RTM_NEWADDR payload %#v", msgs[1].Payload)
+	}
+	if msgs[1].Flags.String() != "NLM_F_CREATE|NLM_F_REQUEST" {
+		t.Errorf("RTM_NEWADDR flags %s", msgs[1].Flags)
+	}
+	if addr.Family.String() != "AF_INET" || addr.PrefixLen != 24 || addr.Scope.String() != "RT_SCOPE_UNIVERSE" || addr.Index != 2 {
+		t.Errorf("ifaddrmsg %+v", addr)
+	}
+	checkAttrs(t, "addr", addr.Attrs, map[string]string{"IFA_ADDRESS": "10.0.0.1", "IFA_LABEL": "eth0"})
+
+	route, ok := msgs[2].Payload.(RouteMessage)
+	if !ok {
+		t.Fatalf("RTM_GETROUTE payload %#v", msgs[2].Payload)
+	}
+	if msgs[2].Flags.String() != "NLM_F_DUMP|NLM_F_REQUEST" {
+		t.Errorf("RTM_GETROUTE flags %s", msgs[2].Flags)
+	}
+	if route.DstLen != 24 || route.Table.String() != "RT_TABLE_MAIN" || route.Protocol.String() != "RTPROT_KERNEL" ||
+		route.Scope.String() != "RT_SCOPE_LINK" || route.Type.String() != "RTN_UNICAST" {
+		t.Errorf("rtmsg %+v", route)
+	}
+	checkAttrs(t, "route", route.Attrs, map[string]string{"RTA_DST": "10.0.0.0", "RTA_OIF": "2", "RTA_TABLE": "RT_TABLE_MAIN"})
+
+	nlErr, ok := msgs[3].Payload.(NetlinkError)
+	if !ok {
+		t.Fatalf("NLMSG_ERROR payload %#v", msgs[3].Payload)
+	}
+	if nlErr.Error != "EPERM" || nlErr.Msg.Type.String() != "RTM_NEWADDR" || nlErr.Msg.Len != 32 {
+		t.Errorf("nlmsgerr %+v", nlErr)
+	}
+	if msgs[4].Type.String() != "NLMSG_DONE" || msgs[4].Payload != nil {
+		t.Errorf("NLMSG_DONE %+v", msgs[4])
+	}
+}
+
+func checkAttrs(t *testing.T, name string, attrs map[string]any, want map[string]string) {
+	t.Helper()
+	if len(attrs) != len(want) {
+		t.Errorf("%s attrs %v, want %v", name, attrs, want)
+	}
+	for k, w := range want {
+		if got := fmt.Sprint(attrs[k]); got != w {
+			t.Errorf("%s attr %s = %s, want %s", name, k, got, w)
+		}
+	}
+}
+
+func TestNetlinkOtherProtocols(t *testing.T) {
+	buf := nlMsg(0x10, unix.NLM_F_REQUEST, []byte("abcd"))
+	arg := netlinkMessages(buf, unix.NETLINK_GENERIC, 1024)
+	msgs := arg.Value.([]NetlinkMessage)
+	if len(msgs) != 1 || msgs[0].Type.String() != "16" || msgs[0].Payload != `"abcd"` {
+		t.Errorf("got %+v, want a dump of a message of type 16", msgs)
+	}
+}
+
+func TestNetlinkMsghdr(t *testing.T) {
+	task := &memTask{}
+	msgs := nlMsg(unix.RTM_GETLINK, unix.NLM_F_REQUEST|unix.NLM_F_DUMP, unix.IfInfomsg{})
+	iov := task.put([]uint64{uint64(task.put(msgs[:10])), 10, uint64(task.put(msgs[10:])), uint64(len(msgs) - 10)})
+	addr := task.put(abi.MessageHeader64{Iov: uint64(iov), IovLen: 2})
+
+	arg, ok := netlinkMsghdr(task, addr, int64(len(msgs)), unix.NETLINK_ROUTE, 1024).(Arg)
+	if !ok || arg.Type != "msghdr" {
+		t.Fatalf("got %#v, want a msghdr", arg)
+	}
+	nl, ok := arg.Formated["Iov"].(Arg)
+	if !ok || len(nl.Value.([]NetlinkMessage)) != 1 {
+		t.Errorf("iov %#v, want one message split across buffers", arg.Formated["Iov"])
+	}
+
+	// Errors of msghdr are kept.
+	if got, ok := netlinkMsghdr(task, 0x10, 1, unix.NETLINK_ROUTE, 1024).(string); !ok || !strings.HasPrefix(got, "0x10 (error decoding") {
+		t.Errorf("unreadable msghdr: got %#v", got)
+	}
+}
+
+func TestNetlinkProtocol(t *testing.T) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Skipf("no netlink sockets: %s", err)
+	}
+	defer unix.Close(fd)
+	link, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sockets, err := os.ReadFile("/proc/self/net/netlink")
+	inode := regexp.MustCompile(`(?m) ` + strings.Trim(link, "socket:[]") + `\s*$`)
+	if err != nil || !inode.Match(sockets) {
+		t.Skip("the socket is not in /proc/self/net/netlink, like in some sandboxes")
+	}
+
+	state := new(TraceState)
+	task := WithPID(&memTask{}, os.Getpid(), state)
+	if p, ok := netlinkProtocol(task, int32(fd)); !ok || p != unix.NETLINK_ROUTE {
+		t.Errorf("netlinkProtocol = %d, %v, want NETLINK_ROUTE", p, ok)
+	}
+	if _, ok := netlinkProtocol(task, int32(os.Stdin.Fd())); ok {
+		t.Errorf("stdin is a netlink socket")
+	}
+	var cached int
+	state.netlinkSockets.Range(func(_, _ any) bool { cached++; return true })
+	if cached != 1 {
+		t.Errorf("%d sockets cached, want 1", cached)
+	}
+
+	// Another trace has its own cache.
+	if _, ok := netlinkProtocol(WithPID(&memTask{}, os.Getpid(), new(TraceState)), int32(fd)); !ok {
+		t.Errorf("netlink socket not found by another trace")
+	}
+}
@@ -0,0 +1,91 @@
+package syscalls
+
+import (
+	"fmt"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+// PerfEventAttrArg is a decoded struct perf_event_attr.
+type PerfEventAttrArg struct {
+	Type         abi.Flags
+	Size         uint32
+	Config       string
+	SamplePeriod uint64 `json:",omitempty"`
+	SampleFreq   uint64 `json:",omitempty"`
+	SampleType   abi.Flags
+	ReadFormat   string
+	Bits         abi.Flags
+	WakeupEvents uint32 `json:",omitempty"`
+	Config1      string `json:",omitempty"` // bp_addr of breakpoints
+	Config2      string `json:",omitempty"` // bp_len of breakpoints
+	BPType       uint32 `json:",omitempty"`
+}
+
+func perfEventAttr(t strace.Task, addr strace.Addr) any {
+	if addr == 0 {
+		return "null"
+	}
+
+	// The struct is versioned by its size field.
+	var hdr [8]byte
+	if _, err := t.Read(addr, hdr[:]); err != nil {
+		return fmt.Sprintf("%#x (error decoding perf_event_attr: %s)", addr, err)
+	}
+	size := ubinary.NativeEndian.Uint32(hdr[4:])
+	if size == 0 {
+		size = unix.PERF_ATTR_SIZE_VER0
+	}
+	a, err := readUnion[unix.PerfEventAttr](t, addr, uint64(size))
+	if err != nil {
+		return fmt.Sprintf("%#x (error decoding perf_event_attr: %s)", addr, err)
+	}
+
+	arg := PerfEventAttrArg{
+		Type:         abi.PerfTypes.Parse(uint64(a.Type)),
+		Size:         a.Size,
+		Config:       perfConfig(a.Type, a.Config),
+		SampleType:   abi.PerfSampleTypeSet.Parse(a.Sample_type),
+		ReadFormat:   fmt.Sprintf("%#x", a.Read_format),
+		Bits:         abi.PerfAttrBitSet.Parse(a.Bits),
+		WakeupEvents: a.Wakeup,
+	}
+	if a.Bits&unix.PerfBitFreq != 0 {
+		arg.SampleFreq = a.Sample
+	} else {
+		arg.SamplePeriod = a.Sample
+	}
+	if a.Ext1 != 0 {
+		arg.Config1 = fmt.Sprintf("%#x", a.Ext1)
+	}
+	if a.Ext2 != 0 {
+		arg.Config2 = fmt.Sprintf("%#x", a.Ext2)
+	}
+	if a.Type == unix.PERF_TYPE_BREAKPOINT {
+		arg.BPType = a.Bp_type
+	}
+	return Arg{Type: "perf_event_attr", Value: arg}
+}
+
+// perfConfig formats the event selected by config, which depends on the
+// event type.
+func perfConfig(typ uint32, config uint64) string {
+	switch typ {
+	case unix.PERF_TYPE_HARDWARE:
+		return abi.PerfHardwareConfigs.Parse(config).String()
+	case unix.PERF_TYPE_SOFTWARE:
+		return abi.PerfSoftwareConfigs.Parse(config).String()
+	case unix.PERF_TYPE_HW_CACHE:
+		if config>>24 != 0 {
+			break
+		}
+		return fmt.Sprintf("%s|%s<<8|%s<<16",
+			abi.PerfHWCacheIDs.Parse(config&0xff),
+			abi.PerfHWCacheOps.Parse(config>>8&0xff),
+			abi.PerfHWCacheResults.Parse(config>>16&0xff))
+	}
+	return fmt.Sprintf("%#x", config)
+}
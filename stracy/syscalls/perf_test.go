@@ -0,0 +1,74 @@
+package syscalls
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestPerfEventAttr(t *testing.T) {
+	task := &memTask{}
+	tests := []struct {
+		name string
+		attr unix.PerfEventAttr
+		want PerfEventAttrArg
+	}{
+		{
+			name: "hardware",
+			attr: unix.PerfEventAttr{Type: unix.PERF_TYPE_HARDWARE, Size: unix.PERF_ATTR_SIZE_VER5, Config: unix.PERF_COUNT_HW_CPU_CYCLES,
+				Sample: 4000, Sample_type: unix.PERF_SAMPLE_IP, Bits: unix.PerfBitFreq | unix.PerfBitDisabled},
+			want: PerfEventAttrArg{Size: unix.PERF_ATTR_SIZE_VER5, Config: "PERF_COUNT_HW_CPU_CYCLES", SampleFreq: 4000, ReadFormat: "0x0"},
+		},
+		{
+			name: "software",
+			attr: unix.PerfEventAttr{Type: unix.PERF_TYPE_SOFTWARE, Size: unix.PERF_ATTR_SIZE_VER1, Config: unix.PERF_COUNT_SW_PAGE_FAULTS,
+				Sample: 100, Wakeup: 1, Read_format: unix.PERF_FORMAT_ID},
+			want: PerfEventAttrArg{Size: unix.PERF_ATTR_SIZE_VER1, Config: "PERF_COUNT_SW_PAGE_FAULTS", SamplePeriod: 100, ReadFormat: "0x4", WakeupEvents: 1},
+		},
+		{
+			name: "hw cache",
+			attr: unix.PerfEventAttr{Type: unix.PERF_TYPE_HW_CACHE, Size: unix.PERF_ATTR_SIZE_VER2,
+				Config: unix.PERF_COUNT_HW_CACHE_L1D | unix.PERF_COUNT_HW_CACHE_OP_READ<<8 | unix.PERF_COUNT_HW_CACHE_RESULT_MISS<<16},
+			want: PerfEventAttrArg{Size: unix.PERF_ATTR_SIZE_VER2,
+				Config: "PERF_COUNT_HW_CACHE_L1D|PERF_COUNT_HW_CACHE_OP_READ<<8|PERF_COUNT_HW_CACHE_RESULT_MISS<<16", ReadFormat: "0x0"},
+		},
+		{
+			name: "breakpoint",
+			attr: unix.PerfEventAttr{Type: unix.PERF_TYPE_BREAKPOINT, Size: unix.PERF_ATTR_SIZE_VER5, Bp_type: 3, Ext1: 0x7f0000001000, Ext2: 8},
+			want: PerfEventAttrArg{Size: unix.PERF_ATTR_SIZE_VER5, Config: "0x0", ReadFormat: "0x0", Config1: "0x7f0000001000", Config2: "0x8", BPType: 3},
+		},
+		{
+			// Fields beyond the size of the first version are not read.
+			name: "size 0",
+			attr: unix.PerfEventAttr{Type: unix.PERF_TYPE_BREAKPOINT, Bp_type: 3, Ext1: 0x1000, Ext2: 8},
+			want: PerfEventAttrArg{Config: "0x0", ReadFormat: "0x0", Config1: "0x1000", BPType: 3},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := perfEventAttr(task, task.put(tt.attr)).(Arg)
+		if !ok || got.Type != "perf_event_attr" {
+			t.Errorf("%s: got %#v", tt.name, got)
+			continue
+		}
+		a := got.Value.(PerfEventAttrArg)
+		a.Type, a.SampleType, a.Bits = tt.want.Type, tt.want.SampleType, tt.want.Bits
+		if !reflect.DeepEqual(a, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, a, tt.want)
+		}
+	}
+
+	got, ok := perfEventAttr(task, task.put(unix.PerfEventAttr{Type: unix.PERF_TYPE_HARDWARE, Sample_type: unix.PERF_SAMPLE_IP | unix.PERF_SAMPLE_TID,
+		Bits: unix.PerfBitDisabled | unix.PerfBitExcludeKernel})).(Arg)
+	if !ok {
+		t.Fatalf("got %#v", got)
+	}
+	a := got.Value.(PerfEventAttrArg)
+	if a.Type.String() != "PERF_TYPE_HARDWARE" || a.SampleType.String() != "PERF_SAMPLE_IP|PERF_SAMPLE_TID" || a.Bits.String() != "disabled|exclude_kernel" {
+		t.Errorf("flags: got %s, %s, %s", a.Type, a.SampleType, a.Bits)
+	}
+
+	if got := perfEventAttr(task, 0x10); got != "0x10 (error decoding perf_event_attr: bad address)" {
+		t.Errorf("unreadable attr: got %v", got)
+	}
+}
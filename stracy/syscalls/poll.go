@@ -0,0 +1,122 @@
+package syscalls
+
+import (
+	"fmt"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
+	"golang.org/x/sys/unix"
+)
+
+// maxPollFDs limits the number of decoded pollfd, fd_set and epoll_event
+// entries.
+const maxPollFDs = 1 << 16
+
+// PollFDArg is a decoded struct pollfd.
+type PollFDArg struct {
+	FD      int32
+	Events  abi.Flags
+	Revents abi.Flags
+}
+
+func pollFDs(t strace.Task, addr strace.Addr, nfds uint64) any {
+	if addr == 0 {
+		return nil
+	}
+	if nfds > maxPollFDs {
+		return fmt.Sprintf("%#x (error decoding pollfds: invalid nfds %d)", addr, nfds)
+	}
+
+	fds := make([]unix.PollFd, nfds)
+	if _, err := t.Read(addr, fds); err != nil {
+		return fmt.Sprintf("%#x (error decoding pollfds: %s)", addr, err)
+	}
+
+	v := make([]PollFDArg, len(fds))
+	for i, fd := range fds {
+		v[i] = PollFDArg{
+			FD:      fd.Fd,
+			Events:  abi.PollEventSet.Parse(uint64(uint16(fd.Events))),
+			Revents: abi.PollEventSet.Parse(uint64(uint16(fd.Revents))),
+		}
+	}
+	return Arg{Type: "pollfd", Value: v}
+}
+
+// fdSet decodes an fd_set of select(2) at the syscall exit. The kernel
+// overwrites the sets in place, so only the ready descriptors are shown, not
+// the ones the program waited for.
+func fdSet(t strace.Task, addr strace.Addr, nfds int32) any {
+	if addr == 0 {
+		return nil
+	}
+	if nfds < 0 || nfds > maxPollFDs {
+		return fmt.Sprintf("%#x (error decoding fd_set: invalid nfds %d)", addr, nfds)
+	}
+
+	bits := make([]uint64, (nfds+63)/64)
+	if _, err := t.Read(addr, bits); err != nil {
+		return fmt.Sprintf("%#x (error decoding fd_set: %s)", addr, err)
+	}
+
+	fds := make([]int32, 0, 4)
+	for fd := int32(0); fd < nfds; fd++ {
+		if bits[fd/64]&(1<<(fd%64)) != 0 {
+			fds = append(fds, fd)
+		}
+	}
+	return Arg{Type: "fd_set", Value: fds}
+}
+
+// EpollEventArg is a decoded struct epoll_event.
+type EpollEventArg struct {
+	Events abi.Flags
+	Data   string
+}
+
+func makeEpollEvent(ev abi.EpollEvent) EpollEventArg {
+	return EpollEventArg{
+		Events: abi.EpollEventSet.Parse(uint64(ev.Events)),
+		Data:   fmt.Sprintf("%#x", ev.Data),
+	}
+}
+
+func epollEvent(t strace.Task, addr strace.Addr) any {
+	ev, err := readStruct[abi.EpollEvent](t, addr)
+	if err != nil {
+		return err.Error()
+	}
+	if ev == nil {
+		return nil
+	}
+	return Arg{Type: "epoll_event", Value: makeEpollEvent(*ev)}
+}
+
+func epollEvents(t strace.Task, addr strace.Addr, n int64) any {
+	if addr == 0 {
+		return nil
+	}
+	if n < 0 {
+		n = 0
+	}
+	if n > maxPollFDs {
+		return fmt.Sprintf("%#x (error decoding epoll events: invalid count %d)", addr, n)
+	}
+
+	buf := make([]byte, n*int64(abi.SizeOfEpollEvent))
+	if _, err := t.Read(addr, buf); err != nil {
+		return fmt.Sprintf("%#x (error decoding epoll events: %s)", addr, err)
+	}
+
+	v := make([]EpollEventArg, n)
+	for i := range v {
+		var ev abi.EpollEvent
+		if _, err := binary.Decode(buf[i*abi.SizeOfEpollEvent:], ubinary.NativeEndian, &ev); err != nil {
+			return fmt.Sprintf("%#x (error decoding epoll events: %s)", addr, err)
+		}
+		v[i] = makeEpollEvent(ev)
+	}
+	return Arg{Type: "epoll_events", Value: v}
+}
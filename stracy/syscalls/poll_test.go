@@ -0,0 +1,152 @@
+package syscalls
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/iimos/play/stracy/abi"
+	"golang.org/x/sys/unix"
+)
+
+func TestPollFDs(t *testing.T) {
+	task := &memTask{}
+	addr := task.put([]unix.PollFd{
+		{Fd: 3, Events: unix.POLLIN | unix.POLLPRI, Revents: unix.POLLIN},
+		{Fd: -1, Events: unix.POLLOUT},
+		{Fd: 5, Events: unix.POLLIN, Revents: unix.POLLHUP | unix.POLLERR},
+	})
+
+	got, ok := pollFDs(task, addr, 3).(Arg)
+	if !ok || got.Type != "pollfd" {
+		t.Fatalf("got %#v", got)
+	}
+	var fds []string
+	for _, fd := range got.Value.([]PollFDArg) {
+		fds = append(fds, fmt.Sprintf("%d %s %s", fd.FD, fd.Events, fd.Revents))
+	}
+	want := []string{"3 POLLIN|POLLPRI POLLIN", "-1 POLLOUT ", "5 POLLIN POLLERR|POLLHUP"}
+	if !reflect.DeepEqual(fds, want) {
+		t.Errorf("got %q, want %q", fds, want)
+	}
+
+	if got := pollFDs(task, 0, 3); got != nil {
+		t.Errorf("NULL: got %v", got)
+	}
+	if got, ok := pollFDs(task, addr, 0).(Arg); !ok || len(got.Value.([]PollFDArg)) != 0 {
+		t.Errorf("no fds: got %#v", got)
+	}
+	if got := pollFDs(task, addr, maxPollFDs+1); got != fmt.Sprintf("%#x (error decoding pollfds: invalid nfds %d)", addr, maxPollFDs+1) {
+		t.Errorf("too many fds: got %v", got)
+	}
+	if got := pollFDs(task, 0x10, 1); got != "0x10 (error decoding pollfds: bad address)" {
+		t.Errorf("unreadable fds: got %v", got)
+	}
+}
+
+func TestFDSet(t *testing.T) {
+	task := &memTask{}
+	addr := task.put([]uint64{1<<0 | 1<<3 | 1<<63, 1 << 1}) // 0, 3, 63 and 65
+
+	tests := []struct {
+		nfds int32
+		want []int32
+	}{
+		{66, []int32{0, 3, 63, 65}},
+		{65, []int32{0, 3, 63}}, // descriptors from nfds on are ignored
+		{4, []int32{0, 3}},
+		{0, []int32{}},
+	}
+	for _, tt := range tests {
+		got, ok := fdSet(task, addr, tt.nfds).(Arg)
+		if !ok || got.Type != "fd_set" || !reflect.DeepEqual(got.Value, tt.want) {
+			t.Errorf("nfds %d: got %#v, want %v", tt.nfds, got, tt.want)
+		}
+	}
+
+	if got := fdSet(task, 0, 66); got != nil {
+		t.Errorf("NULL: got %v", got)
+	}
+	if got := fdSet(task, addr, -1); got != fmt.Sprintf("%#x (error decoding fd_set: invalid nfds -1)", addr) {
+		t.Errorf("negative nfds: got %v", got)
+	}
+	if got := fdSet(task, 0x10, 1); got != "0x10 (error decoding fd_set: bad address)" {
+		t.Errorf("unreadable set: got %v", got)
+	}
+}
+
+func TestEpollEvent(t *testing.T) {
+	task := &memTask{}
+	addr := task.put(abi.EpollEvent{Events: unix.EPOLLIN | unix.EPOLLET, Data: 0x1f00000007})
+
+	got, ok := epollEvent(task, addr).(Arg)
+	if !ok || got.Type != "epoll_event" {
+		t.Fatalf("got %#v", got)
+	}
+	ev := got.Value.(EpollEventArg)
+	if ev.Events.String() != "EPOLLIN|EPOLLET" || ev.Data != "0x1f00000007" {
+		t.Errorf("got %s %s", ev.Events, ev.Data)
+	}
+	if got := epollEvent(task, 0); got != nil {
+		t.Errorf("NULL: got %v", got)
+	}
+	if got := epollEvent(task, 0x10); got != "0x10 (error decoding: bad address)" {
+		t.Errorf("unreadable event: got %v", got)
+	}
+}
+
+func TestEpollEvents(t *testing.T) {
+	task := &memTask{}
+	addr := task.put([]abi.EpollEvent{
+		{Events: unix.EPOLLIN, Data: 3},
+		{Events: unix.EPOLLOUT | unix.EPOLLHUP, Data: 4},
+		{Events: unix.EPOLLIN, Data: 5}, // not ready, past the result
+	})
+
+	got, ok := epollEvents(task, addr, 2).(Arg)
+	if !ok || got.Type != "epoll_events" {
+		t.Fatalf("got %#v", got)
+	}
+	var evs []string
+	for _, ev := range got.Value.([]EpollEventArg) {
+		evs = append(evs, ev.Events.String()+" "+ev.Data)
+	}
+	if want := []string{"EPOLLIN 0x3", "EPOLLOUT|EPOLLHUP 0x4"}; !reflect.DeepEqual(evs, want) {
+		t.Errorf("got %q, want %q", evs, want)
+	}
+
+	// A failed wait returns -1.
+	if got, ok := epollEvents(task, addr, -1).(Arg); !ok || len(got.Value.([]EpollEventArg)) != 0 {
+		t.Errorf("failed wait: got %#v", got)
+	}
+	if got := epollEvents(task, 0, 2); got != nil {
+		t.Errorf("NULL: got %v", got)
+	}
+	if got := epollEvents(task, addr, maxPollFDs+1); got != fmt.Sprintf("%#x (error decoding epoll events: invalid count %d)", addr, maxPollFDs+1) {
+		t.Errorf("too many events: got %v", got)
+	}
+	if got := epollEvents(task, 0x10, 1); got != "0x10 (error decoding epoll events: bad address)" {
+		t.Errorf("unreadable events: got %v", got)
+	}
+}
+
+func BenchmarkEpollEvent(b *testing.B) {
+	task := &memTask{}
+	addr := task.put(abi.EpollEvent{Events: unix.EPOLLIN, Data: 7})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		epollEvent(task, addr)
+	}
+}
+
+func BenchmarkEpollEvents(b *testing.B) {
+	task := &memTask{}
+	evs := make([]abi.EpollEvent, 64)
+	addr := task.put(evs)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		epollEvents(task, addr, int64(len(evs)))
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/abi"
 	"golang.org/x/sys/unix"
 )
 
@@ -62,8 +63,8 @@ func dump(t strace.Task, addr strace.Addr, size uint, maximumBlobSize uint) stri
 }
 
 type iovec struct {
-	P strace.Addr /* Starting address */
-	S uint32      /* Number of bytes to transfer */
+	P uint64 /* Starting address */
+	S uint64 /* Number of bytes to transfer */
 }
 
 func iovecs(t strace.Task, addr strace.Addr, iovcnt int, printContent bool, maxBytes uint64) string {
@@ -85,16 +86,16 @@ func iovecs(t strace.Task, addr strace.Addr, iovcnt int, printContent bool, maxB
 			continue
 		}
 
-		size := uint64(vv.S)
+		size := vv.S
 		if truncated || totalBytes+size > maxBytes {
 			truncated = true
 			size = maxBytes - totalBytes
 		} else {
-			totalBytes += uint64(vv.S)
+			totalBytes += vv.S
 		}
 
 		b := make([]byte, size)
-		amt, err := t.Read(vv.P, b)
+		amt, err := t.Read(strace.Addr(vv.P), b)
 		if err != nil {
 			iovs[i] = fmt.Sprintf("{base=%#x, len=%d, %q..., error decoding string: %v}", vv.P, vv.S, b[:amt], err)
 			continue
@@ -111,6 +112,91 @@ func iovecs(t strace.Task, addr strace.Addr, iovcnt int, printContent bool, maxB
 	return fmt.Sprintf("%#x %s", addr, strings.Join(iovs, ", "))
 }
 
+// uioMaxIOV is UIO_MAXIOV from uapi/linux/uio.h.
+const uioMaxIOV = 1024
+
+// MaxTransferSize caps the number of bytes ReadTransfer copies out of a tracee
+// for a single syscall.
+const MaxTransferSize = 64 << 20
+
+// Transfer is a data buffer moved between a process and a file descriptor by
+// a read- or write-style syscall.
+type Transfer struct {
+	FD    int32
+	Write bool
+	Data  []byte
+}
+
+// ReadTransfer copies the complete buffer of a read- or write-style syscall
+// out of the tracee. It must be called on a successful syscall exit because
+// the return value is used as the number of transferred bytes. A nil Transfer
+// is returned for syscalls that do not move data through a file descriptor.
+func ReadTransfer(si SyscallInfo, t strace.Task, args strace.SyscallArguments, rval strace.SyscallArgument) (*Transfer, error) {
+	if len(si.ArgTypes) < 2 || si.ArgTypes[0] != FD {
+		return nil, nil
+	}
+	size := rval.Int64()
+	if size <= 0 {
+		return nil, nil
+	}
+	if size > MaxTransferSize {
+		size = MaxTransferSize
+	}
+
+	tr := &Transfer{FD: args[0].Int()}
+	var err error
+	switch si.ArgTypes[1] {
+	case ReadBuffer, WriteBuffer:
+		tr.Write = si.ArgTypes[1] == WriteBuffer
+		tr.Data = make([]byte, size)
+		_, err = t.Read(args[1].Pointer(), tr.Data)
+	case ReadIOVec, WriteIOVec:
+		tr.Write = si.ArgTypes[1] == WriteIOVec
+		tr.Data, err = readIOVecs(t, args[1].Pointer(), int(args[2].Int()), size)
+	case SendMsgHdr, RecvMsgHdr:
+		tr.Write = si.ArgTypes[1] == SendMsgHdr
+		var msg *abi.MessageHeader64
+		if msg, err = readStruct[abi.MessageHeader64](t, args[1].Pointer()); err == nil && msg != nil {
+			tr.Data, err = readIOVecs(t, strace.Addr(msg.Iov), int(msg.IovLen), size)
+		}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// readIOVecs concatenates up to size bytes of the buffers described by an
+// array of struct iovec.
+func readIOVecs(t strace.Task, addr strace.Addr, iovcnt int, size int64) ([]byte, error) {
+	if iovcnt < 0 || iovcnt > uioMaxIOV {
+		return nil, fmt.Errorf("invalid iovcnt %d", iovcnt)
+	}
+	v := make([]iovec, iovcnt)
+	if _, err := t.Read(addr, v); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, size)
+	for _, vv := range v {
+		n := int64(vv.S)
+		if left := size - int64(len(data)); n > left {
+			n = left
+		}
+		if n == 0 {
+			continue
+		}
+		b := make([]byte, n)
+		if _, err := t.Read(strace.Addr(vv.P), b); err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
 func fdpair(t strace.Task, addr strace.Addr) string {
 	var fds [2]int32
 	_, err := t.Read(addr, &fds)
@@ -145,13 +231,13 @@ func convertUname(s [65]uint8) string {
 	return string(bytes.TrimRight(s[:], "\x00"))
 }
 
-func uname(t strace.Task, addr strace.Addr) string {
+func uname(t strace.Task, addr strace.Addr) any {
 	var u unix.Utsname
 	if _, err := t.Read(addr, &u); err != nil {
 		return fmt.Sprintf("%#x (error decoding utsname: %s)", addr, err)
 	}
 
-	return fmt.Sprintf("%#x %#v", addr, SaneUname(u))
+	return Arg{Type: "utsname", Value: SaneUname(u)}
 }
 
 // alignUp rounds a length up to an alignment. align must be a power of 2.
@@ -38,14 +38,12 @@ func cmsghdr(t strace.Task, addr strace.Addr, length uint64, maxBytes uint64) st
 	var strs []string
 
 	for i := 0; i < len(buf); {
-		if i+abi.SizeOfControlMessageHeader > len(buf) {
+		var h abi.ControlMessageHeader
+		if _, err := binary.Decode(buf[i:], ubinary.NativeEndian, &h); err != nil {
 			strs = append(strs, "{invalid control message (too short)}")
 			break
 		}
 
-		var h abi.ControlMessageHeader
-		binary.Unmarshal(buf[i:i+abi.SizeOfControlMessageHeader], ubinary.NativeEndian, &h)
-
 		var skipData bool
 		level := "SOL_SOCKET"
 		if h.Level != unix.SOL_SOCKET {
@@ -86,7 +84,10 @@ func cmsghdr(t strace.Task, addr strace.Addr, length uint64, maxBytes uint64) st
 
 			numRights := rightsSize / abi.SizeOfControlMessageRight
 			fds := make(abi.ControlMessageRights, numRights)
-			binary.Unmarshal(buf[i:i+rightsSize], ubinary.NativeEndian, &fds)
+			if err := binary.Unmarshal(buf[i:i+rightsSize], ubinary.NativeEndian, &fds); err != nil {
+				strs = append(strs, fmt.Sprintf("{level=%s, type=%s, length=%d, %v}", level, typ, h.Length, err))
+				break
+			}
 
 			rights := make([]string, 0, len(fds))
 			for _, fd := range fds {
@@ -113,7 +114,10 @@ func cmsghdr(t strace.Task, addr strace.Addr, length uint64, maxBytes uint64) st
 			}
 
 			var creds abi.ControlMessageCredentials
-			binary.Unmarshal(buf[i:i+abi.SizeOfControlMessageCredentials], binary.LittleEndian, &creds)
+			if err := binary.Unmarshal(buf[i:i+abi.SizeOfControlMessageCredentials], binary.LittleEndian, &creds); err != nil {
+				strs = append(strs, fmt.Sprintf("{level=%s, type=%s, length=%d, %v}", level, typ, h.Length, err))
+				break
+			}
 
 			strs = append(strs, fmt.Sprintf(
 				"{level=%s, type=%s, length=%d, pid: %d, uid: %d, gid: %d}",
@@ -137,7 +141,10 @@ func cmsghdr(t strace.Task, addr strace.Addr, length uint64, maxBytes uint64) st
 			}
 
 			var tv unix.Timeval
-			binary.Unmarshal(buf[i:i+abi.SizeOfTimeval], ubinary.NativeEndian, &tv)
+			if err := binary.Unmarshal(buf[i:i+abi.SizeOfTimeval], ubinary.NativeEndian, &tv); err != nil {
+				strs = append(strs, fmt.Sprintf("{level=%s, type=%s, length=%d, %v}", level, typ, h.Length, err))
+				break
+			}
 
 			strs = append(strs, fmt.Sprintf(
 				"{level=%s, type=%s, length=%d, Sec: %d, Usec: %d}",
@@ -190,6 +197,37 @@ func msghdr(t strace.Task, addr strace.Addr, printContent bool, maxBytes uint64)
 	// return fmt.Sprintf("%s, flags=%d}", s, msg.Flags)
 }
 
+// sockOptVal decodes the value returned by getsockopt(2): struct tcp_info
+// for TCP_INFO and a number for int options.
+func sockOptVal(t strace.Task, level, name int32, addr, lengthPtr strace.Addr) any {
+	if addr == 0 {
+		return nil
+	}
+	if lengthPtr == 0 {
+		return fmt.Sprintf("%#x {length null}", addr)
+	}
+	l, err := copySockLen(t, lengthPtr)
+	if err != nil {
+		return fmt.Sprintf("%#x {error reading length: %v}", addr, err)
+	}
+
+	switch {
+	case level == unix.IPPROTO_TCP && name == unix.TCP_INFO:
+		info, err := readUnion[abi.TCPInfo](t, addr, uint64(l))
+		if err != nil {
+			return fmt.Sprintf("%#x (error decoding tcp_info: %s)", addr, err)
+		}
+		return Arg{Type: "tcp_info", Value: *info}
+	case l == 4:
+		var v int32
+		if _, err := t.Read(addr, &v); err != nil {
+			return fmt.Sprintf("%#x (error decoding optval: %s)", addr, err)
+		}
+		return v
+	}
+	return fmt.Sprintf("%#x", addr)
+}
+
 func sockAddr(t strace.Task, addr strace.Addr, length uint32) string {
 	if addr == 0 {
 		return "null"
@@ -221,11 +259,11 @@ func sockAddr(t strace.Task, addr strace.Addr, length uint32) string {
 
 		return fmt.Sprintf("%#x {Family: %s, Addr: %#02x, Port: %d}", addr, familyStr, []byte(fa.Addr), fa.Port)
 	case unix.AF_NETLINK:
-		//sa, err := netlink.ExtractSockAddr(b)
-		//if err != nil {
-		return fmt.Sprintf("%#x {Family: %s, error extracting address: %v}", addr, familyStr, err)
-		//}
-		//return fmt.Sprintf("%#x {Family: %s, PortID: %d, Groups: %d}", addr, familyStr, sa.PortID, sa.Groups)
+		var sa unix.RawSockaddrNetlink
+		if _, err := binary.Decode(b, ubinary.NativeEndian, &sa); err != nil {
+			return fmt.Sprintf("%#x {Family: %s, address too short: %d bytes}", addr, familyStr, len(b))
+		}
+		return fmt.Sprintf("%#x {Family: %s, PortID: %d, Groups: %#x}", addr, familyStr, sa.Pid, sa.Groups)
 	default:
 		return fmt.Sprintf("%#x {Family: %s, family addr format unknown}", addr, familyStr)
 	}
@@ -0,0 +1,44 @@
+package syscalls
+
+import (
+	"testing"
+
+	"github.com/iimos/play/stracy/abi"
+	"golang.org/x/sys/unix"
+)
+
+func TestSockOptVal(t *testing.T) {
+	task := &memTask{}
+	info := abi.TCPInfo{State: 1, RTT: 1500, SndCwnd: 10}
+	infoAddr := task.put(info)
+	infoLen := task.put(uint32(abi.SizeOfTCPInfo))
+	oldLen := task.put(uint32(8)) // a kernel with a shorter struct
+	intAddr := task.put(int32(212992))
+	intLen := task.put(uint32(4))
+
+	got := sockOptVal(task, unix.IPPROTO_TCP, unix.TCP_INFO, infoAddr, infoLen)
+	if a, ok := got.(Arg); !ok || a.Type != "tcp_info" || a.Value != info {
+		t.Errorf("TCP_INFO: got %+v, want %+v", got, info)
+	}
+	got = sockOptVal(task, unix.IPPROTO_TCP, unix.TCP_INFO, infoAddr, oldLen)
+	if a, ok := got.(Arg); !ok || a.Value != (abi.TCPInfo{State: 1}) {
+		t.Errorf("short TCP_INFO: got %+v, want only the first 8 bytes", got)
+	}
+	if got := sockOptVal(task, unix.SOL_SOCKET, unix.SO_RCVBUF, intAddr, intLen); got != int32(212992) {
+		t.Errorf("SO_RCVBUF: got %v, want 212992", got)
+	}
+	if got := sockOptVal(task, unix.SOL_SOCKET, unix.SO_RCVBUF, 0, intLen); got != nil {
+		t.Errorf("NULL optval: got %v", got)
+	}
+}
+
+func BenchmarkMsghdr(b *testing.B) {
+	task := &memTask{}
+	iov := task.put([]uint64{uint64(task.put([]byte("hello"))), 5})
+	addr := task.put(abi.MessageHeader64{Iov: uint64(iov), IovLen: 1})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msghdr(task, addr, true, 1024)
+	}
+}
@@ -15,7 +15,6 @@
 package syscalls
 
 import (
-	"encoding/binary"
 	"fmt"
 	"io/fs"
 	"math/bits"
@@ -27,6 +26,8 @@ import (
 	"github.com/docker/go-units"
 	"github.com/hugelgupf/go-strace/strace"
 	"github.com/iimos/play/stracy/abi"
+	"github.com/iimos/play/stracy/binary"
+	"github.com/iimos/play/stracy/ubinary"
 	"golang.org/x/sys/unix"
 )
 
@@ -131,9 +132,11 @@ func itimerval(t strace.Task, addr strace.Addr) string {
 		return "null"
 	}
 
-	interval := timeval(t, addr)
-	value := timeval(t, addr+strace.Addr(binary.Size(unix.Timeval{})))
-	return fmt.Sprintf("{interval=%s, value=%s}", interval, value)
+	var v [2]unix.Timeval
+	if _, err := t.Read(addr, &v); err != nil {
+		return fmt.Sprintf("%#x (error decoding itimerval: %s)", addr, err)
+	}
+	return fmt.Sprintf("{interval=%s, value=%s}", time.Duration(v[0].Nano()), time.Duration(v[1].Nano()))
 }
 
 func itimerspec(t strace.Task, addr strace.Addr) string {
@@ -142,24 +145,51 @@ func itimerspec(t strace.Task, addr strace.Addr) string {
 	}
 
 	interval := timespec(t, addr)
-	value := timespec(t, addr+strace.Addr(binary.Size(unix.Timespec{})))
+	value := timespec(t, addr+strace.Addr(binary.Size(&unix.Timespec{})))
 	return fmt.Sprintf("{interval=%s, value=%s}", interval, value)
 }
 
-func stringVector(t strace.Task, addr strace.Addr) string {
+// maxStringVectorLen is the maximum number of decoded argv or envp elements.
+const maxStringVectorLen = 256
+
+// stringVector decodes argv or envp into a list of strings, each truncated to
+// maxElemSize. If there are more than maxStringVectorLen elements, the rest is
+// counted in Formated["more"].
+func stringVector(t strace.Task, addr strace.Addr, maxElemSize uint) any {
+	if addr == 0 {
+		return "null"
+	}
 	vs, err := strace.ReadStringVector(t, addr, strace.ExecMaxElemSize, strace.ExecMaxTotalSize)
 	if err != nil {
-		return fmt.Sprintf("%#x {error copying vector: %v}", addr, err)
+		return fmt.Sprintf("%#x (error decoding string vector: %v)", addr, err)
+	}
+
+	arg := Arg{Type: "string_vector"}
+	if len(vs) > maxStringVectorLen {
+		arg.Formated = map[string]any{"more": len(vs) - maxStringVectorLen}
+		vs = vs[:maxStringVectorLen]
+	}
+	for i, v := range vs {
+		if uint(len(v)) > maxElemSize {
+			vs[i] = v[:maxElemSize] + "..."
+		}
 	}
-	return fmt.Sprintf("%q", vs)
+	arg.Value = vs
+	return arg
 }
 
+// readStruct reads a struct in one go and decodes it with the generated
+// unmarshaler, if there is one.
 func readStruct[T any](t strace.Task, addr strace.Addr) (*T, error) {
 	if addr == 0 {
 		return nil, nil
 	}
 	var x T
-	if _, err := t.Read(addr, &x); err != nil {
+	buf := make([]byte, binary.Size(&x))
+	if _, err := t.Read(addr, buf); err != nil {
+		return nil, fmt.Errorf("%#x (error decoding: %s)", addr, err)
+	}
+	if err := binary.Unmarshal(buf, ubinary.NativeEndian, &x); err != nil {
 		return nil, fmt.Errorf("%#x (error decoding: %s)", addr, err)
 	}
 	return &x, nil
@@ -183,7 +213,7 @@ func stack_t(t strace.Task, addr strace.Addr) any {
 	return Arg{Type: "stack_t", Value: *st}
 }
 
-func rusage(t strace.Task, addr strace.Addr) string {
+func rusage(t strace.Task, addr strace.Addr) any {
 	if addr == 0 {
 		return "null"
 	}
@@ -192,7 +222,7 @@ func rusage(t strace.Task, addr strace.Addr) string {
 	if _, err := t.Read(addr, &ru); err != nil {
 		return fmt.Sprintf("%#x (error decoding rusage: %s)", addr, err)
 	}
-	return fmt.Sprintf("%+v", ru)
+	return Arg{Type: "rusage", Value: ru}
 }
 
 func cpuSet(t strace.Task, addr strace.Addr) any {
@@ -265,6 +295,16 @@ func flags(specs []flagSpec, t strace.Task, bits int) Arg {
 	}
 }
 
+// fdArg decodes a file descriptor. The path it refers to is kept in
+// Formated["path"] if it can be looked up.
+func fdArg(t strace.Task, fd int32) Arg {
+	arg := Arg{Type: "fd", Value: fd}
+	if p, ok := fdPath(t, fd); ok {
+		arg.Formated = map[string]any{"path": p}
+	}
+	return arg
+}
+
 // ArgumentsStrings fills arguments for a system call. If an argument
 // cannot be interpreted, then a hex value will be used. Note that
 // a full output slice will always be provided, that is len(return) == len(args).
@@ -281,6 +321,10 @@ func ArgumentsStrings(si SyscallInfo, t strace.Task, args strace.SyscallArgument
 		case SockProtocol:
 			output[i] = abi.SockProtocol(args[i-2].Int(), args[i].Int())
 		case WriteBuffer:
+			if proto, ok := netlinkFD(si, t, args); ok {
+				output[i] = netlinkBuffer(t, args[i].Pointer(), args[i+1].SizeT(), proto, maximumBlobSize)
+				break
+			}
 			output[i] = dump(t, args[i].Pointer(), args[i+1].SizeT(), maximumBlobSize)
 		case WriteIOVec:
 			output[i] = iovecs(t, args[i].Pointer(), int(args[i+1].Int()), true /* content */, uint64(maximumBlobSize))
@@ -289,6 +333,10 @@ func ArgumentsStrings(si SyscallInfo, t strace.Task, args strace.SyscallArgument
 
 		// Available on syscall exit:
 		case ReadBuffer:
+			if proto, ok := netlinkFD(si, t, args); ok && rval.Int64() > 0 {
+				output[i] = netlinkBuffer(t, args[i].Pointer(), uint(rval.Uint64()), proto, maximumBlobSize)
+				break
+			}
 			output[i] = dump(t, args[i].Pointer(), uint(rval.Uint64()), maximumBlobSize)
 		case ReadIOVec:
 			printLength := rval.Uint()
@@ -299,8 +347,28 @@ func ArgumentsStrings(si SyscallInfo, t strace.Task, args strace.SyscallArgument
 		// case WriteIOVec, IOVec, WriteBuffer:
 		// We already have a big blast from write.
 		// output[i] = "..."
+		case SendMsgHdr, RecvMsgHdr:
+			if proto, ok := netlinkFD(si, t, args); ok && rval.Int64() > 0 {
+				output[i] = netlinkMsghdr(t, args[i].Pointer(), rval.Int64(), proto, maximumBlobSize)
+				break
+			}
+			output[i] = ArgumentSimple(t, format, args[i], maximumBlobSize)
 		case PostSockAddr:
 			output[i] = postSockAddr(t, args[i].Pointer(), args[i+1].Pointer())
+		case PollFDs:
+			output[i] = pollFDs(t, args[i].Pointer(), args[i+1].Uint64())
+		case FDSet:
+			output[i] = fdSet(t, args[i].Pointer(), args[0].Int())
+		case EpollEvents:
+			output[i] = epollEvents(t, args[i].Pointer(), rval.Int64())
+		case Dirents:
+			output[i] = dirents(t, args[i].Pointer(), rval.Int64())
+		case CloneArgs:
+			output[i] = cloneArgs(t, args[i].Pointer(), args[i+1].Uint64())
+		case BPFAttr:
+			output[i] = bpfAttr(t, args[i-1].Int(), args[i].Pointer(), args[i+1].Uint64())
+		case SockOptVal:
+			output[i] = sockOptVal(t, args[i-2].Int(), args[i-1].Int(), args[i].Pointer(), args[i+1].Pointer())
 		default:
 			output[i] = ArgumentSimple(t, format, args[i], maximumBlobSize)
 		}
@@ -315,10 +383,10 @@ func ArgumentSimple(t strace.Task, format Type, arg strace.SyscallArgument, maxi
 	// 	return msghdr(t, arg.Pointer(), true /* content */, uint64(maximumBlobSize))
 	// case RecvMsgHdr:
 	// 	return msghdr(t, arg.Pointer(), false /* content */, uint64(maximumBlobSize))
-	case Path:
+	case Path, String:
 		return path(t, arg.Pointer())
 	case ExecveStringVector:
-		return stringVector(t, arg.Pointer())
+		return stringVector(t, arg.Pointer(), maximumBlobSize)
 	// case SockLen:
 	// 	return sockLenPointer(t, arg.Pointer())
 	case SockFamily:
@@ -340,7 +408,9 @@ func ArgumentSimple(t strace.Task, format Type, arg strace.SyscallArgument, maxi
 	case Utimbuf:
 		return utimbuf(t, arg.Pointer())
 	case CloneFlags:
-		return abi.CloneFlagSet.Parse(uint64(arg.Uint()))
+		return cloneFlags(arg.Uint64())
+	case NamespaceFlags:
+		return abi.CloneFlagSet.Parse(arg.Uint64())
 	case OpenFlags:
 		return abi.Open(uint64(arg.Uint()))
 	case Mode:
@@ -357,6 +427,42 @@ func ArgumentSimple(t strace.Task, format Type, arg strace.SyscallArgument, maxi
 		return abi.MmapFlagSet.Parse(uint64(arg.Int()))
 	case MADVFlags:
 		return abi.MadviseFlagSet.Parse(uint64(arg.Int()))
+	case EpollCtlOp:
+		return abi.EpollCtlOps.Parse(uint64(arg.Int()))
+	case EpollEvent:
+		return epollEvent(t, arg.Pointer())
+	case EpollCreateFlags:
+		return abi.EpollCreateFlagSet.Parse(uint64(arg.Int()))
+	case EventFDFlags:
+		return abi.EventFDFlagSet.Parse(uint64(arg.Int()))
+	case TimerFDFlags:
+		return abi.TimerFDFlagSet.Parse(uint64(arg.Int()))
+	case TimerFDSettimeFlags:
+		return abi.TimerFDSettimeFlagSet.Parse(uint64(arg.Int()))
+	case TimerFlags:
+		return abi.TimerFlagSet.Parse(uint64(arg.Int()))
+	case ClockID:
+		return abi.ClockIDs.Parse(uint64(arg.Int()))
+	case BPFCmd:
+		return abi.BPFCommands.Parse(uint64(arg.Int()))
+	case PerfEventAttr:
+		return perfEventAttr(t, arg.Pointer())
+	case PerfEventFlags:
+		return abi.PerfEventOpenFlagSet.Parse(arg.Uint64())
+	case FcntlCmd:
+		return abi.FcntlCmds.Parse(uint64(arg.Int()))
+	case PrctlOption:
+		return abi.PrctlOptions.Parse(uint64(arg.Int()))
+	case InotifyMask:
+		return abi.InotifyMaskSet.Parse(uint64(arg.Uint()))
+	case InotifyInitFlags:
+		return abi.InotifyInitFlagSet.Parse(uint64(arg.Uint()))
+	case FanotifyInitFlags:
+		return abi.FanotifyInitFlagSet.Parse(uint64(arg.Uint()))
+	case FanotifyMarkFlags:
+		return abi.FanotifyMarkFlagSet.Parse(uint64(arg.Uint()))
+	case FanotifyMask:
+		return abi.FanotifyEventSet.Parse(arg.Uint64())
 	case Signal:
 		return SignalString(unix.Signal(arg.Int()))
 	case ArchPrctl:
@@ -372,7 +478,7 @@ func ArgumentSimple(t strace.Task, format Type, arg strace.SyscallArgument, maxi
 			return "0o" + strconv.FormatUint(arg.Uint64(), 8)
 		}
 	case FD:
-		return Arg{Type: "fd", Value: arg.Int()}
+		return fdArg(t, arg.Int())
 	case Dec, PID:
 		return int64(arg.Int())
 	case Hex:
@@ -31,7 +31,7 @@ var syscalls = SyscallMap{
 	unix.SYS_STAT:                   makeSyscallInfo("stat", Hex, Path, Stat),
 	unix.SYS_FSTAT:                  makeSyscallInfo("fstat", Hex, FD, Stat),
 	unix.SYS_LSTAT:                  makeSyscallInfo("lstat", Hex, Path, Stat),
-	unix.SYS_POLL:                   makeSyscallInfo("poll", Hex, Hex, Hex, Hex),
+	unix.SYS_POLL:                   makeSyscallInfo("poll", Dec, PollFDs, Dec, Dec),
 	unix.SYS_LSEEK:                  makeSyscallInfo("lseek", Hex, FD, Hex, Hex),
 	unix.SYS_MMAP:                   makeSyscallInfo("mmap", Hex, Hex, Hex, MMapProt, MMapFlags, FD, Hex),
 	unix.SYS_MPROTECT:               makeSyscallInfo("mprotect", Hex, Hex, Hex, Hex),
@@ -43,11 +43,11 @@ var syscalls = SyscallMap{
 	unix.SYS_IOCTL:                  makeSyscallInfo("ioctl", Hex, FD, Hex, Hex),
 	unix.SYS_PREAD64:                makeSyscallInfo("pread64", Hex, FD, ReadBuffer, Hex, Hex),
 	unix.SYS_PWRITE64:               makeSyscallInfo("pwrite64", Hex, FD, WriteBuffer, Hex, Hex),
-	unix.SYS_READV:                  makeSyscallInfo("readv", Hex, FD, ReadIOVec, Hex),
-	unix.SYS_WRITEV:                 makeSyscallInfo("writev", Hex, FD, WriteIOVec, Hex),
+	unix.SYS_READV:                  makeSyscallInfo("readv", Hex, FD, ReadIOVec, Dec),
+	unix.SYS_WRITEV:                 makeSyscallInfo("writev", Hex, FD, WriteIOVec, Dec),
 	unix.SYS_ACCESS:                 makeSyscallInfo("access", Hex, Path, Oct),
 	unix.SYS_PIPE:                   makeSyscallInfo("pipe", Hex, PipeFDs),
-	unix.SYS_SELECT:                 makeSyscallInfo("select", Hex, FD, Hex, Hex, Hex, Timeval),
+	unix.SYS_SELECT:                 makeSyscallInfo("select", Dec, Dec, FDSet, FDSet, FDSet, Timeval),
 	unix.SYS_SCHED_YIELD:            makeSyscallInfo("sched_yield", Hex),
 	unix.SYS_MREMAP:                 makeSyscallInfo("mremap", Hex, Hex, Hex, Hex, Hex, Hex),
 	unix.SYS_MSYNC:                  makeSyscallInfo("msync", Hex, Hex, Hex, Hex),
@@ -69,8 +69,8 @@ var syscalls = SyscallMap{
 	unix.SYS_SOCKET:                 makeSyscallInfo("socket", FD, SockFamily, SockType, SockProtocol),
 	unix.SYS_CONNECT:                makeSyscallInfo("connect", Hex, FD, SockAddr, Hex),
 	unix.SYS_ACCEPT:                 makeSyscallInfo("accept", Hex, FD, PostSockAddr, SockLen),
-	unix.SYS_SENDTO:                 makeSyscallInfo("sendto", Hex, FD, Hex, Hex, Hex, SockAddr, Hex),
-	unix.SYS_RECVFROM:               makeSyscallInfo("recvfrom", Hex, FD, Hex, Hex, Hex, PostSockAddr, SockLen),
+	unix.SYS_SENDTO:                 makeSyscallInfo("sendto", Hex, FD, WriteBuffer, Hex, Hex, SockAddr, Hex),
+	unix.SYS_RECVFROM:               makeSyscallInfo("recvfrom", Hex, FD, ReadBuffer, Hex, Hex, PostSockAddr, SockLen),
 	unix.SYS_SENDMSG:                makeSyscallInfo("sendmsg", Hex, FD, SendMsgHdr, Hex),
 	unix.SYS_RECVMSG:                makeSyscallInfo("recvmsg", Hex, FD, RecvMsgHdr, Hex),
 	unix.SYS_SHUTDOWN:               makeSyscallInfo("shutdown", Hex, FD, Hex),
@@ -80,13 +80,13 @@ var syscalls = SyscallMap{
 	unix.SYS_GETPEERNAME:            makeSyscallInfo("getpeername", Hex, Hex, PostSockAddr, SockLen),
 	unix.SYS_SOCKETPAIR:             makeSyscallInfo("socketpair", Hex, SockFamily, SockType, SockProtocol, Hex),
 	unix.SYS_SETSOCKOPT:             makeSyscallInfo("setsockopt", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_GETSOCKOPT:             makeSyscallInfo("getsockopt", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_GETSOCKOPT:             makeSyscallInfo("getsockopt", Hex, FD, Dec, Dec, SockOptVal, Hex),
 	unix.SYS_CLONE:                  makeSyscallInfo("clone", Hex, CloneFlags, Hex, Hex, Hex, Hex),
 	unix.SYS_FORK:                   makeSyscallInfo("fork", Hex),
 	unix.SYS_VFORK:                  makeSyscallInfo("vfork", Hex),
 	unix.SYS_EXECVE:                 makeSyscallInfo("execve", Hex, Path, ExecveStringVector, ExecveStringVector),
 	unix.SYS_EXIT:                   makeSyscallInfo("exit", Hex, Hex),
-	unix.SYS_WAIT4:                  makeSyscallInfo("wait4", Hex, Hex, Hex, Hex, Rusage),
+	unix.SYS_WAIT4:                  makeSyscallInfo("wait4", Hex, PID, Hex, Hex, Rusage),
 	unix.SYS_KILL:                   makeSyscallInfo("kill", Hex, PID, Signal),
 	unix.SYS_UNAME:                  makeSyscallInfo("uname", Hex, Uname),
 	unix.SYS_SEMGET:                 makeSyscallInfo("semget", Hex, Hex, Hex, Hex),
@@ -97,7 +97,7 @@ var syscalls = SyscallMap{
 	unix.SYS_MSGSND:                 makeSyscallInfo("msgsnd", Hex, Hex, Hex, Hex, Hex),
 	unix.SYS_MSGRCV:                 makeSyscallInfo("msgrcv", Hex, Hex, Hex, Hex, Hex, Hex),
 	unix.SYS_MSGCTL:                 makeSyscallInfo("msgctl", Hex, Hex, Hex, Hex),
-	unix.SYS_FCNTL:                  makeSyscallInfo("fcntl", Hex, FD, Hex, Hex),
+	unix.SYS_FCNTL:                  makeSyscallInfo("fcntl", Hex, FD, FcntlCmd, Dec),
 	unix.SYS_FLOCK:                  makeSyscallInfo("flock", Hex, FD, Hex),
 	unix.SYS_FSYNC:                  makeSyscallInfo("fsync", Hex, FD),
 	unix.SYS_FDATASYNC:              makeSyscallInfo("fdatasync", Hex, FD),
@@ -182,7 +182,7 @@ var syscalls = SyscallMap{
 	unix.SYS_MODIFY_LDT:             makeSyscallInfo("modify_ldt", Hex, Hex, Hex, Hex),
 	unix.SYS_PIVOT_ROOT:             makeSyscallInfo("pivot_root", Hex, Hex, Hex),
 	unix.SYS__SYSCTL:                makeSyscallInfo("_sysctl", Hex, Hex),
-	unix.SYS_PRCTL:                  makeSyscallInfo("prctl", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_PRCTL:                  makeSyscallInfo("prctl", Hex, PrctlOption, Hex, Hex, Hex, Hex),
 	unix.SYS_ARCH_PRCTL:             makeSyscallInfo("arch_prctl", Hex, ArchPrctl, Hex),
 	unix.SYS_ADJTIMEX:               makeSyscallInfo("adjtimex", Hex, Hex),
 	unix.SYS_SETRLIMIT:              makeSyscallInfo("setrlimit", Hex, Hex, Hex),
@@ -238,111 +238,154 @@ var syscalls = SyscallMap{
 	unix.SYS_IO_CANCEL:         makeSyscallInfo("io_cancel", Hex, Hex, Hex, Hex),
 	unix.SYS_GET_THREAD_AREA:   makeSyscallInfo("get_thread_area", Hex, Hex),
 	unix.SYS_LOOKUP_DCOOKIE:    makeSyscallInfo("lookup_dcookie", Hex, Hex, Hex, Hex),
-	unix.SYS_EPOLL_CREATE:      makeSyscallInfo("epoll_create", FD, Hex),
+	unix.SYS_EPOLL_CREATE:      makeSyscallInfo("epoll_create", FD, Dec),
 	// 	unix.SYS_EPOLL_CTL_OLD:epoll_ctl_old (not implemented in the Linux kernel)
 	// 	unix.SYS_EPOLL_WAIT_OLD:epoll_wait_old (not implemented in the Linux kernel)
 	unix.SYS_REMAP_FILE_PAGES: makeSyscallInfo("remap_file_pages", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_GETDENTS64:       makeSyscallInfo("getdents64", Hex, Hex, Hex, Hex),
+	unix.SYS_GETDENTS64:       makeSyscallInfo("getdents64", Dec, FD, Dirents, Dec),
 	unix.SYS_SET_TID_ADDRESS:  makeSyscallInfo("set_tid_address", Hex, Hex),
 	unix.SYS_RESTART_SYSCALL:  makeSyscallInfo("restart_syscall", Hex),
 	unix.SYS_SEMTIMEDOP:       makeSyscallInfo("semtimedop", Hex, Hex, Hex, Hex, Hex),
 	unix.SYS_FADVISE64:        makeSyscallInfo("fadvise64", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_TIMER_CREATE:     makeSyscallInfo("timer_create", Hex, Hex, Hex, Hex),
-	unix.SYS_TIMER_SETTIME:    makeSyscallInfo("timer_settime", Hex, Hex, Hex, ItimerSpec, PostItimerSpec),
+	unix.SYS_TIMER_CREATE:     makeSyscallInfo("timer_create", Hex, ClockID, Hex, Hex),
+	unix.SYS_TIMER_SETTIME:    makeSyscallInfo("timer_settime", Hex, Hex, TimerFlags, ItimerSpec, PostItimerSpec),
 	unix.SYS_TIMER_GETTIME:    makeSyscallInfo("timer_gettime", Hex, Hex, PostItimerSpec),
 	unix.SYS_TIMER_GETOVERRUN: makeSyscallInfo("timer_getoverrun", Hex, Hex),
 	unix.SYS_TIMER_DELETE:     makeSyscallInfo("timer_delete", Hex, Hex),
-	unix.SYS_CLOCK_SETTIME:    makeSyscallInfo("clock_settime", Hex, Hex, Timespec),
-	unix.SYS_CLOCK_GETTIME:    makeSyscallInfo("clock_gettime", Hex, Hex, PostTimespec),
-	unix.SYS_CLOCK_GETRES:     makeSyscallInfo("clock_getres", Hex, Hex, PostTimespec),
-	unix.SYS_CLOCK_NANOSLEEP:  makeSyscallInfo("clock_nanosleep", Hex, Hex, Hex, Timespec, PostTimespec),
+	unix.SYS_CLOCK_SETTIME:    makeSyscallInfo("clock_settime", Hex, ClockID, Timespec),
+	unix.SYS_CLOCK_GETTIME:    makeSyscallInfo("clock_gettime", Hex, ClockID, PostTimespec),
+	unix.SYS_CLOCK_GETRES:     makeSyscallInfo("clock_getres", Hex, ClockID, PostTimespec),
+	unix.SYS_CLOCK_NANOSLEEP:  makeSyscallInfo("clock_nanosleep", Hex, ClockID, TimerFlags, Timespec, PostTimespec),
 	unix.SYS_EXIT_GROUP:       makeSyscallInfo("exit_group", Hex, Hex),
-	unix.SYS_EPOLL_WAIT:       makeSyscallInfo("epoll_wait", Hex, FD, Hex, Hex, Hex),
-	unix.SYS_EPOLL_CTL:        makeSyscallInfo("epoll_ctl", Hex, FD, Hex, FD, Hex),
+	unix.SYS_EPOLL_WAIT:       makeSyscallInfo("epoll_wait", Dec, FD, EpollEvents, Dec, Dec),
+	unix.SYS_EPOLL_CTL:        makeSyscallInfo("epoll_ctl", Hex, FD, EpollCtlOp, FD, EpollEvent),
 	unix.SYS_TGKILL:           makeSyscallInfo("tgkill", Hex, PID, PID, Signal),
 	unix.SYS_UTIMES:           makeSyscallInfo("utimes", Hex, Path, Timeval),
 	// 	unix.SYS_VSERVER:vserver (not implemented in the Linux kernel)
-	unix.SYS_MBIND:             makeSyscallInfo("mbind", Hex, Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_SET_MEMPOLICY:     makeSyscallInfo("set_mempolicy", Hex, Hex, Hex, Hex),
-	unix.SYS_GET_MEMPOLICY:     makeSyscallInfo("get_mempolicy", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_MQ_OPEN:           makeSyscallInfo("mq_open", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_MQ_UNLINK:         makeSyscallInfo("mq_unlink", Hex, Hex),
-	unix.SYS_MQ_TIMEDSEND:      makeSyscallInfo("mq_timedsend", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_MQ_TIMEDRECEIVE:   makeSyscallInfo("mq_timedreceive", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_MQ_NOTIFY:         makeSyscallInfo("mq_notify", Hex, Hex, Hex),
-	unix.SYS_MQ_GETSETATTR:     makeSyscallInfo("mq_getsetattr", Hex, Hex, Hex, Hex),
-	unix.SYS_KEXEC_LOAD:        makeSyscallInfo("kexec_load", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_WAITID:            makeSyscallInfo("waitid", Hex, Hex, Hex, Hex, Hex, Rusage),
-	unix.SYS_ADD_KEY:           makeSyscallInfo("add_key", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_REQUEST_KEY:       makeSyscallInfo("request_key", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_KEYCTL:            makeSyscallInfo("keyctl", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_IOPRIO_SET:        makeSyscallInfo("ioprio_set", Hex, Hex, Hex, Hex),
-	unix.SYS_IOPRIO_GET:        makeSyscallInfo("ioprio_get", Hex, Hex, Hex),
-	unix.SYS_INOTIFY_INIT:      makeSyscallInfo("inotify_init", Hex),
-	unix.SYS_INOTIFY_ADD_WATCH: makeSyscallInfo("inotify_add_watch", Hex, Hex, Hex, Hex),
-	unix.SYS_INOTIFY_RM_WATCH:  makeSyscallInfo("inotify_rm_watch", Hex, Hex, Hex),
-	unix.SYS_MIGRATE_PAGES:     makeSyscallInfo("migrate_pages", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_OPENAT:            makeSyscallInfo("openat", FD, FD, Path, OpenFlags, Mode),
-	unix.SYS_MKDIRAT:           makeSyscallInfo("mkdirat", Hex, FD, Path, Hex),
-	unix.SYS_MKNODAT:           makeSyscallInfo("mknodat", Hex, FD, Path, Mode, Hex),
-	unix.SYS_FCHOWNAT:          makeSyscallInfo("fchownat", Hex, FD, Path, Hex, Hex, Hex),
-	unix.SYS_FUTIMESAT:         makeSyscallInfo("futimesat", Hex, FD, Path, Hex),
-	unix.SYS_NEWFSTATAT:        makeSyscallInfo("newfstatat", Hex, Hex, Path, Stat, Hex),
-	unix.SYS_UNLINKAT:          makeSyscallInfo("unlinkat", Hex, FD, Path, Hex),
-	unix.SYS_RENAMEAT:          makeSyscallInfo("renameat", Hex, FD, Path, Hex, Path),
-	unix.SYS_LINKAT:            makeSyscallInfo("linkat", Hex, Hex, Path, Hex, Path, Hex),
-	unix.SYS_SYMLINKAT:         makeSyscallInfo("symlinkat", Hex, Path, Hex, Path),
-	unix.SYS_READLINKAT:        makeSyscallInfo("readlinkat", Hex, Hex, Path, ReadBuffer, Hex),
-	unix.SYS_FCHMODAT:          makeSyscallInfo("fchmodat", Hex, Hex, Path, Mode),
-	unix.SYS_FACCESSAT:         makeSyscallInfo("faccessat", Hex, Hex, Path, Oct, Hex),
-	unix.SYS_PSELECT6:          makeSyscallInfo("pselect6", Hex, FD, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_PPOLL:             makeSyscallInfo("ppoll", Hex, Hex, Hex, Timespec, Hex, Hex),
-	unix.SYS_UNSHARE:           makeSyscallInfo("unshare", Hex, Hex),
-	unix.SYS_SET_ROBUST_LIST:   makeSyscallInfo("set_robust_list", Hex, Hex, Hex),
-	unix.SYS_GET_ROBUST_LIST:   makeSyscallInfo("get_robust_list", Hex, Hex, Hex, Hex),
-	unix.SYS_SPLICE:            makeSyscallInfo("splice", Hex, FD, Hex, FD, Hex, Hex, Hex),
-	unix.SYS_TEE:               makeSyscallInfo("tee", Hex, FD, FD, Hex, Hex),
-	unix.SYS_SYNC_FILE_RANGE:   makeSyscallInfo("sync_file_range", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_VMSPLICE:          makeSyscallInfo("vmsplice", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_MOVE_PAGES:        makeSyscallInfo("move_pages", Hex, Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_UTIMENSAT:         makeSyscallInfo("utimensat", Hex, Hex, Path, UTimeTimespec, Hex),
-	unix.SYS_EPOLL_PWAIT:       makeSyscallInfo("epoll_pwait", Hex, FD, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_EPOLL_PWAIT2:      makeSyscallInfo("epoll_pwait2", Hex, FD, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_SIGNALFD:          makeSyscallInfo("signalfd", Hex, Hex, Hex, Hex),
-	unix.SYS_TIMERFD_CREATE:    makeSyscallInfo("timerfd_create", Hex, Hex, Hex),
-	unix.SYS_EVENTFD:           makeSyscallInfo("eventfd", Hex, Hex),
-	unix.SYS_FALLOCATE:         makeSyscallInfo("fallocate", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_TIMERFD_SETTIME:   makeSyscallInfo("timerfd_settime", Hex, Hex, Hex, ItimerSpec, PostItimerSpec),
-	unix.SYS_TIMERFD_GETTIME:   makeSyscallInfo("timerfd_gettime", Hex, Hex, PostItimerSpec),
-	unix.SYS_ACCEPT4:           makeSyscallInfo("accept4", Hex, Hex, PostSockAddr, SockLen, SockFlags),
-	unix.SYS_SIGNALFD4:         makeSyscallInfo("signalfd4", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_EVENTFD2:          makeSyscallInfo("eventfd2", Hex, Hex, Hex),
-	unix.SYS_EPOLL_CREATE1:     makeSyscallInfo("epoll_create1", FD, Hex),
-	unix.SYS_PIPE2:             makeSyscallInfo("pipe2", Hex, PipeFDs, Hex),
-	unix.SYS_INOTIFY_INIT1:     makeSyscallInfo("inotify_init1", Hex, Hex),
-	unix.SYS_PREADV:            makeSyscallInfo("preadv", Hex, Hex, ReadIOVec, Hex, Hex),
-	unix.SYS_PWRITEV:           makeSyscallInfo("pwritev", Hex, Hex, WriteIOVec, Hex, Hex),
-	unix.SYS_RT_TGSIGQUEUEINFO: makeSyscallInfo("rt_tgsigqueueinfo", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_PERF_EVENT_OPEN:   makeSyscallInfo("perf_event_open", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_RECVMMSG:          makeSyscallInfo("recvmmsg", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_FANOTIFY_INIT:     makeSyscallInfo("fanotify_init", Hex, Hex, Hex),
-	unix.SYS_FANOTIFY_MARK:     makeSyscallInfo("fanotify_mark", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_PRLIMIT64:         makeSyscallInfo("prlimit64", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_NAME_TO_HANDLE_AT: makeSyscallInfo("name_to_handle_at", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_OPEN_BY_HANDLE_AT: makeSyscallInfo("open_by_handle_at", Hex, Hex, Hex, Hex),
-	unix.SYS_CLOCK_ADJTIME:     makeSyscallInfo("clock_adjtime", Hex, Hex, Hex),
-	unix.SYS_SYNCFS:            makeSyscallInfo("syncfs", Hex, Hex),
-	unix.SYS_SENDMMSG:          makeSyscallInfo("sendmmsg", Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_SETNS:             makeSyscallInfo("setns", Hex, Hex, Hex),
-	unix.SYS_GETCPU:            makeSyscallInfo("getcpu", Hex, Hex, Hex, Hex),
-	unix.SYS_PROCESS_VM_READV:  makeSyscallInfo("process_vm_readv", Hex, Hex, ReadIOVec, Hex, IOVec, Hex, Hex),
-	unix.SYS_PROCESS_VM_WRITEV: makeSyscallInfo("process_vm_writev", Hex, Hex, IOVec, Hex, WriteIOVec, Hex, Hex),
-	unix.SYS_KCMP:              makeSyscallInfo("kcmp", Hex, Hex, Hex, Hex, Hex, Hex),
-	unix.SYS_FINIT_MODULE:      makeSyscallInfo("finit_module", Hex, Hex, Hex, Hex),
-	unix.SYS_SCHED_SETATTR:     makeSyscallInfo("sched_setattr", Hex, Hex, Hex, Hex),
-	unix.SYS_SCHED_GETATTR:     makeSyscallInfo("sched_getattr", Hex, Hex, Hex, Hex),
-	unix.SYS_RENAMEAT2:         makeSyscallInfo("renameat2", Hex, Hex, Path, Hex, Path, Hex),
-	unix.SYS_SECCOMP:           makeSyscallInfo("seccomp", Hex, Hex, Hex, Hex),
+	unix.SYS_MBIND:                   makeSyscallInfo("mbind", Hex, Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_SET_MEMPOLICY:           makeSyscallInfo("set_mempolicy", Hex, Hex, Hex, Hex),
+	unix.SYS_GET_MEMPOLICY:           makeSyscallInfo("get_mempolicy", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_MQ_OPEN:                 makeSyscallInfo("mq_open", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_MQ_UNLINK:               makeSyscallInfo("mq_unlink", Hex, Hex),
+	unix.SYS_MQ_TIMEDSEND:            makeSyscallInfo("mq_timedsend", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_MQ_TIMEDRECEIVE:         makeSyscallInfo("mq_timedreceive", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_MQ_NOTIFY:               makeSyscallInfo("mq_notify", Hex, Hex, Hex),
+	unix.SYS_MQ_GETSETATTR:           makeSyscallInfo("mq_getsetattr", Hex, Hex, Hex, Hex),
+	unix.SYS_KEXEC_LOAD:              makeSyscallInfo("kexec_load", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_WAITID:                  makeSyscallInfo("waitid", Hex, Hex, Hex, Hex, Hex, Rusage),
+	unix.SYS_ADD_KEY:                 makeSyscallInfo("add_key", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_REQUEST_KEY:             makeSyscallInfo("request_key", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_KEYCTL:                  makeSyscallInfo("keyctl", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_IOPRIO_SET:              makeSyscallInfo("ioprio_set", Hex, Hex, Hex, Hex),
+	unix.SYS_IOPRIO_GET:              makeSyscallInfo("ioprio_get", Hex, Hex, Hex),
+	unix.SYS_INOTIFY_INIT:            makeSyscallInfo("inotify_init", Hex),
+	unix.SYS_INOTIFY_ADD_WATCH:       makeSyscallInfo("inotify_add_watch", Hex, FD, Path, InotifyMask),
+	unix.SYS_INOTIFY_RM_WATCH:        makeSyscallInfo("inotify_rm_watch", Hex, Hex, Hex),
+	unix.SYS_MIGRATE_PAGES:           makeSyscallInfo("migrate_pages", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_OPENAT:                  makeSyscallInfo("openat", FD, FD, Path, OpenFlags, Mode),
+	unix.SYS_MKDIRAT:                 makeSyscallInfo("mkdirat", Hex, FD, Path, Hex),
+	unix.SYS_MKNODAT:                 makeSyscallInfo("mknodat", Hex, FD, Path, Mode, Hex),
+	unix.SYS_FCHOWNAT:                makeSyscallInfo("fchownat", Hex, FD, Path, Hex, Hex, Hex),
+	unix.SYS_FUTIMESAT:               makeSyscallInfo("futimesat", Hex, FD, Path, Hex),
+	unix.SYS_NEWFSTATAT:              makeSyscallInfo("newfstatat", Hex, FD, Path, Stat, Hex),
+	unix.SYS_UNLINKAT:                makeSyscallInfo("unlinkat", Hex, FD, Path, Hex),
+	unix.SYS_RENAMEAT:                makeSyscallInfo("renameat", Hex, FD, Path, FD, Path),
+	unix.SYS_LINKAT:                  makeSyscallInfo("linkat", Hex, FD, Path, FD, Path, Hex),
+	unix.SYS_SYMLINKAT:               makeSyscallInfo("symlinkat", Hex, Path, FD, Path),
+	unix.SYS_READLINKAT:              makeSyscallInfo("readlinkat", Hex, FD, Path, ReadBuffer, Hex),
+	unix.SYS_FCHMODAT:                makeSyscallInfo("fchmodat", Hex, FD, Path, Mode),
+	unix.SYS_FACCESSAT:               makeSyscallInfo("faccessat", Hex, FD, Path, Oct, Hex),
+	unix.SYS_PSELECT6:                makeSyscallInfo("pselect6", Dec, Dec, FDSet, FDSet, FDSet, Timespec, Hex),
+	unix.SYS_PPOLL:                   makeSyscallInfo("ppoll", Dec, PollFDs, Dec, Timespec, Hex, Hex),
+	unix.SYS_UNSHARE:                 makeSyscallInfo("unshare", Dec, NamespaceFlags),
+	unix.SYS_SET_ROBUST_LIST:         makeSyscallInfo("set_robust_list", Hex, Hex, Hex),
+	unix.SYS_GET_ROBUST_LIST:         makeSyscallInfo("get_robust_list", Hex, Hex, Hex, Hex),
+	unix.SYS_SPLICE:                  makeSyscallInfo("splice", Hex, FD, Hex, FD, Hex, Hex, Hex),
+	unix.SYS_TEE:                     makeSyscallInfo("tee", Hex, FD, FD, Hex, Hex),
+	unix.SYS_SYNC_FILE_RANGE:         makeSyscallInfo("sync_file_range", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_VMSPLICE:                makeSyscallInfo("vmsplice", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_MOVE_PAGES:              makeSyscallInfo("move_pages", Hex, Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_UTIMENSAT:               makeSyscallInfo("utimensat", Hex, FD, Path, UTimeTimespec, Hex),
+	unix.SYS_EPOLL_PWAIT:             makeSyscallInfo("epoll_pwait", Dec, FD, EpollEvents, Dec, Dec, Hex, Hex),
+	unix.SYS_EPOLL_PWAIT2:            makeSyscallInfo("epoll_pwait2", Dec, FD, EpollEvents, Dec, Timespec, Hex, Hex),
+	unix.SYS_SIGNALFD:                makeSyscallInfo("signalfd", Hex, Hex, Hex, Hex),
+	unix.SYS_TIMERFD_CREATE:          makeSyscallInfo("timerfd_create", FD, ClockID, TimerFDFlags),
+	unix.SYS_EVENTFD:                 makeSyscallInfo("eventfd", FD, Dec),
+	unix.SYS_FALLOCATE:               makeSyscallInfo("fallocate", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_TIMERFD_SETTIME:         makeSyscallInfo("timerfd_settime", Hex, FD, TimerFDSettimeFlags, ItimerSpec, PostItimerSpec),
+	unix.SYS_TIMERFD_GETTIME:         makeSyscallInfo("timerfd_gettime", Hex, FD, PostItimerSpec),
+	unix.SYS_ACCEPT4:                 makeSyscallInfo("accept4", Hex, Hex, PostSockAddr, SockLen, SockFlags),
+	unix.SYS_SIGNALFD4:               makeSyscallInfo("signalfd4", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_EVENTFD2:                makeSyscallInfo("eventfd2", FD, Dec, EventFDFlags),
+	unix.SYS_EPOLL_CREATE1:           makeSyscallInfo("epoll_create1", FD, EpollCreateFlags),
+	unix.SYS_PIPE2:                   makeSyscallInfo("pipe2", Hex, PipeFDs, Hex),
+	unix.SYS_INOTIFY_INIT1:           makeSyscallInfo("inotify_init1", Hex, InotifyInitFlags),
+	unix.SYS_PREADV:                  makeSyscallInfo("preadv", Hex, FD, ReadIOVec, Dec, Hex),
+	unix.SYS_PWRITEV:                 makeSyscallInfo("pwritev", Hex, FD, WriteIOVec, Dec, Hex),
+	unix.SYS_RT_TGSIGQUEUEINFO:       makeSyscallInfo("rt_tgsigqueueinfo", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_PERF_EVENT_OPEN:         makeSyscallInfo("perf_event_open", FD, PerfEventAttr, PID, Dec, FD, PerfEventFlags),
+	unix.SYS_RECVMMSG:                makeSyscallInfo("recvmmsg", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_FANOTIFY_INIT:           makeSyscallInfo("fanotify_init", Hex, FanotifyInitFlags, OpenFlags),
+	unix.SYS_FANOTIFY_MARK:           makeSyscallInfo("fanotify_mark", Hex, FD, FanotifyMarkFlags, FanotifyMask, FD, Path),
+	unix.SYS_PRLIMIT64:               makeSyscallInfo("prlimit64", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_NAME_TO_HANDLE_AT:       makeSyscallInfo("name_to_handle_at", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_OPEN_BY_HANDLE_AT:       makeSyscallInfo("open_by_handle_at", Hex, Hex, Hex, Hex),
+	unix.SYS_CLOCK_ADJTIME:           makeSyscallInfo("clock_adjtime", Hex, Hex, Hex),
+	unix.SYS_SYNCFS:                  makeSyscallInfo("syncfs", Hex, Hex),
+	unix.SYS_SENDMMSG:                makeSyscallInfo("sendmmsg", Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_SETNS:                   makeSyscallInfo("setns", Dec, FD, NamespaceFlags),
+	unix.SYS_GETCPU:                  makeSyscallInfo("getcpu", Hex, Hex, Hex, Hex),
+	unix.SYS_PROCESS_VM_READV:        makeSyscallInfo("process_vm_readv", Hex, PID, ReadIOVec, Dec, IOVec, Dec, Hex),
+	unix.SYS_PROCESS_VM_WRITEV:       makeSyscallInfo("process_vm_writev", Hex, PID, IOVec, Dec, WriteIOVec, Dec, Hex),
+	unix.SYS_KCMP:                    makeSyscallInfo("kcmp", Hex, Hex, Hex, Hex, Hex, Hex),
+	unix.SYS_FINIT_MODULE:            makeSyscallInfo("finit_module", Hex, Hex, Hex, Hex),
+	unix.SYS_SCHED_SETATTR:           makeSyscallInfo("sched_setattr", Hex, Hex, Hex, Hex),
+	unix.SYS_SCHED_GETATTR:           makeSyscallInfo("sched_getattr", Hex, Hex, Hex, Hex),
+	unix.SYS_RENAMEAT2:               makeSyscallInfo("renameat2", Hex, Hex, Path, Hex, Path, Hex),
+	unix.SYS_SECCOMP:                 makeSyscallInfo("seccomp", Hex, Hex, Hex, Hex),
+	unix.SYS_GETRANDOM:               makeSyscallInfo("getrandom", Dec, Hex, Dec, Hex),
+	unix.SYS_MEMFD_CREATE:            makeSyscallInfo("memfd_create", FD, String, Hex),
+	unix.SYS_KEXEC_FILE_LOAD:         makeSyscallInfo("kexec_file_load", Hex, FD, FD, Dec, Path, Hex),
+	unix.SYS_BPF:                     makeSyscallInfo("bpf", Dec, BPFCmd, BPFAttr, Dec),
+	unix.SYS_EXECVEAT:                makeSyscallInfo("execveat", Hex, FD, Path, ExecveStringVector, ExecveStringVector, Hex),
+	unix.SYS_USERFAULTFD:             makeSyscallInfo("userfaultfd", FD, Hex),
+	unix.SYS_MEMBARRIER:              makeSyscallInfo("membarrier", Hex, Hex, Hex, Hex),
+	unix.SYS_MLOCK2:                  makeSyscallInfo("mlock2", Hex, Hex, Hex, Hex),
+	unix.SYS_COPY_FILE_RANGE:         makeSyscallInfo("copy_file_range", Dec, FD, Hex, FD, Hex, Dec, Hex),
+	unix.SYS_PREADV2:                 makeSyscallInfo("preadv2", Dec, FD, ReadIOVec, Dec, Dec, Dec, Hex),
+	unix.SYS_PWRITEV2:                makeSyscallInfo("pwritev2", Dec, FD, WriteIOVec, Dec, Dec, Dec, Hex),
+	unix.SYS_PKEY_MPROTECT:           makeSyscallInfo("pkey_mprotect", Hex, Hex, Hex, MMapProt, Dec),
+	unix.SYS_PKEY_ALLOC:              makeSyscallInfo("pkey_alloc", Dec, Hex, Hex),
+	unix.SYS_PKEY_FREE:               makeSyscallInfo("pkey_free", Hex, Dec),
+	unix.SYS_STATX:                   makeSyscallInfo("statx", Hex, FD, Path, Hex, Hex, Hex),
+	unix.SYS_IO_PGETEVENTS:           makeSyscallInfo("io_pgetevents", Dec, Hex, Dec, Dec, Hex, Timespec, Hex),
+	unix.SYS_RSEQ:                    makeSyscallInfo("rseq", Hex, Hex, Dec, Hex, Hex),
+	unix.SYS_PIDFD_SEND_SIGNAL:       makeSyscallInfo("pidfd_send_signal", Hex, FD, Signal, Hex, Hex),
+	unix.SYS_IO_URING_SETUP:          makeSyscallInfo("io_uring_setup", FD, Dec, Hex),
+	unix.SYS_IO_URING_ENTER:          makeSyscallInfo("io_uring_enter", Dec, FD, Dec, Dec, Hex, Hex, Dec),
+	unix.SYS_IO_URING_REGISTER:       makeSyscallInfo("io_uring_register", Dec, FD, Dec, Hex, Dec),
+	unix.SYS_OPEN_TREE:               makeSyscallInfo("open_tree", FD, FD, Path, Hex),
+	unix.SYS_MOVE_MOUNT:              makeSyscallInfo("move_mount", Hex, FD, Path, FD, Path, Hex),
+	unix.SYS_FSOPEN:                  makeSyscallInfo("fsopen", FD, String, Hex),
+	unix.SYS_FSCONFIG:                makeSyscallInfo("fsconfig", Hex, FD, Dec, String, Hex, Dec),
+	unix.SYS_FSMOUNT:                 makeSyscallInfo("fsmount", FD, FD, Hex, Hex),
+	unix.SYS_FSPICK:                  makeSyscallInfo("fspick", FD, FD, Path, Hex),
+	unix.SYS_PIDFD_OPEN:              makeSyscallInfo("pidfd_open", FD, PID, Hex),
+	unix.SYS_CLONE3:                  makeSyscallInfo("clone3", Dec, CloneArgs, Dec),
+	unix.SYS_CLOSE_RANGE:             makeSyscallInfo("close_range", Hex, FD, FD, Hex),
+	unix.SYS_OPENAT2:                 makeSyscallInfo("openat2", FD, FD, Path, Hex, Dec),
+	unix.SYS_PIDFD_GETFD:             makeSyscallInfo("pidfd_getfd", FD, FD, Dec, Hex),
+	unix.SYS_FACCESSAT2:              makeSyscallInfo("faccessat2", Hex, FD, Path, Oct, Hex),
+	unix.SYS_PROCESS_MADVISE:         makeSyscallInfo("process_madvise", Dec, FD, Hex, Dec, MADVFlags, Hex),
+	unix.SYS_MOUNT_SETATTR:           makeSyscallInfo("mount_setattr", Hex, FD, Path, Hex, Hex, Dec),
+	unix.SYS_QUOTACTL_FD:             makeSyscallInfo("quotactl_fd", Hex, FD, Hex, Hex, Hex),
+	unix.SYS_LANDLOCK_CREATE_RULESET: makeSyscallInfo("landlock_create_ruleset", FD, Hex, Dec, Hex),
+	unix.SYS_LANDLOCK_ADD_RULE:       makeSyscallInfo("landlock_add_rule", Hex, FD, Hex, Hex, Hex),
+	unix.SYS_LANDLOCK_RESTRICT_SELF:  makeSyscallInfo("landlock_restrict_self", Hex, FD, Hex),
+	unix.SYS_MEMFD_SECRET:            makeSyscallInfo("memfd_secret", FD, Hex),
+	unix.SYS_PROCESS_MRELEASE:        makeSyscallInfo("process_mrelease", Hex, FD, Hex),
+	unix.SYS_FUTEX_WAITV:             makeSyscallInfo("futex_waitv", Dec, Hex, Dec, Hex, Timespec, ClockID),
+	unix.SYS_SET_MEMPOLICY_HOME_NODE: makeSyscallInfo("set_mempolicy_home_node", Hex, Hex, Hex, Dec, Hex),
 }
 
 // Signal table
@@ -161,9 +161,17 @@ const (
 	// Rusage is a struct rusage, formatted after syscall execution.
 	Rusage
 
-	// CloneFlags are clone(2) flags.
+	// CloneFlags are clone(2) flags, the lowest byte of which is the exit
+	// signal.
 	CloneFlags
 
+	// NamespaceFlags are CLONE_* flags of unshare(2) and setns(2).
+	NamespaceFlags
+
+	// CloneArgs is a pointer to struct clone_args of clone3(2), followed by
+	// its size.
+	CloneArgs
+
 	// OpenFlags are open(2) flags.
 	OpenFlags
 
@@ -199,8 +207,114 @@ const (
 
 	// StackT is a signal stack descriptor.
 	StackT
+
+	// PollFDs is an array of struct pollfd. The following arg is used for
+	// the length.
+	//
+	// Formatted after syscall execution, so revents are filled in.
+	PollFDs
+
+	// FDSet is a pointer to an fd_set. The first arg is used for the number
+	// of descriptors.
+	//
+	// Formatted after syscall execution, so only ready descriptors are
+	// left in the set.
+	FDSet
+
+	// EpollCtlOp is an epoll_ctl(2) operation.
+	EpollCtlOp
+
+	// EpollEvent is a pointer to a struct epoll_event.
+	EpollEvent
+
+	// EpollEvents is an array of struct epoll_event. The return value is
+	// used for the length.
+	//
+	// Formatted after syscall execution.
+	EpollEvents
+
+	// EpollCreateFlags are epoll_create1(2) flags.
+	EpollCreateFlags
+
+	// EventFDFlags are eventfd2(2) flags.
+	EventFDFlags
+
+	// TimerFDFlags are timerfd_create(2) flags.
+	TimerFDFlags
+
+	// TimerFDSettimeFlags are timerfd_settime(2) flags.
+	TimerFDSettimeFlags
+
+	// TimerFlags are timer_settime(2) and clock_nanosleep(2) flags.
+	TimerFlags
+
+	// ClockID is a clock id (CLOCK_REALTIME, etc).
+	ClockID
+
+	// BPFCmd is a bpf(2) command.
+	BPFCmd
+
+	// BPFAttr is a pointer to a union bpf_attr. The member is selected by
+	// the first arg, the following arg is the size of the union.
+	BPFAttr
+
+	// PerfEventAttr is a pointer to a struct perf_event_attr.
+	PerfEventAttr
+
+	// PerfEventFlags are perf_event_open(2) flags.
+	PerfEventFlags
+
+	// FcntlCmd is an fcntl(2) command.
+	FcntlCmd
+
+	// PrctlOption is a prctl(2) option.
+	PrctlOption
+
+	// InotifyMask is an inotify_add_watch(2) mask.
+	InotifyMask
+
+	// InotifyInitFlags are inotify_init1(2) flags.
+	InotifyInitFlags
+
+	// FanotifyInitFlags are fanotify_init(2) flags.
+	FanotifyInitFlags
+
+	// FanotifyMarkFlags are fanotify_mark(2) flags.
+	FanotifyMarkFlags
+
+	// FanotifyMask is a fanotify_mark(2) mask of events.
+	FanotifyMask
+
+	// Dirents is a buffer of struct linux_dirent64 entries. The return
+	// value is used for the size.
+	//
+	// Formatted after syscall execution.
+	Dirents
+
+	// SockOptVal is the value of getsockopt(2). The previous args are the
+	// level and the option name, the following one is a pointer to the
+	// length. TCP_INFO is decoded into a struct tcp_info.
+	//
+	// Formatted after syscall execution.
+	SockOptVal
+
+	// String is a pointer to a NUL-terminated string which is not a path,
+	// like the name of a memfd or a filesystem type.
+	String
+
+	// numTypes is the number of types, new types go above.
+	numTypes
 )
 
+// Types returns all argument types.
+func Types() []Type {
+	ts := make([]Type, numTypes)
+	for i := range ts {
+		ts[i] = Type(i)
+	}
+	return ts
+}
+
 // defaultFormat is the syscall argument Format to use if the actual Format is
 // not known. It formats all six arguments as hex.
 var defaultFormat = []Type{Hex, Hex, Hex, Hex, Hex, Hex}
@@ -215,6 +329,21 @@ func Details(s *strace.SyscallEvent) SyscallInfo {
 	}
 }
 
+// syscallsByName indexes syscalls by name.
+var syscallsByName = func() map[string]SyscallInfo {
+	m := make(map[string]SyscallInfo, len(syscalls))
+	for _, si := range syscalls {
+		m[si.Name] = si
+	}
+	return m
+}()
+
+// ByName returns the signature of a syscall by its name.
+func ByName(name string) (SyscallInfo, bool) {
+	si, ok := syscallsByName[name]
+	return si, ok
+}
+
 // SyscallInfo specifies syscall signature.
 type SyscallInfo struct {
 	// Name of a syscall.
@@ -227,6 +356,18 @@ type SyscallInfo struct {
 	ReturnType Type
 }
 
+// DecodeOnEnter reports whether arguments must be decoded on syscall enter,
+// because the tracee memory they point to is gone on exit, like argv of a
+// successful execve.
+func (si SyscallInfo) DecodeOnEnter() bool {
+	for _, t := range si.ArgTypes {
+		if t == ExecveStringVector {
+			return true
+		}
+	}
+	return false
+}
+
 // makeSyscallInfo returns a SyscallInfo for a syscall.
 func makeSyscallInfo(name string, ret Type, args ...Type) SyscallInfo {
 	return SyscallInfo{
@@ -0,0 +1,65 @@
+package syscalls
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"golang.org/x/sys/unix"
+)
+
+// ProcessTask is a task whose thread id is known. Decoders use it to look
+// into /proc, e.g. to find out the protocol of a socket.
+type ProcessTask interface {
+	strace.Task
+	PID() int
+}
+
+// TraceState is decoder state shared by the tasks of one trace, it lives
+// as long as the trace.
+type TraceState struct {
+	// netlinkSockets caches protocols of netlink sockets by inode, -1 is
+	// stored for other sockets.
+	netlinkSockets sync.Map
+}
+
+type pidTask struct {
+	strace.Task
+	pid   int
+	state *TraceState
+}
+
+func (t pidTask) PID() int { return t.pid }
+
+// WithPID attaches the thread id and the state of its trace to a task. A nil
+// state disables caching.
+func WithPID(t strace.Task, pid int, state *TraceState) ProcessTask {
+	return pidTask{Task: t, pid: pid, state: state}
+}
+
+// traceState returns the state of the trace of t, nil if unknown.
+func traceState(t strace.Task) *TraceState {
+	if pt, ok := t.(pidTask); ok {
+		return pt.state
+	}
+	return nil
+}
+
+// fdPath returns what a file descriptor of the task refers to, the way
+// strace -y prints it: a path, "socket:[inode]", "pipe:[inode]" or
+// "anon_inode:[eventfd]". AT_FDCWD refers to the working directory.
+func fdPath(t strace.Task, fd int32) (string, bool) {
+	pt, ok := t.(ProcessTask)
+	if !ok {
+		return "", false
+	}
+	link := fmt.Sprintf("/proc/%d/fd/%d", pt.PID(), fd)
+	if fd == unix.AT_FDCWD {
+		link = fmt.Sprintf("/proc/%d/cwd", pt.PID())
+	} else if fd < 0 {
+		return "", false
+	}
+	p, err := os.Readlink(link)
+	return p, err == nil
+}
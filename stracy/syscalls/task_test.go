@@ -0,0 +1,43 @@
+package syscalls
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"golang.org/x/sys/unix"
+)
+
+// memTask is a task with a fake address space, values are laid out by put.
+type memTask struct {
+	mem []byte
+}
+
+const memBase = 0x1000
+
+func (t *memTask) Name() string { return "test" }
+
+func (t *memTask) Read(addr strace.Addr, v any) (int, error) {
+	off := int(addr) - memBase
+	if off < 0 || off >= len(t.mem) {
+		return 0, unix.EFAULT
+	}
+	if err := binary.Read(bytes.NewReader(t.mem[off:]), binary.LittleEndian, v); err != nil {
+		return 0, err
+	}
+	return binary.Size(v), nil
+}
+
+// put stores a value in the address space and returns its address.
+func (t *memTask) put(v any) strace.Addr {
+	for len(t.mem)%8 != 0 {
+		t.mem = append(t.mem, 0)
+	}
+	addr := strace.Addr(memBase + len(t.mem))
+	var b bytes.Buffer
+	if err := binary.Write(&b, binary.LittleEndian, v); err != nil {
+		panic(err)
+	}
+	t.mem = append(t.mem, b.Bytes()...)
+	return addr
+}
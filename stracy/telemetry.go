@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iimos/play/stracy/otlp"
+	"github.com/iimos/play/stracy/tracer"
+)
+
+// otlpFlags registers OpenTelemetry export flags on fs. The returned function
+// creates an exporter from them after fs is parsed, nil if export is off.
+// Process attributes are redacted with redact.
+func otlpFlags(fs *flag.FlagSet) func(redact *tracer.Redactor) (*otlp.Exporter, error) {
+	endpoint := fs.String("otlp", "", "export syscalls as spans to an OTLP/HTTP collector `url`, e.g. "+otlp.DefaultEndpoint)
+	threshold := fs.Duration("otlp-threshold", time.Millisecond, "export only syscalls lasting at least `duration`")
+	service := fs.String("otlp-service", "", "service.name of exported spans (default executable name)")
+	var headers listFlag
+	fs.Var(&headers, "otlp-header", "add a `name=value` header to export requests (repeatable)")
+
+	return func(redact *tracer.Redactor) (*otlp.Exporter, error) {
+		if *endpoint == "" {
+			return nil, nil
+		}
+		cfg := otlp.Config{
+			Endpoint:    *endpoint,
+			Threshold:   *threshold,
+			ServiceName: *service,
+			Headers:     make(map[string]string),
+			Redact: func(s string) string {
+				s, _ = redact.String(s)
+				return s
+			},
+		}
+		for _, h := range headers {
+			k, v, ok := strings.Cut(h, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid -otlp-header %q, want name=value", h)
+			}
+			cfg.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		return otlp.New(cfg)
+	}
+}
@@ -0,0 +1,266 @@
+package tracer
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/syscalls"
+)
+
+// Alert describes a syscall that took much longer than usual.
+type Alert struct {
+	// FDType is the kind of the file descriptor the syscall worked on, e.g.
+	// "file", "socket", "pipe" or "eventfd", empty if there is none.
+	FDType string `json:",omitempty"`
+
+	// Duration is the syscall duration in nanoseconds.
+	Duration int
+
+	// Baseline is the typical duration of the syscall on this kind of
+	// descriptor in nanoseconds, zero if it's not learned yet.
+	Baseline int `json:",omitempty"`
+
+	// Ratio is Duration / Baseline.
+	Ratio float64 `json:",omitempty"`
+
+	Reason string
+}
+
+// DetectorConfig configures a LatencyDetector. Zero fields take defaults.
+type DetectorConfig struct {
+	// Factor is how many times slower than the baseline a syscall has to be
+	// to be reported. Default 10.
+	Factor float64
+
+	// MinSamples is the number of syscalls seen before the baseline is
+	// trusted. Default 20.
+	MinSamples int
+
+	// MinDuration is the duration below which syscalls are never reported,
+	// however slow they are relative to the baseline. Default 1ms.
+	MinDuration time.Duration
+
+	// Limit is the duration above which syscalls that don't wait by design
+	// are reported even without a baseline, e.g. a connect that took 3s.
+	// Default 1s, negative disables it.
+	Limit time.Duration
+}
+
+// DefaultDetectorConfig is the configuration used for zero fields.
+var DefaultDetectorConfig = DetectorConfig{
+	Factor:      10,
+	MinSamples:  20,
+	MinDuration: time.Millisecond,
+	Limit:       time.Second,
+}
+
+// baselineWeight is the weight of a new sample in the moving average once
+// the baseline is learned. It makes the baseline follow the last hundred or
+// so calls.
+const baselineWeight = 0.02
+
+// baselineZScore is how many standard deviations a sample has to be away from
+// the mean to be an outlier. It keeps calls with naturally spread latency,
+// e.g. reads of files of different sizes, quiet.
+const baselineZScore = 3
+
+// baseline is a moving average and variance of log durations. Latencies are
+// log-normal rather than normal, and logs make "10x slower" a distance.
+type baseline struct {
+	n        int
+	mean     float64
+	variance float64
+}
+
+func (b *baseline) add(x float64) {
+	b.n++
+	w := baselineWeight
+	if 1/float64(b.n) > w {
+		w = 1 / float64(b.n) // plain average until there are enough samples
+	}
+	d := x - b.mean
+	b.mean += w * d
+	b.variance = (1 - w) * (b.variance + w*d*d)
+}
+
+// waitingSyscalls wait for something by design, their duration says nothing
+// about the system. Reads of sockets, pipes and the like are added to them by
+// fd type.
+var waitingSyscalls = map[string]bool{
+	"accept": true, "accept4": true, "clock_nanosleep": true, "epoll_pwait": true,
+	"epoll_pwait2": true, "epoll_wait": true, "futex": true, "io_getevents": true,
+	"io_pgetevents": true, "io_uring_enter": true, "mq_timedreceive": true, "msgrcv": true,
+	"nanosleep": true, "pause": true, "poll": true, "ppoll": true, "pselect6": true,
+	"rt_sigsuspend": true, "rt_sigtimedwait": true, "select": true, "semop": true,
+	"semtimedop": true, "wait4": true, "waitid": true,
+}
+
+var readSyscalls = map[string]bool{
+	"read": true, "readv": true, "preadv": true, "preadv2": true,
+	"recvfrom": true, "recvmsg": true, "recvmmsg": true,
+}
+
+// waits reports whether a syscall on an fd type waits by design.
+func waits(name, fdType string) bool {
+	if waitingSyscalls[name] {
+		return true
+	}
+	return readSyscalls[name] && fdType != "" && fdType != "file" && fdType != "proc"
+}
+
+// LatencyDetector learns per-syscall and per-fd-type latency baselines from
+// a stream of events and reports outliers. It implements Observer, emitting
+// an "alert" instant event after an anomalous syscall. It's not safe for
+// concurrent use.
+type LatencyDetector struct {
+	cfg       DetectorConfig
+	baselines map[string]*baseline
+}
+
+func NewLatencyDetector(cfg DetectorConfig) *LatencyDetector {
+	def := DefaultDetectorConfig
+	if cfg.Factor == 0 {
+		cfg.Factor = def.Factor
+	}
+	if cfg.MinSamples == 0 {
+		cfg.MinSamples = def.MinSamples
+	}
+	if cfg.MinDuration == 0 {
+		cfg.MinDuration = def.MinDuration
+	}
+	if cfg.Limit == 0 {
+		cfg.Limit = def.Limit
+	}
+	return &LatencyDetector{
+		cfg:       cfg,
+		baselines: make(map[string]*baseline),
+	}
+}
+
+// Observe implements Observer. It looks up the type of the first fd argument
+// and returns an alert event if e is an outlier.
+func (d *LatencyDetector) Observe(_ strace.Task, record *strace.TraceRecord, e *Event) []Event {
+	fdType := ""
+	if call := record.Syscall; call != nil {
+		si := syscalls.Details(call)
+		for i, t := range si.ArgTypes {
+			if t == syscalls.FD && i < len(call.Args) {
+				fdType = FDType(record.PID, call.Args[i].Int())
+				break
+			}
+		}
+	}
+	a := d.Check(e, fdType)
+	if a == nil {
+		return nil
+	}
+	return []Event{{
+		Name:      "slow_syscall",
+		Cat:       "alert",
+		Ph:        "i", // Instant event
+		PID:       e.PID,
+		TID:       e.TID,
+		Timestamp: e.Timestamp + e.Duration, // at the exit
+		Args: Args{
+			Syscall:     e.Args.Syscall,
+			SyscallArgs: append([]any(nil), e.Args.SyscallArgs...),
+			Result:      a.Reason,
+			Alert:       a,
+		},
+	}}
+}
+
+// Check adds a complete syscall event to the baseline of its syscall and fd
+// type and returns an alert if the event is an outlier. fdType may be empty,
+// e.g. for recorded traces.
+func (d *LatencyDetector) Check(e *Event, fdType string) *Alert {
+	if e.Ph != "X" || e.Args.Syscall == "" {
+		return nil
+	}
+	if waits(e.Args.Syscall, fdType) {
+		return nil
+	}
+	dur := time.Duration(e.Duration)
+
+	key := e.Args.Syscall
+	if fdType != "" {
+		key += "/" + fdType
+	}
+	b := d.baselines[key]
+	if b == nil {
+		b = &baseline{}
+		d.baselines[key] = b
+	}
+
+	x := math.Log(float64(max(dur, 1)))
+	var a *Alert
+	if b.n >= d.cfg.MinSamples && dur >= d.cfg.MinDuration {
+		typical := math.Exp(b.mean)
+		ratio := float64(dur) / typical
+		if ratio >= d.cfg.Factor && x-b.mean >= baselineZScore*math.Sqrt(b.variance) {
+			a = &Alert{
+				Baseline: int(typical),
+				Ratio:    math.Round(ratio*10) / 10,
+				Reason:   fmt.Sprintf("%.0fx slower than usual (%s)", ratio, roundDuration(time.Duration(typical))),
+			}
+		}
+	}
+	// Calls that are always slow are reported only once.
+	if a == nil && d.cfg.Limit > 0 && dur >= d.cfg.Limit && (b.n == 0 || math.Exp(b.mean) < float64(d.cfg.Limit)) {
+		a = &Alert{Reason: fmt.Sprintf("took %s", roundDuration(dur))}
+	}
+	b.add(x)
+
+	if a != nil {
+		a.FDType = fdType
+		a.Duration = e.Duration
+	}
+	return a
+}
+
+// roundDuration keeps three significant digits.
+func roundDuration(d time.Duration) time.Duration {
+	for r := time.Duration(1); r < time.Hour; r *= 10 {
+		if d < 1000*r {
+			return d.Round(r)
+		}
+	}
+	return d
+}
+
+// FDType returns the kind of an open file descriptor of a process: "file",
+// "device", "tty", "proc", "socket", "pipe", an anonymous inode type like
+// "eventfd" or "bpf-map", or empty if the descriptor isn't open.
+func FDType(pid int, fd int32) string {
+	if fd < 0 {
+		return ""
+	}
+	target, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, fd))
+	if err != nil {
+		return ""
+	}
+	return fdTypeOf(target)
+}
+
+// fdTypeOf classifies a /proc/PID/fd link target.
+func fdTypeOf(target string) string {
+	switch {
+	case strings.HasPrefix(target, "socket:"):
+		return "socket"
+	case strings.HasPrefix(target, "pipe:"):
+		return "pipe"
+	case strings.HasPrefix(target, "anon_inode:"):
+		return strings.Trim(strings.TrimPrefix(target, "anon_inode:"), "[]")
+	case strings.HasPrefix(target, "/dev/pts/"), strings.HasPrefix(target, "/dev/tty"), target == "/dev/console":
+		return "tty"
+	case strings.HasPrefix(target, "/dev/"):
+		return "device"
+	case strings.HasPrefix(target, "/proc/"), strings.HasPrefix(target, "/sys/"):
+		return "proc"
+	}
+	return "file"
+}
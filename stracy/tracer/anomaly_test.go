@@ -0,0 +1,113 @@
+package tracer_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/tracer"
+)
+
+func syscallEvent(name string, dur time.Duration) *tracer.Event {
+	return &tracer.Event{Name: name, Cat: "successful", Ph: "X", Duration: int(dur), Args: tracer.Args{Syscall: name}}
+}
+
+func TestLatencyDetector(t *testing.T) {
+	d := tracer.NewLatencyDetector(tracer.DetectorConfig{})
+
+	// learn fsync of files taking 80-120µs
+	for i := 0; i < 50; i++ {
+		dur := time.Duration(80+i%5*10) * time.Microsecond
+		if a := d.Check(syscallEvent("fsync", dur), "file"); a != nil {
+			t.Fatalf("alert while learning: %+v", a)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		fdType string
+		dur    time.Duration
+		alert  bool
+	}{
+		{"fsync", "file", 150 * time.Microsecond, false},
+		{"fsync", "file", 5 * time.Millisecond, true},
+		{"fsync", "device", 5 * time.Millisecond, false}, // no baseline yet
+		{"connect", "socket", 3 * time.Second, true},
+		{"read", "file", 2 * time.Second, true},
+		{"read", "socket", 5 * time.Second, false},
+		{"epoll_wait", "", 10 * time.Second, false},
+	}
+	for _, tt := range tests {
+		a := d.Check(syscallEvent(tt.name, tt.dur), tt.fdType)
+		if (a != nil) != tt.alert {
+			t.Errorf("%s on %s for %s: got alert %+v, want %v", tt.name, tt.fdType, tt.dur, a, tt.alert)
+			continue
+		}
+		if a != nil && (a.FDType != tt.fdType || a.Duration != int(tt.dur) || a.Reason == "") {
+			t.Errorf("%s on %s for %s: bad alert %+v", tt.name, tt.fdType, tt.dur, a)
+		}
+	}
+
+	a := d.Check(syscallEvent("fsync", 10*time.Millisecond), "file")
+	if a == nil {
+		t.Fatal("no alert for a 10ms fsync")
+	}
+	if a.Baseline < int(80*time.Microsecond) || a.Baseline > int(130*time.Microsecond) || a.Ratio < 75 {
+		t.Errorf("baseline %dns, ratio %.1f, want ~100µs and ~100x", a.Baseline, a.Ratio)
+	}
+
+	// always slow calls are reported once
+	if a := d.Check(syscallEvent("connect", 3*time.Second), "socket"); a != nil {
+		t.Errorf("second slow connect: %+v", a)
+	}
+}
+
+func TestFDType(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	f, err := os.Create(filepath.Join(t.TempDir(), "f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pid := os.Getpid()
+	for fd, want := range map[uintptr]string{r.Fd(): "pipe", f.Fd(): "file", 1 << 20: ""} {
+		if got := tracer.FDType(pid, int32(fd)); got != want {
+			t.Errorf("FDType(%d) = %q, want %q", fd, got, want)
+		}
+	}
+}
+
+func TestRunLatencyDetector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// every first call is slower than the limit
+	d := tracer.NewLatencyDetector(tracer.DetectorConfig{Limit: time.Nanosecond})
+	events := run(t, context.Background(), exec.Command("cat", path), tracer.WithObserver(d))
+
+	alerts := events.Filter(func(e tracer.Event) bool { return e.Cat == "alert" })
+	if len(alerts) == 0 {
+		t.Fatal("no alerts")
+	}
+	fileRead := false
+	for _, e := range alerts {
+		if e.Ph != "i" || e.Args.Alert == nil {
+			t.Fatalf("bad alert event %+v", e)
+		}
+		fileRead = fileRead || e.Args.Syscall == "read" && e.Args.Alert.FDType == "file"
+	}
+	if !fileRead {
+		t.Errorf("no alert for a read of a file in %+v", alerts)
+	}
+}
@@ -0,0 +1,120 @@
+package tracer
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/ptrace"
+)
+
+// Backend runs a command under ptrace and calls fn for its syscalls,
+// signals, new children and exits while the thread is stopped. Decoders
+// only rely on what fn gets, so they work with any backend.
+type Backend interface {
+	Trace(cmd *exec.Cmd, fn strace.EventCallback) error
+}
+
+var (
+	// GoStrace traces with github.com/hugelgupf/go-strace, the default.
+	GoStrace Backend = goStrace{}
+
+	// Native traces with the ptrace package: syscall entries and exits are
+	// told apart by PTRACE_GET_SYSCALL_INFO and the command is killed if the
+	// tracer exits.
+	Native Backend = native{}
+)
+
+// WithBackend sets the Backend that traces commands.
+func WithBackend(b Backend) Option {
+	return func(t *Tracer) {
+		t.backend = b
+	}
+}
+
+type goStrace struct{}
+
+func (goStrace) Trace(cmd *exec.Cmd, fn strace.EventCallback) error {
+	return strace.Trace(cmd, fn)
+}
+
+type native struct{}
+
+func (native) Trace(cmd *exec.Cmd, fn strace.EventCallback) error {
+	t, err := ptrace.Start(cmd)
+	if err != nil {
+		return err
+	}
+	for {
+		s, err := t.Wait()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			t.Close()
+			return err
+		}
+		if record := traceRecord(s); record != nil {
+			if err := fn(nativeTask{s.Thread}, record); err != nil {
+				t.Close()
+				return err
+			}
+		}
+		if err := t.Resume(s); err != nil {
+			t.Close()
+			return err
+		}
+	}
+}
+
+// traceRecord converts a stop to the go-strace record, nil for stops that
+// go-strace doesn't report.
+func traceRecord(s *ptrace.Stop) *strace.TraceRecord {
+	r := &strace.TraceRecord{PID: s.Thread.TID, Time: s.Time}
+	switch s.Kind {
+	case ptrace.SyscallEnter, ptrace.SyscallExit:
+		r.Event = strace.SyscallEnter
+		call := &strace.SyscallEvent{Sysno: s.Syscall.Nr}
+		for i, v := range s.Syscall.Args {
+			call.Args[i].Value = uintptr(v)
+		}
+		if s.Kind == ptrace.SyscallExit {
+			r.Event = strace.SyscallExit
+			call.Ret[0].Value = uintptr(s.Syscall.Ret)
+			call.Errno = s.Syscall.Errno
+			call.Duration = s.Syscall.Duration
+		}
+		r.Syscall = call
+	case ptrace.Signal, ptrace.GroupStop:
+		r.Event = strace.SignalStop
+		r.SignalStop = &strace.SignalEvent{Signal: s.Signal}
+	case ptrace.NewChild:
+		r.Event = strace.NewChild
+		r.NewChild = &strace.NewChildEvent{PID: s.Child}
+	case ptrace.Exited:
+		if s.Status.Signaled() {
+			r.Event = strace.SignalExit
+			r.SignalExit = &strace.SignalEvent{Signal: s.Status.Signal()}
+		} else {
+			r.Event = strace.Exit
+			r.Exit = &strace.ExitEvent{WaitStatus: s.Status}
+		}
+	default:
+		return nil
+	}
+	return r
+}
+
+// nativeTask reads memory of a thread traced by the Native backend.
+type nativeTask struct {
+	th *ptrace.Thread
+}
+
+func (t nativeTask) Read(addr strace.Addr, v any) (int, error) {
+	return t.th.Read(uintptr(addr), v)
+}
+
+func (t nativeTask) Name() string {
+	return fmt.Sprintf("[pid %d]", t.th.TID)
+}
@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"syscall"
+
+	"github.com/iimos/play/stracy/syscalls"
+)
+
+// Format https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU/preview
+type Event struct {
+	Name      string `json:"name"`
+	Cat       string `json:"cat"`
+	Ph        string `json:"ph"`
+	PID       int    `json:"pid"`
+	TID       int    `json:"tid"`
+	Timestamp int    `json:"ts"`
+	Duration  int    `json:"dur,omitempty"`
+	Args      Args   `json:"args"`
+}
+
+type Args struct {
+	Syscall     string
+	SyscallArgs []interface{}
+	Result      interface{}
+	Duration    float64
+
+	// Timing tells how the thread spent the Event.Duration of a syscall.
+	Timing *Timing `json:",omitempty"`
+
+	// Payload points to the complete syscall buffer in the capture file.
+	Payload []PayloadSpan `json:",omitempty"`
+
+	// Exec describes the started program of execve and execveat events.
+	Exec *Exec `json:",omitempty"`
+
+	// Namespaces describes the container or namespaces of the process if
+	// they differ from the tracer's ones.
+	Namespaces *Namespaces `json:",omitempty"`
+
+	// HostPaths are absolute path arguments of a process with another root
+	// directory as the tracer sees them, by their index in SyscallArgs, see
+	// Namespaces.HostPath.
+	HostPaths map[int]string `json:",omitempty"`
+
+	// GoRuntime is the activity of the Go runtime a syscall of a Go binary
+	// is attributed to, like "netpoll", see GoRuntime.
+	GoRuntime string `json:",omitempty"`
+
+	// Go describes the Go binary of "go_binary" events.
+	Go *GoBinary `json:",omitempty"`
+
+	// Redacted lists secrets replaced with markers by a Redactor.
+	Redacted []Redaction `json:",omitempty"`
+
+	// Counters holds values of counter ("C") events, e.g. mapped memory
+	// bytes by category.
+	Counters map[string]uint64 `json:",omitempty"`
+
+	// Alert describes the latency anomaly of "alert" events.
+	Alert *Alert `json:",omitempty"`
+
+	// Errno is the error of a failed syscall. It's only set for events
+	// produced by a Tracer, recorded traces keep it in Result.
+	Errno syscall.Errno `json:"-"`
+}
+
+// PayloadSpan points to a piece of a syscall buffer stored in the capture
+// file. A buffer may be split into several spans when it does not fit into a
+// single packet.
+type PayloadSpan struct {
+	Offset int64
+	Size   int
+}
+
+// Failed reports whether the event is a failed syscall.
+func (e Event) Failed() bool {
+	return e.Cat == "failed"
+}
+
+// Path returns the first path argument of a syscall event, as passed by the
+// tracee. Relative paths are not resolved.
+func (e Event) Path() string {
+	si, ok := syscalls.ByName(e.Args.Syscall)
+	if !ok {
+		return ""
+	}
+	for i, t := range si.ArgTypes {
+		if t != syscalls.Path || i >= len(e.Args.SyscallArgs) {
+			continue
+		}
+		if p, ok := e.Args.SyscallArgs[i].(string); ok {
+			return p
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package tracer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/syscalls"
+	"golang.org/x/sys/unix"
+)
+
+// Exec describes the program started by execve or execveat.
+type Exec struct {
+	// Path is the absolute path of the executable with symlinks resolved.
+	// For processes with another root directory than the tracer, like in a
+	// container, it's the path as seen by the process, symlinks are not
+	// resolved then.
+	Path string `json:",omitempty"`
+
+	// Interpreter is the shebang line of a script: the interpreter and its
+	// optional argument.
+	Interpreter []string `json:",omitempty"`
+
+	// Environment changes relative to the environment the process was
+	// started with, that is envp of its own or its parent's last exec.
+	EnvAdded   []string `json:",omitempty"` // NAME=value
+	EnvChanged []string `json:",omitempty"` // NAME=new value
+	EnvRemoved []string `json:",omitempty"` // NAME
+}
+
+// execArgs are indexes of execve-like syscall arguments, dirfd and flags are
+// -1 if the syscall has none.
+type execArgs struct {
+	dirfd, path, envp, flags int
+}
+
+var execSyscalls = map[string]execArgs{
+	"execve":   {dirfd: -1, path: 0, envp: 2, flags: -1},
+	"execveat": {dirfd: 0, path: 1, envp: 3, flags: 4},
+}
+
+// newExec inspects an exec call on syscall enter. args are the decoded
+// arguments of the call, ns are namespaces of the process, nil if they are
+// the tracer's ones.
+func newExec(pid int, ns *Namespaces, call *strace.SyscallEvent, name string, args []any, maxElemSize uint) *Exec {
+	idx, ok := execSyscalls[name]
+	if !ok {
+		return nil
+	}
+	x := &Exec{}
+
+	p, _ := args[idx.path].(string)
+	dirfd, flags := int64(unix.AT_FDCWD), uint64(0)
+	if idx.dirfd >= 0 {
+		dirfd = int64(call.Args[idx.dirfd].Int())
+	}
+	if idx.flags >= 0 {
+		flags = call.Args[idx.flags].Uint64()
+	}
+	var root string
+	if ns != nil {
+		root = ns.Root
+	}
+	x.Path = resolveExecPath(pid, root, dirfd, p, flags)
+	if x.Path != "" {
+		x.Interpreter = shebang(root, x.Path)
+	}
+
+	envp, ok := args[idx.envp].(syscalls.Arg)
+	if !ok {
+		return x // NULL or unreadable
+	}
+	env, _ := envp.Value.([]string)
+	more, _ := envp.Formated["more"].(int)
+	prev, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return x
+	}
+	x.diffEnv(strings.Split(strings.TrimSuffix(string(prev), "\x00"), "\x00"), env, more > 0, maxElemSize)
+	return x
+}
+
+// resolveExecPath returns the absolute path of an executable as seen by the
+// tracee. Symlinks are only resolved if the tracee's root directory is the
+// tracer's one, that is root is "".
+func resolveExecPath(pid int, root string, dirfd int64, p string, flags uint64) string {
+	var dir string
+	switch {
+	case p == "" && flags&unix.AT_EMPTY_PATH != 0:
+		p, _ = os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, dirfd))
+	case filepath.IsAbs(p):
+	case dirfd == unix.AT_FDCWD:
+		dir, _ = os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid))
+	default:
+		dir, _ = os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, dirfd))
+	}
+	if p == "" {
+		return ""
+	}
+	p = filepath.Join(dir, p)
+	if root != "" {
+		return p
+	}
+	if resolved, err := filepath.EvalSymlinks(p); err == nil {
+		return resolved
+	}
+	return p
+}
+
+// shebang returns the interpreter line of a script, nil if path is not a
+// script. The path is looked up in the root directory root, see openIn.
+func shebang(root, path string) []string {
+	f, err := openIn(root, path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	buf := make([]byte, 256) // BINPRM_BUF_SIZE
+	n, _ := f.Read(buf)
+	line, ok := bytes.CutPrefix(buf[:n], []byte("#!"))
+	if !ok {
+		return nil
+	}
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	// Like the kernel, everything after the interpreter is a single argument.
+	interp, arg, _ := strings.Cut(strings.TrimSpace(string(line)), " ")
+	if interp == "" {
+		return nil
+	}
+	if arg = strings.TrimSpace(arg); arg != "" {
+		return []string{interp, arg}
+	}
+	return []string{interp}
+}
+
+// openIn opens a file by the path seen by a process with the root directory
+// root, "" for the tracer's one. Symlinks are resolved within root.
+func openIn(root, path string) (*os.File, error) {
+	if root == "" {
+		return os.Open(path)
+	}
+	dir, err := os.Open(root)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	fd, err := unix.Openat2(int(dir.Fd()), path, &unix.OpenHow{
+		Flags:   unix.O_RDONLY | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_IN_ROOT,
+	})
+	if err != nil {
+		return nil, &os.PathError{Op: "openat2", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
+
+// diffEnv compares the environment next of an exec, decoded with elements
+// cut to maxElemSize, to the previous one. Cut values are compared by their
+// prefix. Removed variables are unknown if next is cut short.
+func (x *Exec) diffEnv(prev, next []string, cut bool, maxElemSize uint) {
+	old := make(map[string]string, len(prev))
+	for _, kv := range prev {
+		k, v, _ := strings.Cut(kv, "=")
+		old[k] = v
+	}
+
+	cur := make(map[string]bool, len(next))
+	var cutNames []string
+	for _, kv := range next {
+		prefix, truncated := strings.CutSuffix(kv, "...")
+		truncated = truncated && uint(len(prefix)) == maxElemSize
+		if !truncated {
+			prefix = kv
+		}
+		k, v, ok := strings.Cut(prefix, "=")
+		if !ok && truncated {
+			cutNames = append(cutNames, k)
+			continue
+		}
+		cur[k] = true
+		ov, ok := old[k]
+		switch {
+		case !ok:
+			x.EnvAdded = append(x.EnvAdded, kv)
+		case truncated && !strings.HasPrefix(ov, v), !truncated && ov != v:
+			x.EnvChanged = append(x.EnvChanged, kv)
+		}
+	}
+	if !cut {
+		for k := range old {
+			if !cur[k] && k != "" && !slices.ContainsFunc(cutNames, func(p string) bool { return strings.HasPrefix(k, p) }) {
+				x.EnvRemoved = append(x.EnvRemoved, k)
+			}
+		}
+	}
+	sort.Strings(x.EnvAdded)
+	sort.Strings(x.EnvChanged)
+	sort.Strings(x.EnvRemoved)
+}
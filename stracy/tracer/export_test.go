@@ -0,0 +1,19 @@
+package tracer
+
+import "time"
+
+// State returns the number of threads and processes g keeps state of.
+func (g *GoRuntime) State() (threads, processes int) {
+	return len(g.tgids), len(g.binaries)
+}
+
+// SampleProcess samples a process twice, like two ticks of WithSampler. It
+// returns the event of the second sample and the number of threads read.
+func SampleProcess(pid int) (e Event, ok bool, threads int) {
+	s := newSampler()
+	seen := make(map[int]bool)
+	s.sampleProcess(pid, seen)
+	time.Sleep(time.Millisecond)
+	e, ok = s.sampleProcess(pid, seen)
+	return e, ok, len(seen)
+}
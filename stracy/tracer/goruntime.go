@@ -0,0 +1,171 @@
+package tracer
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"strings"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"golang.org/x/sys/unix"
+)
+
+// Activities of the Go runtime syscalls are attributed to, see GoRuntime.
+const (
+	GoNetpoll   = "netpoll"   // the network poller: epoll and its wakeup eventfd
+	GoSysmon    = "sysmon"    // the monitor thread sleeping between checks
+	GoScheduler = "scheduler" // parking and waking threads, yielding
+	GoGC        = "gc"        // returning memory to the OS (scavenger)
+	GoHeap      = "heap"      // mapping memory for the heap and stacks
+	GoThread    = "thread"    // starting and setting up threads
+	GoSignal    = "signal"    // installing and handling signals
+	GoPreempt   = "preempt"   // asynchronous preemption with SIGURG
+)
+
+// GoBinary describes the Go binary a process runs, found in its buildinfo
+// section.
+type GoBinary struct {
+	GoVersion string // like "go1.21.0"
+	Path      string `json:",omitempty"` // main package path
+}
+
+// GoRuntime tags syscalls of Go binaries made by the Go runtime rather than
+// by the program: Args.GoRuntime is set to the activity, like GoNetpoll or
+// GoSysmon. There are no stack traces, syscalls are classified by their
+// arguments and by how the runtime uses them on Linux, so a program calling
+// e.g. futex(2) on its own gets its calls tagged too.
+//
+// When a Go binary is seen for the first time, a "go_binary" instant event
+// with its GoBinary is emitted. GoRuntime implements Observer, it's not safe
+// for concurrent use.
+type GoRuntime struct {
+	tgids    map[int]int       // thread group IDs by thread
+	threads  map[int]int       // number of threads in tgids by thread group
+	binaries map[int]*GoBinary // by thread group, nil for other binaries
+}
+
+func NewGoRuntime() *GoRuntime {
+	return &GoRuntime{tgids: make(map[int]int), threads: make(map[int]int), binaries: make(map[int]*GoBinary)}
+}
+
+// Observe implements Observer.
+func (g *GoRuntime) Observe(t strace.Task, record *strace.TraceRecord, e *Event) []Event {
+	tid := record.PID
+	tgid, ok := g.tgids[tid]
+	if !ok {
+		if tgid = procTgid(tid); tgid == 0 {
+			return nil // gone
+		}
+		g.tgids[tid] = tgid
+		g.threads[tgid]++
+	}
+	bin, known := g.binaries[tgid]
+	exec := isExec(e.Name) && !e.Failed()
+	if exec {
+		// The exec killed other threads of the process.
+		for other, group := range g.tgids {
+			if group == tgid && other != tid {
+				g.Exited(other)
+			}
+		}
+	}
+	if !known || exec {
+		bin = readGoBinary(tgid)
+		g.binaries[tgid] = bin
+	}
+	if bin == nil {
+		return nil
+	}
+	e.Args.GoRuntime = goRuntimeActivity(tid, e.Name, record.Syscall.Args)
+	if known && !exec {
+		return nil
+	}
+	return []Event{{
+		Name:      "go_binary",
+		Cat:       "go",
+		Ph:        "i", // Instant event
+		PID:       e.PID,
+		TID:       e.TID,
+		Timestamp: e.Timestamp + e.Duration,
+		Args: Args{
+			Syscall: e.Name,
+			Result:  strings.TrimSpace(bin.GoVersion + " " + bin.Path),
+			Go:      bin,
+		},
+	}}
+}
+
+// Exited implements ExitObserver. The binary of a process is forgotten with
+// its last known thread.
+func (g *GoRuntime) Exited(tid int) {
+	tgid, ok := g.tgids[tid]
+	if !ok {
+		return
+	}
+	delete(g.tgids, tid)
+	if g.threads[tgid]--; g.threads[tgid] <= 0 {
+		delete(g.threads, tgid)
+		delete(g.binaries, tgid)
+	}
+}
+
+func isExec(name string) bool {
+	return name == "execve" || name == "execveat"
+}
+
+// readGoBinary reads the buildinfo of the executable of a thread, nil if
+// it isn't a Go binary.
+func readGoBinary(tid int) *GoBinary {
+	info, err := buildinfo.ReadFile(fmt.Sprintf("/proc/%d/exe", tid))
+	if err != nil {
+		return nil
+	}
+	return &GoBinary{GoVersion: info.GoVersion, Path: info.Path}
+}
+
+// goRuntimeActivity classifies a syscall of a Go binary, "" if it's likely
+// made by the program.
+func goRuntimeActivity(tid int, name string, args strace.SyscallArguments) string {
+	switch name {
+	case "epoll_create", "epoll_create1", "epoll_ctl", "epoll_wait", "epoll_pwait", "epoll_pwait2", "eventfd2":
+		return GoNetpoll
+	case "read", "write":
+		// netpollBreak writes 8 bytes to an eventfd to interrupt
+		// epoll_pwait, the poller drains it.
+		if args[2].Uint64() == 8 && FDType(tid, args[0].Int()) == "eventfd" {
+			return GoNetpoll
+		}
+	case "nanosleep":
+		// runtime.usleep, the program sleeps with timers instead.
+		return GoSysmon
+	case "futex", "sched_yield":
+		return GoScheduler
+	case "madvise":
+		return GoGC
+	case "mmap":
+		if args[4].Int() == -1 && args[3].Uint64()&unix.MAP_ANONYMOUS != 0 {
+			return GoHeap
+		}
+	case "munmap":
+		return GoHeap
+	case "clone":
+		if args[0].Uint64()&unix.CLONE_THREAD != 0 {
+			return GoThread
+		}
+	case "sigaltstack", "gettid", "arch_prctl", "sched_getaffinity":
+		return GoThread
+	case "rt_sigaction", "rt_sigprocmask", "rt_sigreturn":
+		return GoSignal
+	case "tgkill", "tkill":
+		sig := args[2]
+		if name == "tkill" {
+			sig = args[1]
+		}
+		if unix.Signal(sig.Int()) == unix.SIGURG {
+			return GoPreempt
+		}
+	case "getpid":
+		// signalM calls tgkill(getpid(), tid, SIGURG).
+		return GoPreempt
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package tracer
+
+import (
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Matcher reports whether an event satisfies a condition.
+type Matcher func(e Event) bool
+
+// Syscall matches syscall events with one of the names.
+func Syscall(names ...string) Matcher {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return func(e Event) bool {
+		return set[e.Args.Syscall]
+	}
+}
+
+// Failed matches failed syscalls.
+func Failed() Matcher {
+	return Event.Failed
+}
+
+// Errno matches syscalls failed with errno.
+func Errno(errno syscall.Errno) Matcher {
+	return func(e Event) bool {
+		return e.Failed() && e.Args.Errno == errno
+	}
+}
+
+// PID matches events of a process.
+func PID(pid int) Matcher {
+	return func(e Event) bool {
+		return e.PID == pid
+	}
+}
+
+// PathUnder matches events with a path argument inside one of dirs.
+// Relative paths are compared as is.
+func PathUnder(dirs ...string) Matcher {
+	cleaned := make([]string, len(dirs))
+	for i, dir := range dirs {
+		cleaned[i] = strings.TrimSuffix(filepath.Clean(dir), "/")
+	}
+	return func(e Event) bool {
+		p := e.Path()
+		if p == "" {
+			return false
+		}
+		p = filepath.Clean(p)
+		for _, dir := range cleaned {
+			if p == dir || strings.HasPrefix(p, dir+"/") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasPath matches events with a path argument.
+func HasPath() Matcher {
+	return func(e Event) bool {
+		return e.Path() != ""
+	}
+}
+
+// And matches events matching all of ms.
+func And(ms ...Matcher) Matcher {
+	return func(e Event) bool {
+		for _, m := range ms {
+			if !m(e) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches events matching any of ms.
+func Or(ms ...Matcher) Matcher {
+	return func(e Event) bool {
+		for _, m := range ms {
+			if m(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches events not matching m.
+func Not(m Matcher) Matcher {
+	return func(e Event) bool {
+		return !m(e)
+	}
+}
+
+// Events is a list of events with helpers for assertions.
+type Events []Event
+
+// Filter returns events matching m.
+func (es Events) Filter(m Matcher) Events {
+	var res Events
+	for _, e := range es {
+		if m(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Count returns the number of events matching m.
+func (es Events) Count(m Matcher) int {
+	n := 0
+	for _, e := range es {
+		if m(e) {
+			n++
+		}
+	}
+	return n
+}
+
+// Any reports whether any event matches m.
+func (es Events) Any(m Matcher) bool {
+	for _, e := range es {
+		if m(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Paths returns distinct path arguments of events in the order of their
+// appearance.
+func (es Events) Paths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, e := range es {
+		if p := e.Path(); p != "" && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
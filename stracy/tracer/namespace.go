@@ -0,0 +1,235 @@
+package tracer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iimos/play/stracy/syscalls"
+)
+
+// Namespaces describes the namespaces and the cgroup of a process that
+// doesn't share them with the tracer, like a process in a container.
+type Namespaces struct {
+	// PID is the process ID in the PID namespace of the process.
+	PID int `json:",omitempty"`
+
+	// IDs are inode numbers of the namespaces of the process, like "mnt" or
+	// "net", that differ from the tracer's ones.
+	IDs map[string]uint64 `json:",omitempty"`
+
+	// Cgroup is the cgroup of the process, of the unified hierarchy if the
+	// process is in one.
+	Cgroup string `json:",omitempty"`
+
+	// Container is the ID of the container the process runs in, found in
+	// the cgroup of Docker, Podman, containerd and CRI-O containers.
+	Container string `json:",omitempty"`
+
+	// Root is where the tracer sees the root directory of the process if
+	// it's another one. Paths in events are relative to the root of the
+	// process, see HostPath.
+	Root string `json:",omitempty"`
+}
+
+// namespaceTypes are names of namespaces in /proc/PID/ns.
+var namespaceTypes = []string{"cgroup", "ipc", "mnt", "net", "pid", "time", "user", "uts"}
+
+// HostPath returns the path as seen by the tracer of an absolute path of
+// the process. Paths in another mount namespace are only reachable while
+// the process is alive.
+func (ns *Namespaces) HostPath(p string) string {
+	if ns == nil || ns.Root == "" || !filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(ns.Root, p)
+}
+
+// hostPaths returns HostPath of absolute path arguments of an event, nil if
+// the process has the tracer's root directory.
+func hostPaths(si syscalls.SyscallInfo, e *Event) map[int]string {
+	ns := e.Args.Namespaces
+	if ns == nil || ns.Root == "" {
+		return nil
+	}
+	var paths map[int]string
+	for i, typ := range si.ArgTypes {
+		if typ != syscalls.Path || i >= len(e.Args.SyscallArgs) {
+			continue
+		}
+		if p, ok := e.Args.SyscallArgs[i].(string); ok && filepath.IsAbs(p) {
+			if paths == nil {
+				paths = make(map[int]string)
+			}
+			paths[i] = ns.HostPath(p)
+		}
+	}
+	return paths
+}
+
+// namespaceCache reads Namespaces of traced processes once and keeps them
+// until they may change.
+type namespaceCache struct {
+	self  *Namespaces
+	procs map[int]*Namespaces // nil values for processes sharing everything with the tracer
+}
+
+func newNamespaceCache() *namespaceCache {
+	c := &namespaceCache{procs: make(map[int]*Namespaces)}
+	c.self, _ = readNamespaces(os.Getpid())
+	return c
+}
+
+// get returns Namespaces of a process, nil if it shares them with the
+// tracer.
+func (c *namespaceCache) get(pid int) *Namespaces {
+	if ns, ok := c.procs[pid]; ok {
+		return ns
+	}
+	if c.self == nil {
+		return nil // no /proc
+	}
+	ns, err := readNamespaces(pid)
+	if err != nil {
+		return nil // the process is gone
+	}
+	ns = ns.relativeTo(c.self, pid)
+	c.procs[pid] = ns
+	return ns
+}
+
+// forget drops a process after it exits or changes its namespaces.
+func (c *namespaceCache) forget(pid int) {
+	delete(c.procs, pid)
+}
+
+// changesNamespaces reports whether a successful syscall may change
+// Namespaces of the calling process.
+func changesNamespaces(name string) bool {
+	switch name {
+	case "unshare", "setns", "chroot", "pivot_root":
+		return true
+	}
+	return false
+}
+
+// readNamespaces reads namespaces of a process from /proc.
+func readNamespaces(pid int) (*Namespaces, error) {
+	dir := fmt.Sprintf("/proc/%d", pid)
+	status, err := os.ReadFile(dir + "/status")
+	if err != nil {
+		return nil, err
+	}
+	ns := &Namespaces{
+		PID: nsPID(status),
+		IDs: make(map[string]uint64, len(namespaceTypes)),
+	}
+	for _, typ := range namespaceTypes {
+		link, err := os.Readlink(dir + "/ns/" + typ)
+		if err != nil {
+			continue // not supported by the kernel
+		}
+		// Like "mnt:[4026531841]".
+		_, ino, _ := strings.Cut(link, ":[")
+		if id, err := strconv.ParseUint(strings.TrimSuffix(ino, "]"), 10, 64); err == nil {
+			ns.IDs[typ] = id
+		}
+	}
+	if data, err := os.ReadFile(dir + "/cgroup"); err == nil {
+		ns.Cgroup = parseCgroup(data)
+		ns.Container = containerID(ns.Cgroup)
+	}
+	ns.Root, _ = os.Readlink(dir + "/root")
+	return ns, nil
+}
+
+// relativeTo leaves only what differs from the tracer's Namespaces self, it
+// returns nil if nothing does.
+func (ns *Namespaces) relativeTo(self *Namespaces, pid int) *Namespaces {
+	for typ, id := range ns.IDs {
+		if self.IDs[typ] == id {
+			delete(ns.IDs, typ)
+		}
+	}
+	if len(ns.IDs) == 0 {
+		ns.IDs = nil
+	}
+	if ns.PID == pid {
+		ns.PID = 0
+	}
+	switch {
+	case ns.IDs["mnt"] != 0:
+		// The link points to the root of another mount namespace,
+		// its path is relative to that namespace.
+		ns.Root = fmt.Sprintf("/proc/%d/root", pid)
+	case ns.Root == self.Root:
+		ns.Root = ""
+	}
+	if ns.IDs == nil && ns.PID == 0 && ns.Root == "" && ns.Cgroup == self.Cgroup {
+		return nil
+	}
+	return ns
+}
+
+// nsPID returns the innermost PID of the NSpid line of /proc/PID/status.
+func nsPID(status []byte) int {
+	sc := bufio.NewScanner(bytes.NewReader(status))
+	for sc.Scan() {
+		ids, ok := strings.CutPrefix(sc.Text(), "NSpid:")
+		if !ok {
+			continue
+		}
+		f := strings.Fields(ids)
+		if len(f) == 0 {
+			return 0
+		}
+		pid, _ := strconv.Atoi(f[len(f)-1])
+		return pid
+	}
+	return 0
+}
+
+// parseCgroup returns the cgroup path of /proc/PID/cgroup. The path in the
+// unified hierarchy ("0::/path") is preferred, with cgroup v1 or a hybrid
+// setup the first path other than the root is used.
+func parseCgroup(data []byte) string {
+	var v1 string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" && parts[2] != "/" {
+			return parts[2]
+		}
+		if v1 == "" && parts[2] != "/" {
+			v1 = parts[2]
+		}
+	}
+	if v1 == "" {
+		return "/"
+	}
+	return v1
+}
+
+// reContainerID matches a cgroup directory of a container, like
+// "docker-<id>.scope", "cri-containerd-<id>.scope" or just "<id>".
+var reContainerID = regexp.MustCompile(`^(?:[a-z-]+-)?([0-9a-f]{64})(?:\.scope)?$`)
+
+// containerID returns the ID of the container a cgroup belongs to, "" if
+// there's none. The innermost ID wins for nested containers.
+func containerID(cgroup string) string {
+	dirs := strings.Split(cgroup, "/")
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if m := reContainerID.FindStringSubmatch(dirs[i]); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
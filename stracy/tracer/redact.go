@@ -0,0 +1,223 @@
+package tracer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iimos/play/stracy/syscalls"
+)
+
+// Redaction marks a piece of an event replaced by a redactor.
+type Redaction struct {
+	// Path locates the value, e.g. "SyscallArgs.1", "Result" or
+	// "SyscallArgs.2.Value.0" for values nested in decoded arguments.
+	Path string
+	Rule string
+	// Start and End are byte offsets of the marker in the redacted string.
+	Start int
+	End   int
+}
+
+// RedactRule finds secrets in strings. If the pattern has capturing groups,
+// only the last one is redacted, so the context (a header name, a variable
+// name) stays readable.
+type RedactRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+
+	// accept filters matches by their submatches.
+	accept func(submatches []string) bool
+}
+
+// DefaultEnvPatterns are globs of environment variable names whose values are
+// redacted by the env rule.
+var DefaultEnvPatterns = []string{"*PASSWORD*", "*PASSWD*", "*SECRET*", "*TOKEN*", "*CREDENTIAL*", "*API_KEY*", "*PRIVATE_KEY*"}
+
+var namedRedactRules = map[string][]RedactRule{
+	"aws": {
+		{Name: "aws", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+		{Name: "aws", Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	},
+	"bearer": {
+		{Name: "bearer", Pattern: regexp.MustCompile(`(?i)\bBearer\s+([A-Za-z0-9\-._~+/]+=*)`)},
+	},
+	"postgres": {
+		// PasswordMessage and SASL responses of the wire protocol: 'p',
+		// int32 length, payload.
+		{Name: "postgres", Pattern: regexp.MustCompile(`(?s)\Ap\x00\x00..([^\x00]+)`)},
+		{Name: "postgres", Pattern: regexp.MustCompile(`postgres(?:ql)?://[^:/@\s]+:([^@\s]+)@`)},
+	},
+	"env": {EnvRedactRule(DefaultEnvPatterns...)},
+}
+
+// NamedRedactRules returns the built-in rules: aws (access key ids and secret
+// keys), bearer (bearer tokens), postgres (password messages of the wire
+// protocol and passwords in connection URLs) and env (values of
+// environment variables matching DefaultEnvPatterns).
+func NamedRedactRules(name string) ([]RedactRule, bool) {
+	rules, ok := namedRedactRules[name]
+	return rules, ok
+}
+
+// RedactRuleNames lists the built-in rule names.
+func RedactRuleNames() []string {
+	names := make([]string, 0, len(namedRedactRules))
+	for name := range namedRedactRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegexpRedactRule creates a rule from a regular expression.
+func RegexpRedactRule(expr string) (RedactRule, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return RedactRule{}, err
+	}
+	return RedactRule{Name: "regexp", Pattern: re}, nil
+}
+
+// EnvRedactRule redacts values of NAME=value pairs, e.g. in execve envp or
+// written .env files, if NAME matches one of the globs.
+func EnvRedactRule(globs ...string) RedactRule {
+	return RedactRule{
+		Name:    "env",
+		Pattern: regexp.MustCompile(`\b([A-Za-z_][A-Za-z0-9_]*)=((?:[^\s"\\]|\\.)+)`),
+		accept: func(m []string) bool {
+			for _, g := range globs {
+				if ok, _ := path.Match(g, m[1]); ok {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// Redactor replaces secrets in events with markers like "[redacted:aws]".
+type Redactor struct {
+	rules []RedactRule
+}
+
+// NewRedactor creates a redactor applying rules.
+func NewRedactor(rules ...RedactRule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// Redact replaces secrets in syscall arguments and the result of e and
+// records them in e.Args.Redacted.
+func (r *Redactor) Redact(e *Event) {
+	if r == nil || len(r.rules) == 0 {
+		return
+	}
+	for i, a := range e.Args.SyscallArgs {
+		e.Args.SyscallArgs[i] = r.value(a, "SyscallArgs."+strconv.Itoa(i), &e.Args.Redacted)
+	}
+	e.Args.Result = r.value(e.Args.Result, "Result", &e.Args.Redacted)
+	for i, p := range e.Args.HostPaths {
+		e.Args.HostPaths[i] = r.value(p, "HostPaths."+strconv.Itoa(i), &e.Args.Redacted).(string)
+	}
+	if x := e.Args.Exec; x != nil {
+		r.value(x.EnvAdded, "Exec.EnvAdded", &e.Args.Redacted)
+		r.value(x.EnvChanged, "Exec.EnvChanged", &e.Args.Redacted)
+	}
+}
+
+// RedactBytes replaces secrets in a raw buffer.
+func (r *Redactor) RedactBytes(b []byte) []byte {
+	if r == nil || len(r.rules) == 0 {
+		return b
+	}
+	s, spans := r.String(string(b))
+	if len(spans) == 0 {
+		return b
+	}
+	return []byte(s)
+}
+
+func (r *Redactor) value(v any, path string, found *[]Redaction) any {
+	switch v := v.(type) {
+	case string:
+		s, spans := r.String(v)
+		for _, sp := range spans {
+			sp.Path = path
+			*found = append(*found, sp)
+		}
+		return s
+	case []any:
+		for i, x := range v {
+			v[i] = r.value(x, path+"."+strconv.Itoa(i), found)
+		}
+	case []string:
+		for i, x := range v {
+			v[i] = r.value(x, path+"."+strconv.Itoa(i), found).(string)
+		}
+	case map[string]any:
+		for k, x := range v {
+			v[k] = r.value(x, path+"."+k, found)
+		}
+	case syscalls.Arg:
+		v.Value = r.value(v.Value, path+".Value", found)
+		for k, x := range v.Formated {
+			v.Formated[k] = r.value(x, path+".Formated."+k, found)
+		}
+		return v
+	}
+	return v
+}
+
+// String replaces secrets in s. Returned redactions have no Path.
+func (r *Redactor) String(s string) (string, []Redaction) {
+	type hit struct {
+		start, end int
+		rule       string
+	}
+	var hits []hit
+	for _, rule := range r.rules {
+		for _, m := range rule.Pattern.FindAllStringSubmatchIndex(s, -1) {
+			if rule.accept != nil {
+				sub := make([]string, len(m)/2)
+				for i := range sub {
+					if m[2*i] >= 0 {
+						sub[i] = s[m[2*i]:m[2*i+1]]
+					}
+				}
+				if !rule.accept(sub) {
+					continue
+				}
+			}
+			g := len(m)/2 - 1
+			if m[2*g] < 0 || m[2*g] == m[2*g+1] {
+				continue
+			}
+			hits = append(hits, hit{m[2*g], m[2*g+1], rule.Name})
+		}
+	}
+	if len(hits) == 0 {
+		return s, nil
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].start < hits[j].start })
+
+	var (
+		b     strings.Builder
+		spans []Redaction
+		pos   int
+	)
+	for _, h := range hits {
+		if h.start < pos {
+			continue // overlaps with a previous secret
+		}
+		b.WriteString(s[pos:h.start])
+		marker := fmt.Sprintf("[redacted:%s]", h.rule)
+		spans = append(spans, Redaction{Rule: h.rule, Start: b.Len(), End: b.Len() + len(marker)})
+		b.WriteString(marker)
+		pos = h.end
+	}
+	b.WriteString(s[pos:])
+	return b.String(), spans
+}
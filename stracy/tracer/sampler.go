@@ -0,0 +1,234 @@
+package tracer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithSampler samples scheduler statistics of traced processes from /proc
+// every interval and emits them as "sched" counter events, one per process.
+// Counters are:
+//
+//   - cpu: time on a CPU in percent of one CPU (schedstat)
+//   - user, system: the same split into user and kernel time, with the
+//     resolution of clock ticks (stat)
+//   - runqueue: time waiting for a CPU in percent, threads are runnable but
+//     don't run (schedstat)
+//   - voluntary, involuntary: context switches per second, voluntary ones
+//     are blocking, involuntary ones are preemptions (status)
+//   - rss: resident memory in bytes (stat)
+//   - threads: the number of threads
+//
+// The time off CPU outside of syscalls shows why threads are slow between
+// them: runqueue for CPU contention, involuntary switches for preemption.
+//
+// Counter events are emitted from another goroutine, OnEvent calls are
+// still serialized.
+func WithSampler(interval time.Duration) Option {
+	return func(t *Tracer) {
+		t.sampleInterval = interval
+	}
+}
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc/PID/stat. It's 100
+// on all architectures Linux supports.
+const clockTicks = 100
+
+// taskSample is a sample of a thread.
+type taskSample struct {
+	schedstat
+	user, sys  uint64 // stat, clock ticks
+	vol, invol uint64 // status
+	rss        uint64 // stat, pages, the same for all threads
+	time       time.Time
+}
+
+// sampler keeps previous samples of threads to compute rates.
+type sampler struct {
+	prev  map[int]taskSample // by thread ID
+	tgids map[int]int        // thread group IDs of traced threads
+}
+
+func newSampler() *sampler {
+	return &sampler{prev: make(map[int]taskSample), tgids: make(map[int]int)}
+}
+
+// sample samples the processes of traced threads tids and returns a
+// counter event per process. Processes get their first event on the second
+// sample, rates need two.
+func (s *sampler) sample(tids []int) []Event {
+	procs := make(map[int]bool)
+	for _, tid := range tids {
+		tgid, ok := s.tgids[tid]
+		if !ok {
+			tgid = procTgid(tid)
+			s.tgids[tid] = tgid
+		}
+		if tgid > 0 {
+			procs[tgid] = true
+		}
+	}
+	for tid, tgid := range s.tgids {
+		if !procs[tgid] {
+			delete(s.tgids, tid)
+		}
+	}
+
+	var events []Event
+	seen := make(map[int]bool)
+	for pid := range procs {
+		if e, ok := s.sampleProcess(pid, seen); ok {
+			events = append(events, e)
+		}
+	}
+	for tid := range s.prev {
+		if !seen[tid] {
+			delete(s.prev, tid)
+		}
+	}
+	return events
+}
+
+func (s *sampler) sampleProcess(pid int, seen map[int]bool) (Event, bool) {
+	dirs, err := filepath.Glob(fmt.Sprintf("/proc/%d/task/*", pid))
+	if err != nil || len(dirs) == 0 {
+		return Event{}, false
+	}
+	var (
+		run, wait, elapsed  time.Duration
+		userTicks, sysTicks uint64
+		vol, invol          uint64
+		rss, threads        uint64
+		rated               bool
+	)
+	for _, dir := range dirs {
+		tid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		cur, ok := readTaskSample(dir)
+		if !ok {
+			continue // exited
+		}
+		threads++
+		rss = cur.rss
+		seen[tid] = true
+		prev, ok := s.prev[tid]
+		s.prev[tid] = cur
+		if !ok {
+			continue
+		}
+		rated = true
+		elapsed = max(elapsed, cur.time.Sub(prev.time))
+		run += positive(cur.run - prev.run)
+		wait += positive(cur.wait - prev.wait)
+		userTicks += delta(cur.user, prev.user)
+		sysTicks += delta(cur.sys, prev.sys)
+		vol += delta(cur.vol, prev.vol)
+		invol += delta(cur.invol, prev.invol)
+	}
+	if !rated || elapsed <= 0 || rss == 0 {
+		// No RSS: the process is exiting, its memory is released.
+		return Event{}, false
+	}
+	percent := func(d time.Duration) uint64 {
+		return uint64(100 * d / elapsed)
+	}
+	perSecond := func(n uint64) uint64 {
+		return uint64(time.Duration(n) * time.Second / elapsed)
+	}
+	ticksTime := func(n uint64) time.Duration {
+		return time.Duration(n) * time.Second / clockTicks
+	}
+	return Event{
+		Name:      "sched",
+		Cat:       "sched",
+		Ph:        "C", // Counter event
+		PID:       pid,
+		TID:       pid,
+		Timestamp: int(time.Now().UnixNano()),
+		Args: Args{Counters: map[string]uint64{
+			"cpu":         percent(run),
+			"user":        percent(ticksTime(userTicks)),
+			"system":      percent(ticksTime(sysTicks)),
+			"runqueue":    percent(wait),
+			"voluntary":   perSecond(vol),
+			"involuntary": perSecond(invol),
+			"rss":         rss * uint64(os.Getpagesize()),
+			"threads":     threads,
+		}},
+	}, true
+}
+
+// delta is the increase of a counter, 0 if a thread ID was reused.
+func delta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+// readTaskSample reads a thread of /proc/PID/task.
+func readTaskSample(dir string) (s taskSample, ok bool) {
+	s.time = time.Now()
+
+	if s.schedstat, ok = readSchedstatFile(dir + "/schedstat"); !ok {
+		return s, false
+	}
+
+	stat, err := os.ReadFile(dir + "/stat")
+	if err != nil {
+		return s, false
+	}
+	// The command name may contain spaces and parentheses, fields
+	// follow the last ')', starting with the 3rd one, the state.
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return s, false
+	}
+	f := strings.Fields(string(stat[i+1:]))
+	if len(f) < 22 {
+		return s, false
+	}
+	s.user, _ = strconv.ParseUint(f[14-3], 10, 64)
+	s.sys, _ = strconv.ParseUint(f[15-3], 10, 64)
+	s.rss, _ = strconv.ParseUint(f[24-3], 10, 64)
+
+	status, err := os.ReadFile(dir + "/status")
+	if err != nil {
+		return s, false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(status))
+	for sc.Scan() {
+		name, value, _ := strings.Cut(sc.Text(), ":")
+		switch name {
+		case "voluntary_ctxt_switches":
+			s.vol, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		case "nonvoluntary_ctxt_switches":
+			s.invol, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	return s, true
+}
+
+// procTgid returns the thread group ID of a thread, 0 if it's gone.
+func procTgid(tid int) int {
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", tid))
+	if err != nil {
+		return 0
+	}
+	sc := bufio.NewScanner(bytes.NewReader(status))
+	for sc.Scan() {
+		if v, ok := strings.CutPrefix(sc.Text(), "Tgid:"); ok {
+			tgid, _ := strconv.Atoi(strings.TrimSpace(v))
+			return tgid
+		}
+	}
+	return 0
+}
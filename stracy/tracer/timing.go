@@ -0,0 +1,165 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Timing splits the wall time of a syscall (Event.Duration) by what the
+// thread was doing, in nanoseconds. It tells syscalls that block, like a
+// read of a socket, from ones that keep the CPU busy in the kernel, like a
+// read of /dev/urandom.
+type Timing struct {
+	// CPU is the time the thread ran on a CPU, in the kernel mostly.
+	CPU int
+
+	// RunQueue is the time the thread was runnable but waited for a CPU.
+	RunQueue int
+
+	// Blocked is the rest of the wall time, the thread slept: it waited
+	// for I/O, a lock, a timer, etc.
+	Blocked int
+}
+
+// Overhead is the time tracing adds to every syscall: the tracee stops at
+// the entry and the exit of the syscall and waits for the tracer to resume
+// it. See Calibrate.
+type Overhead struct {
+	// Wall is subtracted from Event.Duration.
+	Wall time.Duration
+
+	// CPU is the kernel time spent on the stops, subtracted from
+	// Timing.CPU.
+	CPU time.Duration
+}
+
+// WithOverhead subtracts the overhead of tracing from syscall times.
+func WithOverhead(o Overhead) Option {
+	return func(t *Tracer) {
+		t.overhead = o
+	}
+}
+
+// calibrationSyscalls do next to nothing, all their time is the overhead of
+// tracing.
+var calibrationSyscalls = Syscall("getpid", "getppid", "gettid", "getuid", "geteuid", "getgid", "getegid")
+
+// minCalibrationSamples is how many calibration syscalls Calibrate needs.
+const minCalibrationSamples = 100
+
+// Calibrate measures the overhead of tracing by running cmd, which should
+// make many calls of syscalls doing nothing, like getppid(2). The overhead
+// is the minimum time of such calls, so it's never more than what tracing
+// adds to a syscall. opts configure the Tracer of the calibration run, they
+// should select the same backend as the real one.
+func Calibrate(ctx context.Context, cmd *exec.Cmd, opts ...Option) (Overhead, error) {
+	var wall, cpu []time.Duration
+	opts = append(opts, WithOverhead(Overhead{}), OnEvent(func(e Event) {
+		if !calibrationSyscalls(e) {
+			return
+		}
+		wall = append(wall, time.Duration(e.Duration))
+		if e.Args.Timing != nil {
+			cpu = append(cpu, time.Duration(e.Args.Timing.CPU))
+		}
+	}))
+	if _, err := New(opts...).Run(ctx, cmd); err != nil {
+		return Overhead{}, err
+	}
+	if len(wall) < minCalibrationSamples {
+		return Overhead{}, fmt.Errorf("calibration: got %d syscalls doing nothing, want at least %d", len(wall), minCalibrationSamples)
+	}
+	return Overhead{Wall: minDuration(wall), CPU: minDuration(cpu)}, nil
+}
+
+func minDuration(ds []time.Duration) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	m := ds[0]
+	for _, d := range ds[1:] {
+		m = min(m, d)
+	}
+	return m
+}
+
+// schedstat is a sample of /proc/PID/task/TID/schedstat.
+type schedstat struct {
+	run, wait time.Duration // on a CPU and in the runqueue
+}
+
+// readSchedstat reads the scheduler statistics of a thread, ok is false if
+// the kernel has none (CONFIG_SCHED_INFO) or the thread is gone.
+func readSchedstat(tid int) (schedstat, bool) {
+	// The task directory of a thread is reachable through its own ID, the
+	// ID of the thread group isn't needed.
+	return readSchedstatFile(fmt.Sprintf("/proc/%d/task/%d/schedstat", tid, tid))
+}
+
+func readSchedstatFile(path string) (s schedstat, ok bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, false
+	}
+	// "run_ns wait_ns timeslices"
+	var slices int
+	if _, err := fmt.Sscan(string(data), &s.run, &s.wait, &slices); err != nil {
+		return s, false
+	}
+	return s, true
+}
+
+// syscallEntry is where a thread entered its current syscall.
+type syscallEntry struct {
+	time time.Time // of the syscall-enter-stop
+
+	// resumed is when the tracer was about to resume the thread, the
+	// time decoding the entry took isn't a part of the syscall.
+	resumed time.Time
+	sched   schedstat
+	ok      bool // sched is valid
+}
+
+// newSyscallEntry samples a thread stopped at the entry of a syscall right
+// before it's resumed.
+func newSyscallEntry(tid int, at time.Time) syscallEntry {
+	e := syscallEntry{time: at}
+	e.sched, e.ok = readSchedstat(tid)
+	e.resumed = time.Now()
+	return e
+}
+
+// times returns the wall time of a syscall of a thread exiting at exit and
+// how the time was spent. The overhead of tracing is subtracted.
+func (o Overhead) times(tid int, entry syscallEntry, exit time.Time) (time.Duration, *Timing) {
+	wall := positive(exit.Sub(entry.resumed) - o.Wall)
+	if !entry.ok {
+		return wall, nil
+	}
+	s, ok := readSchedstat(tid)
+	if !ok {
+		return wall, nil
+	}
+	cpu := positive(s.run - entry.sched.run - o.CPU)
+	runq := positive(s.wait - entry.sched.wait)
+	if cpu+runq > wall {
+		// Subtracting the overhead may leave less wall time than the
+		// samples of the scheduler measured.
+		wall = cpu + runq
+	}
+	return wall, &Timing{
+		CPU:      int(cpu),
+		RunQueue: int(runq),
+		Blocked:  int(wall - cpu - runq),
+	}
+}
+
+func positive(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,364 @@
+// Package tracer traces a command with ptrace and turns its syscalls into
+// decoded events. It's what stracy uses under the hood, and it can be
+// embedded into Go tests:
+//
+//	events, err := tracer.New(tracer.WithSyscalls("openat")).Run(ctx, exec.Command("./app"))
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	if bad := events.Filter(tracer.Not(tracer.PathUnder(os.TempDir()))); len(bad) > 0 {
+//		t.Errorf("opened files outside of TMPDIR: %v", bad.Paths())
+//	}
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/hugelgupf/go-strace/strace"
+	"github.com/iimos/play/stracy/syscalls"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultMaxBlobSize is the default number of bytes of buffers decoded into
+// events.
+const DefaultMaxBlobSize = 1024
+
+// Observer is notified about every syscall event before it's emitted. It may
+// amend the event and return extra events to emit after it.
+type Observer interface {
+	Observe(t strace.Task, record *strace.TraceRecord, e *Event) []Event
+}
+
+// ExitObserver is an Observer keeping state per thread. It's told when a
+// thread exits, so the state can be dropped.
+type ExitObserver interface {
+	Observer
+	Exited(tid int)
+}
+
+// Tracer traces commands. A Tracer may be reused, but only one trace can be
+// active per process.
+type Tracer struct {
+	maxBlobSize uint
+	filter      Matcher
+	observers   []Observer
+	onEvent     func(Event)
+	onEnter     func(t strace.Task, record *strace.TraceRecord)
+	redactor    *Redactor
+	debug       io.Writer
+	backend     Backend
+	overhead    Overhead
+
+	sampleInterval time.Duration
+}
+
+// Option configures a Tracer.
+type Option func(*Tracer)
+
+// WithSyscalls limits emitted events to the named syscalls.
+func WithSyscalls(names ...string) Option {
+	return WithFilter(Syscall(names...))
+}
+
+// WithFilter limits emitted events to the ones matching m. Several filters
+// are combined with And.
+func WithFilter(m Matcher) Option {
+	return func(t *Tracer) {
+		if t.filter != nil {
+			m = And(t.filter, m)
+		}
+		t.filter = m
+	}
+}
+
+// WithMaxBlobSize sets the number of bytes of buffers (read/write data,
+// strings) decoded into events.
+func WithMaxBlobSize(n uint) Option {
+	return func(t *Tracer) {
+		t.maxBlobSize = n
+	}
+}
+
+// WithObserver adds an observer of all syscall events, including filtered
+// out ones.
+func WithObserver(o Observer) Option {
+	return func(t *Tracer) {
+		t.observers = append(t.observers, o)
+	}
+}
+
+// OnEvent sets a handler receiving events as they happen. The handler is
+// called while the traced thread is stopped, so it should be fast. Events
+// passed to the handler are not collected by Run.
+func OnEvent(fn func(Event)) Option {
+	return func(t *Tracer) {
+		t.onEvent = fn
+	}
+}
+
+// OnSyscallEnter sets a function called on every syscall entry, before the
+// kernel executes the syscall. Syscalls that never return, like exit_group,
+// are only seen here. Arguments are not decoded.
+func OnSyscallEnter(fn func(t strace.Task, record *strace.TraceRecord)) Option {
+	return func(t *Tracer) {
+		t.onEnter = fn
+	}
+}
+
+// WithRedactor replaces secrets in events with r before they are filtered and
+// emitted. Observers see unredacted events.
+func WithRedactor(r *Redactor) Option {
+	return func(t *Tracer) {
+		t.redactor = r
+	}
+}
+
+// WithDebugLog writes process lifecycle events (exits, signals, new
+// children) to w.
+func WithDebugLog(w io.Writer) Option {
+	return func(t *Tracer) {
+		t.debug = w
+	}
+}
+
+// New creates a Tracer.
+func New(opts ...Option) *Tracer {
+	t := &Tracer{maxBlobSize: DefaultMaxBlobSize, backend: GoStrace}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Run starts cmd, traces it and all its children until they exit and returns
+// the collected events. If ctx is done, traced processes are killed and
+// ctx.Err() is returned.
+func (t *Tracer) Run(ctx context.Context, cmd *exec.Cmd) (Events, error) {
+	var (
+		events Events
+		mu     sync.Mutex
+		pids   = make(map[int]bool)
+
+		// entered holds events of syscalls decoded on enter by thread.
+		entered = make(map[int]Event)
+
+		// entries holds where threads entered their current syscalls.
+		entries = make(map[int]syscallEntry)
+
+		namespaces = newNamespaceCache()
+
+		// decoders holds state of syscall decoders for this trace.
+		decoders = new(syscalls.TraceState)
+	)
+	stop := context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for pid := range pids {
+			unix.Kill(pid, unix.SIGKILL)
+		}
+	})
+	defer stop()
+
+	var emitMu sync.Mutex // the sampler emits concurrently
+	emit := func(e Event) {
+		emitMu.Lock()
+		defer emitMu.Unlock()
+		t.redactor.Redact(&e)
+		if t.filter != nil && !t.filter(e) {
+			return
+		}
+		if t.onEvent != nil {
+			t.onEvent(e)
+		} else {
+			events = append(events, e)
+		}
+	}
+
+	stopSampler := t.startSampler(func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		tids := make([]int, 0, len(pids))
+		for pid := range pids {
+			tids = append(tids, pid)
+		}
+		return tids
+	}, emit)
+
+	err := t.backend.Trace(cmd, func(task strace.Task, record *strace.TraceRecord) error {
+		// Decoders look into /proc of the thread, e.g. for socket protocols.
+		task = syscalls.WithPID(task, record.PID, decoders)
+		mu.Lock()
+		switch record.Event {
+		case strace.Exit, strace.SignalExit:
+			delete(pids, record.PID)
+		default:
+			pids[record.PID] = true
+		}
+		if record.Event == strace.NewChild {
+			pids[record.NewChild.PID] = true
+		}
+		mu.Unlock()
+		if ctx.Err() != nil {
+			unix.Kill(record.PID, unix.SIGKILL)
+			return nil
+		}
+
+		switch record.Event {
+		case strace.SyscallEnter:
+			if t.onEnter != nil {
+				t.onEnter(task, record)
+			}
+			if si := syscalls.Details(record.Syscall); si.DecodeOnEnter() {
+				entered[record.PID] = t.newEnterEvent(task, record, si, namespaces.get(record.PID))
+			}
+			entries[record.PID] = newSyscallEntry(record.PID, record.Time)
+		case strace.SyscallExit:
+			e := t.newEvent(task, record)
+			if entry, ok := entries[record.PID]; ok {
+				delete(entries, record.PID)
+				wall, timing := t.overhead.times(record.PID, entry, record.Time)
+				e.Timestamp = int(entry.time.UnixNano())
+				e.Duration = int(wall)
+				e.Args.Timing = timing
+			}
+			if enter, ok := entered[record.PID]; ok {
+				delete(entered, record.PID)
+				if enter.Name == e.Name {
+					e.Args.SyscallArgs = enter.Args.SyscallArgs
+					e.Args.Exec = enter.Args.Exec
+				}
+			}
+			if e.Name == "" {
+				t.debugf("empty syscall: %v\n", record.Syscall.Sysno)
+				return nil
+			}
+			if !e.Failed() && changesNamespaces(e.Name) {
+				namespaces.forget(record.PID)
+			}
+			e.Args.Namespaces = namespaces.get(record.PID)
+			e.Args.HostPaths = hostPaths(syscalls.Details(record.Syscall), &e)
+			var extra []Event
+			for _, o := range t.observers {
+				extra = append(extra, o.Observe(task, record, &e)...)
+			}
+			emit(e)
+			for _, x := range extra {
+				emit(x)
+			}
+
+		case strace.SignalExit:
+			delete(entered, record.PID)
+			delete(entries, record.PID)
+			namespaces.forget(record.PID)
+			t.exited(record.PID)
+			t.debugf("PID %d exited from signal %s\n", record.PID, syscalls.SignalString(record.SignalExit.Signal))
+		case strace.Exit:
+			delete(entered, record.PID)
+			delete(entries, record.PID)
+			namespaces.forget(record.PID)
+			t.exited(record.PID)
+			t.debugf("PID %d exited from exit status %d (code = %d)\n", record.PID, record.Exit.WaitStatus, record.Exit.WaitStatus.ExitStatus())
+		case strace.SignalStop:
+			t.debugf("PID %d got signal %s\n", record.PID, syscalls.SignalString(record.SignalStop.Signal))
+		case strace.NewChild:
+			t.debugf("PID %d spawned new child %d\n", record.PID, record.NewChild.PID)
+		}
+		return nil
+	})
+	stopSampler()
+	if ctx.Err() != nil {
+		return events, ctx.Err()
+	}
+	return events, err
+}
+
+// startSampler starts sampling processes of threads returned by tids if
+// WithSampler is set. The returned function stops it.
+func (t *Tracer) startSampler(tids func() []int, emit func(Event)) (stop func()) {
+	if t.sampleInterval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s := newSampler()
+		ticker := time.NewTicker(t.sampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for _, e := range s.sample(tids()) {
+					emit(e)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// exited tells observers keeping per-thread state that tid exited.
+func (t *Tracer) exited(tid int) {
+	for _, o := range t.observers {
+		if o, ok := o.(ExitObserver); ok {
+			o.Exited(tid)
+		}
+	}
+}
+
+func (t *Tracer) debugf(format string, args ...any) {
+	if t.debug != nil {
+		fmt.Fprintf(t.debug, format, args...)
+	}
+}
+
+// newEvent decodes a syscall exit into an event. It's stamped at the entry
+// of the syscall, Run refines the times if it saw the entry.
+func (t *Tracer) newEvent(task strace.Task, record *strace.TraceRecord) Event {
+	call := record.Syscall
+	syscallInfo := syscalls.Details(call)
+
+	e := Event{
+		Name:      syscallInfo.Name,
+		Cat:       "successful",
+		Ph:        "X", // Complete event
+		PID:       record.PID,
+		TID:       record.PID,
+		Timestamp: int(record.Time.Add(-call.Duration).UnixNano()),
+		Duration:  int(call.Duration.Nanoseconds()),
+		Args: Args{
+			Syscall: syscallInfo.Name,
+		},
+	}
+
+	if call.Errno == 0 {
+		e.Args.Result = syscalls.ArgumentSimple(task, syscallInfo.ReturnType, call.Ret[0], t.maxBlobSize)
+	} else {
+		e.Args.Result = fmt.Sprintf("%q (%d)", call.Errno, call.Errno)
+		e.Args.Errno = call.Errno
+		e.Cat = "failed"
+	}
+
+	e.Args.SyscallArgs = syscalls.ArgumentsStrings(syscallInfo, task, call.Args, call.Ret[0], t.maxBlobSize)
+	return e
+}
+
+// newEnterEvent decodes arguments of a syscall on enter, the result is filled
+// on exit.
+func (t *Tracer) newEnterEvent(task strace.Task, record *strace.TraceRecord, si syscalls.SyscallInfo, ns *Namespaces) Event {
+	call := record.Syscall
+	e := Event{Name: si.Name}
+	e.Args.SyscallArgs = syscalls.ArgumentsStrings(si, task, call.Args, call.Ret[0], t.maxBlobSize)
+	e.Args.Exec = newExec(record.PID, ns, call, si.Name, e.Args.SyscallArgs, t.maxBlobSize)
+	return e
+}
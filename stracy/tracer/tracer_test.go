@@ -0,0 +1,434 @@
+package tracer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iimos/play/stracy/syscalls"
+	"github.com/iimos/play/stracy/tracer"
+)
+
+func run(t *testing.T, ctx context.Context, cmd *exec.Cmd, opts ...tracer.Option) tracer.Events {
+	t.Helper()
+	events, err := tracer.New(opts...).Run(ctx, cmd)
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("ptrace is not permitted: %s", err)
+	}
+	if err != nil && !errors.Is(err, ctx.Err()) {
+		t.Fatalf("Run: %s", err)
+	}
+	return events
+}
+
+func TestRun(t *testing.T) {
+	for name, backend := range map[string]tracer.Backend{"go-strace": tracer.GoStrace, "native": tracer.Native} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "data.txt")
+			if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			events := run(t, context.Background(), exec.Command("cat", path, filepath.Join(dir, "missing")), tracer.WithBackend(backend))
+
+			opens := tracer.Syscall("open", "openat")
+			if !events.Any(tracer.And(opens, tracer.PathUnder(dir), tracer.Not(tracer.Failed()))) {
+				t.Errorf("no successful open of %s in %v", path, events.Filter(opens).Paths())
+			}
+			if n := events.Count(tracer.And(opens, tracer.Errno(syscall.ENOENT), tracer.PathUnder(dir))); n != 1 {
+				t.Errorf("got %d opens of the missing file failed with ENOENT, want 1", n)
+			}
+			if n := events.Count(tracer.Syscall("fsync", "fdatasync")); n != 0 {
+				t.Errorf("got %d fsyncs, want 0", n)
+			}
+			if n := events.Count(tracer.Syscall("exit_group")); n != 0 {
+				t.Errorf("got %d exit_group exits, want 0", n)
+			}
+		})
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+	events := run(t, context.Background(), exec.Command("true"), tracer.WithSyscalls("close"))
+	if len(events) == 0 {
+		t.Fatal("no close events")
+	}
+	if n := events.Count(tracer.Not(tracer.Syscall("close"))); n != 0 {
+		t.Errorf("got %d events other than close", n)
+	}
+}
+
+func TestRunOnEvent(t *testing.T) {
+	n := 0
+	events := run(t, context.Background(), exec.Command("true"), tracer.OnEvent(func(tracer.Event) { n++ }))
+	if n == 0 {
+		t.Error("handler was not called")
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d collected events, want none when a handler is set", len(events))
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := tracer.New().Run(ctx, exec.Command("sleep", "10"))
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("ptrace is not permitted: %s", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("tracee was not killed, Run took %s", elapsed)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	events := tracer.Events{
+		{PID: 1, Cat: "successful", Args: tracer.Args{Syscall: "openat", SyscallArgs: []any{"AT_FDCWD", "/tmp/x/a", "O_RDONLY"}}},
+		{PID: 1, Cat: "failed", Args: tracer.Args{Syscall: "openat", SyscallArgs: []any{"AT_FDCWD", "/etc/passwd", "O_RDONLY"}, Errno: syscall.EACCES}},
+		{PID: 2, Cat: "successful", Args: tracer.Args{Syscall: "fsync", SyscallArgs: []any{3}}},
+		{PID: 2, Cat: "successful", Args: tracer.Args{Syscall: "stat", SyscallArgs: []any{"/tmp/xy", nil}}},
+	}
+
+	tests := []struct {
+		name string
+		m    tracer.Matcher
+		want int
+	}{
+		{"syscall", tracer.Syscall("openat", "fsync"), 3},
+		{"failed", tracer.Failed(), 1},
+		{"errno", tracer.Errno(syscall.EACCES), 1},
+		{"errno mismatch", tracer.Errno(syscall.ENOENT), 0},
+		{"pid", tracer.PID(2), 2},
+		{"path under", tracer.PathUnder("/tmp/x/"), 1},
+		{"path under root", tracer.PathUnder("/"), 3},
+		{"has path", tracer.HasPath(), 3},
+		{"and", tracer.And(tracer.Syscall("openat"), tracer.Not(tracer.PathUnder("/tmp"))), 1},
+		{"or", tracer.Or(tracer.PID(2), tracer.Failed()), 3},
+	}
+	for _, tt := range tests {
+		if got := events.Count(tt.m); got != tt.want {
+			t.Errorf("%s: got %d events, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if got, want := events.Paths(), []string{"/tmp/x/a", "/etc/passwd", "/tmp/xy"}; len(got) != len(want) {
+		t.Errorf("Paths() = %v, want %v", got, want)
+	}
+}
+
+func TestRunExec(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh -e\ntrue\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Long values are cut to the blob size in events.
+	long := strings.Repeat("x", 200)
+	cmd := exec.Command("env", "STRACY_TEST=1", "STRACY_LONG="+long, script, "arg1")
+	cmd.Env = append(os.Environ(), "STRACY_KEEP="+long, "STRACY_GONE=1", "STRACY_"+long+"=1")
+	cmd.Args = slices.Insert(cmd.Args, 1, "-u", "STRACY_GONE")
+	events := run(t, context.Background(), cmd, tracer.WithSyscalls("execve"), tracer.WithMaxBlobSize(64))
+	var ev *tracer.Event
+	for i, e := range events {
+		if !e.Failed() && e.Path() == script {
+			ev = &events[i]
+		}
+	}
+	if ev == nil {
+		t.Fatalf("no successful execve of %s in %v", script, events.Paths())
+	}
+
+	argv, ok := ev.Args.SyscallArgs[1].(syscalls.Arg)
+	if !ok {
+		t.Fatalf("argv is %#v, want a string vector", ev.Args.SyscallArgs[1])
+	}
+	if got, want := argv.Value, []string{script, "arg1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("argv = %q, want %q", got, want)
+	}
+
+	x := ev.Args.Exec
+	if x == nil {
+		t.Fatal("no exec info")
+	}
+	if x.Path != script {
+		t.Errorf("Path = %q, want %q", x.Path, script)
+	}
+	if want := []string{"/bin/sh", "-e"}; !reflect.DeepEqual(x.Interpreter, want) {
+		t.Errorf("Interpreter = %q, want %q", x.Interpreter, want)
+	}
+	if want := []string{"STRACY_LONG=" + long[:64-len("STRACY_LONG=")] + "...", "STRACY_TEST=1"}; !reflect.DeepEqual(x.EnvAdded, want) {
+		t.Errorf("EnvAdded = %q, want %q", x.EnvAdded, want)
+	}
+	if len(x.EnvChanged) != 0 {
+		t.Errorf("EnvChanged = %q, want none", x.EnvChanged)
+	}
+	if want := []string{"STRACY_GONE"}; !reflect.DeepEqual(x.EnvRemoved, want) {
+		t.Errorf("EnvRemoved = %q, want %q", x.EnvRemoved, want)
+	}
+}
+
+func TestRunNamespaces(t *testing.T) {
+	if _, err := exec.LookPath("unshare"); err != nil {
+		t.Skip("no unshare")
+	}
+	events := run(t, context.Background(), exec.Command("unshare", "--uts", "--mount", "--pid", "--fork", "true"))
+
+	unshare := events.Filter(tracer.Syscall("unshare"))
+	if len(unshare) != 1 {
+		t.Fatalf("got %d unshare events, want 1", len(unshare))
+	}
+	if unshare[0].Failed() {
+		t.Skipf("unshare failed: %v", unshare[0].Args.Result)
+	}
+	if got, want := fmt.Sprint(unshare[0].Args.SyscallArgs[0]), "CLONE_NEWNS|CLONE_NEWUTS|CLONE_NEWPID"; got != want {
+		t.Errorf("unshare flags = %s, want %s", got, want)
+	}
+	if ns := unshare[0].Args.Namespaces; ns == nil || ns.IDs["uts"] == 0 || ns.IDs["mnt"] == 0 || ns.IDs["pid"] != 0 {
+		t.Errorf("Namespaces of unshare = %+v, want new uts and mnt", ns)
+	}
+	if ns := events[0].Args.Namespaces; ns != nil {
+		t.Errorf("Namespaces before unshare = %+v, want nil", ns)
+	}
+
+	var child *tracer.Event
+	for i, e := range events {
+		if e.Name == "execve" && !e.Failed() && e.PID != unshare[0].PID {
+			child = &events[i]
+		}
+	}
+	if child == nil {
+		t.Fatal("no execve of the child")
+	}
+	ns := child.Args.Namespaces
+	if ns == nil {
+		t.Fatal("no Namespaces of the child")
+	}
+	if ns.PID != 1 {
+		t.Errorf("PID = %d, want 1", ns.PID)
+	}
+	if ns.IDs["pid"] == 0 || ns.IDs["uts"] != unshare[0].Args.Namespaces.IDs["uts"] {
+		t.Errorf("IDs = %v, want a new pid namespace and the uts one of the parent", ns.IDs)
+	}
+	if want := fmt.Sprintf("/proc/%d/root", child.PID); ns.Root != want {
+		t.Errorf("Root = %q, want %q", ns.Root, want)
+	}
+	if got, want := ns.HostPath("/etc"), fmt.Sprintf("/proc/%d/root/etc", child.PID); got != want {
+		t.Errorf("HostPath() = %q, want %q", got, want)
+	}
+	if x := child.Args.Exec; x == nil || filepath.Base(x.Path) != "true" {
+		t.Errorf("Exec = %+v, want true", x)
+	}
+	if p, _ := child.Args.SyscallArgs[0].(string); child.Args.HostPaths[0] != ns.HostPath(p) {
+		t.Errorf("HostPaths = %v, want %s of %s", child.Args.HostPaths, ns.HostPath(p), p)
+	}
+	if paths := unshare[0].Args.HostPaths; paths != nil {
+		t.Errorf("HostPaths of unshare = %v, want none", paths)
+	}
+}
+
+func TestRunTiming(t *testing.T) {
+	if _, err := os.Stat("/proc/self/schedstat"); err != nil {
+		t.Skip("no schedstat")
+	}
+
+	t.Run("blocked", func(t *testing.T) {
+		events := run(t, context.Background(), exec.Command("sleep", "0.05"))
+		sleeps := events.Filter(tracer.Syscall("nanosleep", "clock_nanosleep"))
+		if len(sleeps) != 1 || sleeps[0].Args.Timing == nil {
+			t.Fatalf("got sleeps %+v, want one with timing", sleeps)
+		}
+		if tm := sleeps[0].Args.Timing; tm.Blocked < int(40*time.Millisecond) || tm.CPU > int(10*time.Millisecond) {
+			t.Errorf("sleep timing = %+v, want blocked", tm)
+		}
+	})
+
+	t.Run("running", func(t *testing.T) {
+		events := run(t, context.Background(), exec.Command("dd", "if=/dev/urandom", "of=/dev/null", "bs=16M", "count=1"))
+		reads := events.Filter(tracer.And(tracer.Syscall("read"), func(e tracer.Event) bool { return e.Duration > int(time.Millisecond) }))
+		if len(reads) != 1 || reads[0].Args.Timing == nil {
+			t.Fatalf("got long reads %+v, want one with timing", reads)
+		}
+		if tm := reads[0].Args.Timing; tm.CPU < tm.Blocked {
+			t.Errorf("read of /dev/urandom timing = %+v, want running", tm)
+		}
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		start := int(time.Now().UnixNano())
+		events := run(t, context.Background(), exec.Command("sleep", "0.01"))
+		end := int(time.Now().UnixNano())
+		// Events are stamped at the entry, syscalls of a thread don't
+		// overlap.
+		var prev tracer.Event
+		for _, e := range events {
+			if e.Timestamp < start || e.Timestamp+e.Duration > end {
+				t.Fatalf("%s at %d+%d is out of the run %d-%d", e.Name, e.Timestamp, e.Duration, start, end)
+			}
+			if prev.Name != "" && e.Timestamp < prev.Timestamp+prev.Duration {
+				t.Errorf("%s at %d starts before %s at %d+%d ended", e.Name, e.Timestamp, prev.Name, prev.Timestamp, prev.Duration)
+			}
+			prev = e
+		}
+	})
+}
+
+func TestCalibrate(t *testing.T) {
+	if os.Getenv("STRACY_CALIBRATE") != "" {
+		for i := 0; i < 1000; i++ {
+			syscall.Getppid()
+		}
+		os.Exit(0)
+	}
+	calibration := func() *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCalibrate$")
+		cmd.Env = append(os.Environ(), "STRACY_CALIBRATE=1")
+		return cmd
+	}
+	o, err := tracer.Calibrate(context.Background(), calibration())
+	if errors.Is(err, syscall.EPERM) {
+		t.Skipf("ptrace is not permitted: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Calibrate: %s", err)
+	}
+	if o.Wall <= 0 || o.Wall > 10*time.Millisecond {
+		t.Errorf("overhead = %v, want some microseconds", o.Wall)
+	}
+
+	mean := func(opts ...tracer.Option) time.Duration {
+		events := run(t, context.Background(), calibration(), opts...).Filter(tracer.Syscall("getppid"))
+		var sum int
+		for _, e := range events {
+			sum += e.Duration
+		}
+		return time.Duration(sum / len(events))
+	}
+	if raw, corrected := mean(), mean(tracer.WithOverhead(o)); corrected >= raw {
+		t.Errorf("mean getppid time is %v with the overhead subtracted, %v without", corrected, raw)
+	}
+}
+
+func TestRunSampler(t *testing.T) {
+	events := run(t, context.Background(), exec.Command("dd", "if=/dev/urandom", "of=/dev/null", "bs=1M", "count=64"), tracer.WithSampler(20*time.Millisecond))
+	samples := events.Filter(func(e tracer.Event) bool { return e.Name == "sched" })
+	if len(samples) == 0 {
+		t.Fatal("no sched samples")
+	}
+	var cpu uint64
+	for _, e := range samples {
+		c := e.Args.Counters
+		if e.Ph != "C" || c["rss"] == 0 || c["threads"] != 1 {
+			t.Errorf("sample %+v, want a counter with rss and one thread", e)
+		}
+		cpu = max(cpu, c["cpu"])
+	}
+	// Reading /dev/urandom keeps the CPU busy.
+	if cpu < 50 {
+		t.Errorf("max cpu = %d%%, want busy", cpu)
+	}
+}
+
+func TestSamplerExitingProcess(t *testing.T) {
+	if e, ok, _ := tracer.SampleProcess(os.Getpid()); !ok || e.Args.Counters["rss"] == 0 {
+		t.Fatalf("sample of the test = %+v, %v, want one with rss", e, ok)
+	}
+
+	// A zombie has released its memory, like a process in exit_group.
+	cmd := exec.Command("true")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	stat := fmt.Sprintf("/proc/%d/stat", cmd.Process.Pid)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if data, err := os.ReadFile(stat); err == nil && strings.Contains(string(data), ") Z ") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("true didn't exit")
+		}
+	}
+	e, ok, threads := tracer.SampleProcess(cmd.Process.Pid)
+	if threads != 1 {
+		t.Fatalf("read %d threads of the zombie, want 1", threads)
+	}
+	if ok {
+		t.Errorf("sample of a zombie = %+v, want none", e)
+	}
+}
+
+func TestRunGoRuntime(t *testing.T) {
+	if os.Getenv("STRACY_GORUNTIME") != "" {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			os.Exit(1)
+		}
+		go func() {
+			if c, err := ln.Accept(); err == nil {
+				c.Close()
+			}
+		}()
+		if c, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+			c.Close()
+		}
+		os.WriteFile(os.Getenv("STRACY_GORUNTIME"), []byte("hello"), 0o644)
+		os.Exit(0)
+	}
+	path := filepath.Join(t.TempDir(), "out.txt")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunGoRuntime$")
+	cmd.Env = append(os.Environ(), "STRACY_GORUNTIME="+path)
+	// The native backend ignores threads that are gone when resumed, the
+	// Go runtime starts and ends threads all the time.
+	g := tracer.NewGoRuntime()
+	events := run(t, context.Background(), cmd, tracer.WithObserver(g), tracer.WithBackend(tracer.Native))
+
+	bins := events.Filter(func(e tracer.Event) bool { return e.Name == "go_binary" })
+	if len(bins) != 1 || bins[0].Args.Go == nil || bins[0].Args.Go.GoVersion == "" {
+		t.Fatalf("go_binary events %+v, want one with the Go version", bins)
+	}
+	activities := make(map[string]bool)
+	wrote := false
+	for _, e := range events {
+		activities[e.Args.GoRuntime] = true
+		if e.Name == "write" && slices.Contains(e.Args.SyscallArgs, any("hello")) {
+			wrote = true
+			if e.Args.GoRuntime != "" {
+				t.Errorf("write of the program is tagged %q", e.Args.GoRuntime)
+			}
+		}
+	}
+	if !wrote {
+		t.Error("no write of the program")
+	}
+	for _, a := range []string{tracer.GoNetpoll, tracer.GoScheduler, tracer.GoSignal} {
+		if !activities[a] {
+			t.Errorf("no syscalls tagged %q, got %v", a, activities)
+		}
+	}
+	if threads, processes := g.State(); threads != 0 || processes != 0 {
+		t.Errorf("%d threads and %d processes are kept after the exit", threads, processes)
+	}
+
+	for _, e := range run(t, context.Background(), exec.Command("true"), tracer.WithObserver(tracer.NewGoRuntime()), tracer.WithBackend(tracer.Native)) {
+		if e.Name == "go_binary" || e.Args.GoRuntime != "" {
+			t.Errorf("%s of true is attributed to the Go runtime: %+v", e.Name, e)
+		}
+	}
+}